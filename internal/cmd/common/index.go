@@ -1,14 +1,20 @@
 package common
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 
 	"github.com/spf13/viper"
 	flowpipeapiclient "github.com/turbot/flowpipe-sdk-go"
 	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/perr"
 )
 
 type customTransport struct {
@@ -44,3 +50,54 @@ func GetApiClient() *flowpipeapiclient.APIClient {
 
 	return apiClient
 }
+
+// CallApi sends a request to an endpoint of the API not covered by the generated API client, e.g. the process commands
+// or the dry runs, with the configuration of the API client. The input, if any, is sent as the JSON body of the request
+// and the JSON response, if any, is decoded into the output. An error response is returned as the error of the server.
+func CallApi(ctx context.Context, method, path string, input, output any) error {
+	apiConfig := GetApiClient().GetConfig()
+
+	var body io.Reader
+	if input != nil {
+		requestBody, err := json.Marshal(input)
+		if err != nil {
+			return perr.InternalWithMessage("Failed to build the API request.")
+		}
+		body = bytes.NewReader(requestBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiConfig.Servers[0].URL+path, body)
+	if err != nil {
+		return perr.InternalWithMessage("Failed to build the API request.")
+	}
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := apiConfig.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	resBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return perr.InternalWithMessage("Failed reading response body.")
+	}
+
+	if resp.StatusCode >= 300 {
+		var errorModel perr.ErrorModel
+		if err := json.Unmarshal(resBody, &errorModel); err != nil || errorModel.Title == "" {
+			return perr.InternalWithMessage(fmt.Sprintf("API request %s %s failed with status %d.", method, path, resp.StatusCode))
+		}
+		return errorModel
+	}
+
+	if output != nil && len(resBody) > 0 {
+		if err := json.Unmarshal(resBody, output); err != nil {
+			return perr.InternalWithMessage("Failed to deserialize the API response.")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+type testCommand struct {
+	Command string `json:"command"`
+}
+
+func TestCallApi(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v0/process/exec_1/command":
+			var input testCommand
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&input) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": input.Command + "d"})
+		case "/api/v0/process/exec_2/command":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v0/process/exec_3/command":
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(perr.NotFoundWithMessage("execution exec_3 not found"))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("bad gateway"))
+		}
+	}))
+	defer server.Close()
+
+	viper.Set(constants.ArgHost, server.URL)
+	defer viper.Set(constants.ArgHost, nil)
+
+	ctx := context.Background()
+
+	// the input is sent as the JSON body, the response is decoded into the output
+	var output map[string]string
+	assert.Nil(CallApi(ctx, http.MethodPost, "/process/exec_1/command", testCommand{Command: "pause"}, &output))
+	assert.Equal(map[string]string{"status": "paused"}, output)
+
+	// an empty response leaves the output alone
+	var process *map[string]string
+	assert.Nil(CallApi(ctx, http.MethodPost, "/process/exec_2/command", testCommand{Command: "cancel"}, &process))
+	assert.Nil(process)
+
+	// the error of the server is returned
+	err := CallApi(ctx, http.MethodPost, "/process/exec_3/command", testCommand{Command: "cancel"}, nil)
+	var errorModel perr.ErrorModel
+	if assert.True(errors.As(err, &errorModel)) {
+		assert.Equal(http.StatusNotFound, errorModel.Status)
+		assert.Equal("execution exec_3 not found", errorModel.Detail)
+	}
+
+	err = CallApi(ctx, http.MethodGet, "/trigger/missing/state", nil, &output)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "API request GET /trigger/missing/state failed with status 502.")
+	}
+}
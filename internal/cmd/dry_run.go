@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/flowpipe/internal/cmd/common"
+	"github.com/turbot/flowpipe/internal/service/api"
+	"github.com/turbot/flowpipe/internal/service/manager"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/printers"
+)
+
+// dryRunTriggerFunc prints the pipelines the trigger would queue with their args, the pipelines are not run
+func dryRunTriggerFunc(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	input := types.CmdDryRun{ArgsString: getPipelineArgs(cmd)}
+
+	var resp *types.FpTriggerDryRun
+	var err error
+	// if a host is set, use it to connect to API server
+	if viper.IsSet(constants.ArgHost) {
+		resp, err = dryRunRemote[types.FpTriggerDryRun](ctx, "trigger", api.ConstructTriggerFullyQualifiedName(args[0]), input)
+	} else {
+		resp, err = dryRunTriggerLocal(ctx, args[0], input)
+	}
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	printer, err := printers.GetPrinter[types.FpTriggerDryRun](cmd)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "Error obtaining printer")
+		return
+	}
+	err = printer.PrintResource(ctx, types.NewPrintableTriggerDryRun(resp), cmd.OutOrStdout())
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "Error when printing")
+	}
+}
+
+func dryRunTriggerLocal(ctx context.Context, triggerName string, input types.CmdDryRun) (*types.FpTriggerDryRun, error) {
+	// create and start the manager in local mode (i.e. do not set listen address), nothing is executed
+	m, err := manager.NewManager(ctx).Start()
+	error_helpers.FailOnError(err)
+	defer func() {
+		_ = m.Stop()
+	}()
+
+	// construct the trigger name _after_ initializing so the cache is initialized
+	return api.DryRunTrigger(ctx, input, api.ConstructTriggerFullyQualifiedName(triggerName))
+}
+
+// dryRunPipelineFunc validates the pipeline args and prints the steps the pipeline would run, the steps are not run
+func dryRunPipelineFunc(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	input := types.CmdDryRun{ArgsString: getPipelineArgs(cmd)}
+
+	var resp *types.FpPipelineDryRun
+	var err error
+	// if a host is set, use it to connect to API server
+	if viper.IsSet(constants.ArgHost) {
+		resp, err = dryRunRemote[types.FpPipelineDryRun](ctx, "pipeline", api.ConstructPipelineFullyQualifiedName(args[0]), input)
+	} else {
+		resp, err = dryRunPipelineLocal(ctx, args[0], input)
+	}
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	printer, err := printers.GetPrinter[types.FpPipelineDryRun](cmd)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "Error obtaining printer")
+		return
+	}
+	err = printer.PrintResource(ctx, types.NewPrintablePipelineDryRun(resp), cmd.OutOrStdout())
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "Error when printing")
+	}
+}
+
+func dryRunPipelineLocal(ctx context.Context, pipelineName string, input types.CmdDryRun) (*types.FpPipelineDryRun, error) {
+	// create and start the manager in local mode (i.e. do not set listen address), nothing is executed
+	m, err := manager.NewManager(ctx).Start()
+	error_helpers.FailOnError(err)
+	defer func() {
+		_ = m.Stop()
+	}()
+
+	// construct the pipeline name _after_ initializing so the cache is initialized
+	return api.DryRunPipeline(input, api.ConstructPipelineFullyQualifiedName(pipelineName))
+}
+
+// dryRunRemote dry runs a pipeline or a trigger on a remote server
+func dryRunRemote[T any](ctx context.Context, resourceType, name string, input types.CmdDryRun) (*T, error) {
+	var result T
+	err := common.CallApi(ctx, http.MethodPost, "/"+resourceType+"/"+name+"/dry_run", input, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -204,7 +204,8 @@ func pipelineRunCmd() *cobra.Command {
 		AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a pipeline argument. Multiple --arg may be passed.").
 		AddBoolFlag(constants.ArgVerbose, false, "Enable verbose output.").
 		AddBoolFlag(constants.ArgDetach, false, "Run the pipeline in detached mode.").
-		AddStringFlag(constants.ArgExecutionId, "", "Specify pipeline execution id. Execution id will generated if not provided.")
+		AddStringFlag(constants.ArgExecutionId, "", "Specify pipeline execution id. Execution id will generated if not provided.").
+		AddBoolFlag(constants.ArgDryRun, false, "Validate the pipeline args and print the steps the pipeline would run, without running them.")
 
 	return cmd
 }
@@ -214,6 +215,12 @@ type pollEventLogFunc func(ctx context.Context, executionId string, last int) (b
 
 func runPipelineFunc(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
+
+	if viper.GetBool(constants.ArgDryRun) {
+		dryRunPipelineFunc(cmd, args)
+		return
+	}
+
 	var resp types.PipelineExecutionResponse
 	var err error
 	var pollLogFunc pollEventLogFunc
@@ -864,7 +871,7 @@ func pollServerEventLog(ctx context.Context, executionId string, last int) (bool
 				last = index
 
 				// TODO: execution paused
-				if e.Message == event.HandlerExecutionFinished || e.Message == event.HandlerExecutionFailed || e.Message == event.HandlerExecutionPaused {
+				if e.Message == event.HandlerExecutionFinished || e.Message == event.HandlerExecutionFailed || e.Message == event.HandlerExecutionPaused || e.Message == event.HandlerExecutionCancelled {
 					jsonData, err := json.Marshal(item.Detail)
 					if err != nil {
 						return false, 0, nil, perr.InternalWithMessage("error marshalling log detail")
@@ -907,7 +914,7 @@ func pollLocalEventLog(ctx context.Context, executionId string, last int) (bool,
 			slog.Info("poll local event log - execution paused")
 
 			complete = true
-		} else if item.Message == event.HandlerExecutionFinished || item.Message == event.HandlerExecutionFailed {
+		} else if item.Message == event.HandlerExecutionFinished || item.Message == event.HandlerExecutionFailed || item.Message == event.HandlerExecutionCancelled {
 
 			jsonData, err := json.Marshal(item.Detail)
 			if err != nil {
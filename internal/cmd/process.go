@@ -2,18 +2,23 @@ package cmd
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/turbot/flowpipe/internal/cmd/common"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/command"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
@@ -45,6 +50,9 @@ func processCmd() *cobra.Command {
 	cmd.AddCommand(processListCmd())
 	cmd.AddCommand(processTailCmd())
 	cmd.AddCommand(processResumeCmd())
+	cmd.AddCommand(processPauseCmd())
+	cmd.AddCommand(processRetryCmd())
+	cmd.AddCommand(processCancelCmd())
 
 	return cmd
 }
@@ -55,7 +63,7 @@ func processResumeCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Run:   resumeProcessFunc,
 		Short: "Resume a process",
-		Long:  `Resume a process.`,
+		Long:  `Resume a paused process. With --host the process is resumed on the server and its status is printed once resumed, use "process show" to check on it.`,
 	}
 	// initialize hooks
 	cmdconfig.OnCmd(cmd)
@@ -63,6 +71,50 @@ func processResumeCmd() *cobra.Command {
 	return cmd
 }
 
+func processPauseCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "pause <execution-id>",
+		Args:  cobra.ExactArgs(1),
+		Run:   pauseProcessFunc,
+		Short: "Pause a process",
+		Long:  `Pause a running process, including any child pipelines. With --host the process is paused on the server, otherwise the interrupted process is paused in the local database. Steps that are already running are allowed to finish, use "process resume" to continue the process.`,
+	}
+	// initialize hooks
+	cmdconfig.OnCmd(cmd).
+		AddStringFlag(localconstants.ArgReason, "", "Reason for pausing the process.")
+
+	return cmd
+}
+
+func processRetryCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "retry <execution-id>",
+		Args:  cobra.ExactArgs(1),
+		Run:   retryProcessFunc,
+		Short: "Retry a failed process",
+		Long:  `Retry a failed process from the steps that failed in a new process linked to the failed one. The outputs of the steps that completed are kept, only the failed and never started steps are run again. The failed process is left as it is.`,
+	}
+	// initialize hooks
+	cmdconfig.OnCmd(cmd)
+
+	return cmd
+}
+
+func processCancelCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "cancel <execution-id>",
+		Args:  cobra.ExactArgs(1),
+		Run:   cancelProcessFunc,
+		Short: "Cancel a process",
+		Long:  `Cancel a process, including any child pipelines. Steps that are already running are allowed to finish.`,
+	}
+	// initialize hooks
+	cmdconfig.OnCmd(cmd).
+		AddStringFlag(localconstants.ArgReason, "", "Reason for cancelling the process.")
+
+	return cmd
+}
+
 func processShowCmd() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "show <execution-id>",
@@ -79,25 +131,55 @@ func processShowCmd() *cobra.Command {
 
 func resumeProcessFunc(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
-	var err error
 	executionId := args[0]
 
-	var m *manager.Manager
+	if viper.IsSet(constants.ArgHost) {
+		// the process carries on running on the server, there is nothing to wait for here
+		process, err := commandProcessRemote(ctx, executionId, types.CmdProcess{Command: "resume"})
+		if err != nil {
+			error_helpers.ShowError(ctx, err)
+			return
+		}
+		printProcess(ctx, cmd, process)
+		return
+	}
 
-	var resp types.PipelineExecutionResponse
-	var pollLogFunc pollEventLogFunc
+	m, resp, err := resumeProcessLocal(ctx, executionId)
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	followProcessLocal(ctx, cmd, m, resp, "Resuming...")
+}
+
+func retryProcessFunc(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	executionId := args[0]
 
 	if viper.IsSet(constants.ArgHost) {
-		_, err = getProcessRemote(executionId)
-	} else {
-		m, resp, err = resumeProcessLocal(ctx, executionId)
-		pollLogFunc = pollLocalEventLog
+		// the process carries on running on the server, there is nothing to wait for here
+		process, err := commandProcessRemote(ctx, executionId, types.CmdProcess{Command: "retry"})
+		if err != nil {
+			error_helpers.ShowError(ctx, err)
+			return
+		}
+		printProcess(ctx, cmd, process)
+		return
 	}
+
+	m, resp, err := retryProcessLocal(ctx, executionId)
 	if err != nil {
 		error_helpers.ShowError(ctx, err)
 		return
 	}
 
+	followProcessLocal(ctx, cmd, m, resp, "Retrying...")
+}
+
+// followProcessLocal displays the progress of a process that has been restarted in this process (i.e. resumed or
+// retried) until it completes, then exits with the exit code matching the final status of the process.
+func followProcessLocal(ctx context.Context, cmd *cobra.Command, m *manager.Manager, resp types.PipelineExecutionResponse, progressMessage string) {
 	exitCode := 0
 	lastStatus := ""
 
@@ -105,38 +187,31 @@ func resumeProcessFunc(cmd *cobra.Command, args []string) {
 		if m != nil {
 			_ = m.Stop()
 		}
-		slog.Debug("Completed execution from resumeProcessFunc", "status", lastStatus, "exitCode", exitCode)
+		slog.Debug("Completed execution from followProcessLocal", "status", lastStatus, "exitCode", exitCode)
 		os.Exit(exitCode)
 	}()
 
 	isDetach := viper.GetBool(constants.ArgDetach)
-	isRemote := viper.IsSet(constants.ArgHost)
 	isVerbose := viper.IsSet(constants.ArgVerbose)
-	if !isRemote && isDetach {
+	if isDetach {
 		error_helpers.ShowError(ctx, fmt.Errorf("unable to use --detach with local execution"))
 		return
 	}
 	output := viper.GetString(constants.ArgOutput)
-	streamLogs := (output == "plain" || output == "pretty") && (o.IsServerMode || isRemote || isVerbose)
-	progressLogs := (output == "plain" || output == "pretty") && !o.IsServerMode && !isRemote && !isVerbose
+	streamLogs := (output == "plain" || output == "pretty") && (o.IsServerMode || isVerbose)
+	progressLogs := (output == "plain" || output == "pretty") && !o.IsServerMode && !isVerbose
 
 	if progressLogs {
-		o.PipelineProgress = o.NewProgress("Resuming...")
+		o.PipelineProgress = o.NewProgress(progressMessage)
 	}
 
 	switch {
-	case isDetach:
-		err := displayDetached(ctx, cmd, resp)
-		if err != nil {
-			error_helpers.FailOnErrorWithMessage(err, "failed printing execution information")
-			return
-		}
 	case streamLogs:
-		lastStatus = displayStreamingLogs(ctx, cmd, resp, pollLogFunc)
+		lastStatus = displayStreamingLogs(ctx, cmd, resp, pollLocalEventLog)
 	case progressLogs:
-		lastStatus = displayProgressLogs(ctx, cmd, resp, pollLogFunc)
+		lastStatus = displayProgressLogs(ctx, cmd, resp, pollLocalEventLog)
 	default:
-		lastStatus = displayBasicOutput(ctx, cmd, resp, pollLogFunc)
+		lastStatus = displayBasicOutput(ctx, cmd, resp, pollLocalEventLog)
 	}
 
 	switch lastStatus {
@@ -147,7 +222,140 @@ func resumeProcessFunc(cmd *cobra.Command, args []string) {
 	case event.HandlerExecutionPaused:
 		exitCode = fperr.ExitCodeExecutionPaused
 	}
+}
+
+func cancelProcessFunc(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	var resp *types.Process
+	var err error
+	executionId := args[0]
+	reason := viper.GetString(localconstants.ArgReason)
+
+	if viper.IsSet(constants.ArgHost) {
+		resp, err = commandProcessRemote(ctx, executionId, types.CmdProcess{Command: "cancel", Reason: reason})
+	} else {
+		resp, err = cancelProcessLocal(ctx, executionId, reason)
+	}
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	printProcess(ctx, cmd, resp)
+}
+
+func pauseProcessFunc(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	var resp *types.Process
+	var err error
+	executionId := args[0]
+	reason := viper.GetString(localconstants.ArgReason)
+
+	if viper.IsSet(constants.ArgHost) {
+		resp, err = commandProcessRemote(ctx, executionId, types.CmdProcess{Command: "pause", Reason: reason})
+	} else {
+		resp, err = pauseProcessLocal(ctx, executionId, reason)
+	}
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
 
+	printProcess(ctx, cmd, resp)
+}
+
+func printProcess(ctx context.Context, cmd *cobra.Command, resp *types.Process) {
+	if resp == nil {
+		return
+	}
+
+	printer, err := printers.GetPrinter[types.Process](cmd)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "failed obtaining printer")
+		return
+	}
+	printableResource := types.NewPrintableProcessFromSingle(resp)
+	err = printer.PrintResource(ctx, printableResource, cmd.OutOrStdout())
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "failed when printing")
+		return
+	}
+}
+
+func cancelProcessLocal(ctx context.Context, executionId, reason string) (*types.Process, error) {
+	// create and start the manager in local mode (i.e. do not set listen address)
+	m, err := manager.NewManager(ctx, manager.WithESService()).Start()
+	error_helpers.FailOnError(err)
+	defer func() {
+		_ = m.Stop()
+	}()
+
+	_, _, err = api.CancelProcess(executionId, reason, m.ESService)
+	if err != nil {
+		return nil, err
+	}
+
+	// wait for the cancellation to be recorded before stopping the manager
+	return waitForProcessEnd(ctx, func() (*types.Process, error) {
+		return api.GetProcess(executionId)
+	})
+}
+
+func pauseProcessLocal(ctx context.Context, executionId, reason string) (*types.Process, error) {
+	// create and start the manager in local mode (i.e. do not set listen address)
+	m, err := manager.NewManager(ctx, manager.WithESService()).Start()
+	error_helpers.FailOnError(err)
+	defer func() {
+		_ = m.Stop()
+	}()
+
+	_, _, err = api.PauseProcess(executionId, reason, m.ESService)
+	if err != nil {
+		return nil, err
+	}
+
+	// wait for the pause to be recorded before stopping the manager
+	return waitForProcessStatus(ctx, func() (*types.Process, error) {
+		return api.GetProcess(executionId)
+	}, append([]string{"paused"}, event.EndEvents...))
+}
+
+// commandProcessRemote sends a command to a process running on a remote server
+func commandProcessRemote(ctx context.Context, executionId string, input types.CmdProcess) (*types.Process, error) {
+	var process *types.Process
+	err := common.CallApi(ctx, http.MethodPost, "/process/"+executionId+"/command", input, &process)
+	if err != nil {
+		return nil, err
+	}
+
+	return process, nil
+}
+
+// waitForProcessEnd polls the process until its outer pipeline reaches an end state (or we give up waiting)
+func waitForProcessEnd(ctx context.Context, getProcess func() (*types.Process, error)) (*types.Process, error) {
+	return waitForProcessStatus(ctx, getProcess, event.EndEvents)
+}
+
+// waitForProcessStatus polls the process until its outer pipeline reaches one of the given statuses (or we give up waiting)
+func waitForProcessStatus(ctx context.Context, getProcess func() (*types.Process, error), statuses []string) (*types.Process, error) {
+	var process *types.Process
+	var err error
+	for i := 0; i < 50; i++ {
+		process, err = getProcess()
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(statuses, process.Status) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return process, nil
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return process, nil
 }
 
 func showProcessFunc(cmd *cobra.Command, args []string) {
@@ -308,6 +516,27 @@ func resumeProcessLocal(ctx context.Context, executionId string) (*manager.Manag
 	return m, response, nil
 }
 
+func retryProcessLocal(ctx context.Context, executionId string) (*manager.Manager, types.PipelineExecutionResponse, error) {
+	// create and start the manager in local mode (i.e. do not set listen address)
+	m, err := manager.NewManager(ctx, manager.WithESService()).Start()
+	error_helpers.FailOnError(err)
+
+	retryExecutionId, pipelineExecutionId, pipelineName, err := api.RetryProcess(executionId, m.ESService)
+	if err != nil {
+		_ = m.Stop()
+		return nil, types.PipelineExecutionResponse{}, err
+	}
+
+	response := types.PipelineExecutionResponse{}
+	response.Flowpipe = types.FlowpipeResponseMetadata{
+		ExecutionID:         retryExecutionId,
+		PipelineExecutionID: pipelineExecutionId,
+		Pipeline:            pipelineName,
+	}
+
+	return m, response, nil
+}
+
 // list
 func processListCmd() *cobra.Command {
 	var cmd = &cobra.Command{
@@ -315,23 +544,41 @@ func processListCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Run:   listProcessFunc,
 		Short: "List processes",
-		Long:  `List processes.`,
+		Long: `List processes, latest first.
+
+The processes are listed a page at a time, pass the next token printed after the
+list to --next-token to list the next page.`,
 	}
 	// initialize hooks
-	cmdconfig.OnCmd(cmd)
+	cmdconfig.OnCmd(cmd).
+		AddStringFlag(localconstants.ArgProcessPipeline, "", "Only list the processes of the pipeline (fully qualified name).").
+		AddStringFlag(localconstants.ArgProcessStatus, "", "Only list the processes in the status, one of: queued, started, finished, failed, cancelled.").
+		AddStringFlag(localconstants.ArgProcessTrigger, "", "Only list the processes run by the trigger (fully qualified name).").
+		AddStringFlag(localconstants.ArgStartedBefore, "", "Only list the processes started before the time (RFC 3339, e.g. 2024-01-02T15:04:05Z).").
+		AddStringFlag(localconstants.ArgStartedAfter, "", "Only list the processes started after the time (RFC 3339, e.g. 2024-01-02T15:04:05Z).").
+		AddBoolFlag(localconstants.ArgFailedStep, false, "Only list the processes with a failed step, --failed-step=false lists the processes without a failed step.").
+		AddStringFlag(localconstants.ArgSort, "desc", "Sort order of the processes by start time, one of: asc, desc.").
+		AddIntFlag(localconstants.ArgLimit, 25, "Maximum number of processes to list, between 1 and 100.").
+		AddStringFlag(localconstants.ArgNextToken, "", "Next token of the previous list, to list the next page of processes.")
 
 	return cmd
 }
 
 func listProcessFunc(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
+
+	query, err := listProcessQuery(cmd)
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
 	var resp *types.ListProcessResponse
-	var err error
 	// if a host is set, use it to connect to API server
 	if viper.IsSet(constants.ArgHost) {
-		resp, err = listProcessRemote()
+		resp, err = listProcessRemote(ctx, query)
 	} else {
-		resp, err = listProcessLocal(cmd, args)
+		resp, err = listProcessLocal(cmd, query)
 	}
 	if err != nil {
 		error_helpers.ShowError(ctx, err)
@@ -350,26 +597,112 @@ func listProcessFunc(cmd *cobra.Command, args []string) {
 			error_helpers.ShowErrorWithMessage(ctx, err, "Error when printing")
 			return
 		}
+
+		if resp.NextToken != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "\nMore processes to list, use --%s %s\n", localconstants.ArgNextToken, *resp.NextToken)
+		}
 	}
 }
 
-func listProcessRemote() (*types.ListProcessResponse, error) {
-	ctx := context.Background()
-	limit := int32(25) // int32 | The max number of items to fetch per page of data, subject to a min and max of 1 and 100 respectively. If not specified will default to 25. (optional) (default to 25)
-	nextToken := ""    // string | When list results are truncated, next_token will be returned, which is a cursor to fetch the next page of data. Pass next_token to the subsequent list request to fetch the next page of data. (optional)
+// listProcessRequest is the process list query built from the command flags
+type listProcessRequest struct {
+	types.ListProcessRequestQuery
+	NextToken string
+	Limit     int
+}
+
+func listProcessQuery(cmd *cobra.Command) (*listProcessRequest, error) {
+	query := &listProcessRequest{
+		ListProcessRequestQuery: types.ListProcessRequestQuery{
+			Pipeline: viper.GetString(localconstants.ArgProcessPipeline),
+			Status:   viper.GetString(localconstants.ArgProcessStatus),
+			Trigger:  viper.GetString(localconstants.ArgProcessTrigger),
+			Sort:     viper.GetString(localconstants.ArgSort),
+		},
+		NextToken: viper.GetString(localconstants.ArgNextToken),
+		Limit:     viper.GetInt(localconstants.ArgLimit),
+	}
 
-	apiClient := common.GetApiClient()
+	if query.Status != "" && !slices.Contains([]string{"queued", "started", "finished", "failed", localconstants.StateCancelled}, query.Status) {
+		return nil, perr.BadRequestWithMessage("invalid status " + query.Status + ", must be one of: queued, started, finished, failed, cancelled")
+	}
+	if query.Sort != "asc" && query.Sort != "desc" {
+		return nil, perr.BadRequestWithMessage("invalid sort order " + query.Sort + ", must be one of: asc, desc")
+	}
 
-	resp, _, err := apiClient.ProcessApi.List(ctx).Limit(limit).NextToken(nextToken).Execute()
+	for arg, t := range map[string]**time.Time{
+		localconstants.ArgStartedBefore: &query.StartedBefore,
+		localconstants.ArgStartedAfter:  &query.StartedAfter,
+	} {
+		value := viper.GetString(arg)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, perr.BadRequestWithMessage("invalid --" + arg + " time " + value + ", must be in RFC 3339 format")
+		}
+		*t = &parsed
+	}
+
+	if cmd.Flags().Changed(localconstants.ArgFailedStep) {
+		failedStep := viper.GetBool(localconstants.ArgFailedStep)
+		query.FailedStep = &failedStep
+	}
+
+	return query, nil
+}
+
+// listProcessRemote lists the processes of a remote server. The generated API client does not cover the process
+// list filters.
+func listProcessRemote(ctx context.Context, query *listProcessRequest) (*types.ListProcessResponse, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(query.Limit))
+	params.Set("sort", query.Sort)
+	if query.NextToken != "" {
+		params.Set("next_token", query.NextToken)
+	}
+	if query.Pipeline != "" {
+		params.Set("pipeline", query.Pipeline)
+	}
+	if query.Status != "" {
+		params.Set("status", query.Status)
+	}
+	if query.Trigger != "" {
+		params.Set("trigger", query.Trigger)
+	}
+	if query.StartedBefore != nil {
+		params.Set("started_before", query.StartedBefore.Format(time.RFC3339))
+	}
+	if query.StartedAfter != nil {
+		params.Set("started_after", query.StartedAfter.Format(time.RFC3339))
+	}
+	if query.FailedStep != nil {
+		params.Set("failed_step", strconv.FormatBool(*query.FailedStep))
+	}
+
+	var result types.ListProcessResponse
+	err := common.CallApi(ctx, http.MethodGet, "/process?"+params.Encode(), nil, &result)
 	if err != nil {
 		return nil, err
 	}
-	// map the API data type into the internal data type
-	return types.ListProcessResponseFromAPIResponse(resp)
+
+	return &result, nil
 }
 
-func listProcessLocal(cmd *cobra.Command, args []string) (*types.ListProcessResponse, error) {
+func listProcessLocal(cmd *cobra.Command, query *listProcessRequest) (*types.ListProcessResponse, error) {
 	ctx := cmd.Context()
+
+	// the next token is base64 encoded, decode it as the API does
+	var nextToken string
+	if query.NextToken != "" {
+		data, err := base64.StdEncoding.DecodeString(query.NextToken)
+		if err != nil {
+			return nil, perr.BadRequestWithMessage("invalid next token " + query.NextToken)
+		}
+		nextToken = string(data)
+	}
+
 	// create and start the manager in local mode (i.e. do not set listen address)
 	m, err := manager.NewManager(ctx).Start()
 	error_helpers.FailOnError(err)
@@ -378,7 +711,7 @@ func listProcessLocal(cmd *cobra.Command, args []string) (*types.ListProcessResp
 		_ = m.Stop()
 	}()
 
-	return api.ListProcesses()
+	return api.ListProcesses(query.ListProcessRequestQuery, nextToken, query.Limit)
 }
 
 // tail
@@ -66,6 +66,7 @@ func rootCommand() *cobra.Command {
 	// add all the subcommands
 	rootCmd.AddCommand(
 		serverCmd(),
+		workerCmd(),
 		pipelineCmd(),
 		triggerCmd(),
 		processCmd(),
@@ -38,6 +38,13 @@ func serverCmd() *cobra.Command {
 		AddIntFlag(constants.ArgPort, localconstants.DefaultServerPort, "Server port.").
 		AddStringFlag(constants.ArgListen, localconstants.DefaultListen, "Listen address port.").
 		AddStringFlag(constants.ArgBaseUrl, localconstants.DefaultFlowpipeHost, "Base URL for the webhook triggers and http input ("+localconstants.DefaultFlowpipeHost+").").
+		AddStringFlag(localconstants.ArgMessageBus, localconstants.DefaultMessageBus, "Message bus used for commands and events, one of: "+localconstants.MessageBusMemory+", "+localconstants.MessageBusSQLite+". Unacknowledged messages on the "+localconstants.MessageBusSQLite+" bus are replayed on restart.").
+		AddStringFlag(localconstants.ArgClusterNodeID, "", "Unique ID of this server in the cluster.").
+		AddStringFlag(localconstants.ArgClusterAddress, "", "Address (<host>:<port>) the cluster node listens on, setting it runs the server in cluster mode where only the leader runs the schedule and query triggers.").
+		AddStringFlag(localconstants.ArgClusterPeers, "", "Comma separated list of the cluster nodes, each in the form <node id>=<host>:<port>. The cluster needs at least 3 nodes.").
+		AddStringFlag(localconstants.ArgWorkerToken, "", "Token the workers authenticate with, setting it dispatches the --worker-step-types steps to the workers instead of running them in the server.").
+		AddStringFlag(localconstants.ArgWorkerStepTypes, localconstants.DefaultWorkerStepTypes, "Comma separated list of the step types run by the workers.").
+		AddStringFlag(localconstants.ArgOtelEndpoint, "", "OTLP HTTP endpoint (e.g. http://localhost:4318) the OpenTelemetry traces of the executions are exported to.").
 		AddBoolFlag(constants.ArgWatch, true, "Watch mod files for changes when running Flowpipe server").
 		AddBoolFlag(constants.ArgVerbose, false, "Enable verbose output")
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"net/http"
 	"os"
 	"reflect"
 	"time"
@@ -41,6 +42,7 @@ func triggerCmd() *cobra.Command {
 	cmd.AddCommand(triggerListCmd())
 	cmd.AddCommand(triggerShowCmd())
 	cmd.AddCommand(triggerRunCmd())
+	cmd.AddCommand(triggerStateCmd())
 
 	return cmd
 }
@@ -203,7 +205,8 @@ func triggerRunCmd() *cobra.Command {
 		AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a trigger argument. Multiple --arg may be passed.").
 		AddBoolFlag(constants.ArgVerbose, false, "Enable verbose output.").
 		AddBoolFlag(constants.ArgDetach, false, "Run the trigger in detached mode.").
-		AddStringFlag(constants.ArgExecutionId, "", "Specify trigger execution id. Execution id will generated if not provided.")
+		AddStringFlag(constants.ArgExecutionId, "", "Specify trigger execution id. Execution id will generated if not provided.").
+		AddBoolFlag(constants.ArgDryRun, false, "Print the pipelines the trigger would run and their args, without running them.")
 
 	return cmd
 }
@@ -337,6 +340,12 @@ func executeTrigger(cmd *cobra.Command, args []string, isRemote bool) (*manager.
 
 func runTriggerFunc(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
+
+	if viper.GetBool(constants.ArgDryRun) {
+		dryRunTriggerFunc(cmd, args)
+		return
+	}
+
 	var resp types.TriggerExecutionResponse
 	var err error
 	var pollLogFunc pollEventLogFunc
@@ -443,3 +452,148 @@ func runTriggerFunc(cmd *cobra.Command, args []string) {
 	}
 
 }
+
+// state
+
+func triggerStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Query trigger state commands",
+		Long:  `Inspect and reset the rows known by a query trigger.`,
+	}
+
+	cmd.AddCommand(triggerStateShowCmd())
+	cmd.AddCommand(triggerStateCommandCmd("reset", "Reset the state of a query trigger, its next poll captures all the rows returned by its query as inserted"))
+	cmd.AddCommand(triggerStateCommandCmd("seed", "Seed the state of a query trigger with the rows currently returned by its query, without running its pipelines"))
+
+	return cmd
+}
+
+func triggerStateShowCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "show <trigger-name>",
+		Args:  cobra.ExactArgs(1),
+		Run:   showTriggerStateFunc,
+		Short: "Show the state of a query trigger",
+		Long:  `Show the number of rows known by a query trigger and the rows captured by its last poll.`,
+	}
+
+	// initialize hooks
+	cmdconfig.OnCmd(cmd)
+
+	return cmd
+}
+
+func triggerStateCommandCmd(command, description string) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:  command + " <trigger-name>",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			commandTriggerStateFunc(cmd, args, command)
+		},
+		Short: description,
+		Long:  description + ".",
+	}
+
+	// initialize hooks
+	cmdconfig.OnCmd(cmd)
+
+	return cmd
+}
+
+func showTriggerStateFunc(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	var resp *types.FpTriggerState
+	var err error
+	triggerName := args[0]
+	// if a host is set, use it to connect to API server
+	if viper.IsSet(constants.ArgHost) {
+		resp, err = triggerStateRemote(ctx, triggerName, nil)
+	} else {
+		resp, err = triggerStateLocal(ctx, triggerName, nil)
+	}
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	printTriggerState(ctx, cmd, resp)
+}
+
+func commandTriggerStateFunc(cmd *cobra.Command, args []string, command string) {
+	ctx := cmd.Context()
+	var resp *types.FpTriggerState
+	var err error
+	triggerName := args[0]
+	input := &types.CmdTriggerState{Command: command}
+	// if a host is set, use it to connect to API server
+	if viper.IsSet(constants.ArgHost) {
+		resp, err = triggerStateRemote(ctx, triggerName, input)
+	} else {
+		resp, err = triggerStateLocal(ctx, triggerName, input)
+	}
+	if err != nil {
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	printTriggerState(ctx, cmd, resp)
+}
+
+func printTriggerState(ctx context.Context, cmd *cobra.Command, resp *types.FpTriggerState) {
+	if resp == nil {
+		return
+	}
+
+	printer, err := printers.GetPrinter[types.FpTriggerState](cmd)
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "Error obtaining printer")
+		return
+	}
+	printableResource := types.NewPrintableTriggerState(resp)
+	err = printer.PrintResource(ctx, printableResource, cmd.OutOrStdout())
+	if err != nil {
+		error_helpers.ShowErrorWithMessage(ctx, err, "Error when printing")
+	}
+}
+
+// triggerStateLocal shows the state of the query trigger, or runs the state command if there is one
+func triggerStateLocal(ctx context.Context, triggerName string, input *types.CmdTriggerState) (*types.FpTriggerState, error) {
+	// create and start the manager in local mode (i.e. do not set listen address)
+	m, err := manager.NewManager(ctx).Start()
+	error_helpers.FailOnError(err)
+	defer func() {
+		_ = m.Stop()
+	}()
+
+	triggerName = api.ConstructTriggerFullyQualifiedName(triggerName)
+
+	if input == nil {
+		return api.GetTriggerState(triggerName)
+	}
+
+	switch input.Command {
+	case "reset":
+		return api.ResetTriggerState(triggerName)
+	case "seed":
+		return api.SeedTriggerState(ctx, triggerName)
+	}
+	return nil, perr.BadRequestWithMessage("invalid trigger state command " + input.Command)
+}
+
+// triggerStateRemote shows the state of a query trigger on a remote server, or runs the state command if there is one
+func triggerStateRemote(ctx context.Context, triggerName string, input *types.CmdTriggerState) (*types.FpTriggerState, error) {
+	var triggerState types.FpTriggerState
+
+	var err error
+	if input == nil {
+		err = common.CallApi(ctx, http.MethodGet, "/trigger/"+triggerName+"/state", nil, &triggerState)
+	} else {
+		err = common.CallApi(ctx, http.MethodPost, "/trigger/"+triggerName+"/state/command", input, &triggerState)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &triggerState, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/fperr"
+	"github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/flowpipe/internal/worker"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+)
+
+func workerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "worker",
+		Args:  cobra.NoArgs,
+		Short: "Run a Flowpipe worker that runs steps for a Flowpipe server",
+		Long: `Run a Flowpipe worker that runs steps for a Flowpipe server.
+
+The worker claims the steps the server (started with --worker-token) dispatches to
+the workers, runs them and reports their output back to the server. Function steps
+are loaded from the mod in --mod-location.`,
+		Run: startWorkerFunc,
+	}
+
+	cmdconfig.
+		OnCmd(cmd).
+		AddStringFlag(localconstants.ArgWorkerToken, "", "Token to authenticate with the server, must match the server --worker-token.").
+		AddStringFlag(localconstants.ArgWorkerStepTypes, "", "Comma separated list of the step types this worker runs, all the step types dispatched by the server if not set.").
+		AddIntFlag(localconstants.ArgWorkerConcurrency, localconstants.DefaultWorkerConcurrency, "Maximum number of steps the worker runs at the same time.")
+
+	return cmd
+}
+
+func startWorkerFunc(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	output.IsServerMode = true
+
+	if !viper.IsSet(constants.ArgHost) {
+		error_helpers.ShowError(ctx, fmt.Errorf("worker requires the server to connect to via --host <host>"))
+		return
+	}
+
+	stepTypes, err := worker.ParseStepTypes(viper.GetString(localconstants.ArgWorkerStepTypes))
+	fperr.FailOnError(err, nil, "")
+
+	w, err := worker.NewWorker(ctx, util.GetHost(), viper.GetString(localconstants.ArgWorkerToken),
+		worker.WithStepTypes(stepTypes),
+		worker.WithConcurrency(viper.GetInt(localconstants.ArgWorkerConcurrency)),
+		worker.WithModPath(viper.GetString(constants.ArgModLocation)),
+		worker.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: viper.GetBool("api.tls_insecure")}, //nolint:gosec // user defined
+			},
+		}),
+	)
+	fperr.FailOnError(err, nil, "")
+
+	err = w.Start()
+	fperr.FailOnError(err, nil, "")
+
+	output.RenderServerOutput(ctx,
+		types.NewServerOutputStatusChange(time.Now(), "Started", app_specific.AppVersion.String()),
+		types.NewServerOutput(time.Now(), "flowpipe", "Worker "+w.ID+" running steps for "+w.Host),
+		types.NewServerOutput(time.Now(), "flowpipe", "Press Ctrl+C to exit"))
+
+	// Block until we receive a signal
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	sig := <-sigs
+	slog.Info("Worker exiting", "signal", sig)
+
+	err = w.Stop()
+	fperr.FailOnError(err, nil, "")
+
+	output.RenderServerOutput(ctx, types.NewServerOutputStatusChange(time.Now(), "Stopped", ""))
+}
@@ -1,6 +1,7 @@
 package cmdconfig
 
 import (
+	localconstants "github.com/turbot/flowpipe/internal/constants"
 	serviceconfig "github.com/turbot/flowpipe/internal/service/config"
 	"github.com/turbot/pipe-fittings/app_specific"
 	"github.com/turbot/pipe-fittings/cmdconfig"
@@ -49,6 +50,13 @@ func envMappings() map[string]cmdconfig.EnvMapping {
 		"FLOWPIPE_MAX_CONCURRENCY_CONTAINER": {ConfigVar: []string{constants.ArgMaxConcurrencyContainer}, VarType: cmdconfig.EnvVarTypeInt},
 		"FLOWPIPE_MAX_CONCURRENCY_FUNCTION":  {ConfigVar: []string{constants.ArgMaxConcurrencyFunction}, VarType: cmdconfig.EnvVarTypeInt},
 		"FLOWPIPE_PROCESS_RETENTION":         {ConfigVar: []string{constants.ArgProcessRetention}, VarType: cmdconfig.EnvVarTypeInt},
+		"FLOWPIPE_MESSAGE_BUS":               {ConfigVar: []string{localconstants.ArgMessageBus}, VarType: cmdconfig.EnvVarTypeString},
+		"FLOWPIPE_CLUSTER_NODE_ID":           {ConfigVar: []string{localconstants.ArgClusterNodeID}, VarType: cmdconfig.EnvVarTypeString},
+		"FLOWPIPE_CLUSTER_ADDRESS":           {ConfigVar: []string{localconstants.ArgClusterAddress}, VarType: cmdconfig.EnvVarTypeString},
+		"FLOWPIPE_CLUSTER_PEERS":             {ConfigVar: []string{localconstants.ArgClusterPeers}, VarType: cmdconfig.EnvVarTypeString},
+		"FLOWPIPE_WORKER_TOKEN":              {ConfigVar: []string{localconstants.ArgWorkerToken}, VarType: cmdconfig.EnvVarTypeString},
+		"FLOWPIPE_WORKER_STEP_TYPES":         {ConfigVar: []string{localconstants.ArgWorkerStepTypes}, VarType: cmdconfig.EnvVarTypeString},
+		"FLOWPIPE_OTEL_ENDPOINT":             {ConfigVar: []string{localconstants.ArgOtelEndpoint}, VarType: cmdconfig.EnvVarTypeString},
 		"FLOWPIPE_BASE_URL":                  {ConfigVar: []string{constants.ArgBaseUrl}, VarType: cmdconfig.EnvVarTypeString},
 	}
 }
@@ -5,4 +5,28 @@ const (
 
 	ArgPipelineExecutionMode = "execution-mode"
 	ArgPipelineWaitTime      = "wait-time"
+
+	ArgReason = "reason"
+
+	ArgMessageBus = "message-bus"
+
+	ArgClusterNodeID  = "cluster-node-id"
+	ArgClusterAddress = "cluster-address"
+	ArgClusterPeers   = "cluster-peers"
+
+	ArgWorkerToken       = "worker-token"
+	ArgWorkerStepTypes   = "worker-step-types"
+	ArgWorkerConcurrency = "concurrency"
+
+	ArgOtelEndpoint = "otel-endpoint"
+
+	ArgProcessPipeline = "pipeline"
+	ArgProcessStatus   = "status"
+	ArgProcessTrigger  = "trigger"
+	ArgStartedBefore   = "started-before"
+	ArgStartedAfter    = "started-after"
+	ArgFailedStep      = "failed-step"
+	ArgSort            = "sort"
+	ArgLimit           = "limit"
+	ArgNextToken       = "next-token"
 )
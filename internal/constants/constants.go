@@ -12,6 +12,11 @@ const (
 	DefaultWaitRetry          = 60
 	ExecutionModeSynchronous  = "synchronous"
 	ExecutionModeAsynchronous = "asynchronous"
+	DefaultMessageBus         = MessageBusMemory
+	MessageBusMemory          = "memory"
+	MessageBusSQLite          = "sqlite"
+	DefaultWorkerStepTypes    = "container,function"
+	DefaultWorkerConcurrency  = 10
 
 	MaxScanSize = bufio.MaxScanTokenSize * 40
 
@@ -0,0 +1,178 @@
+package constants
+
+import "time"
+
+// Flowpipe specific attributes, the common attributes are defined in pipe-fittings schema
+const (
+	AttributeTypeOnRestart        = "on_restart"
+	AttributeTypePath             = "path"
+	AttributeTypeEvents           = "events"
+	AttributeTypeDebounce         = "debounce"
+	AttributeTypeSecret           = "secret"
+	AttributeTypeHeader           = "header"
+	AttributeTypeAlgorithm        = "algorithm"
+	AttributeTypeTolerance        = "tolerance"
+	AttributeTypeCursorColumn     = "cursor_column"
+	AttributeTypeTimezone         = "timezone"
+	AttributeTypeCatchUp          = "catch_up"
+	AttributeTypeOverlap          = "overlap"
+	AttributeTypeStream           = "stream"
+	AttributeTypeConsumer         = "consumer"
+	AttributeTypeUpstream         = "upstream"
+	AttributeTypeStatuses         = "statuses"
+	AttributeTypeMailbox          = "mailbox"
+	AttributeTypeMoveTo           = "move_to"
+	AttributeTypeCommand          = "command"
+	AttributeTypeStdin            = "stdin"
+	AttributeTypeAllowedExitCodes = "allowed_exit_codes"
+	AttributeTypeItemsPath        = "items_path"
+	AttributeTypeTokenPath        = "token_path"
+	AttributeTypeTokenParam       = "token_param"
+	AttributeTypeOffsetParam      = "offset_param"
+	AttributeTypeLimitParam       = "limit_param"
+	AttributeTypeLimit            = "limit"
+	AttributeTypeMaxPages         = "max_pages"
+	AttributeTypeMaxItems         = "max_items"
+	AttributeTypePages            = "pages"
+	AttributeTypeClientCertPem    = "client_cert_pem"
+	AttributeTypeClientKeyPem     = "client_key_pem"
+	AttributeTypeProxyUrl         = "proxy_url"
+	AttributeTypeConnectTimeout   = "connect_timeout"
+	AttributeTypeReadTimeout      = "read_timeout"
+	AttributeTypeFollowRedirects  = "follow_redirects"
+	AttributeTypeMaxRedirects     = "max_redirects"
+	AttributeTypeHttp2            = "http2"
+	AttributeTypeFile             = "file"
+	AttributeTypeContent          = "content"
+	AttributeTypeFilename         = "filename"
+	AttributeTypeResponseFile     = "response_file"
+	AttributeTypeSize             = "size"
+	AttributeTypeSha256           = "sha256"
+	AttributeTypeStatements       = "statements"
+	AttributeTypeRowsAffected     = "rows_affected"
+	AttributeTypeLastInsertId     = "last_insert_id"
+
+	BlockTypeSignature  = "signature"
+	BlockTypePagination = "pagination"
+	BlockTypeMultipart  = "multipart"
+)
+
+// Flowpipe specific step types
+const (
+	BlockTypePipelineStepCommand = "command"
+)
+
+// Command step
+const (
+	DefaultCommandShell        = "sh"
+	CommandWaitDelay           = 5 * time.Second
+	MaxCommandOutputLines      = 10000
+	CommandOutputBatchLines    = 100
+	CommandOutputBatchInterval = time.Second
+)
+
+// HTTP step pagination styles
+const (
+	PaginationStyleLinkHeader = "link_header"
+	PaginationStyleToken      = "token"
+	PaginationStyleOffset     = "offset"
+
+	DefaultPaginationOffsetParam = "offset"
+	DefaultPaginationLimitParam  = "limit"
+	DefaultPaginationLimit       = 100
+	DefaultPaginationMaxPages    = 100
+)
+
+// HTTP step transport
+const (
+	DefaultHttpFollowRedirects = true
+	DefaultHttpMaxRedirects    = 10
+)
+
+// HTTP step multipart request body
+const (
+	DefaultMultipartContentType = "application/octet-stream"
+)
+
+// Flowpipe specific trigger types
+const (
+	TriggerTypeFile     = "file"
+	TriggerTypeQueue    = "queue"
+	TriggerTypePipeline = "pipeline"
+	TriggerTypeEmail    = "email"
+)
+
+// on_restart policies
+const (
+	OnRestartResume  = "resume"
+	OnRestartFail    = "fail"
+	DefaultOnRestart = OnRestartResume
+)
+
+// File trigger events
+const (
+	FileEventCreate = "create"
+	FileEventModify = "modify"
+	FileEventDelete = "delete"
+
+	DefaultFileTriggerDebounce = "1s"
+)
+
+// Queue trigger
+const (
+	DefaultQueueTriggerUrl = "nats://127.0.0.1:4222"
+)
+
+// Pipeline trigger statuses
+const (
+	PipelineStatusFinished = "finished"
+	PipelineStatusFailed   = "failed"
+	PipelineStatusCanceled = "canceled"
+)
+
+// Email trigger
+const (
+	DefaultEmailTriggerMailbox = "INBOX"
+	MaxEmailTriggerMessages    = 100
+)
+
+// catch_up policies
+const (
+	CatchUpNone    = "none"
+	CatchUpLatest  = "latest"
+	CatchUpAll     = "all"
+	DefaultCatchUp = CatchUpNone
+	MaxCatchUpRuns = 100
+)
+
+// overlap policies
+const (
+	OverlapAllow          = "allow"
+	OverlapSkip           = "skip"
+	OverlapQueue          = "queue"
+	OverlapCancelPrevious = "cancel_previous"
+	DefaultOverlap        = OverlapAllow
+	MaxQueuedTriggerRuns  = 100
+)
+
+// Trigger events
+const (
+	TriggerEventSkipped   = "skipped"
+	TriggerEventCancelled = "cancelled"
+	MaxTriggerEvents      = 100
+	TriggerShowEvents     = 10
+)
+
+// HTTP trigger HMAC signature
+const (
+	SignatureAlgorithmSha1   = "sha1"
+	SignatureAlgorithmSha256 = "sha256"
+
+	SignatureFormatHex    = "hex"
+	SignatureFormatGithub = "github"
+	SignatureFormatStripe = "stripe"
+
+	DefaultSignatureAlgorithm = SignatureAlgorithmSha256
+	DefaultSignatureFormat    = SignatureFormatHex
+	DefaultSignatureTolerance = "5m"
+)
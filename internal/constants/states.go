@@ -1,9 +1,10 @@
 package constants
 
 const (
-	StateFailed   = "failed"
-	StateFinished = "finished"
-	StateSkipped  = "skipped"
+	StateFailed    = "failed"
+	StateFinished  = "finished"
+	StateSkipped   = "skipped"
+	StateCancelled = "cancelled" // the pipeline executions of a cancelled execution are PipelineStatusCanceled
 
 	FailureModeIgnored  = "ignored" // ignored=true
 	FailureModeStandard = "normal"  // "normal" failure, retry or ignored=true will be followed
@@ -8,8 +8,10 @@ import (
 	"sync"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/metrics"
 	"github.com/turbot/flowpipe/internal/store"
 	"github.com/turbot/pipe-fittings/perr"
 )
@@ -38,9 +40,56 @@ func (c FpEventBusImpl) Publish(ctx context.Context, event interface{}) error {
 		return err
 	}
 
+	recordEventMetrics(event)
+
 	return c.Eb.Publish(ctx, event)
 }
 
+// recordEventMetrics counts the pipelines and steps ending (and the pipelines starting) from the events, the steps
+// starting are counted by the step start command
+func recordEventMetrics(evt interface{}) {
+	commandEvent, ok := evt.(event.CommandEvent)
+	if !ok {
+		return
+	}
+
+	ex, err := execution.GetExecution(commandEvent.GetEvent().ExecutionID)
+	if err != nil {
+		return
+	}
+
+	pipelineName := func(pipelineExecutionID string) string {
+		pex := ex.PipelineExecutions[pipelineExecutionID]
+		if pex == nil {
+			return ""
+		}
+		return pex.Name
+	}
+
+	switch e := evt.(type) {
+	case *event.PipelineStarted:
+		metrics.PipelineStarted(pipelineName(e.PipelineExecutionID))
+	case *event.PipelineFinished:
+		metrics.PipelineFinished(pipelineName(e.PipelineExecutionID))
+	case *event.PipelineFailed:
+		metrics.PipelineFailed(pipelineName(e.PipelineExecutionID))
+	case *event.StepFinished:
+		pex := ex.PipelineExecutions[e.PipelineExecutionID]
+		if pex == nil || pex.StepExecutions[e.StepExecutionID] == nil || e.Output == nil {
+			return
+		}
+
+		// step names are in the form <step type>.<name>
+		stepType, _, _ := strings.Cut(pex.StepExecutions[e.StepExecutionID].Name, ".")
+		switch e.Output.Status {
+		case constants.StateFinished:
+			metrics.StepFinished(pex.Name, stepType)
+		case constants.StateFailed:
+			metrics.StepFailed(pex.Name, stepType)
+		}
+	}
+}
+
 func LogEventMessage(ctx context.Context, evt interface{}, lock *sync.Mutex) error {
 	commandEvent, ok := evt.(event.CommandEvent)
 
@@ -50,8 +99,22 @@ func LogEventMessage(ctx context.Context, evt interface{}, lock *sync.Mutex) err
 
 	logMessage := event.NewEventLogFromCommand(commandEvent)
 
-	executionID := commandEvent.GetEvent().ExecutionID
+	return appendEventLog(ctx, commandEvent.GetEvent().ExecutionID, logMessage)
+}
+
+// LogStepOutput appends a batch of lines of output of a running step to the process event log
+func LogStepOutput(ctx context.Context, stepOutput *event.StepOutput) error {
+	executionID := stepOutput.Event.ExecutionID
+
+	// the step runs without the execution lock
+	lock := event.GetEventStoreMutex(executionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return appendEventLog(ctx, executionID, event.NewEventLogFromStepOutput(stepOutput))
+}
 
+func appendEventLog(ctx context.Context, executionID string, logMessage event.EventLogImpl) error {
 	var ex *execution.ExecutionInMemory
 	var err error
 
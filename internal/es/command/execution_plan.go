@@ -83,13 +83,29 @@ func (h ExecutionPlanHandler) Handle(ctx context.Context, c interface{}) error {
 	if allFinished {
 
 		failure := false
+		canceled := false
 
-		// any failure?
+		// any failure or cancellation?
 		for _, pex := range ex.PipelineExecutions {
 			if pex.Status == "failed" {
 				failure = true
 				break
 			}
+			if pex.IsCanceled() {
+				canceled = true
+			}
+		}
+
+		// a cancelled execution is reported as such regardless of whether it was started by a trigger,
+		// failures take precedence over cancellation
+		if !failure && canceled {
+			cmd := event.ExecutionCancelledFromExecutionPlan(cmd)
+			err = h.EventBus.Publish(ctx, cmd)
+			if err != nil {
+				slog.Error("Error publishing event", "error", err)
+				return nil
+			}
+			return nil
 		}
 
 		if ex.TriggerExecution != nil {
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"context"
+
+	"log/slog"
+
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+type ExecutionRetryHandler CommandHandler
+
+func (h ExecutionRetryHandler) HandlerName() string {
+	return execution.ExecutionRetryCommand.HandlerName()
+}
+
+func (h ExecutionRetryHandler) NewCommand() interface{} {
+	return &event.ExecutionRetry{}
+}
+
+// execution_retry command handler
+// issue this to retry a failed execution from the failed steps in a new execution
+func (h ExecutionRetryHandler) Handle(ctx context.Context, c interface{}) error {
+	cmd, ok := c.(*event.ExecutionRetry)
+	if !ok {
+		slog.Error("invalid command type", "expected", "*event.ExecutionRetry", "actual", c)
+		return perr.BadRequestWithMessage("invalid command type expected *event.ExecutionRetry")
+	}
+
+	plannerMutex := event.GetEventStoreMutex(cmd.Event.ExecutionID)
+	plannerMutex.Lock()
+	defer func() {
+		plannerMutex.Unlock()
+	}()
+
+	// the pipeline executions of the failed execution were copied to the new execution when the command was logged
+	ex, err := execution.GetExecution(cmd.Event.ExecutionID)
+	if err != nil {
+		slog.Error("execution_retry: Error loading execution", "execution_id", cmd.Event.ExecutionID, "retry_of", cmd.RetryOf, "error", err)
+		return err
+	}
+
+	// Only the failed root pipelines are retried. The child pipelines that failed the pipeline steps are retried
+	// before their parent, the pipeline steps are then left running until the retried child pipelines finish.
+	for _, rootPipelineExecutionId := range ex.RootPipelines {
+		pex, ok := ex.PipelineExecutions[rootPipelineExecutionId]
+		if !ok || !pex.IsFail() {
+			continue
+		}
+
+		for _, childPex := range append(ex.FailedChildPipelineExecutions(pex.ID), pex) {
+			e := event.NewPipelineRetriedFromExecutionRetry(cmd, childPex.ID)
+			err := h.EventBus.Publish(ctx, e)
+			if err != nil {
+				slog.Error("Error publishing PipelineRetried event", "pipeline_execution_id", childPex.ID, "error", err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"context"
 	"log/slog"
+	"slices"
 
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
@@ -32,6 +33,51 @@ func (h PipelineCancelHandler) Handle(ctx context.Context, c interface{}) error
 		plannerMutex.Unlock()
 	}()
 
+	ex, err := execution.GetExecution(cmd.Event.ExecutionID)
+	if err != nil {
+		slog.Error("pipeline_cancel: Error loading pipeline execution", "error", err)
+		err2 := h.EventBus.Publish(ctx, event.NewPipelineFailed(ctx, event.ForPipelineCancelToPipelineFailed(cmd, err)))
+		if err2 != nil {
+			slog.Error("Error publishing PipelineFailed event", "error", err2)
+		}
+		return nil
+	}
+
+	pex, ok := ex.PipelineExecutions[cmd.PipelineExecutionID]
+	if !ok {
+		slog.Error("Can't cancel pipeline execution that does not exist", "pipeline_execution_id", cmd.PipelineExecutionID)
+		return perr.BadRequestWithMessage("Can't cancel pipeline execution " + cmd.PipelineExecutionID + " that does not exist")
+	}
+
+	if slices.Contains(event.EndEvents, pex.Status) {
+		slog.Info("Pipeline execution already ended, nothing to cancel", "pipeline_execution_id", cmd.PipelineExecutionID, "pipelineStatus", pex.Status)
+		return nil
+	}
+
+	// Cancel the child pipelines (started by pipeline steps) before the pipeline itself. By the time the
+	// parent pipeline is cancelled the whole tree is in its end state, so the execution can be completed.
+	for _, childPex := range ex.ChildPipelineExecutions(pex.ID) {
+		if slices.Contains(event.EndEvents, childPex.Status) {
+			continue
+		}
+
+		e := event.NewPipelineCanceledFromPipelineCancel(cmd)
+		e.PipelineExecutionID = childPex.ID
+		err := h.EventBus.Publish(ctx, e)
+		if err != nil {
+			slog.Error("Error publishing PipelineCanceled event", "pipeline_execution_id", childPex.ID, "error", err)
+			return err
+		}
+		workerDispatches.cancelPipeline(cmd.Event.ExecutionID, childPex.ID)
+	}
+
 	e := event.NewPipelineCanceledFromPipelineCancel(cmd)
-	return h.EventBus.Publish(ctx, e)
+	err = h.EventBus.Publish(ctx, e)
+	if err != nil {
+		return err
+	}
+
+	// the steps waiting for a worker are failed, their output is ignored as the pipeline is cancelled
+	workerDispatches.cancelPipeline(cmd.Event.ExecutionID, pex.ID)
+	return nil
 }
@@ -3,6 +3,7 @@ package command
 import (
 	"context"
 	"log/slog"
+	"slices"
 
 	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/event"
@@ -42,6 +43,13 @@ func (h PipelineFailHandler) Handle(ctx context.Context, c interface{}) error {
 	}
 	pe := ex.PipelineExecutions[cmd.PipelineExecutionID]
 
+	// The pipeline may have been failed already, i.e. a pipeline that is failed after a server restart while its
+	// child pipeline is also failing it through the failed pipeline step
+	if slices.Contains(event.EndEvents, pe.Status) {
+		slog.Info("Pipeline execution already ended, nothing to fail", "pipeline_execution_id", cmd.PipelineExecutionID, "pipelineStatus", pe.Status)
+		return nil
+	}
+
 	// 2023-12-05: do not calculate output if pipeline fails
 	output := make(map[string]any, 1)
 
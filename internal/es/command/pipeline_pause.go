@@ -45,13 +45,20 @@ func (h PipelinePauseHandler) Handle(ctx context.Context, c interface{}) error {
 		return nil
 	}
 
-	pex := ex.PipelineExecutions[cmd.PipelineExecutionID]
+	pex, ok := ex.PipelineExecutions[cmd.PipelineExecutionID]
+	if !ok {
+		slog.Error("Can't pause pipeline execution that does not exist", "pipeline_execution_id", cmd.PipelineExecutionID)
+		return perr.BadRequestWithMessage("Can't pause pipeline execution " + cmd.PipelineExecutionID + " that does not exist")
+	}
 
 	if pex.Status != "started" && pex.Status != "queued" {
 		slog.Error("Can't pause pipeline execution that is not started or queued", "pipeline_execution_id", cmd.PipelineExecutionID, "pipelineStatus", pex.Status)
 		return perr.BadRequestWithMessage("Can't pause pipeline execution that is not started or queued")
 	}
 
+	// Pause the pipeline before its running child pipelines (started by pipeline steps). The pause is recorded as the
+	// event is published, while the planner mutex is held, so a paused child that plans its parent finds the parent
+	// already paused rather than planning it.
 	e, err := event.NewPipelinePaused(event.ForPipelinePause(cmd))
 	if err != nil {
 		err2 := h.EventBus.Publish(ctx, event.NewPipelineFailed(ctx, event.ForPipelinePauseToPipelineFailed(cmd, err)))
@@ -60,5 +67,33 @@ func (h PipelinePauseHandler) Handle(ctx context.Context, c interface{}) error {
 		}
 		return nil
 	}
-	return h.EventBus.Publish(ctx, e)
+
+	err = h.EventBus.Publish(ctx, e)
+	if err != nil {
+		slog.Error("Error publishing PipelinePaused event", "pipeline_execution_id", pex.ID, "error", err)
+		return err
+	}
+	workerDispatches.pause(cmd.Event.ExecutionID, pex.ID)
+
+	for _, childPex := range ex.ChildPipelineExecutions(pex.ID) {
+		if childPex.Status != "started" && childPex.Status != "queued" {
+			continue
+		}
+
+		e, err := event.NewPipelinePaused(event.ForPipelinePause(cmd))
+		if err != nil {
+			slog.Error("Error creating PipelinePaused event", "pipeline_execution_id", childPex.ID, "error", err)
+			return err
+		}
+		e.PipelineExecutionID = childPex.ID
+
+		err = h.EventBus.Publish(ctx, e)
+		if err != nil {
+			slog.Error("Error publishing PipelinePaused event", "pipeline_execution_id", childPex.ID, "error", err)
+			return err
+		}
+		workerDispatches.pause(cmd.Event.ExecutionID, childPex.ID)
+	}
+
+	return nil
 }
@@ -45,9 +45,9 @@ func (h PipelinePlanHandler) Handle(ctx context.Context, c interface{}) error {
 	}
 	pex := ex.PipelineExecutions[cmd.PipelineExecutionID]
 
-	// If the pipeline has been canceled or paused, then no planning is required as no
+	// If the pipeline has been canceled, paused or failed, then no planning is required as no
 	// more work should be done.
-	if pex.IsCanceled() || pex.IsPaused() || pex.IsFinishing() || pex.IsFinished() {
+	if pex.IsCanceled() || pex.IsPaused() || pex.IsFinishing() || pex.IsFinished() || pex.IsFail() {
 		return nil
 	}
 
@@ -52,6 +52,12 @@ func (h PipelineResumeHandler) Handle(ctx context.Context, c interface{}) error
 	}
 
 	e := event.NewPipelineResumedFromPipelineResume(cmd)
+	err = h.EventBus.Publish(ctx, e)
+	if err != nil {
+		return err
+	}
 
-	return h.EventBus.Publish(ctx, e)
+	// dispatch again the steps taken out of the worker queue when the pipeline was paused
+	workerDispatches.resume(cmd.Event.ExecutionID, cmd.PipelineExecutionID)
+	return nil
 }
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/container"
+	"github.com/turbot/flowpipe/internal/es/event"
+)
+
+// stepOutputLogger appends the output of a running step to the process event log in batches, each batch takes the
+// execution lock and writes a single event once it has CommandOutputBatchLines lines or CommandOutputBatchInterval
+// after its first line. The last batch is written by flush once the step has ended.
+type stepOutputLogger struct {
+	ctx      context.Context
+	cmd      *event.StepStart
+	interval time.Duration
+	size     int
+	log      func(ctx context.Context, stepOutput *event.StepOutput) error
+
+	mutex sync.Mutex
+	lines []event.StepOutputLine
+	timer *time.Timer
+}
+
+func newStepOutputLogger(ctx context.Context, cmd *event.StepStart) *stepOutputLogger {
+	return &stepOutputLogger{
+		ctx:      ctx,
+		cmd:      cmd,
+		interval: constants.CommandOutputBatchInterval,
+		size:     constants.CommandOutputBatchLines,
+		log:      LogStepOutput,
+	}
+}
+
+func (l *stepOutputLogger) outputLine(line container.OutputLine) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lines = append(l.lines, event.StepOutputLine{Stream: line.Stream, Line: line.Line})
+	if len(l.lines) >= l.size {
+		l.write()
+		return
+	}
+	if l.timer == nil {
+		l.timer = time.AfterFunc(l.interval, l.flush)
+	}
+}
+
+// flush writes the lines not written yet
+func (l *stepOutputLogger) flush() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.write()
+}
+
+func (l *stepOutputLogger) write() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if len(l.lines) == 0 {
+		return
+	}
+
+	lines := l.lines
+	l.lines = nil
+	if err := l.log(l.ctx, event.NewStepOutput(l.cmd, lines)); err != nil {
+		slog.Error("Error logging step output", "step", l.cmd.StepName, "error", err)
+	}
+}
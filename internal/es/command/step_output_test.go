@@ -0,0 +1,122 @@
+package command
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/container"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/worker"
+)
+
+type recordingStepOutputLog struct {
+	mutex   sync.Mutex
+	batches [][]event.StepOutputLine
+}
+
+func (r *recordingStepOutputLog) log(ctx context.Context, stepOutput *event.StepOutput) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.batches = append(r.batches, stepOutput.Lines)
+	return nil
+}
+
+func (r *recordingStepOutputLog) batchSizes() []int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var sizes []int
+	for _, batch := range r.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestStepOutputLoggerBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := &recordingStepOutputLog{}
+	cmd := &event.StepStart{
+		Event:               event.NewEventForExecutionID("exec_command"),
+		PipelineExecutionID: "pexec_command",
+		StepExecutionID:     "sexec_command",
+		StepName:            "command.build",
+	}
+	logger := newStepOutputLogger(context.Background(), cmd)
+	logger.interval = 100 * time.Millisecond
+	logger.size = 3
+	logger.log = recorder.log
+
+	// a full batch is written at once
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		logger.outputLine(container.OutputLine{Stream: container.StdoutType, Line: line})
+	}
+	assert.Equal([]int{3}, recorder.batchSizes())
+
+	// the lines of a batch that isn't full are written after the interval
+	assert.Eventually(func() bool {
+		return len(recorder.batchSizes()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal([]int{3, 1}, recorder.batchSizes())
+
+	// the last lines are written by the flush once the step has ended
+	logger.outputLine(container.OutputLine{Stream: container.StderrType, Line: "5\n"})
+	logger.flush()
+	logger.flush()
+	assert.Equal([]int{3, 1, 1}, recorder.batchSizes())
+
+	assert.Equal([]event.StepOutputLine{{Stream: container.StdoutType, Line: "1\n"}, {Stream: container.StdoutType, Line: "2\n"}, {Stream: container.StdoutType, Line: "3\n"}}, recorder.batches[0])
+	assert.Equal([]event.StepOutputLine{{Stream: container.StderrType, Line: "5\n"}}, recorder.batches[2])
+}
+
+func TestRunInPipelinePaused(t *testing.T) {
+	assert := assert.New(t)
+
+	runs := make(chan context.Context, 2)
+	done := make(chan error, 1)
+	go func() {
+		_, err := runInPipeline("exec_paused", "pexec_paused", "command.build", func(ctx context.Context) (*resources.Output, error) {
+			runs <- ctx
+			<-ctx.Done()
+			return nil, context.Cause(ctx)
+		})
+		done <- err
+	}()
+
+	// the pause stops the step, it's run again once the pipeline is resumed
+	<-runs
+	workerDispatches.pause("exec_paused", "pexec_paused")
+	workerDispatches.resume("exec_paused", "pexec_paused")
+
+	// the cancellation stops the step for good
+	<-runs
+	workerDispatches.cancelPipeline("exec_paused", "pexec_paused")
+	assert.Equal(errPipelineCanceled, <-done)
+
+	// the step cancelled while its pipeline is paused isn't run again
+	go func() {
+		_, err := runInPipeline("exec_paused", "pexec_paused", "command.build", func(ctx context.Context) (*resources.Output, error) {
+			runs <- ctx
+			<-ctx.Done()
+			return nil, context.Cause(ctx)
+		})
+		done <- err
+	}()
+
+	ctx := <-runs
+	workerDispatches.pause("exec_paused", "pexec_paused")
+	<-ctx.Done()
+	assert.Equal(worker.ErrPaused, context.Cause(ctx))
+	workerDispatches.cancelPipeline("exec_paused", "pexec_paused")
+
+	err := <-done
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "step command.build was cancelled while its pipeline was paused")
+	}
+	assert.Empty(runs)
+}
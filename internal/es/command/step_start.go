@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -10,11 +11,14 @@ import (
 	"github.com/turbot/flowpipe/internal/es/db"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/metrics"
 	o "github.com/turbot/flowpipe/internal/output"
 	"github.com/turbot/flowpipe/internal/parse"
 	"github.com/turbot/flowpipe/internal/primitive"
 	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/worker"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
@@ -22,7 +26,27 @@ import (
 	"github.com/turbot/pipe-fittings/schema"
 )
 
-type StepStartHandler CommandHandler
+type stepDoneKey struct{}
+
+// WithStepDone returns a context for the step start command that calls done once the step has run and its follow-up
+// event (StepFinished, StepPipelineStarted or PipelineFailed) has been published. The command is handled before the
+// step runs, a durable message bus acknowledges the command with done instead so a step still running when the
+// server stops is run again on restart.
+func WithStepDone(ctx context.Context, done func()) context.Context {
+	return context.WithValue(ctx, stepDoneKey{}, done)
+}
+
+func stepDone(ctx context.Context) {
+	if done, ok := ctx.Value(stepDoneKey{}).(func()); ok {
+		done()
+	}
+}
+
+type StepStartHandler struct {
+	EventBus FpEventBus
+	// Runs the step types handled by the workers, nil if the steps are only run by this process
+	Workers *worker.Queue
+}
 
 func (h StepStartHandler) HandlerName() string {
 	return execution.StepStartCommand.HandlerName()
@@ -41,6 +65,14 @@ func (h StepStartHandler) Handle(ctx context.Context, c interface{}) error {
 
 	go func(ctx context.Context, c interface{}, h StepStartHandler) {
 
+		// the step is done once the follow-up event has been published, unless it's left to be recovered on restart
+		leftForRecovery := false
+		defer func() {
+			if !leftForRecovery {
+				stepDone(ctx)
+			}
+		}()
+
 		cmd, ok := c.(*event.StepStart)
 		if !ok {
 			slog.Error("invalid command type", "expected", "*event.StepStart", "actual", c)
@@ -156,72 +188,114 @@ func (h StepStartHandler) Handle(ctx context.Context, c interface{}) error {
 		plannerMutex.Unlock()
 		plannerMutex = nil
 
+		observeStepDuration := metrics.StepStarted(pipelineDefn.Name(), stepDefn.GetType())
+
 		var primitiveError error
-		switch stepDefn.GetType() {
-		case schema.BlockTypePipelineStepHttp:
-			p := primitive.HTTPRequest{}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepPipeline:
-			p := primitive.RunPipeline{}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepEmail:
-			p := primitive.Email{}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepQuery:
-			p := primitive.Query{}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepSleep:
-			p := primitive.Sleep{}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepTransform:
-			p := primitive.Transform{}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepFunction:
-			p := primitive.Function{
-				ModPath: pipelineDefn.GetMod().ModPath,
+		if h.Workers.Handles(stepDefn.GetType()) {
+			// the worker only runs the primitive, the output is handled here as if the primitive ran locally.
+			// NOTE: the command context is cancelled once the command is handled, the step waits for the worker
+			// until its pipeline is paused or cancelled
+			output, primitiveError = dispatchToWorkers(h.Workers, types.WorkerJob{
+				ExecutionID:            cmd.Event.ExecutionID,
+				PipelineExecutionID:    cmd.PipelineExecutionID,
+				StepExecutionID:        cmd.StepExecutionID,
+				PipelineName:           pipelineDefn.PipelineName,
+				StepName:               cmd.StepName,
+				StepType:               stepDefn.GetType(),
+				FullyQualifiedStepName: stepDefn.GetFullyQualifiedName(),
+				Input:                  cmd.StepInput,
+			})
+			if errors.Is(primitiveError, worker.ErrClosed) {
+				// the server is stopping, leave the step to be recovered on restart
+				leftForRecovery = true
+				return
 			}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepContainer:
-			p := primitive.Container{FullyQualifiedStepName: stepDefn.GetFullyQualifiedName()}
-			output, primitiveError = p.Run(ctx, cmd.StepInput)
-		case schema.BlockTypePipelineStepInput:
-			if routerUrl, routed := primitive.GetInputRouter(); routed {
-				endStepFunc := func(stepExecution *execution.StepExecution, out *resources.Output) error {
-					return EndStepFromApi(ex, stepExecution, pipelineDefn, stepDefn, out, h.EventBus)
+		} else {
+			// propagate the trace to the services called by the step
+			ctx = tracing.ContextWithStep(ctx, cmd.StepExecutionID)
+
+			switch stepDefn.GetType() {
+			case schema.BlockTypePipelineStepHttp:
+				p := primitive.HTTPRequest{
+					ModPath: pipelineDefn.GetMod().ModPath,
 				}
-				p := primitive.NewRoutedInput(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName, schema.BlockTypePipelineStepInput, routerUrl, endStepFunc)
-				cmd.StepInput["router_url"] = routerUrl
 				output, primitiveError = p.Run(ctx, cmd.StepInput)
-			} else {
-				p := primitive.NewInputPrimitive(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName)
+			case schema.BlockTypePipelineStepPipeline:
+				p := primitive.RunPipeline{}
 				output, primitiveError = p.Run(ctx, cmd.StepInput)
-			}
-		case schema.BlockTypePipelineStepMessage:
-			if routerUrl, routed := primitive.GetInputRouter(); routed {
-				endStepFunc := func(stepExecution *execution.StepExecution, out *resources.Output) error {
-					return EndStepFromApi(ex, stepExecution, pipelineDefn, stepDefn, out, h.EventBus)
+			case schema.BlockTypePipelineStepEmail:
+				p := primitive.Email{}
+				output, primitiveError = p.Run(ctx, cmd.StepInput)
+			case schema.BlockTypePipelineStepQuery:
+				p := primitive.Query{}
+				output, primitiveError = p.Run(ctx, cmd.StepInput)
+			case schema.BlockTypePipelineStepSleep:
+				p := primitive.Sleep{}
+				output, primitiveError = p.Run(ctx, cmd.StepInput)
+			case schema.BlockTypePipelineStepTransform:
+				p := primitive.Transform{}
+				output, primitiveError = p.Run(ctx, cmd.StepInput)
+			case schema.BlockTypePipelineStepFunction:
+				p := primitive.Function{
+					ModPath: pipelineDefn.GetMod().ModPath,
 				}
-				p := primitive.NewRoutedInput(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName, schema.BlockTypePipelineStepMessage, routerUrl, endStepFunc)
-				cmd.StepInput["router_url"] = routerUrl
 				output, primitiveError = p.Run(ctx, cmd.StepInput)
-			} else {
-				p := primitive.NewMessagePrimitive(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName)
+			case schema.BlockTypePipelineStepContainer:
+				p := primitive.Container{FullyQualifiedStepName: stepDefn.GetFullyQualifiedName()}
 				output, primitiveError = p.Run(ctx, cmd.StepInput)
-			}
-		default:
-			slog.Error("Unknown step type", "type", stepDefn.GetType())
+			case constants.BlockTypePipelineStepCommand:
+				stepOutput := newStepOutputLogger(ctx, cmd)
+				p := primitive.Exec{
+					ModPath:    pipelineDefn.GetMod().ModPath,
+					OutputLine: stepOutput.outputLine,
+				}
+				// the command is killed when its pipeline is paused or cancelled, a command killed by the pause is run
+				// again when the pipeline is resumed
+				output, primitiveError = runInPipeline(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepName, func(runCtx context.Context) (*resources.Output, error) {
+					return p.Run(runCtx, cmd.StepInput)
+				})
+				stepOutput.flush()
+			case schema.BlockTypePipelineStepInput:
+				if routerUrl, routed := primitive.GetInputRouter(); routed {
+					endStepFunc := func(stepExecution *execution.StepExecution, out *resources.Output) error {
+						return EndStepFromApi(ex, stepExecution, pipelineDefn, stepDefn, out, h.EventBus)
+					}
+					p := primitive.NewRoutedInput(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName, schema.BlockTypePipelineStepInput, routerUrl, endStepFunc)
+					cmd.StepInput["router_url"] = routerUrl
+					output, primitiveError = p.Run(ctx, cmd.StepInput)
+				} else {
+					p := primitive.NewInputPrimitive(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName)
+					output, primitiveError = p.Run(ctx, cmd.StepInput)
+				}
+			case schema.BlockTypePipelineStepMessage:
+				if routerUrl, routed := primitive.GetInputRouter(); routed {
+					endStepFunc := func(stepExecution *execution.StepExecution, out *resources.Output) error {
+						return EndStepFromApi(ex, stepExecution, pipelineDefn, stepDefn, out, h.EventBus)
+					}
+					p := primitive.NewRoutedInput(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName, schema.BlockTypePipelineStepMessage, routerUrl, endStepFunc)
+					cmd.StepInput["router_url"] = routerUrl
+					output, primitiveError = p.Run(ctx, cmd.StepInput)
+				} else {
+					p := primitive.NewMessagePrimitive(cmd.Event.ExecutionID, cmd.PipelineExecutionID, cmd.StepExecutionID, pipelineDefn.PipelineName, cmd.StepName)
+					output, primitiveError = p.Run(ctx, cmd.StepInput)
+				}
+			default:
+				slog.Error("Unknown step type", "type", stepDefn.GetType())
 
-			plannerMutex = event.GetEventStoreMutex(cmd.Event.ExecutionID)
-			plannerMutex.Lock()
+				plannerMutex = event.GetEventStoreMutex(cmd.Event.ExecutionID)
+				plannerMutex.Lock()
 
-			err2 := h.EventBus.Publish(ctx, event.NewPipelineFailed(ctx, event.ForStepStartToPipelineFailed(cmd, err)))
-			if err2 != nil {
-				slog.Error("Error publishing event", "error", err2)
-			}
+				err2 := h.EventBus.Publish(ctx, event.NewPipelineFailed(ctx, event.ForStepStartToPipelineFailed(cmd, err)))
+				if err2 != nil {
+					slog.Error("Error publishing event", "error", err2)
+				}
 
-			return
+				return
+			}
 		}
 
+		observeStepDuration()
+
 		plannerMutex = event.GetEventStoreMutex(cmd.Event.ExecutionID)
 		plannerMutex.Lock()
 
@@ -0,0 +1,142 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/worker"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+var errPipelineCanceled = errors.New("pipeline canceled")
+
+// dispatchContext is shared by the steps of a pipeline execution waiting for a worker or running a command. Its context
+// is cancelled when the pipeline is paused or cancelled.
+type dispatchContext struct {
+	key    string
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	refs   int
+
+	// closed when the paused pipeline is resumed or cancelled
+	resumed  chan struct{}
+	canceled bool
+}
+
+type dispatchContexts struct {
+	mu       sync.Mutex
+	contexts map[string]*dispatchContext
+}
+
+var workerDispatches = &dispatchContexts{contexts: map[string]*dispatchContext{}}
+
+func dispatchKey(executionID, pipelineExecutionID string) string {
+	return executionID + "/" + pipelineExecutionID
+}
+
+func (d *dispatchContexts) acquire(executionID, pipelineExecutionID string) *dispatchContext {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := dispatchKey(executionID, pipelineExecutionID)
+	dc := d.contexts[key]
+	if dc == nil {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		dc = &dispatchContext{
+			key:     key,
+			ctx:     ctx,
+			cancel:  cancel,
+			resumed: make(chan struct{}),
+		}
+		d.contexts[key] = dc
+	}
+	dc.refs++
+	return dc
+}
+
+func (d *dispatchContexts) release(dc *dispatchContext) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dc.refs--
+	if dc.refs > 0 {
+		return
+	}
+	if d.contexts[dc.key] == dc {
+		delete(d.contexts, dc.key)
+	}
+	dc.cancel(nil)
+}
+
+// pause takes the steps of the pipeline execution that are not claimed yet out of the worker queue, they are
+// dispatched again once the pipeline is resumed
+func (d *dispatchContexts) pause(executionID, pipelineExecutionID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if dc := d.contexts[dispatchKey(executionID, pipelineExecutionID)]; dc != nil {
+		dc.cancel(worker.ErrPaused)
+	}
+}
+
+func (d *dispatchContexts) resume(executionID, pipelineExecutionID string) {
+	d.end(executionID, pipelineExecutionID, false)
+}
+
+// cancelPipeline stops waiting for the workers, the steps of the pipeline execution are taken out of the worker queue
+func (d *dispatchContexts) cancelPipeline(executionID, pipelineExecutionID string) {
+	d.end(executionID, pipelineExecutionID, true)
+}
+
+func (d *dispatchContexts) end(executionID, pipelineExecutionID string, canceled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := dispatchKey(executionID, pipelineExecutionID)
+	dc := d.contexts[key]
+	if dc == nil {
+		return
+	}
+
+	// the steps dispatched from now on get a new context
+	delete(d.contexts, key)
+	if canceled {
+		dc.canceled = true
+		dc.cancel(errPipelineCanceled)
+	}
+	if dc.ctx.Err() != nil {
+		close(dc.resumed)
+	}
+}
+
+// dispatchToWorkers runs the step on a worker. The step stops waiting for a worker when its pipeline is cancelled. A
+// step not claimed by a worker when its pipeline is paused is dispatched again when the pipeline is resumed.
+func dispatchToWorkers(workers *worker.Queue, job types.WorkerJob) (*resources.Output, error) {
+	return runInPipeline(job.ExecutionID, job.PipelineExecutionID, job.StepName, func(ctx context.Context) (*resources.Output, error) {
+		return workers.Dispatch(ctx, job)
+	})
+}
+
+// runInPipeline runs the step with a context cancelled when its pipeline is paused or cancelled. A step that stopped
+// because its pipeline was paused, i.e. that returned worker.ErrPaused, is run again when the pipeline is resumed.
+func runInPipeline(executionID, pipelineExecutionID, stepName string, run func(ctx context.Context) (*resources.Output, error)) (*resources.Output, error) {
+	dc := workerDispatches.acquire(executionID, pipelineExecutionID)
+	output, err := run(dc.ctx)
+
+	for errors.Is(err, worker.ErrPaused) {
+		<-dc.resumed
+		workerDispatches.release(dc)
+		if dc.canceled {
+			return nil, perr.InternalWithMessage("step " + stepName + " was cancelled while its pipeline was paused")
+		}
+
+		dc = workerDispatches.acquire(executionID, pipelineExecutionID)
+		output, err = run(dc.ctx)
+	}
+
+	workerDispatches.release(dc)
+	return output, err
+}
@@ -0,0 +1,3 @@
+mod "process_mod" {
+    title = "Mod with long running pipelines to control processes"
+}
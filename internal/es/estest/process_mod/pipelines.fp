@@ -0,0 +1,64 @@
+pipeline "sleep_then_echo" {
+    step "sleep" "first" {
+        duration = "2s"
+    }
+
+    step "transform" "echo" {
+        depends_on = [step.sleep.first]
+        value      = "after sleep"
+    }
+
+    output "val" {
+        value = step.transform.echo.value
+    }
+}
+
+pipeline "nested_sleep" {
+    step "pipeline" "child" {
+        pipeline = pipeline.sleep_then_echo
+    }
+
+    step "transform" "echo" {
+        depends_on = [step.pipeline.child]
+        value      = "after child"
+    }
+
+    output "val" {
+        value = step.transform.echo.value
+    }
+}
+
+pipeline "echo_then_fail" {
+    step "transform" "echo" {
+        value = "before fail"
+    }
+
+    step "transform" "fail" {
+        depends_on = [step.transform.echo]
+        value      = "fail"
+
+        throw {
+            if      = result.value == "fail"
+            message = "from throw block"
+        }
+    }
+}
+
+pipeline "fail_while_sleeping" {
+    step "sleep" "first" {
+        duration = "2s"
+    }
+
+    step "transform" "echo" {
+        depends_on = [step.sleep.first]
+        value      = "after sleep"
+    }
+
+    step "transform" "src" {
+        value = "not json"
+    }
+
+    step "transform" "bad" {
+        value = jsondecode(step.transform.src.value)
+    }
+}
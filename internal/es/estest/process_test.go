@@ -0,0 +1,497 @@
+package estest
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	localcmdconfig "github.com/turbot/flowpipe/internal/cmdconfig"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/filepaths"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/service/api"
+	"github.com/turbot/flowpipe/internal/service/manager"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+type ProcessTestSuite struct {
+	suite.Suite
+	*FlowpipeTestSuite
+
+	server                *http.Server
+	SetupSuiteRunCount    int
+	TearDownSuiteRunCount int
+}
+
+// The SetupSuite method will be run by testify once, at the very
+// start of the testing suite, before any tests are run.
+func (suite *ProcessTestSuite) SetupSuite() {
+
+	err := os.Setenv("RUN_MODE", "TEST_ES")
+	if err != nil {
+		panic(err)
+	}
+
+	cache.ResetAllCache()
+
+	suite.server = StartServer()
+
+	// sets app specific constants defined in pipe-fittings
+	viper.SetDefault("main.version", "0.0.0-test.0")
+	viper.SetDefault(constants.ArgProcessRetention, 604800) // 7 days
+	localcmdconfig.SetAppSpecificConstants()
+
+	// Get the current working directory
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	pipelineDirPath := path.Join(cwd, "process_mod")
+
+	viper.GetViper().Set(constants.ArgModLocation, pipelineDirPath)
+
+	// delete flowpipe.db
+	flowpipeDbFilename := filepaths.FlowpipeDBFileName()
+
+	_, err = os.Stat(flowpipeDbFilename)
+	if !os.IsNotExist(err) {
+		// Remove the directory and its contents
+		err = os.Remove(flowpipeDbFilename)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// Create a single, global context for the application
+	ctx := context.Background()
+	suite.ctx = ctx
+
+	// create and start the manager in local mode (i.e. do not set listen address)
+	m, err := manager.NewManager(ctx, manager.WithESService()).Start()
+	error_helpers.FailOnError(err)
+	suite.esService = m.ESService
+
+	suite.manager = m
+
+	suite.SetupSuiteRunCount++
+}
+
+// The TearDownSuite method will be run by testify once, at the very
+// end of the testing suite, after all tests have been run.
+func (suite *ProcessTestSuite) TearDownSuite() {
+	// Wait for a bit to allow the Watermill to finish running the pipelines
+	time.Sleep(3 * time.Second)
+
+	err := suite.esService.Stop()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.server.Shutdown(suite.ctx) //nolint:errcheck // just a test case
+	suite.TearDownSuiteRunCount++
+}
+
+func (suite *ProcessTestSuite) BeforeTest(suiteName, testName string) {
+
+}
+
+func (suite *ProcessTestSuite) AfterTest(suiteName, testName string) {
+}
+
+// stepExecutionsByName returns the step executions of the pipeline execution by the name of their step
+func stepExecutionsByName(pex *execution.PipelineExecution) map[string]*execution.StepExecution {
+	stepExecutions := map[string]*execution.StepExecution{}
+	for _, stepExecution := range pex.StepExecutions {
+		stepExecutions[stepExecution.Name] = stepExecution
+	}
+	return stepExecutions
+}
+
+func (suite *ProcessTestSuite) TestCancelRunningProcess() {
+	assert := assert.New(suite.T())
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.sleep_then_echo", 500*time.Millisecond, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	pipelineExecutionId, pipelineName, err := api.CancelProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	if err != nil {
+		assert.Fail("Error cancelling execution", err)
+		return
+	}
+	assert.Equal(pipelineCmd.PipelineExecutionID, pipelineExecutionId)
+	assert.Equal("process_mod.pipeline.sleep_then_echo", pipelineName)
+
+	ex, err := getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, localconstants.StateCancelled)
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal(localconstants.StateCancelled, ex.Status)
+
+	pex := ex.PipelineExecutions[pipelineCmd.PipelineExecutionID]
+	assert.Equal(localconstants.PipelineStatusCanceled, pex.Status)
+
+	// the sleep step was running when the process was cancelled, no step is planned after it
+	time.Sleep(2 * time.Second)
+	stepExecutions := stepExecutionsByName(pex)
+	assert.Contains(stepExecutions, "sleep.first")
+	assert.NotContains(stepExecutions, "transform.echo")
+
+	// an execution can only be cancelled once
+	_, _, err = api.CancelProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	assert.NotNil(err)
+}
+
+func (suite *ProcessTestSuite) TestCancelPausedProcess() {
+	assert := assert.New(suite.T())
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.sleep_then_echo", 500*time.Millisecond, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	_, _, err = api.PauseProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	if err != nil {
+		assert.Fail("Error pausing execution", err)
+		return
+	}
+
+	ex, err := getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, "paused")
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal("paused", ex.Status)
+
+	_, _, err = api.CancelProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	if err != nil {
+		assert.Fail("Error cancelling execution", err)
+		return
+	}
+
+	ex, err = getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, localconstants.StateCancelled)
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal(localconstants.StateCancelled, ex.Status)
+	assert.Equal(localconstants.PipelineStatusCanceled, ex.PipelineExecutions[pipelineCmd.PipelineExecutionID].Status)
+	assert.NotContains(stepExecutionsByName(ex.PipelineExecutions[pipelineCmd.PipelineExecutionID]), "transform.echo")
+}
+
+func (suite *ProcessTestSuite) TestCancelProcessWithChildPipeline() {
+	assert := assert.New(suite.T())
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.nested_sleep", 1*time.Second, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	_, _, err = api.CancelProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	if err != nil {
+		assert.Fail("Error cancelling execution", err)
+		return
+	}
+
+	ex, err := getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, localconstants.StateCancelled)
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal(localconstants.StateCancelled, ex.Status)
+
+	// the child pipeline is cancelled with its parent
+	children := ex.ChildPipelineExecutions(pipelineCmd.PipelineExecutionID)
+	assert.Equal(1, len(children))
+	for _, pex := range append(children, ex.PipelineExecutions[pipelineCmd.PipelineExecutionID]) {
+		assert.Equal(localconstants.PipelineStatusCanceled, pex.Status, pex.Name)
+	}
+
+	time.Sleep(2 * time.Second)
+	assert.NotContains(stepExecutionsByName(children[0]), "transform.echo")
+	assert.NotContains(stepExecutionsByName(ex.PipelineExecutions[pipelineCmd.PipelineExecutionID]), "transform.echo")
+}
+
+func (suite *ProcessTestSuite) TestPauseResumeProcess() {
+	assert := assert.New(suite.T())
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.sleep_then_echo", 500*time.Millisecond, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	_, _, err = api.PauseProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	if err != nil {
+		assert.Fail("Error pausing execution", err)
+		return
+	}
+
+	// the sleep step carries on while the process is paused but no step is planned after it
+	time.Sleep(2 * time.Second)
+	ex, err := getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, "paused")
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal("paused", ex.Status)
+	stepExecutions := stepExecutionsByName(ex.PipelineExecutions[pipelineCmd.PipelineExecutionID])
+	assert.Equal("finished", stepExecutions["sleep.first"].Status)
+	assert.NotContains(stepExecutions, "transform.echo")
+
+	// a paused execution can't be paused again
+	_, _, err = api.PauseProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	assert.NotNil(err)
+
+	_, _, err = api.ResumeProcess(pipelineCmd.Event.ExecutionID, suite.esService)
+	if err != nil {
+		assert.Fail("Error resuming execution", err)
+		return
+	}
+
+	_, pex, err := getPipelineExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event, pipelineCmd.PipelineExecutionID, 100*time.Millisecond, 50, "finished")
+	if err != nil {
+		assert.Fail("Error getting pipeline execution", err)
+		return
+	}
+	assert.Equal("finished", pex.Status)
+	assert.Equal("after sleep", pex.PipelineOutput["val"])
+}
+
+func (suite *ProcessTestSuite) TestPauseProcessNotInCache() {
+	assert := assert.New(suite.T())
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.sleep_then_echo", 500*time.Millisecond, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	_, pex, err := getPipelineExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event, pipelineCmd.PipelineExecutionID, 100*time.Millisecond, 50, "finished")
+	if err != nil {
+		assert.Fail("Error getting pipeline execution", err)
+		return
+	}
+	assert.Equal("finished", pex.Status)
+
+	// the execution is loaded from the database once it has left the cache, a finished execution can't be paused
+	cache.GetCache().Delete(pipelineCmd.Event.ExecutionID)
+	_, _, err = api.PauseProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	assert.True(perr.IsBadRequest(err), err)
+
+	_, _, err = api.PauseProcess("exec_unknown", "test", suite.esService)
+	assert.True(perr.IsNotFound(err), err)
+}
+
+func (suite *ProcessTestSuite) TestPauseResumeProcessWithChildPipeline() {
+	assert := assert.New(suite.T())
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.nested_sleep", 1*time.Second, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	_, _, err = api.PauseProcess(pipelineCmd.Event.ExecutionID, "test", suite.esService)
+	if err != nil {
+		assert.Fail("Error pausing execution", err)
+		return
+	}
+
+	// the child pipeline is paused with its parent, neither plans a step once the running sleep step finishes
+	time.Sleep(2 * time.Second)
+	ex, err := getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, "paused")
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal("paused", ex.Status)
+
+	children := ex.ChildPipelineExecutions(pipelineCmd.PipelineExecutionID)
+	assert.Equal(1, len(children))
+	for _, pex := range append(children, ex.PipelineExecutions[pipelineCmd.PipelineExecutionID]) {
+		assert.Equal("paused", pex.Status, pex.Name)
+		assert.NotContains(stepExecutionsByName(pex), "transform.echo", pex.Name)
+	}
+
+	_, _, err = api.ResumeProcess(pipelineCmd.Event.ExecutionID, suite.esService)
+	if err != nil {
+		assert.Fail("Error resuming execution", err)
+		return
+	}
+
+	ex, pex, err := getPipelineExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event, pipelineCmd.PipelineExecutionID, 100*time.Millisecond, 50, "finished")
+	if err != nil {
+		assert.Fail("Error getting pipeline execution", err)
+		return
+	}
+	assert.Equal("finished", pex.Status)
+	assert.Equal("after child", pex.PipelineOutput["val"])
+
+	children = ex.ChildPipelineExecutions(pipelineCmd.PipelineExecutionID)
+	assert.Equal(1, len(children))
+	assert.Equal("finished", children[0].Status)
+	assert.Equal("after sleep", children[0].PipelineOutput["val"])
+}
+
+func (suite *ProcessTestSuite) TestFailedPipelineIsNotPlannedAgain() {
+	assert := assert.New(suite.T())
+
+	// the pipeline fails on the bad step while the sleep step is still running
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.fail_while_sleeping", 500*time.Millisecond, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	_, pex, err := getPipelineExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event, pipelineCmd.PipelineExecutionID, 100*time.Millisecond, 50, "failed")
+	if err != nil {
+		assert.Fail("Error getting pipeline execution", err)
+		return
+	}
+	assert.Equal("failed", pex.Status)
+	assert.NotEqual("finished", stepExecutionsByName(pex)["sleep.first"].Status)
+
+	// the sleep step finishing plans the pipeline again, a failed pipeline isn't planned so it doesn't fail twice and
+	// the step after the sleep step isn't started
+	time.Sleep(3 * time.Second)
+	ex, err := getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, "failed")
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal("failed", ex.Status)
+	assert.Equal(1, len(ex.Errors))
+
+	pex = ex.PipelineExecutions[pipelineCmd.PipelineExecutionID]
+	assert.Equal("failed", pex.Status)
+	assert.Equal(1, len(pex.Errors))
+	stepExecutions := stepExecutionsByName(pex)
+	assert.Equal("finished", stepExecutions["sleep.first"].Status)
+	assert.NotContains(stepExecutions, "transform.echo")
+}
+
+func (suite *ProcessTestSuite) TestRetryFailedProcess() {
+	assert := assert.New(suite.T())
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.echo_then_fail", 500*time.Millisecond, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	ex, err := getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, "failed")
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal("failed", ex.Status)
+	failedStepCount := len(ex.PipelineExecutions[pipelineCmd.PipelineExecutionID].StepExecutions)
+
+	retryExecutionId, pipelineExecutionId, pipelineName, err := api.RetryProcess(pipelineCmd.Event.ExecutionID, suite.esService)
+	if err != nil {
+		assert.Fail("Error retrying execution", err)
+		return
+	}
+	assert.NotEqual(pipelineCmd.Event.ExecutionID, retryExecutionId)
+	assert.Equal(pipelineCmd.PipelineExecutionID, pipelineExecutionId)
+	assert.Equal("process_mod.pipeline.echo_then_fail", pipelineName)
+
+	// the step fails again in the retry
+	retryEx, err := getExAndWait(suite.FlowpipeTestSuite, retryExecutionId, 100*time.Millisecond, 50, "failed")
+	if err != nil {
+		assert.Fail("Error getting retry execution", err)
+		return
+	}
+	assert.Equal("failed", retryEx.Status)
+	assert.Equal(pipelineCmd.Event.ExecutionID, retryEx.RetryOf)
+	assert.Equal(2, retryEx.Attempt)
+
+	// only the failed step is run again, the output of the step that completed is kept
+	retryStepExecutions := stepExecutionsByName(retryEx.PipelineExecutions[pipelineExecutionId])
+	assert.Equal("finished", retryStepExecutions["transform.echo"].Status)
+	assert.Equal("before fail", retryStepExecutions["transform.echo"].Output.Data["value"])
+	assert.Equal("failed", retryStepExecutions["transform.fail"].Status)
+
+	// the failed execution is left as it was
+	ex, err = getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, "failed")
+	if err != nil {
+		assert.Fail("Error getting execution", err)
+		return
+	}
+	assert.Equal("failed", ex.Status)
+	assert.Equal("", ex.RetryOf)
+	assert.Equal(failedStepCount, len(ex.PipelineExecutions[pipelineCmd.PipelineExecutionID].StepExecutions))
+
+	// retrying the retry is the next attempt of the same process
+	retryAgainExecutionId, _, _, err := api.RetryProcess(retryExecutionId, suite.esService)
+	if err != nil {
+		assert.Fail("Error retrying execution", err)
+		return
+	}
+	retryAgainEx, err := getExAndWait(suite.FlowpipeTestSuite, retryAgainExecutionId, 100*time.Millisecond, 50, "failed")
+	if err != nil {
+		assert.Fail("Error getting retry execution", err)
+		return
+	}
+	assert.Equal(retryExecutionId, retryAgainEx.RetryOf)
+	assert.Equal(3, retryAgainEx.Attempt)
+}
+
+func (suite *ProcessTestSuite) TestListProcessesWithoutRetention() {
+	assert := assert.New(suite.T())
+
+	// the processes aren't kept in pipeline_run, the running processes are listed from memory
+	viper.Set(constants.ArgProcessRetention, 0)
+	defer viper.Set(constants.ArgProcessRetention, 604800)
+
+	_, pipelineCmd, err := runPipeline(suite.FlowpipeTestSuite, "process_mod.pipeline.sleep_then_echo", 500*time.Millisecond, resources.Input{})
+	if err != nil {
+		assert.Fail("Error creating execution", err)
+		return
+	}
+
+	processIDs := func(query types.ListProcessRequestQuery) []string {
+		result, err := api.ListProcesses(query, "", 100)
+		if err != nil {
+			assert.Fail("Error listing processes", err)
+			return nil
+		}
+		var ids []string
+		for _, process := range result.Items {
+			ids = append(ids, process.ID)
+		}
+		return ids
+	}
+
+	assert.Contains(processIDs(types.ListProcessRequestQuery{}), pipelineCmd.Event.ExecutionID)
+	assert.Contains(processIDs(types.ListProcessRequestQuery{Pipeline: "process_mod.pipeline.sleep_then_echo", Status: "started"}), pipelineCmd.Event.ExecutionID)
+	assert.NotContains(processIDs(types.ListProcessRequestQuery{Status: "failed"}), pipelineCmd.Event.ExecutionID)
+	assert.NotContains(processIDs(types.ListProcessRequestQuery{Pipeline: "process_mod.pipeline.echo_then_fail"}), pipelineCmd.Event.ExecutionID)
+
+	_, err = getExAndWait(suite.FlowpipeTestSuite, pipelineCmd.Event.ExecutionID, 100*time.Millisecond, 50, "finished")
+	assert.Nil(err)
+}
+
+func TestProcessTestingSuite(t *testing.T) {
+	suite.Run(t, &ProcessTestSuite{
+		FlowpipeTestSuite: &FlowpipeTestSuite{},
+	})
+}
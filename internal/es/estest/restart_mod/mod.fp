@@ -0,0 +1,3 @@
+mod "restart_mod" {
+    title = "Mod with pipelines run across a server restart"
+}
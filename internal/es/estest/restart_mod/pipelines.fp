@@ -0,0 +1,42 @@
+pipeline "sleep_then_echo" {
+    step "sleep" "first" {
+        duration = "4s"
+    }
+
+    step "transform" "echo" {
+        depends_on = [step.sleep.first]
+        value      = "after sleep"
+    }
+
+    output "val" {
+        value = step.transform.echo.value
+    }
+}
+
+pipeline "nested_sleep" {
+    step "pipeline" "child" {
+        pipeline = pipeline.sleep_then_echo
+    }
+
+    step "transform" "echo" {
+        depends_on = [step.pipeline.child]
+        value      = "after child"
+    }
+
+    output "val" {
+        value = step.transform.echo.value
+    }
+}
+
+pipeline "nested_sleep_fail_on_restart" {
+    on_restart = "fail"
+
+    step "pipeline" "child" {
+        pipeline = pipeline.sleep_then_echo
+    }
+
+    step "transform" "echo" {
+        depends_on = [step.pipeline.child]
+        value      = "after child"
+    }
+}
@@ -0,0 +1,295 @@
+package estest
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	localcmdconfig "github.com/turbot/flowpipe/internal/cmdconfig"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/filepaths"
+	"github.com/turbot/flowpipe/internal/service/manager"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// The restart tests run the server in a separate process (this test binary running TestRestartServerProcess) so it
+// can be killed mid-step, the same as a crash.
+const (
+	restartServerPhaseEnv       = "FLOWPIPE_TEST_RESTART_PHASE"
+	restartServerPipelineEnv    = "FLOWPIPE_TEST_RESTART_PIPELINE"
+	restartServerExecutionIDEnv = "FLOWPIPE_TEST_RESTART_EXECUTION_ID"
+	restartServerMessageBusEnv  = "FLOWPIPE_TEST_RESTART_MESSAGE_BUS"
+)
+
+func restartModLocation() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	return path.Join(cwd, "restart_mod")
+}
+
+// TestRestartServerProcess is the server of the restart tests, it isn't a test on its own
+func TestRestartServerProcess(t *testing.T) {
+	phase := os.Getenv(restartServerPhaseEnv)
+	if phase == "" {
+		t.Skip("only run as the server process of the restart tests")
+	}
+
+	err := os.Setenv("RUN_MODE", "TEST_ES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.SetDefault("main.version", "0.0.0-test.0")
+	viper.Set(constants.ArgProcessRetention, 604800) // 7 days
+	viper.Set(localconstants.ArgMessageBus, os.Getenv(restartServerMessageBusEnv))
+	viper.Set(constants.ArgModLocation, restartModLocation())
+	localcmdconfig.SetAppSpecificConstants()
+
+	ctx := context.Background()
+	m, err := manager.NewManager(ctx, manager.WithServerConfig("localhost", 7411)).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	switch phase {
+	case "run":
+		executionCmd := event.NewExecutionQueueForPipeline("", os.Getenv(restartServerPipelineEnv))
+		err := m.ESService.Send(executionCmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the test kills the server once the sleep step is running
+		os.Stdout.WriteString("execution_id=" + executionCmd.Event.ExecutionID + "\n") //nolint:errcheck // just a test case
+		select {}
+
+	case "restart":
+		executionID := os.Getenv(restartServerExecutionIDEnv)
+		for i := 0; i < 100; i++ {
+			time.Sleep(100 * time.Millisecond)
+			ex, err := execution.GetExecution(executionID)
+			if err == nil && slices.Contains(event.EndEvents, ex.Status) {
+				break
+			}
+		}
+
+		err := m.Stop()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func restartServer(phase, messageBus, pipeline, executionID string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRestartServerProcess$") //nolint:gosec // just a test case
+	cmd.Env = append(os.Environ(),
+		restartServerPhaseEnv+"="+phase,
+		restartServerMessageBusEnv+"="+messageBus,
+		restartServerPipelineEnv+"="+pipeline,
+		restartServerExecutionIDEnv+"="+executionID)
+	return cmd
+}
+
+// runAndKillServer runs the pipeline in a new server process and kills the server once the pipeline has been
+// running for the given time. It returns the execution ID.
+func runAndKillServer(t *testing.T, messageBus, pipeline string, runningTime time.Duration) string {
+	server := restartServer("run", messageBus, pipeline, "")
+	stdout, err := server.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = server.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executionID := ""
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if id, found := strings.CutPrefix(scanner.Text(), "execution_id="); found {
+			executionID = id
+			break
+		}
+	}
+	if executionID == "" {
+		_ = server.Process.Kill()
+		t.Fatal("the server didn't start the execution")
+	}
+
+	time.Sleep(runningTime)
+	err = server.Process.Kill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = server.Wait()
+
+	return executionID
+}
+
+// restartServer starts the server again and waits for the execution to end
+func restartServerAndWait(t *testing.T, messageBus, executionID string) {
+	output, err := restartServer("restart", messageBus, "", executionID).CombinedOutput()
+	if err != nil {
+		t.Fatal(err, string(output))
+	}
+}
+
+// setupRestartTest points the test process to the flowpipe.db of the restart mod, removes the database of the
+// previous test and loads the pipelines of the mod to read the executions. It returns a function to restore the mod
+// location.
+func setupRestartTest(t *testing.T) func() {
+	viper.SetDefault("main.version", "0.0.0-test.0")
+	viper.SetDefault(constants.ArgProcessRetention, 604800) // 7 days
+	localcmdconfig.SetAppSpecificConstants()
+
+	previousModLocation := viper.GetString(constants.ArgModLocation)
+	viper.Set(constants.ArgModLocation, restartModLocation())
+
+	err := os.Remove(filepaths.FlowpipeDBFileName())
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+
+	_, err = manager.NewManager(context.Background()).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func() {
+		viper.Set(constants.ArgModLocation, previousModLocation)
+	}
+}
+
+func TestReplayStepAfterRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setupRestartTest(t)()
+
+	// kill the server in the middle of the sleep step
+	executionID := runAndKillServer(t, localconstants.MessageBusSQLite, "restart_mod.pipeline.sleep_then_echo", 2*time.Second)
+
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := store.ListMessages(db, "command")
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the step start command was handled but the step was still running, it must not have been acknowledged
+	var topics []string
+	for _, m := range messages {
+		topics = append(topics, m.Topic)
+	}
+	assert.Contains(topics, "event.StepStart")
+
+	ex, err := execution.LoadExecutionFromProcessDB(&event.Event{ExecutionID: executionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("started", ex.Status)
+
+	restartServerAndWait(t, localconstants.MessageBusSQLite, executionID)
+
+	// the step start command is replayed after the restart, the sleep step runs again and the pipeline finishes
+	ex, err = execution.LoadExecutionFromProcessDB(&event.Event{ExecutionID: executionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("finished", ex.Status)
+	assert.Equal(1, len(ex.RootPipelines))
+	pex := ex.PipelineExecutions[ex.RootPipelines[0]]
+	assert.Equal("finished", pex.Status)
+	assert.Equal("after sleep", pex.PipelineOutput["val"])
+
+	db, err = store.OpenFlowpipeDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	messages, err = store.ListMessages(db, "command")
+	assert.Nil(err)
+	for _, m := range messages {
+		assert.NotEqual("event.StepStart", m.Topic)
+	}
+}
+
+func TestRecoverExecutionAfterRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setupRestartTest(t)()
+
+	// kill the server while the child pipeline is sleeping, the in memory message bus loses the step start command
+	executionID := runAndKillServer(t, localconstants.MessageBusMemory, "restart_mod.pipeline.nested_sleep", 2*time.Second)
+
+	executionIDs, err := store.ListInFlightExecutionIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal([]string{executionID}, executionIDs)
+
+	restartServerAndWait(t, localconstants.MessageBusMemory, executionID)
+
+	// the pipelines are resumed, the interrupted sleep step is run again
+	ex, err := execution.LoadExecutionFromProcessDB(&event.Event{ExecutionID: executionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("finished", ex.Status)
+	pex := ex.PipelineExecutions[ex.RootPipelines[0]]
+	assert.Equal("finished", pex.Status)
+	assert.Equal("after child", pex.PipelineOutput["val"])
+
+	children := ex.ChildPipelineExecutions(pex.ID)
+	assert.Equal(1, len(children))
+	assert.Equal("finished", children[0].Status)
+	assert.Equal("after sleep", children[0].PipelineOutput["val"])
+
+	executionIDs, err = store.ListInFlightExecutionIDs()
+	assert.Nil(err)
+	assert.Equal(0, len(executionIDs))
+}
+
+func TestFailExecutionAfterRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setupRestartTest(t)()
+
+	executionID := runAndKillServer(t, localconstants.MessageBusMemory, "restart_mod.pipeline.nested_sleep_fail_on_restart", 2*time.Second)
+
+	restartServerAndWait(t, localconstants.MessageBusMemory, executionID)
+
+	// the on_restart policy of the root pipeline applies to its child pipeline, both are failed and the steps after
+	// the interrupted steps are never started
+	ex, err := execution.LoadExecutionFromProcessDB(&event.Event{ExecutionID: executionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("failed", ex.Status)
+	pex := ex.PipelineExecutions[ex.RootPipelines[0]]
+	assert.Equal("failed", pex.Status)
+	assert.NotContains(stepExecutionsByName(pex), "transform.echo")
+
+	children := ex.ChildPipelineExecutions(pex.ID)
+	assert.Equal(1, len(children))
+	assert.Equal("failed", children[0].Status)
+	assert.NotContains(stepExecutionsByName(children[0]), "transform.echo")
+
+	executionIDs, err := store.ListInFlightExecutionIDs()
+	assert.Nil(err)
+	assert.Equal(0, len(executionIDs))
+}
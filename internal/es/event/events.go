@@ -1,9 +1,12 @@
 package event
 
+import "github.com/turbot/flowpipe/internal/constants"
+
 var EndEvents = []string{
 	"finished",
 	"failed",
-	"cancelled",
+	constants.PipelineStatusCanceled,
+	constants.StateCancelled,
 }
 
 const (
@@ -20,6 +23,7 @@ const (
 	CommandExecutionPause     = "command.execution_pause"
 	HandlerExecutionPaused    = "handler.execution_paused"
 	HandlerExecutionCancelled = "handler.execution_cancelled"
+	CommandExecutionRetry     = "command.execution_retry"
 
 	CommandPipelineCancel      = "command.pipeline_cancel"
 	HandlerPipelineCancelled   = "handler.pipeline_canceled"
@@ -37,6 +41,7 @@ const (
 	HandlerPipelineQueued      = "handler.pipeline_queued"
 	CommandPipelineResume      = "command.pipeline_resume"
 	HandlerPipelineResumed     = "handler.pipeline_resumed"
+	HandlerPipelineRetried     = "handler.pipeline_retried"
 	CommandPipelineStart       = "command.pipeline_start"
 	HandlerPipelineStarted     = "handler.pipeline_started"
 	HandlerStepFinished        = "handler.step_finished"
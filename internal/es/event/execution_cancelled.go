@@ -0,0 +1,23 @@
+package event
+
+type ExecutionCancelled struct {
+	Event         *Event         `json:"event"`
+	PipelineQueue *PipelineQueue `json:"pipeline_queue"`
+	TriggerQueue  *TriggerQueue  `json:"trigger_queue"`
+}
+
+func (e *ExecutionCancelled) GetEvent() *Event {
+	return e.Event
+}
+
+func (e *ExecutionCancelled) HandlerName() string {
+	return HandlerExecutionCancelled
+}
+
+func ExecutionCancelledFromExecutionPlan(e *ExecutionPlan) *ExecutionCancelled {
+	return &ExecutionCancelled{
+		Event:         NewFlowEvent(e.Event),
+		PipelineQueue: e.PipelineQueue,
+		TriggerQueue:  e.TriggerQueue,
+	}
+}
@@ -0,0 +1,46 @@
+package event
+
+import (
+	"encoding/json"
+
+	"github.com/turbot/flowpipe/internal/util"
+)
+
+// ExecutionRetry starts a new execution that retries a failed execution from its failed steps. The new execution
+// starts from the pipeline and step executions of the failed execution, which is left as it is.
+type ExecutionRetry struct {
+	// Event metadata
+	Event *Event `json:"event"`
+
+	// The failed execution that is retried
+	RetryOf string `json:"retry_of"`
+	// The attempt of the new execution, the failed execution that was first run is attempt 1
+	Attempt int `json:"attempt"`
+	// The name of the root pipeline of the failed execution
+	Name string `json:"name"`
+	// The pipeline and step executions of the failed execution the retry starts from, the retry is replayed from its
+	// own event log once the failed execution is purged
+	Snapshot json.RawMessage `json:"snapshot"`
+
+	// Reason for retrying the execution
+	Reason string `json:"reason,omitempty"`
+}
+
+func (e *ExecutionRetry) GetEvent() *Event {
+	return e.Event
+}
+
+func (e *ExecutionRetry) HandlerName() string {
+	return CommandExecutionRetry
+}
+
+// NewExecutionRetry creates the command that retries the given failed execution in a new execution
+func NewExecutionRetry(retryOf string, attempt int, pipelineName string, snapshot json.RawMessage) *ExecutionRetry {
+	return &ExecutionRetry{
+		Event:    NewEventForExecutionID(util.NewExecutionId()),
+		RetryOf:  retryOf,
+		Attempt:  attempt,
+		Name:     pipelineName,
+		Snapshot: snapshot,
+	}
+}
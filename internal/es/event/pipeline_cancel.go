@@ -24,12 +24,13 @@ func (e *PipelineCancel) HandlerName() string {
 type PipelineCancelOption func(*PipelineCancel) error
 
 // NewPipelineCancel creates a new PipelineCancel event.
-func NewPipelineCancel(pipelineExecutionID string, opts ...PipelineCancelOption) (*PipelineCancel, error) {
+func NewPipelineCancel(executionId, pipelineExecutionId string, opts ...PipelineCancelOption) (*PipelineCancel, error) {
 	// Defaults
-	e := NewEventForExecutionID(pipelineExecutionID)
+	e := NewEventForExecutionID(executionId)
 	// Defaults
 	evt := &PipelineCancel{
-		Event: e,
+		Event:               e,
+		PipelineExecutionID: pipelineExecutionId,
 	}
 	// Set options
 	for _, opt := range opts {
@@ -40,3 +41,10 @@ func NewPipelineCancel(pipelineExecutionID string, opts ...PipelineCancelOption)
 	}
 	return evt, nil
 }
+
+func WithPipelineCancelReason(reason string) PipelineCancelOption {
+	return func(cmd *PipelineCancel) error {
+		cmd.Reason = reason
+		return nil
+	}
+}
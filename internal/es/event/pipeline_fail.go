@@ -225,3 +225,20 @@ func ForPipelineFinishedToPipelineFail(e *PipelineFinished, err error) PipelineF
 		}
 	}
 }
+
+func ForPipelineRetriedToPipelineFail(e *PipelineRetried, err error) PipelineFailOption {
+	return func(cmd *PipelineFail) {
+		cmd.Event = NewFlowEvent(e.Event)
+
+		var errorModel perr.ErrorModel
+		if ok := errors.As(err, &errorModel); !ok {
+			errorModel = perr.InternalWithMessage(err.Error())
+		}
+
+		cmd.PipelineExecutionID = e.PipelineExecutionID
+		cmd.Error = &resources.StepError{
+			Error:               errorModel,
+			PipelineExecutionID: e.PipelineExecutionID,
+		}
+	}
+}
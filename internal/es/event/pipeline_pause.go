@@ -47,3 +47,10 @@ func PipelinePauseFromPipelinePlanned(e *PipelinePlanned) *PipelinePause {
 	}
 	return cmd
 }
+
+func WithPipelinePauseReason(reason string) PipelinePauseOption {
+	return func(cmd *PipelinePause) error {
+		cmd.Reason = reason
+		return nil
+	}
+}
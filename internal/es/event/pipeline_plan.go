@@ -68,6 +68,18 @@ func ForPipelineResumed(e *PipelineResumed) PipelinePlanOption {
 	}
 }
 
+func ForPipelineRetried(e *PipelineRetried) PipelinePlanOption {
+	return func(cmd *PipelinePlan) error {
+		cmd.Event = NewFlowEvent(e.Event)
+		if e.PipelineExecutionID != "" {
+			cmd.PipelineExecutionID = e.PipelineExecutionID
+		} else {
+			return fmt.Errorf("missing pipeline execution ID in pipeline retried event: %v", e)
+		}
+		return nil
+	}
+}
+
 func ForPipelineStepFinished(e *StepFinished) PipelinePlanOption {
 	return func(cmd *PipelinePlan) error {
 		cmd.Event = NewFlowEvent(e.Event)
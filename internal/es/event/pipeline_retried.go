@@ -0,0 +1,29 @@
+package event
+
+type PipelineRetried struct {
+	// Event metadata
+	Event *Event `json:"event"`
+	// Unique identifier for this pipeline execution
+	PipelineExecutionID string `json:"pipeline_execution_id"`
+	// Reason for retrying the pipeline execution
+	Reason string `json:"reason,omitempty"`
+}
+
+func (e *PipelineRetried) GetEvent() *Event {
+	return e.Event
+}
+
+func (e *PipelineRetried) HandlerName() string {
+	return HandlerPipelineRetried
+}
+
+// NewPipelineRetriedFromExecutionRetry creates a new PipelineRetried event for a pipeline execution of the retried
+// execution.
+func NewPipelineRetriedFromExecutionRetry(cmd *ExecutionRetry, pipelineExecutionId string) *PipelineRetried {
+	e := &PipelineRetried{
+		Event:               NewFlowEvent(cmd.Event),
+		PipelineExecutionID: pipelineExecutionId,
+		Reason:              cmd.Reason,
+	}
+	return e
+}
@@ -0,0 +1,44 @@
+package event
+
+import (
+	"github.com/turbot/flowpipe/internal/util"
+)
+
+const StepOutputMessage = "step_output"
+
+// StepOutput is a batch of lines of output of a running step, e.g. of a command step. It's not an event of the event
+// bus, it's appended to the process event log as the step runs so the output can be followed before the step ends.
+type StepOutput struct {
+	Event               *Event           `json:"event"`
+	PipelineExecutionID string           `json:"pipeline_execution_id"`
+	StepExecutionID     string           `json:"step_execution_id"`
+	StepName            string           `json:"step_name"`
+	Lines               []StepOutputLine `json:"lines"`
+}
+
+type StepOutputLine struct {
+	Stream string `json:"stream"`
+	Line   string `json:"line"`
+}
+
+func NewStepOutput(cmd *StepStart, lines []StepOutputLine) *StepOutput {
+	return &StepOutput{
+		Event:               NewFlowEvent(cmd.Event),
+		PipelineExecutionID: cmd.PipelineExecutionID,
+		StepExecutionID:     cmd.StepExecutionID,
+		StepName:            cmd.StepName,
+		Lines:               lines,
+	}
+}
+
+func NewEventLogFromStepOutput(stepOutput *StepOutput) EventLogImpl {
+	return EventLogImpl{
+		StructVersion: "2.0",
+		ID:            util.NewProcessLogId(),
+		ProcessID:     stepOutput.Event.ExecutionID,
+		Message:       StepOutputMessage,
+		Level:         "info",
+		CreatedAt:     stepOutput.Event.CreatedAt,
+		Detail:        stepOutput,
+	}
+}
@@ -1,6 +1,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/turbot/flowpipe/internal/resources"
 )
 
@@ -9,6 +11,9 @@ type TriggerQueue struct {
 	TriggerExecutionID string         `json:"trigger_execution_id"`
 	Name string          `json:"name"`
 	Args resources.Input `json:"args"`
+
+	// The time a schedule trigger was scheduled to run, a missed run replayed at startup runs later than this
+	ScheduledTime *time.Time `json:"scheduled_time,omitempty"`
 }
 
 func (e *TriggerQueue) GetEvent() *Event {
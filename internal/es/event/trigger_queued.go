@@ -1,6 +1,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/turbot/flowpipe/internal/resources"
 )
 
@@ -9,6 +11,8 @@ type TriggerQueued struct {
 	TriggerExecutionID string         `json:"trigger_execution_id"`
 	Name string          `json:"name"`
 	Args resources.Input `json:"args"`
+
+	ScheduledTime *time.Time `json:"scheduled_time,omitempty"`
 }
 
 func (e *TriggerQueued) GetEvent() *Event {
@@ -25,5 +29,6 @@ func TriggerQueuedFromTriggerQueue(q *TriggerQueue) *TriggerQueued {
 		TriggerExecutionID: q.TriggerExecutionID,
 		Name:               q.Name,
 		Args:               q.Args,
+		ScheduledTime:      q.ScheduledTime,
 	}
 }
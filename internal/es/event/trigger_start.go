@@ -1,6 +1,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/turbot/flowpipe/internal/resources"
 )
 
@@ -8,6 +10,8 @@ type TriggerStart struct {
 	Event *Event         `json:"event"`
 	Name string          `json:"name"`
 	Args resources.Input `json:"args"`
+
+	ScheduledTime *time.Time `json:"scheduled_time,omitempty"`
 }
 
 func (e *TriggerStart) GetEvent() *Event {
@@ -20,8 +24,9 @@ func (e *TriggerStart) HandlerName() string {
 
 func TriggerStartFromTriggerQueued(q *TriggerQueued) *TriggerStart {
 	return &TriggerStart{
-		Event: NewFlowEvent(q.Event),
-		Name:  q.Name,
-		Args:  q.Args,
+		Event:         NewFlowEvent(q.Event),
+		Name:          q.Name,
+		Args:          q.Args,
+		ScheduledTime: q.ScheduledTime,
 	}
 }
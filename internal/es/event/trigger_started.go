@@ -1,6 +1,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/turbot/flowpipe/internal/resources"
 )
 
@@ -8,6 +10,8 @@ type TriggerStarted struct {
 	Event   *Event             `json:"event"`
 	Trigger *resources.Trigger `json:"trigger"`
 	Args    resources.Input    `json:"args"`
+
+	ScheduledTime *time.Time `json:"scheduled_time,omitempty"`
 }
 
 func (e *TriggerStarted) GetEvent() *Event {
@@ -20,8 +24,9 @@ func (e *TriggerStarted) HandlerName() string {
 
 func TriggerStartedFromTriggerStart(s *TriggerStart, trigger *resources.Trigger) *TriggerStarted {
 	return &TriggerStarted{
-		Event:   NewFlowEvent(s.Event),
-		Args:    s.Args,
-		Trigger: trigger,
+		Event:         NewFlowEvent(s.Event),
+		Args:          s.Args,
+		Trigger:       trigger,
+		ScheduledTime: s.ScheduledTime,
 	}
 }
@@ -55,6 +55,11 @@ type Execution struct {
 
 	// Execution level errors - new concept since we elevated the importance of execution
 	Errors []perr.ErrorModel `json:"errors"`
+
+	// The failed execution retried by this execution and the attempt of this execution, the failed execution that was
+	// first run is attempt 1
+	RetryOf string `json:"retry_of,omitempty"`
+	Attempt int    `json:"attempt,omitempty"`
 }
 
 func (ex *Execution) FindPipelineExecutionByItsParentStepExecution(stepExecutionId string) *PipelineExecution {
@@ -66,6 +71,114 @@ func (ex *Execution) FindPipelineExecutionByItsParentStepExecution(stepExecution
 	return nil
 }
 
+// ChildPipelineExecutions returns all the pipeline executions started (directly or indirectly) by the given pipeline
+// execution. The deepest child pipelines are returned first.
+func (ex *Execution) ChildPipelineExecutions(pipelineExecutionID string) []*PipelineExecution {
+	var children []*PipelineExecution
+	for _, pe := range ex.PipelineExecutions {
+		if pe.ParentExecutionID != pipelineExecutionID {
+			continue
+		}
+		children = append(children, ex.ChildPipelineExecutions(pe.ID)...)
+		children = append(children, pe)
+	}
+	return children
+}
+
+// FailedChildPipelineExecutions returns the failed child pipelines that caused a pipeline step in the given
+// pipeline execution to fail. The deepest child pipelines are returned first.
+func (ex *Execution) FailedChildPipelineExecutions(pipelineExecutionID string) []*PipelineExecution {
+	pe := ex.PipelineExecutions[pipelineExecutionID]
+	if pe == nil {
+		return nil
+	}
+
+	var children []*PipelineExecution
+	for _, se := range pe.StepExecutions {
+		if se.Status != constants.StateFailed {
+			continue
+		}
+
+		childPe := ex.FindPipelineExecutionByItsParentStepExecution(se.ID)
+		if childPe == nil || !childPe.IsFail() {
+			continue
+		}
+
+		children = append(children, ex.FailedChildPipelineExecutions(childPe.ID)...)
+		children = append(children, childPe)
+	}
+	return children
+}
+
+// resetFailedSteps prepares a failed pipeline execution to be retried. The outputs of the completed steps are kept,
+// the failed and never started steps are removed so the planner will queue them again.
+//
+// Pipeline steps whose child pipeline is being retried (the child is retried before its parent) are moved back
+// to started so they are finished by the child pipeline.
+func (ex *Execution) resetFailedSteps(pe *PipelineExecution) {
+	retriedSteps := map[string]bool{}
+
+	for _, se := range pe.StepExecutions {
+		if se.Status != constants.StateFailed {
+			continue
+		}
+
+		childPe := ex.FindPipelineExecutionByItsParentStepExecution(se.ID)
+		if childPe == nil || slices.Contains(event.EndEvents, childPe.Status) {
+			continue
+		}
+
+		key := ""
+		if se.StepForEach != nil {
+			key = se.StepForEach.Key
+		}
+
+		// se.Name is the fully qualified name of the step, which is how the step status is keyed
+		stepStatus := pe.StepStatus[se.Name][key]
+		if stepStatus == nil {
+			continue
+		}
+
+		delete(stepStatus.Failed, se.ID)
+		stepStatus.Started[se.ID] = true
+		stepStatus.StepExecutions = slices.DeleteFunc(stepStatus.StepExecutions, func(s StepExecution) bool {
+			return s.ID == se.ID
+		})
+
+		se.Status = "started"
+		se.Output = nil
+		se.EndTime = time.Time{}
+
+		retriedSteps[se.Name] = true
+	}
+
+	for stepName, indexedStatus := range pe.StepStatus {
+		if retriedSteps[stepName] {
+			continue
+		}
+
+		// A step with no status is a step that was planned but never started, i.e. it depends on a failed step
+		reset := len(indexedStatus) == 0
+		for _, status := range indexedStatus {
+			if status.IsFail() || !status.IsComplete() {
+				reset = true
+				break
+			}
+		}
+
+		if !reset {
+			continue
+		}
+
+		delete(pe.StepStatus, stepName)
+		for id, se := range pe.StepExecutions {
+			if se.Name == stepName {
+				delete(pe.StepExecutions, id)
+			}
+		}
+	}
+}
+
 func (ex *Execution) BuildEvalContext(pipelineDefn *resources.Pipeline, pe *PipelineExecution) (*hcl.EvalContext, error) {
 	executionVariables, err := pe.GetExecutionVariables()
 	if err != nil {
@@ -953,6 +1066,8 @@ var (
 	ExecutionFailedEvent   = event.ExecutionFailed{}
 	ExecutionPausedEvent   = event.ExecutionPaused{}
 
+	ExecutionCancelledEvent = event.ExecutionCancelled{}
+
 	TriggerQueuedEvent   = event.TriggerQueued{}
 	TriggerFailedEvent   = event.TriggerFailed{}
 	TriggerStartedEvent  = event.TriggerStarted{}
@@ -961,6 +1076,7 @@ var (
 	PipelineQueuedEvent   = event.PipelineQueued{}
 	PipelineStartedEvent  = event.PipelineStarted{}
 	PipelineResumedEvent  = event.PipelineResumed{}
+	PipelineRetriedEvent  = event.PipelineRetried{}
 	PipelinePlannedEvent  = event.PipelinePlanned{}
 	PipelineCanceledEvent = event.PipelineCanceled{}
 	PipelinePausedEvent   = event.PipelinePaused{}
@@ -982,6 +1098,7 @@ var (
 	ExecutionPlanCommand   = event.ExecutionPlan{}
 	ExecutionFinishCommand = event.ExecutionFinish{}
 	ExecutionFailCommand   = event.ExecutionFail{}
+	ExecutionRetryCommand  = event.ExecutionRetry{}
 
 	TriggerFinishCommand = event.TriggerFinish{}
 	TriggerQueueCommand  = event.TriggerQueue{}
@@ -1003,6 +1120,33 @@ var (
 	StepPipelineFinishCommand = event.StepPipelineFinish{} // this command is fired when a child pipeline has finished. This is to inform the parent pipeline to continue the execution
 )
 
+// retrySnapshot is the state of a failed execution a retry starts from. The step executions aren't serialised with
+// their pipeline execution, they are kept by pipeline execution ID.
+type retrySnapshot struct {
+	RootPipelines      []string                             `json:"root_pipelines"`
+	PipelineExecutions map[string]*PipelineExecution        `json:"pipeline_executions"`
+	StepExecutions     map[string]map[string]*StepExecution `json:"step_executions"`
+}
+
+// RetrySnapshot returns the pipeline and step executions of the failed execution, the retry of the execution starts
+// from them
+func (ex *Execution) RetrySnapshot() (json.RawMessage, error) {
+	stepExecutions := map[string]map[string]*StepExecution{}
+	for id, pe := range ex.PipelineExecutions {
+		stepExecutions[id] = pe.StepExecutions
+	}
+
+	data, err := json.Marshal(retrySnapshot{
+		RootPipelines:      ex.RootPipelines,
+		PipelineExecutions: ex.PipelineExecutions,
+		StepExecutions:     stepExecutions,
+	})
+	if err != nil {
+		return nil, perr.InternalWithMessage("Failed to snapshot execution " + ex.ID + ": " + err.Error())
+	}
+	return data, nil
+}
+
 func (ex *Execution) appendEvent(entry interface{}) error {
 
 	switch et := entry.(type) {
@@ -1023,6 +1167,36 @@ func (ex *Execution) appendEvent(entry interface{}) error {
 	case *event.ExecutionPaused:
 		ex.Status = "paused"
 
+	case *event.ExecutionCancelled:
+		ex.Status = constants.StateCancelled
+
+	case *event.ExecutionRetry:
+		// The retry starts from a copy of the pipeline and step executions of the failed execution, the failed
+		// execution is left as it is. The trigger of the failed execution isn't run again.
+		var snapshot retrySnapshot
+		if len(et.Snapshot) > 0 {
+			err := json.Unmarshal(et.Snapshot, &snapshot)
+			if err != nil {
+				slog.Error("Failed to read the snapshot of the retried execution", "execution", ex.ID, "retry_of", et.RetryOf, "error", err)
+				return perr.InternalWithMessage("Failed to read the snapshot of the retried execution " + et.RetryOf)
+			}
+		}
+		if len(snapshot.PipelineExecutions) == 0 {
+			return perr.NotFoundWithMessage("retried execution " + et.RetryOf + " not found")
+		}
+
+		for id, pe := range snapshot.PipelineExecutions {
+			pe.StepExecutions = snapshot.StepExecutions[id]
+			if pe.StepExecutions == nil {
+				pe.StepExecutions = map[string]*StepExecution{}
+			}
+		}
+		ex.PipelineExecutions = snapshot.PipelineExecutions
+		ex.RootPipelines = snapshot.RootPipelines
+		ex.Status = "queued"
+		ex.RetryOf = et.RetryOf
+		ex.Attempt = et.Attempt
+
 	case *event.TriggerQueue:
 		if ex.TriggerExecution != nil {
 			return perr.BadRequestWithMessage("trigger execution already exists")
@@ -1078,6 +1252,23 @@ func (ex *Execution) appendEvent(entry interface{}) error {
 		ex.Status = "started"
 		ex.ResumedAt = et.Event.CreatedAt
 
+	case *event.PipelineRetried:
+		pe := ex.PipelineExecutions[et.PipelineExecutionID]
+		if pe == nil {
+			return perr.NotFoundWithMessage("pipeline execution " + et.PipelineExecutionID + " not found")
+		}
+		ex.resetFailedSteps(pe)
+
+		pe.Status = "started"
+		pe.Errors = []resources.StepError{}
+		pe.PipelineOutput = nil
+		pe.EndTime = time.Time{}
+		pe.ResumedAt = et.Event.CreatedAt
+
+		ex.Status = "started"
+		ex.Errors = nil
+		ex.ResumedAt = et.Event.CreatedAt
+
 	case *event.PipelinePlanned:
 		pe := ex.PipelineExecutions[et.PipelineExecutionID]
 
@@ -1255,7 +1446,7 @@ func (ex *Execution) appendEvent(entry interface{}) error {
 
 	case *event.PipelineCanceled:
 		pe := ex.PipelineExecutions[et.PipelineExecutionID]
-		pe.Status = "canceled"
+		pe.Status = constants.PipelineStatusCanceled
 		pe.EndTime = et.Event.CreatedAt
 
 	case *event.PipelinePaused:
@@ -1335,6 +1526,16 @@ func (ex *Execution) AppendEventLogEntry(logEntry event.EventLogImpl) error {
 
 	switch logEntry.GetEventType() {
 
+	case ExecutionRetryCommand.HandlerName(): // "command.execution_retry"
+		var et event.ExecutionRetry
+		err := json.Unmarshal(jsonData, &et)
+		if err != nil {
+			slog.Error("Fail to unmarshall command.execution_retry event", "execution", ex.ID, "error", err)
+			return perr.InternalWithMessage("Fail to unmarshall command.execution_retry event")
+		}
+
+		return ex.appendEvent(&et)
+
 	case PipelineQueuedEvent.HandlerName(): // "handler.pipeline_queued"
 		var et event.PipelineQueued
 		err := json.Unmarshal(jsonData, &et)
@@ -1366,6 +1567,16 @@ func (ex *Execution) AppendEventLogEntry(logEntry event.EventLogImpl) error {
 
 		return ex.appendEvent(&et)
 
+	case PipelineRetriedEvent.HandlerName(): // "handler.pipeline_retried"
+		var et event.PipelineRetried
+		err := json.Unmarshal(jsonData, &et)
+		if err != nil {
+			slog.Error("Fail to unmarshall handler.pipeline_retried event", "execution", ex.ID, "error", err)
+			return perr.InternalWithMessage("Fail to unmarshall handler.pipeline_retried event")
+		}
+
+		return ex.appendEvent(&et)
+
 	case PipelinePlannedEvent.HandlerName(): // "handler.pipeline_planned"
 		var et event.PipelinePlanned
 		err := json.Unmarshal(jsonData, &et)
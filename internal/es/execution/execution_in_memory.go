@@ -727,6 +727,26 @@ func (ex *ExecutionInMemory) AppendSerialisedEventLogEntry(logEntry event.EventL
 
 		return ex.appendEvent(&et)
 
+	case ExecutionCancelledEvent.HandlerName(): // "handler.execution_cancelled"
+		var et event.ExecutionCancelled
+		err := json.Unmarshal(jsonData, &et)
+		if err != nil {
+			slog.Error("Fail to unmarshall handler.execution_cancelled event", "execution", ex.ID, "error", err)
+			return perr.InternalWithMessage("Fail to unmarshall handler.execution_cancelled event")
+		}
+
+		return ex.appendEvent(&et)
+
+	case ExecutionRetryCommand.HandlerName(): // "command.execution_retry"
+		var et event.ExecutionRetry
+		err := json.Unmarshal(jsonData, &et)
+		if err != nil {
+			slog.Error("Fail to unmarshall command.execution_retry event", "execution", ex.ID, "error", err)
+			return perr.InternalWithMessage("Fail to unmarshall command.execution_retry event")
+		}
+
+		return ex.appendEvent(&et)
+
 	case PipelineQueueCommand.HandlerName(): // "command.pipeline_queue"
 		var et event.PipelineQueue
 		err := json.Unmarshal(jsonData, &et)
@@ -768,6 +788,16 @@ func (ex *ExecutionInMemory) AppendSerialisedEventLogEntry(logEntry event.EventL
 
 		return ex.appendEvent(&et)
 
+	case PipelineRetriedEvent.HandlerName(): // "handler.pipeline_retried"
+		var et event.PipelineRetried
+		err := json.Unmarshal(jsonData, &et)
+		if err != nil {
+			slog.Error("Fail to unmarshall handler.pipeline_retried event", "execution", ex.ID, "error", err)
+			return perr.InternalWithMessage("Fail to unmarshall handler.pipeline_retried event")
+		}
+
+		return ex.appendEvent(&et)
+
 	case PipelinePlannedEvent.HandlerName(): // "handler.pipeline_planned"
 		var et event.PipelinePlanned
 		err := json.Unmarshal(jsonData, &et)
@@ -946,6 +976,24 @@ func (ex *ExecutionInMemory) AppendEventLogEntry(logEntry event.EventLogImpl) er
 
 		return ex.appendEvent(et)
 
+	case ExecutionCancelledEvent.HandlerName(): // "handler.execution_cancelled"
+		et, ok := logEntry.GetDetail().(*event.ExecutionCancelled)
+		if !ok {
+			slog.Error("Fail to unmarshall handler.execution_cancelled event", "execution", ex.ID)
+			return perr.InternalWithMessage("Fail to unmarshall handler.execution_cancelled event")
+		}
+
+		return ex.appendEvent(et)
+
+	case ExecutionRetryCommand.HandlerName(): // "command.execution_retry"
+		et, ok := logEntry.GetDetail().(*event.ExecutionRetry)
+		if !ok {
+			slog.Error("Fail to unmarshall command.execution_retry event", "execution", ex.ID)
+			return perr.InternalWithMessage("Fail to unmarshall command.execution_retry event")
+		}
+
+		return ex.appendEvent(et)
+
 	case PipelineQueueCommand.HandlerName(): // "command.pipeline_queue"
 		et, ok := logEntry.GetDetail().(*event.PipelineQueue)
 		if !ok {
@@ -982,6 +1030,15 @@ func (ex *ExecutionInMemory) AppendEventLogEntry(logEntry event.EventLogImpl) er
 
 		return ex.appendEvent(et)
 
+	case PipelineRetriedEvent.HandlerName(): // "handler.pipeline_retried"
+		et, ok := logEntry.GetDetail().(*event.PipelineRetried)
+		if !ok {
+			slog.Error("Fail to unmarshall handler.pipeline_retried event", "execution", ex.ID)
+			return perr.InternalWithMessage("Fail to unmarshall handler.pipeline_retried event")
+		}
+
+		return ex.appendEvent(et)
+
 	case PipelinePlannedEvent.HandlerName(): // "handler.pipeline_planned"
 		et, ok := logEntry.GetDetail().(*event.PipelinePlanned)
 		if !ok {
@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/perr"
 )
 
 func TestExecutionLoadFromDB(t *testing.T) {
@@ -27,3 +29,131 @@ func TestExecutionLoadFromDB(t *testing.T) {
 
 	assert.Equal("pexec_cqlecr4204vm48hs8lpg", pe.ID)
 }
+
+func TestResetFailedSteps(t *testing.T) {
+	assert := assert.New(t)
+
+	newStepStatus := func() *StepStatus {
+		return &StepStatus{
+			Queued:   map[string]bool{},
+			Started:  map[string]bool{},
+			Finished: map[string]bool{},
+			Failed:   map[string]bool{},
+		}
+	}
+
+	finished := newStepStatus()
+	finished.Finished["sexec_a"] = true
+
+	failed := newStepStatus()
+	failed.Failed["sexec_b"] = true
+
+	failedPipelineStep := newStepStatus()
+	failedPipelineStep.Failed["sexec_d"] = true
+	failedPipelineStep.StepExecutions = []StepExecution{{ID: "sexec_d", Name: "pipeline.d", Status: "failed"}}
+
+	pe := &PipelineExecution{
+		ID: "pexec_parent",
+		StepStatus: map[string]map[string]*StepStatus{
+			"transform.a": {"0": finished},
+			"http.b":      {"0": failed},
+			"transform.c": {},
+			"pipeline.d":  {"0": failedPipelineStep},
+		},
+		StepExecutions: map[string]*StepExecution{
+			"sexec_a": {ID: "sexec_a", Name: "transform.a", Status: "finished", StepForEach: &resources.StepForEach{Key: "0"}},
+			"sexec_b": {ID: "sexec_b", Name: "http.b", Status: "failed", StepForEach: &resources.StepForEach{Key: "0"}},
+			"sexec_d": {ID: "sexec_d", Name: "pipeline.d", Status: "failed", StepForEach: &resources.StepForEach{Key: "0"}},
+		},
+	}
+
+	ex := &Execution{
+		PipelineExecutions: map[string]*PipelineExecution{
+			"pexec_parent": pe,
+			// the child pipeline has already been retried
+			"pexec_child": {ID: "pexec_child", ParentExecutionID: "pexec_parent", ParentStepExecutionID: "sexec_d", Status: "started"},
+		},
+	}
+
+	ex.resetFailedSteps(pe)
+
+	// completed step is kept
+	assert.NotNil(pe.StepStatus["transform.a"])
+	assert.NotNil(pe.StepExecutions["sexec_a"])
+
+	// failed and never started steps are removed so they are planned again
+	assert.Nil(pe.StepStatus["http.b"])
+	assert.Nil(pe.StepExecutions["sexec_b"])
+	_, ok := pe.StepStatus["transform.c"]
+	assert.False(ok)
+
+	// pipeline step waits for the retried child pipeline
+	assert.Equal("started", pe.StepExecutions["sexec_d"].Status)
+	assert.True(pe.StepStatus["pipeline.d"]["0"].Started["sexec_d"])
+	assert.False(pe.StepStatus["pipeline.d"]["0"].IsFail())
+	assert.Equal(0, len(pe.StepStatus["pipeline.d"]["0"].StepExecutions))
+}
+
+func TestExecutionRetryFromSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	failedEx := &Execution{
+		ID:            "exec_failed",
+		RootPipelines: []string{"pexec_failed"},
+		PipelineExecutions: map[string]*PipelineExecution{
+			"pexec_failed": {
+				ID:     "pexec_failed",
+				Name:   "local.pipeline.etl",
+				Status: "failed",
+				StepStatus: map[string]map[string]*StepStatus{
+					"transform.extract": {"0": {Finished: map[string]bool{"sexec_extract": true}}},
+				},
+				StepExecutions: map[string]*StepExecution{
+					"sexec_extract": {
+						ID:     "sexec_extract",
+						Name:   "transform.extract",
+						Status: "finished",
+						Output: &resources.Output{Data: map[string]interface{}{"value": "orders"}},
+					},
+				},
+			},
+		},
+	}
+
+	snapshot, err := failedEx.RetrySnapshot()
+	if !assert.Nil(err) {
+		return
+	}
+
+	// the retry is replayed from its own event, without the event log of the failed execution
+	cmd := event.NewExecutionRetry("exec_failed", 2, "local.pipeline.etl", snapshot)
+	ex := &Execution{ID: cmd.Event.ExecutionID, PipelineExecutions: map[string]*PipelineExecution{}}
+	if !assert.Nil(ex.appendEvent(cmd)) {
+		return
+	}
+
+	assert.Equal("exec_failed", ex.RetryOf)
+	assert.Equal(2, ex.Attempt)
+	assert.Equal([]string{"pexec_failed"}, ex.RootPipelines)
+	if assert.NotNil(ex.PipelineExecutions["pexec_failed"]) {
+		pe := ex.PipelineExecutions["pexec_failed"]
+		assert.Equal("local.pipeline.etl", pe.Name)
+		assert.Equal("orders", pe.StepExecutions["sexec_extract"].Output.Data["value"])
+		assert.True(pe.StepStatus["transform.extract"]["0"].Finished["sexec_extract"])
+	}
+
+	// the failed execution is left as it is
+	assert.NotSame(failedEx.PipelineExecutions["pexec_failed"], ex.PipelineExecutions["pexec_failed"])
+
+	// a retry without the state of the failed execution can't be replayed
+	err = (&Execution{PipelineExecutions: map[string]*PipelineExecution{}}).appendEvent(event.NewExecutionRetry("exec_failed", 2, "local.pipeline.etl", nil))
+	if assert.NotNil(err) {
+		assert.Equal(404, err.(perr.ErrorModel).Status)
+	}
+
+	// an unknown pipeline execution is not found rather than a panic
+	err = ex.appendEvent(&event.PipelineRetried{Event: event.NewFlowEvent(cmd.Event), PipelineExecutionID: "pexec_unknown"})
+	if assert.NotNil(err) {
+		assert.Equal(404, err.(perr.ErrorModel).Status)
+	}
+}
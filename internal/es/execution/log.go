@@ -14,9 +14,13 @@ import (
 
 func LogEventMessageToFile(ctx context.Context, logEntry event.EventLogImpl) error {
 
-	commandEvent, ok := logEntry.GetDetail().(event.CommandEvent)
-
-	if !ok {
+	var executionID string
+	switch detail := logEntry.GetDetail().(type) {
+	case event.CommandEvent:
+		executionID = detail.GetEvent().ExecutionID
+	case *event.StepOutput:
+		executionID = detail.Event.ExecutionID
+	default:
 		return perr.BadRequestWithMessage("event is not a CommandEvent")
 	}
 
@@ -31,7 +35,7 @@ func LogEventMessageToFile(ctx context.Context, logEntry event.EventLogImpl) err
 		return perr.InternalWithMessage("Error creating directory " + err.Error())
 	}
 
-	eventStoreFilePath := filepaths.EventStoreFilePath(commandEvent.GetEvent().ExecutionID)
+	eventStoreFilePath := filepaths.EventStoreFilePath(executionID)
 
 	// Append the JSON data to a file
 	file, err := os.OpenFile(eventStoreFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/db"
 	"github.com/turbot/flowpipe/internal/fperr"
 	"github.com/turbot/flowpipe/internal/resources"
@@ -316,7 +317,7 @@ func BuildSingleStepExecutionOutput(lastStepExecution *StepExecution, stepName s
 
 // IsCanceled returns true if the pipeline has been canceled
 func (pe *PipelineExecution) IsCanceled() bool {
-	return pe.Status == "canceled"
+	return pe.Status == constants.PipelineStatusCanceled
 }
 
 // IsPaused returns true if the pipeline has been paused
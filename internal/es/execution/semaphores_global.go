@@ -5,6 +5,7 @@ import (
 	"log/slog"
 
 	"github.com/spf13/viper"
+	"github.com/turbot/flowpipe/internal/metrics"
 	"github.com/turbot/flowpipe/internal/output"
 	"github.com/turbot/pipe-fittings/constants"
 	"golang.org/x/sync/semaphore"
@@ -49,6 +50,9 @@ func InitGlobalStepSemaphores() {
 }
 
 func GetStepTypeSemaphore(stepType string) error {
+	// the steps blocked here are the queue depth of the step type
+	defer metrics.StepSemaphoreWait(stepType)()
+
 	var err error
 	switch stepType {
 	case "http":
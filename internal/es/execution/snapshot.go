@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/schema"
@@ -342,7 +343,7 @@ func Category(category string) map[string]interface{} {
 			"color": "grey",
 			"icon":  "timer",
 		}
-	case "exec":
+	case "exec", constants.BlockTypePipelineStepCommand:
 		return map[string]interface{}{
 			"name":  "exec",
 			"title": "Exec",
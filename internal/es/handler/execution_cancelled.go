@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/tracing"
+	"github.com/turbot/flowpipe/internal/trigger"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+type ExecutionCancelled EventHandler
+
+func (h ExecutionCancelled) HandlerName() string {
+	return execution.ExecutionCancelledEvent.HandlerName()
+}
+
+func (h ExecutionCancelled) NewEvent() interface{} {
+	return &event.ExecutionCancelled{}
+}
+
+func (h ExecutionCancelled) Handle(ctx context.Context, ei interface{}) error {
+
+	evt, ok := ei.(*event.ExecutionCancelled)
+
+	if !ok {
+		slog.Error("invalid event type", "expected", "*event.ExecutionCancelled", "actual", ei)
+		return perr.BadRequestWithMessage("invalid event type expected *event.ExecutionCancelled")
+	}
+
+	slog.Info("Received execution cancelled event", "execution_id", evt.Event.ExecutionID)
+
+	plannerMutex := event.GetEventStoreMutex(evt.Event.ExecutionID)
+	plannerMutex.Lock()
+	defer func() {
+		if plannerMutex != nil {
+			plannerMutex.Unlock()
+		}
+	}()
+
+	tracing.EndExecution(evt.Event.ExecutionID, nil)
+
+	SendQueuedTriggerExecution(ctx, h.CommandBus, evt.Event.ExecutionID)
+	trigger.EndQueueMessage(evt.Event.ExecutionID, localconstants.StateCancelled)
+
+	slog.Info("Execution cancelled", "execution_id", evt.Event.ExecutionID)
+
+	return nil
+}
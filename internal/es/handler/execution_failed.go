@@ -4,8 +4,11 @@ import (
 	"context"
 	"log/slog"
 
+	localconstants "github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/tracing"
+	"github.com/turbot/flowpipe/internal/trigger"
 	"github.com/turbot/pipe-fittings/perr"
 )
 
@@ -36,5 +39,15 @@ func (h ExecutionFailed) Handle(ctx context.Context, ei interface{}) error {
 		}
 	}()
 
+	// the trigger span covers the whole execution
+	var err error
+	if evt.Error.Status != 0 {
+		err = evt.Error
+	}
+	tracing.EndExecution(evt.Event.ExecutionID, err)
+
+	SendQueuedTriggerExecution(ctx, h.CommandBus, evt.Event.ExecutionID)
+	trigger.EndQueueMessage(evt.Event.ExecutionID, localconstants.StateFailed)
+
 	return nil
 }
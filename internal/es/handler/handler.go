@@ -15,6 +15,7 @@ import (
 	"github.com/turbot/flowpipe/internal/metrics"
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/trigger"
 	"github.com/turbot/pipe-fittings/cache"
 	"github.com/turbot/pipe-fittings/perr"
 )
@@ -68,16 +69,24 @@ func LogEventMessage(ctx context.Context, cmd interface{}, lock *sync.Mutex) err
 
 	newExecution := false
 
-	var name string
+	var name, pipelineName, triggerName string
 	if executionQueueCmd, ok := commandEvent.(*event.ExecutionQueue); ok {
 		newExecution = true
 		if executionQueueCmd.TriggerQueue != nil {
 			name = executionQueueCmd.TriggerQueue.Name
+			triggerName = executionQueueCmd.TriggerQueue.Name
 		} else if executionQueueCmd.PipelineQueue != nil {
 			name = executionQueueCmd.PipelineQueue.Name
+			pipelineName = executionQueueCmd.PipelineQueue.Name
+			triggerName = executionQueueCmd.PipelineQueue.Trigger
 		} else {
 			return perr.BadRequestWithMessage("Invalid ExecutionQueue command, no TriggerQueue or PipelineQueue")
 		}
+	} else if executionRetryCmd, ok := commandEvent.(*event.ExecutionRetry); ok {
+		// the retry of a failed execution is a new execution
+		newExecution = true
+		name = executionRetryCmd.Name
+		pipelineName = executionRetryCmd.Name
 	}
 
 	var ex *execution.ExecutionInMemory
@@ -100,7 +109,7 @@ func LogEventMessage(ctx context.Context, cmd interface{}, lock *sync.Mutex) err
 
 		metrics.RunMetricInstance.StartExecution(executionID, name)
 
-		err = store.StartPipeline(executionID, name)
+		err = store.StartPipeline(executionID, pipelineName, triggerName)
 		if err != nil {
 			slog.Error("Unable to save pipeline in the database", "error", err)
 			return err
@@ -115,6 +124,15 @@ func LogEventMessage(ctx context.Context, cmd interface{}, lock *sync.Mutex) err
 		}
 	}
 
+	// the pipeline of a trigger execution is known once the trigger queues it
+	if pipelineQueueCmd, ok := commandEvent.(*event.PipelineQueue); ok && pipelineQueueCmd.Trigger != "" && pipelineQueueCmd.ParentStepExecutionID == "" {
+		err = store.SetPipelineRunPipeline(executionID, pipelineQueueCmd.Name)
+		if err != nil {
+			slog.Error("Unable to save pipeline in the database", "error", err)
+			return err
+		}
+	}
+
 	err = ex.AddEvent(logMessage)
 	if err != nil {
 		slog.Error("Error adding event to execution", "error", err)
@@ -142,3 +160,25 @@ func pipelineCompletionHandler(executionID, pipelineExecutionID string, pipeline
 		db.RemoveStepExecutionIDMap(se.ID)
 	}
 }
+
+// firstStepError returns the first of the step errors, if any
+func firstStepError(stepErrors []resources.StepError) error {
+	if len(stepErrors) == 0 {
+		return nil
+	}
+	return stepErrors[0].Error
+}
+
+// SendQueuedTriggerExecution sends the next fire of the trigger of the ended execution, if the fire has been queued
+// behind the execution by the overlap policy of the trigger. A fire that can't be sent is ended too, the fires queued
+// behind it would otherwise never run.
+func SendQueuedTriggerExecution(ctx context.Context, commandBus FpCommandBus, executionID string) {
+	for next := trigger.EndExecution(executionID); next != nil; next = trigger.EndExecution(next.Event.ExecutionID) {
+		slog.Info("Sending queued trigger execution", "execution_id", next.Event.ExecutionID, "previous_execution_id", executionID)
+		err := commandBus.Send(ctx, next)
+		if err == nil {
+			return
+		}
+		slog.Error("Error sending queued trigger execution", "execution_id", next.Event.ExecutionID, "error", err)
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"log/slog"
 	"slices"
 
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/output"
 	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/tracing"
+	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/pipe-fittings/perr"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type PipelineCanceled EventHandler
@@ -28,33 +33,41 @@ func (h PipelineCanceled) Handle(ctx context.Context, ei interface{}) error {
 		return perr.BadRequestWithMessage("invalid event type expected *event.PipelineCanceled")
 	}
 
-	err := store.UpdatePipelineState(evt.Event.ExecutionID, "cancelled")
-	if err != nil {
-		slog.Error("pipeline_cancelled: Error updating pipeline state", "error", err)
-	}
-
 	plannerMutex := event.GetEventStoreMutex(evt.Event.ExecutionID)
 	plannerMutex.Lock()
 	defer func() {
 		plannerMutex.Unlock()
 	}()
 
-	_, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
+	tracing.PipelineEvent(evt.PipelineExecutionID, "pipeline_canceled", attribute.String("reason", evt.Reason))
+	tracing.EndPipeline(evt.PipelineExecutionID, nil)
+
+	ex, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
 	if err != nil {
 		slog.Error("pipeline_cancelled: Error loading pipeline execution", "error", err)
 		return err
 	}
 
-	ex, err := execution.GetExecution(evt.Event.ExecutionID)
-	if err != nil {
-		slog.Error("pipeline_finished: Error loading pipeline execution", "error", err)
-		return err
+	// Child pipelines are cancelled together with their parent (see command.pipeline_cancel), the parent
+	// pipeline is the one that completes the execution.
+	if ex.PipelineExecutions[evt.PipelineExecutionID].ParentStepExecutionID != "" {
+		slog.Debug("pipeline_cancelled: child pipeline cancelled", "execution_id", evt.Event.ExecutionID, "pipeline_execution_id", evt.PipelineExecutionID)
+		return nil
 	}
 
+	err = store.UpdatePipelineState(evt.Event.ExecutionID, constants.StateCancelled)
 	if err != nil {
-		slog.Error("pipeline_finished: Error saving execution", "error", err)
-		// Should we raise pipeline fail here?
-		return nil
+		slog.Error("pipeline_cancelled: Error updating pipeline state", "error", err)
+	}
+
+	if output.IsServerMode {
+		prefix := types.NewPrefixWithServer(pipelineDefn.PipelineName, types.NewServerOutputPrefixWithExecId(evt.Event.CreatedAt, "pipeline", &evt.Event.ExecutionID))
+		msg := "Cancelled"
+		if evt.Reason != "" {
+			msg += ": " + evt.Reason
+		}
+		pe := types.NewParsedEvent(prefix, evt.Event.ExecutionID, event.HandlerPipelineCancelled, "", msg)
+		output.RenderServerOutput(ctx, pe)
 	}
 
 	pipelineCompletionHandler(evt.Event.ExecutionID, evt.PipelineExecutionID, pipelineDefn, ex.PipelineExecutions[evt.PipelineExecutionID].StepExecutions)
@@ -67,5 +80,7 @@ func (h PipelineCanceled) Handle(ctx context.Context, ei interface{}) error {
 			slog.Error("Error publishing event", "error", err)
 		}
 	}
+
+	firePipelineTriggers(ctx, h.CommandBus, &ex.Execution, evt.PipelineExecutionID, pipelineDefn.Name(), constants.PipelineStatusCanceled, ex.PipelineExecutions[evt.PipelineExecutionID].PipelineOutput)
 	return nil
 }
@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"slices"
 
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/utils"
 
 	"github.com/turbot/flowpipe/internal/output"
@@ -41,6 +43,8 @@ func (h PipelineFailed) Handle(ctx context.Context, ei interface{}) error {
 		plannerMutex.Unlock()
 	}()
 
+	tracing.EndPipeline(evt.PipelineExecutionID, firstStepError(evt.Errors))
+
 	ex, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
 	if err != nil {
 		slog.Error("pipeline_failed error loading pipeline execution", "error", err)
@@ -54,7 +58,15 @@ func (h PipelineFailed) Handle(ctx context.Context, ei interface{}) error {
 		return err
 	}
 
+	// The parent pipeline may have ended already (i.e. failed after a server restart), there's no pipeline step
+	// left to finish
+	parentEnded := false
 	if parentStepExecution != nil {
+		parentPex := ex.PipelineExecutions[parentStepExecution.PipelineExecutionID]
+		parentEnded = parentPex != nil && slices.Contains(event.EndEvents, parentPex.Status)
+	}
+
+	if parentStepExecution != nil && !parentEnded {
 		cmd, err := event.NewStepPipelineFinish(
 			event.ForPipelineFailed(evt),
 			event.WithPipelineExecutionID(parentStepExecution.PipelineExecutionID),
@@ -93,8 +105,9 @@ func (h PipelineFailed) Handle(ctx context.Context, ei interface{}) error {
 	// release the execution mutex (do the same thing for pipeline_failed and pipeline_finished)
 	pipelineCompletionHandler(evt.Event.ExecutionID, evt.PipelineExecutionID, pipelineDefn, ex.PipelineExecutions[evt.PipelineExecutionID].StepExecutions)
 
-	// raise execution plan command if this pipeline is in the root pipeline list
-	if slices.Contains(ex.RootPipelines, evt.PipelineExecutionID) {
+	// raise execution plan command if this pipeline is in the root pipeline list, or if its parent pipeline has
+	// already ended as there's no parent left to complete the execution
+	if slices.Contains(ex.RootPipelines, evt.PipelineExecutionID) || parentEnded {
 		cmd := event.ExecutionPlanFromPipelineFailed(evt)
 		err = h.CommandBus.Send(ctx, cmd)
 		if err != nil {
@@ -102,5 +115,8 @@ func (h PipelineFailed) Handle(ctx context.Context, ei interface{}) error {
 		}
 	}
 
+	// a child pipeline whose parent has already ended doesn't fire the pipeline triggers
+	firePipelineTriggers(ctx, h.CommandBus, &ex.Execution, evt.PipelineExecutionID, pipelineDefn.Name(), constants.PipelineStatusFailed, evt.PipelineOutput)
+
 	return nil
 }
@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"slices"
 
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/utils"
 
 	"github.com/turbot/flowpipe/internal/output"
@@ -48,6 +50,8 @@ func (h PipelineFinished) Handle(ctx context.Context, ei interface{}) error {
 		plannerMutex.Unlock()
 	}()
 
+	tracing.EndPipeline(evt.PipelineExecutionID, nil)
+
 	ex, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
 	if err != nil {
 		slog.Error("pipeline_finished: Error loading pipeline execution", "error", err)
@@ -122,5 +126,7 @@ func (h PipelineFinished) Handle(ctx context.Context, ei interface{}) error {
 		}
 	}
 
+	firePipelineTriggers(ctx, h.CommandBus, &ex.Execution, evt.PipelineExecutionID, pipelineDefn.Name(), constants.PipelineStatusFinished, evt.PipelineOutput)
+
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/perr"
 )
 
@@ -36,6 +37,8 @@ func (h PipelinePaused) Handle(ctx context.Context, ei interface{}) error {
 		plannerMutex.Unlock()
 	}()
 
+	tracing.PausePipeline(evt.PipelineExecutionID, evt.Reason)
+
 	ex, _, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
 	if err != nil {
 		slog.Error("pipeline_finished: Error loading pipeline execution", "error", err)
@@ -56,6 +59,14 @@ func (h PipelinePaused) Handle(ctx context.Context, ei interface{}) error {
 	}
 
 	pex := ex.PipelineExecutions[evt.PipelineExecutionID]
+
+	// the parent paused together with its child is planned again once resumed
+	if parentPex, ok := ex.PipelineExecutions[pex.ParentExecutionID]; ok && parentPex.IsPaused() {
+		slog.Info("PipelinePaused event handled - parent pipeline is paused", "execution_id", evt.Event.ExecutionID, "pipeline_execution_id", evt.PipelineExecutionID,
+			"parent_execution_id", pex.ParentExecutionID)
+		return nil
+	}
+
 	if pex.ParentExecutionID != "" {
 		// raise a pipeline plan command for the parent
 		cmd, err := event.NewPipelinePlan()
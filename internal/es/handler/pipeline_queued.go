@@ -6,6 +6,7 @@ import (
 
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/perr"
 )
 
@@ -38,6 +39,8 @@ func (h PipelineQueued) Handle(ctx context.Context, ei interface{}) error {
 		}
 	}()
 
+	tracing.StartPipeline(evt.Event.ExecutionID, evt.PipelineExecutionID, evt.ParentStepExecutionID, evt.Name)
+
 	_, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
 	if err != nil {
 		slog.Error("pipeline_queued: Error loading pipeline execution", "error", err)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/perr"
 )
 
@@ -33,6 +34,8 @@ func (h PipelineResumed) Handle(ctx context.Context, ei interface{}) error {
 		plannerMutex.Unlock()
 	}()
 
+	tracing.ResumePipeline(evt.PipelineExecutionID)
+
 	cmd, err := event.NewPipelinePlan(event.ForPipelineResumed(evt))
 	if err != nil {
 		return h.CommandBus.Send(ctx, event.NewPipelineFail(event.ForPipelineResumedToPipelineFail(evt, err)))
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"slices"
+
+	"log/slog"
+
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/tracing"
+	"github.com/turbot/pipe-fittings/perr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type PipelineRetried EventHandler
+
+func (h PipelineRetried) HandlerName() string {
+	return execution.PipelineRetriedEvent.HandlerName()
+}
+
+func (PipelineRetried) NewEvent() interface{} {
+	return &event.PipelineRetried{}
+}
+
+func (h PipelineRetried) Handle(ctx context.Context, ei interface{}) error {
+	evt, ok := ei.(*event.PipelineRetried)
+	if !ok {
+		slog.Error("invalid event type", "expected", "*event.PipelineRetried", "actual", ei)
+		return perr.BadRequestWithMessage("invalid event type expected *event.PipelineRetried")
+	}
+
+	slog.Info("PipelineRetried event received", "execution_id", evt.Event.ExecutionID, "pipeline_execution_id", evt.PipelineExecutionID)
+
+	plannerMutex := event.GetEventStoreMutex(evt.Event.ExecutionID)
+	plannerMutex.Lock()
+	defer func() {
+		if plannerMutex != nil {
+			plannerMutex.Unlock()
+		}
+	}()
+
+	ex, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
+	if err != nil {
+		slog.Error("pipeline_retried: Error loading pipeline execution", "error", err)
+		return h.CommandBus.Send(ctx, event.NewPipelineFail(event.ForPipelineRetriedToPipelineFail(evt, err)))
+	}
+
+	// the span of the pipeline execution ended when the pipeline failed, the retry is traced in a new span
+	pex := ex.PipelineExecutions[evt.PipelineExecutionID]
+	tracing.StartPipeline(evt.Event.ExecutionID, evt.PipelineExecutionID, pex.ParentStepExecutionID, pipelineDefn.Name())
+	tracing.PipelineEvent(evt.PipelineExecutionID, "pipeline_retried", attribute.String("reason", evt.Reason))
+
+	if slices.Contains(ex.RootPipelines, evt.PipelineExecutionID) {
+		err = store.UpdatePipelineState(evt.Event.ExecutionID, "started")
+		if err != nil {
+			slog.Error("pipeline_retried: Error updating pipeline state", "error", err)
+		}
+	}
+
+	cmd, err := event.NewPipelinePlan(event.ForPipelineRetried(evt))
+	if err != nil {
+		return h.CommandBus.Send(ctx, event.NewPipelineFail(event.ForPipelineRetriedToPipelineFail(evt, err)))
+	}
+
+	// The pipeline semaphore was released when the pipeline failed, it needs to be acquired again before
+	// the pipeline is planned. Release the planner mutex first, otherwise we'll create a deadlock while waiting
+	// for the semaphore.
+	plannerMutex.Unlock()
+	plannerMutex = nil
+
+	go func() {
+		err := execution.GetPipelineSemaphore(pipelineDefn)
+		if err != nil {
+			err2 := h.CommandBus.Send(ctx, event.NewPipelineFail(event.ForPipelineRetriedToPipelineFail(evt, err)))
+			if err2 != nil {
+				slog.Error("Error publishing event", "error", err2)
+			}
+			return
+		}
+
+		err = h.CommandBus.Send(ctx, cmd)
+		if err != nil {
+			slog.Error("Error publishing event", "error", err)
+		}
+	}()
+
+	return nil
+}
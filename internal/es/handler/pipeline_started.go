@@ -8,6 +8,7 @@ import (
 	"github.com/turbot/flowpipe/internal/es/execution"
 	"github.com/turbot/flowpipe/internal/output"
 	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/pipe-fittings/perr"
 )
@@ -45,6 +46,8 @@ func (h PipelineStarted) Handle(ctx context.Context, ei interface{}) error {
 		plannerMutex.Unlock()
 	}()
 
+	tracing.PipelineEvent(evt.PipelineExecutionID, "pipeline_started")
+
 	if output.IsServerMode {
 		pipelineName := ""
 		ex, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
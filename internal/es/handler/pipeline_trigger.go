@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/es/db"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/metrics"
+	"github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/tracing"
+	"github.com/turbot/flowpipe/internal/trigger"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/util"
+)
+
+// firePipelineTriggers runs the pipeline triggers of the ended pipeline execution, each trigger runs its pipeline in a
+// new execution. Only a root pipeline execution fires the triggers, a pipeline run by a step is part of the execution
+// of its parent.
+func firePipelineTriggers(ctx context.Context, commandBus FpCommandBus, ex *execution.Execution, pipelineExecutionID, pipelineName, status string, pipelineOutput map[string]interface{}) {
+	if !slices.Contains(ex.RootPipelines, pipelineExecutionID) {
+		return
+	}
+
+	pex := ex.PipelineExecutions[pipelineExecutionID]
+	if pex == nil {
+		return
+	}
+
+	triggers, err := db.ListAllTriggers()
+	if err != nil {
+		// no mod loaded, e.g. a pipeline run from the command line
+		slog.Debug("Unable to list the pipeline triggers", "error", err)
+		return
+	}
+
+	upstream := trigger.UpstreamPipeline{
+		ExecutionID:         ex.ID,
+		PipelineExecutionID: pex.ID,
+		Pipeline:            pipelineName,
+		Status:              status,
+		Args:                pex.Args,
+		Output:              pipelineOutput,
+	}
+
+	for i := range triggers {
+		t := &triggers[i]
+
+		config, ok := t.Config.(*resources.TriggerPipeline)
+		if !ok || !config.FiresOn(pipelineName, status) {
+			continue
+		}
+
+		if t.Enabled != nil && !*t.Enabled {
+			slog.Debug("Trigger is disabled", "name", t.Name())
+			continue
+		}
+
+		triggerExecutionID := util.NewExecutionId()
+		triggerRunner := trigger.NewTriggerRunnerPipeline(t, triggerExecutionID, "", upstream)
+
+		metrics.TriggerFired(t.Name(), t.Config.GetType())
+		tracing.StartTrigger(ctx, triggerExecutionID, t.Name(), t.Config.GetType())
+
+		cmds, err := triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+		if err != nil {
+			slog.Error("Error executing trigger", "trigger", t.Name(), "upstream_execution_id", ex.ID, "error", err)
+			tracing.EndTrigger(triggerExecutionID, err)
+			metrics.TriggerFailed(t.Name(), t.Config.GetType())
+			if output.IsServerMode {
+				output.RenderServerOutput(ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "executing trigger", err))
+			}
+			continue
+		}
+
+		for _, cmd := range cmds {
+			executionCmd := &event.ExecutionQueue{
+				Event:         event.NewEventForExecutionID(triggerExecutionID),
+				PipelineQueue: cmd,
+			}
+
+			if err := commandBus.Send(ctx, executionCmd); err != nil {
+				slog.Error("Error sending pipeline command", "trigger", t.Name(), "error", err)
+				tracing.EndTrigger(triggerExecutionID, err)
+				metrics.TriggerFailed(t.Name(), t.Config.GetType())
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type recordingCommandBus struct {
+	commands []interface{}
+}
+
+func (c *recordingCommandBus) Send(ctx context.Context, cmd interface{}) error {
+	c.commands = append(c.commands, cmd)
+	return nil
+}
+
+func setupPipelineTriggers(triggers ...*resources.Trigger) {
+	var names []string
+	for _, t := range triggers {
+		cache.GetCache().SetWithTTL(t.Name(), t, 10*time.Minute)
+		names = append(names, t.Name())
+	}
+	cache.GetCache().SetWithTTL("#trigger.names", names, 10*time.Minute)
+}
+
+func pipelineTrigger(name, upstream, pipeline string, statuses ...string) *resources.Trigger {
+	return &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "local.trigger.pipeline." + name,
+		},
+		Pipeline: cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal(pipeline),
+		}),
+		Config: &resources.TriggerPipeline{
+			Upstream: upstream,
+			Statuses: statuses,
+		},
+	}
+}
+
+// firedPipelines returns the pipelines run by the commands sent to the command bus
+func firedPipelines(t *testing.T, commandBus *recordingCommandBus) []string {
+	var pipelines []string
+	for _, cmd := range commandBus.commands {
+		executionCmd, ok := cmd.(*event.ExecutionQueue)
+		if !assert.True(t, ok, "unexpected command %T", cmd) {
+			continue
+		}
+		pipelines = append(pipelines, executionCmd.PipelineQueue.Name)
+	}
+	return pipelines
+}
+
+func TestFirePipelineTriggersStatuses(t *testing.T) {
+	disabled := pipelineTrigger("disabled", "local.pipeline.etl", "local.pipeline.disabled", "finished", "failed", "canceled")
+	enabled := false
+	disabled.Enabled = &enabled
+
+	setupPipelineTriggers(
+		pipelineTrigger("on_finished", "local.pipeline.etl", "local.pipeline.report", "finished"),
+		pipelineTrigger("on_failed", "local.pipeline.etl", "local.pipeline.alert", "failed", "canceled"),
+		pipelineTrigger("on_other", "local.pipeline.other", "local.pipeline.other_report", "finished", "failed", "canceled"),
+		disabled,
+	)
+
+	ex := &execution.Execution{
+		ID: "exec_upstream",
+		PipelineExecutions: map[string]*execution.PipelineExecution{
+			"pexec_upstream": {ID: "pexec_upstream", Args: resources.Input{"table": "orders"}},
+		},
+		RootPipelines: []string{"pexec_upstream"},
+	}
+
+	tests := []struct {
+		status    string
+		pipelines []string
+	}{
+		{status: "finished", pipelines: []string{"local.pipeline.report"}},
+		{status: "failed", pipelines: []string{"local.pipeline.alert"}},
+		{status: "canceled", pipelines: []string{"local.pipeline.alert"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.status, func(t *testing.T) {
+			assert := assert.New(t)
+
+			commandBus := &recordingCommandBus{}
+			firePipelineTriggers(context.Background(), commandBus, ex, "pexec_upstream", "local.pipeline.etl", test.status, map[string]interface{}{"rows": 10})
+
+			assert.Equal(test.pipelines, firedPipelines(t, commandBus))
+
+			// the pipeline is run in a new execution with the upstream pipeline
+			for _, cmd := range commandBus.commands {
+				executionCmd := cmd.(*event.ExecutionQueue)
+				assert.NotEqual("exec_upstream", executionCmd.Event.ExecutionID)
+				assert.Equal(executionCmd.Event.ExecutionID, executionCmd.PipelineQueue.Event.ExecutionID)
+				assert.Equal("exec_upstream", executionCmd.PipelineQueue.Args["execution_id"])
+				assert.Equal(test.status, executionCmd.PipelineQueue.Args["status"])
+				assert.Equal(map[string]interface{}{"table": "orders"}, executionCmd.PipelineQueue.Args["args"])
+				assert.Equal(map[string]interface{}{"rows": 10}, executionCmd.PipelineQueue.Args["output"])
+			}
+		})
+	}
+}
+
+func TestFirePipelineTriggersChildPipeline(t *testing.T) {
+	assert := assert.New(t)
+
+	setupPipelineTriggers(
+		pipelineTrigger("on_etl", "local.pipeline.etl", "local.pipeline.report", "finished", "failed", "canceled"),
+	)
+
+	// the etl pipeline is run by a step of the nightly pipeline
+	ex := &execution.Execution{
+		ID: "exec_nightly",
+		PipelineExecutions: map[string]*execution.PipelineExecution{
+			"pexec_nightly": {ID: "pexec_nightly"},
+			"pexec_etl":     {ID: "pexec_etl", ParentExecutionID: "pexec_nightly"},
+		},
+		RootPipelines: []string{"pexec_nightly"},
+	}
+
+	commandBus := &recordingCommandBus{}
+	firePipelineTriggers(context.Background(), commandBus, ex, "pexec_etl", "local.pipeline.etl", "finished", nil)
+	assert.Empty(commandBus.commands)
+
+	// the etl pipeline run on its own fires the trigger
+	ex = &execution.Execution{
+		ID: "exec_etl",
+		PipelineExecutions: map[string]*execution.PipelineExecution{
+			"pexec_etl": {ID: "pexec_etl"},
+		},
+		RootPipelines: []string{"pexec_etl"},
+	}
+
+	firePipelineTriggers(context.Background(), commandBus, ex, "pexec_etl", "local.pipeline.etl", "finished", nil)
+	assert.Equal([]string{"local.pipeline.report"}, firedPipelines(t, commandBus))
+}
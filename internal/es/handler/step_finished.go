@@ -4,9 +4,12 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
 	"github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/perr"
@@ -39,6 +42,19 @@ func (h StepFinished) Handle(ctx context.Context, ei interface{}) error {
 		plannerMutex.Unlock()
 	}()
 
+	var stepErr error
+	if evt.Output != nil {
+		stepErr = firstStepError(evt.Output.Errors)
+	}
+	tracing.EndStep(evt.StepExecutionID, stepErr)
+
+	if evt.Output != nil && evt.Output.Status == constants.StateFailed {
+		err := store.SetPipelineRunFailedStep(evt.Event.ExecutionID)
+		if err != nil {
+			slog.Error("step_finished: Error recording the failed step", "error", err)
+		}
+	}
+
 	ex, pipelineDefn, err := execution.GetPipelineDefnFromExecution(evt.Event.ExecutionID, evt.PipelineExecutionID)
 	if err != nil {
 		slog.Error("step_finished: Error loading pipeline execution", "error", err)
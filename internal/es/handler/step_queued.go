@@ -7,6 +7,7 @@ import (
 
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/perr"
 )
 
@@ -66,6 +67,8 @@ func (h StepQueued) Handle(ctx context.Context, ei interface{}) error {
 	// Step has been queued (but not yet started), so here we just need to start the step
 	// the code should be the same as the pipeline_planned event handler
 	evt.StepType = stepDefn.GetType()
+	tracing.StartStep(evt.PipelineExecutionID, evt.StepExecutionID, evt.StepName, evt.StepType)
+
 	cmd, err := event.NewStepStartFromStepQueued(evt)
 
 	if err != nil {
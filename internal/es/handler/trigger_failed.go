@@ -6,6 +6,7 @@ import (
 
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/perr"
 )
 
@@ -35,6 +36,8 @@ func (h TriggerFailed) Handle(ctx context.Context, ei interface{}) error {
 		}
 	}()
 
+	tracing.EndTrigger(evt.Event.ExecutionID, perr.InternalWithMessage("trigger "+evt.Name+" failed"))
+
 	// There's only 1 trigger for each execution, it's a straight forward process to
 	// fail the execution
 	cmd := event.ExecutionFailFromTriggerFailed(evt)
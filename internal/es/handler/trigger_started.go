@@ -8,7 +8,9 @@ import (
 	"github.com/turbot/flowpipe/internal/es/db"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/metrics"
 	"github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/flowpipe/internal/trigger"
 	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/pipe-fittings/perr"
@@ -62,6 +64,13 @@ func (h TriggerStarted) Handle(ctx context.Context, ei interface{}) error {
 		return nil
 	}
 
+	if scheduleRunner, ok := triggerRunner.(*trigger.TriggerRunnerSchedule); ok && evt.ScheduledTime != nil {
+		scheduleRunner.ScheduledTime = *evt.ScheduledTime
+	}
+
+	metrics.TriggerFired(trg.Name(), trg.Config.GetType())
+	tracing.StartTrigger(ctx, evt.Event.ExecutionID, trg.Name(), trg.Config.GetType())
+
 	cmds, err := triggerRunner.GetPipelineQueuesWithArgs(ctx, evt.Args, nil)
 	if err != nil {
 		slog.Error("Error executing trigger", "error", err)
@@ -91,6 +100,7 @@ func (h TriggerStarted) Handle(ctx context.Context, ei interface{}) error {
 		return nil
 	}
 
+	var sent []*event.PipelineQueue
 	for _, cmd := range cmds {
 		if err := h.CommandBus.Send(context.TODO(), cmd); err != nil {
 			slog.Error("Error sending pipeline command", "error", err)
@@ -100,6 +110,15 @@ func (h TriggerStarted) Handle(ctx context.Context, ei interface{}) error {
 
 			fperr := perr.InternalWithMessage("error sending pipeline command " + err.Error())
 			h.raiseError(ctx, evt, fperr)
+			continue
+		}
+		sent = append(sent, cmd)
+	}
+
+	// the messages of the pipelines not sent are left unseen, they are processed by the next poll
+	if emailRunner, ok := triggerRunner.(*trigger.TriggerRunnerEmail); ok {
+		if err := emailRunner.MessagesSent(ctx, sent); err != nil {
+			slog.Error("Error flagging the processed messages", "trigger", trg.Name(), "error", err)
 		}
 	}
 
@@ -107,6 +126,13 @@ func (h TriggerStarted) Handle(ctx context.Context, ei interface{}) error {
 }
 
 func (h TriggerStarted) raiseError(ctx context.Context, evt *event.TriggerStarted, errToLog perr.ErrorModel) {
+	// the trigger config isn't carried by the event
+	triggerType := ""
+	if trg, err := db.GetTrigger(evt.Trigger.Name()); err == nil && trg.Config != nil {
+		triggerType = trg.Config.GetType()
+	}
+	metrics.TriggerFailed(evt.Trigger.Name(), triggerType)
+
 	cmd := event.ExecutionFailFromTriggerStarted(evt, errToLog)
 	err := h.CommandBus.Send(ctx, cmd)
 	if err != nil {
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "flowpipe"
+
+var (
+	pipelinesStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipeline_started_total",
+		Help:      "Number of pipeline executions started.",
+	}, []string{"pipeline"})
+
+	pipelinesFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipeline_finished_total",
+		Help:      "Number of pipeline executions finished successfully.",
+	}, []string{"pipeline"})
+
+	pipelinesFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipeline_failed_total",
+		Help:      "Number of pipeline executions failed.",
+	}, []string{"pipeline"})
+
+	stepsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "step_started_total",
+		Help:      "Number of step executions started.",
+	}, []string{"pipeline", "step_type"})
+
+	stepsFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "step_finished_total",
+		Help:      "Number of step executions finished successfully.",
+	}, []string{"pipeline", "step_type"})
+
+	stepsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "step_failed_total",
+		Help:      "Number of step executions failed, including the failures ignored by the step error block.",
+	}, []string{"pipeline", "step_type"})
+
+	stepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "step_duration_seconds",
+		Help:      "Time spent running the step primitive.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{"pipeline", "step_type"})
+
+	stepSemaphoreWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "step_semaphore_waiting",
+		Help:      "Number of steps waiting for the step type concurrency limit.",
+	}, []string{"step_type"})
+
+	triggersFired = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trigger_fired_total",
+		Help:      "Number of times a trigger fired.",
+	}, []string{"trigger", "trigger_type"})
+
+	triggersFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trigger_failed_total",
+		Help:      "Number of times a trigger failed to run its pipelines.",
+	}, []string{"trigger", "trigger_type"})
+
+	queryTriggerRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "query_trigger_rows_total",
+		Help:      "Number of rows inserted, updated and deleted detected by the query triggers.",
+	}, []string{"trigger", "capture"})
+
+	registry = newRegistry()
+)
+
+func newRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		pipelinesStarted,
+		pipelinesFinished,
+		pipelinesFailed,
+		stepsStarted,
+		stepsFinished,
+		stepsFailed,
+		stepDuration,
+		stepSemaphoreWaiting,
+		triggersFired,
+		triggersFailed,
+		queryTriggerRows,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "executions_running",
+			Help:      "Number of executions currently running.",
+		}, func() float64 {
+			return float64(len(RunMetricInstance.RunningExecutions()))
+		}),
+	)
+	return r
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	// the API compresses the responses
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{DisableCompression: true})
+}
+
+func PipelineStarted(pipeline string) {
+	pipelinesStarted.WithLabelValues(pipeline).Inc()
+}
+
+func PipelineFinished(pipeline string) {
+	pipelinesFinished.WithLabelValues(pipeline).Inc()
+}
+
+func PipelineFailed(pipeline string) {
+	pipelinesFailed.WithLabelValues(pipeline).Inc()
+}
+
+// StepStarted counts the step and returns a function that records the step duration when called
+func StepStarted(pipeline, stepType string) func() {
+	stepsStarted.WithLabelValues(pipeline, stepType).Inc()
+
+	start := time.Now()
+	return func() {
+		stepDuration.WithLabelValues(pipeline, stepType).Observe(time.Since(start).Seconds())
+	}
+}
+
+func StepFinished(pipeline, stepType string) {
+	stepsFinished.WithLabelValues(pipeline, stepType).Inc()
+}
+
+func StepFailed(pipeline, stepType string) {
+	stepsFailed.WithLabelValues(pipeline, stepType).Inc()
+}
+
+// StepSemaphoreWait counts the step as waiting for the step type semaphore, the returned function must be called
+// once the semaphore is acquired
+func StepSemaphoreWait(stepType string) func() {
+	gauge := stepSemaphoreWaiting.WithLabelValues(stepType)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+func TriggerFired(trigger, triggerType string) {
+	triggersFired.WithLabelValues(trigger, triggerType).Inc()
+}
+
+func TriggerFailed(trigger, triggerType string) {
+	triggersFailed.WithLabelValues(trigger, triggerType).Inc()
+}
+
+func QueryTriggerRows(trigger string, inserted, updated, deleted int) {
+	queryTriggerRows.WithLabelValues(trigger, "insert").Add(float64(inserted))
+	queryTriggerRows.WithLabelValues(trigger, "update").Add(float64(updated))
+	queryTriggerRows.WithLabelValues(trigger, "delete").Add(float64(deleted))
+}
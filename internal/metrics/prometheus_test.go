@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	PipelineStarted("mod.pipeline.test")
+	PipelineFailed("mod.pipeline.test")
+	observe := StepStarted("mod.pipeline.test", "http")
+	observe()
+	StepFailed("mod.pipeline.test", "http")
+	TriggerFired("mod.trigger.query.test", "query")
+	QueryTriggerRows("mod.trigger.query.test", 3, 2, 1)
+
+	done := StepSemaphoreWait("container")
+	assert.Equal(float64(1), testutil.ToFloat64(stepSemaphoreWaiting.WithLabelValues("container")))
+	done()
+	assert.Equal(float64(0), testutil.ToFloat64(stepSemaphoreWaiting.WithLabelValues("container")))
+
+	assert.Equal(float64(1), testutil.ToFloat64(pipelinesStarted.WithLabelValues("mod.pipeline.test")))
+	assert.Equal(float64(1), testutil.ToFloat64(stepsStarted.WithLabelValues("mod.pipeline.test", "http")))
+	assert.Equal(float64(3), testutil.ToFloat64(queryTriggerRows.WithLabelValues("mod.trigger.query.test", "insert")))
+	assert.Equal(float64(1), testutil.ToFloat64(queryTriggerRows.WithLabelValues("mod.trigger.query.test", "delete")))
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v0/metrics", nil))
+	assert.Equal(200, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	assert.Nil(err)
+	assert.Contains(string(body), `flowpipe_pipeline_failed_total{pipeline="mod.pipeline.test"} 1`)
+	assert.Contains(string(body), `flowpipe_step_duration_seconds_count{pipeline="mod.pipeline.test",step_type="http"} 1`)
+	assert.Contains(string(body), `flowpipe_trigger_fired_total{trigger="mod.trigger.query.test",trigger_type="query"} 1`)
+	assert.Contains(string(body), `flowpipe_query_trigger_rows_total{capture="update",trigger="mod.trigger.query.test"} 2`)
+	assert.Contains(string(body), "flowpipe_executions_running 0")
+}
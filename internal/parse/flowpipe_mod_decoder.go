@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/connection"
@@ -857,6 +858,8 @@ func GetPipelineStepBlockSchema(stepType string) *hcl.BodySchema {
 		return resources.PipelineStepFunctionBlockSchema
 	case schema.BlockTypePipelineStepContainer:
 		return resources.PipelineStepContainerBlockSchema
+	case localconstants.BlockTypePipelineStepCommand:
+		return resources.PipelineStepCommandBlockSchema
 	case schema.BlockTypePipelineStepInput:
 		return resources.PipelineStepInputBlockSchema
 	case schema.BlockTypePipelineStepMessage:
@@ -874,6 +877,14 @@ func GetTriggerBlockSchema(triggerType string) *hcl.BodySchema {
 		return resources.TriggerQueryBlockSchema
 	case schema.TriggerTypeHttp:
 		return resources.TriggerHttpBlockSchema
+	case localconstants.TriggerTypeFile:
+		return resources.TriggerFileBlockSchema
+	case localconstants.TriggerTypeQueue:
+		return resources.TriggerQueueBlockSchema
+	case localconstants.TriggerTypePipeline:
+		return resources.TriggerPipelineBlockSchema
+	case localconstants.TriggerTypeEmail:
+		return resources.TriggerEmailBlockSchema
 	default:
 		return nil
 	}
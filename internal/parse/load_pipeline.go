@@ -111,5 +111,12 @@ func LoadPipelinesReturningItsMod(ctx context.Context, configPath string) (*modc
 		return nil, errorsAndWarnings.Error
 	}
 
+	if mod != nil && mod.GetModResources() != nil {
+		err = resources.ValidatePipelineTriggers(resources.GetModResources(mod).Triggers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return mod, nil
 }
@@ -1,23 +1,68 @@
 package primitive
 
 import (
-	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/container"
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
 )
 
-type Exec struct{}
+// Exec runs a local command. Without args the command is run by the shell, e.g. "ls -l | wc -l", with args the
+// command is the program to run and the args are passed as they are. The step fails if the command exits with a code
+// other than the allowed exit codes.
+type Exec struct {
+	// a relative workdir is relative to the mod, the command runs in the mod directory when it has no workdir
+	ModPath string
+
+	// OutputLine is called for every line of stdout or stderr kept in the step output, as it's written
+	OutputLine func(line container.OutputLine)
+}
 
 func (e *Exec) ValidateInput(ctx context.Context, i resources.Input) error {
-	if i["command"] == nil {
-		return perr.BadRequestWithMessage("Exec input must define a command")
+	command, ok := i[constants.AttributeTypeCommand].(string)
+	if !ok || command == "" {
+		return perr.BadRequestWithMessage("Command input must define a " + constants.AttributeTypeCommand)
+	}
+
+	if _, ok := toStringSlice(i[schema.AttributeTypeArgs]); !ok {
+		return perr.BadRequestWithMessage("Command attribute '" + schema.AttributeTypeArgs + "' must be an array of strings")
+	}
+
+	if _, ok := toStringMap(i[schema.AttributeTypeEnv]); !ok {
+		return perr.BadRequestWithMessage("Command attribute '" + schema.AttributeTypeEnv + "' must be a map of strings")
+	}
+
+	for _, attributeName := range []string{schema.AttributeTypeWorkdir, constants.AttributeTypeStdin} {
+		if i[attributeName] != nil {
+			if _, ok := i[attributeName].(string); !ok {
+				return perr.BadRequestWithMessage("Command attribute '" + attributeName + "' must be a string")
+			}
+		}
+	}
+
+	if _, err := commandTimeout(i[schema.AttributeTypeTimeout]); err != nil {
+		return err
 	}
+
+	if _, ok := toIntSlice(i[constants.AttributeTypeAllowedExitCodes]); !ok {
+		return perr.BadRequestWithMessage("Command attribute '" + constants.AttributeTypeAllowedExitCodes + "' must be an array of whole numbers")
+	}
+
 	return nil
 }
 
@@ -26,77 +71,328 @@ func (e *Exec) Run(ctx context.Context, input resources.Input) (*resources.Outpu
 		return nil, err
 	}
 
-	// TODO - support arguments per https://www.terraform.io/language/resources/provisioners/local-exec#argument-reference
+	command := input[constants.AttributeTypeCommand].(string)
+	args, _ := toStringSlice(input[schema.AttributeTypeArgs])
+	env, _ := toStringMap(input[schema.AttributeTypeEnv])
+	timeout, _ := commandTimeout(input[schema.AttributeTypeTimeout])
 
-	//nolint:gosec // TODO G204: Subprocess launched with a potential tainted input or cmd arguments (gosec)
-	cmd := exec.Command("sh", "-c", input["command"].(string))
+	allowedExitCodes, _ := toIntSlice(input[constants.AttributeTypeAllowedExitCodes])
+	if input[constants.AttributeTypeAllowedExitCodes] == nil {
+		allowedExitCodes = []int{0}
+	}
 
-	// Capture stdout in real-time
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
+	// the command is killed when the context is done, e.g. when the pipeline of the step is paused or cancelled
+	cmdCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(cmdCtx, timeout)
+		defer cancel()
 	}
-	stdoutLines := []string{}
-	stdoutScanner := bufio.NewScanner(stdout)
-	stdoutScanner.Buffer(make([]byte, constants.MaxScanSize), constants.MaxScanSize)
-	go func() {
-		for stdoutScanner.Scan() {
-			t := stdoutScanner.Text()
-			// TODO - send to logs immediately
-			stdoutLines = append(stdoutLines, t)
-		}
-	}()
 
-	// Capture stderr in real-time
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
+	var cmd *exec.Cmd
+	if input[schema.AttributeTypeArgs] == nil {
+		//nolint:gosec // running the command of the step is the purpose of the step
+		cmd = exec.CommandContext(cmdCtx, constants.DefaultCommandShell, "-c", command)
+	} else {
+		//nolint:gosec // running the command of the step is the purpose of the step
+		cmd = exec.CommandContext(cmdCtx, command, args...)
+	}
+	// the command runs in its own process group, the processes it started are killed with it, e.g. the processes of a
+	// shell command
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 	}
-	stderrLines := []string{}
-	stderrScanner := bufio.NewScanner(stderr)
-	stderrScanner.Buffer(make([]byte, constants.MaxScanSize), constants.MaxScanSize)
-	go func() {
-		for stderrScanner.Scan() {
-			t := stderrScanner.Text()
-			// TODO - send to logs immediately
-			stderrLines = append(stderrLines, t)
+	// the output written after the kill is dropped once the wait delay has passed, e.g. the output of a background
+	// process that inherited stdout
+	cmd.WaitDelay = constants.CommandWaitDelay
+
+	cmd.Dir = e.ModPath
+	if workdir, ok := input[schema.AttributeTypeWorkdir].(string); ok && workdir != "" {
+		if filepath.IsAbs(workdir) {
+			cmd.Dir = workdir
+		} else {
+			cmd.Dir = filepath.Join(e.ModPath, workdir)
 		}
-	}()
+	}
+
+	// the command inherits the environment of Flowpipe, the env of the step is added to it
+	cmd.Env = os.Environ()
+	envNames := make([]string, 0, len(env))
+	for name := range env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		cmd.Env = append(cmd.Env, name+"="+env[name])
+	}
+
+	if stdin, ok := input[constants.AttributeTypeStdin].(string); ok {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	o := newCommandOutput(e.OutputLine)
+	cmd.Stdout = commandOutputWriter{output: o, stream: container.StdoutType}
+	cmd.Stderr = commandOutputWriter{output: o, stream: container.StderrType}
 
 	start := time.Now().UTC()
-	if err := cmd.Start(); err != nil {
-		return nil, err
+	err := cmd.Run()
+	lines, dropped := o.flush()
+	finish := time.Now().UTC()
+
+	if dropped > 0 {
+		slog.Warn("Command output truncated", "command", command, "lines", len(lines), "dropped", dropped)
 	}
 
 	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// -1 if the command was killed, e.g. when it timed out
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
 
-	if err := cmd.Wait(); err != nil {
-		if exiterr, ok := err.(*exec.ExitError); ok {
-			// The program has exited with an exit code != 0
+	output := resources.Output{
+		Data: map[string]interface{}{
+			schema.AttributeTypeExitCode: exitCode,
+			schema.AttributeTypeStdout:   streamText(lines, container.StdoutType),
+			schema.AttributeTypeStderr:   streamText(lines, container.StderrType),
+			schema.AttributeTypeLines:    lines,
+		},
+	}
 
-			// This works on both Unix and Windows. Although package
-			// syscall is generally platform dependent, WaitStatus is
-			// defined for both Unix and Windows and in both cases has
-			// an ExitStatus() method with the same signature.
-			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-				exitCode = status.ExitStatus()
+	switch {
+	case ctx.Err() != nil:
+		// the command was stopped with the step, the cause tells whether the pipeline was paused or cancelled
+		output.Flowpipe = FlowpipeMetadataOutput(start, finish)
+		return &output, context.Cause(ctx)
+	case errors.Is(cmdCtx.Err(), context.DeadlineExceeded):
+		output.Errors = []resources.StepError{
+			{
+				Error: perr.ExecutionErrorWithMessage("Command timed out after " + timeout.String()),
+			},
+		}
+	case err != nil && exitErr == nil:
+		// the command didn't start, e.g. the program or the workdir doesn't exist
+		output.Errors = []resources.StepError{
+			{
+				Error: perr.ExecutionErrorWithMessage("Unable to run command: " + err.Error()),
+			},
+		}
+	case !slices.Contains(allowedExitCodes, exitCode):
+		message := fmt.Sprintf("Command exited with code %d", exitCode)
+		if stderr := strings.TrimSpace(streamText(lines, container.StderrType)); stderr != "" {
+			// as the container step, the stderr is truncated to 256 chars
+			if len(stderr) > 256 {
+				stderr = stderr[:256]
 			}
-		} else {
-			// Unexpected error type, set exit_code to -1 (because I don't have a better idea)
-			// TODO - log a warning
-			exitCode = -1
+			message += ": " + stderr
+		}
+		output.Errors = []resources.StepError{
+			{
+				Error: perr.ExecutionErrorWithMessage(message),
+			},
 		}
-	}
-	finish := time.Now().UTC()
-
-	output := resources.Output{
-		Data: map[string]interface{}{},
 	}
 
-	output.Data["exit_code"] = exitCode
-	output.Data["stdout_lines"] = stdoutLines
-	output.Data["stderr_lines"] = stderrLines
 	output.Flowpipe = FlowpipeMetadataOutput(start, finish)
 
 	return &output, nil
 }
+
+// commandOutput collects the output of a command line by line, in the order the lines are written. The lines keep
+// their newline as the lines of the container step. Only the first MaxCommandOutputLines lines are kept, the lines
+// the command writes after are dropped.
+type commandOutput struct {
+	mutex      sync.Mutex
+	lines      []container.OutputLine
+	dropped    int
+	partial    map[string]string
+	outputLine func(line container.OutputLine)
+}
+
+func newCommandOutput(outputLine func(line container.OutputLine)) *commandOutput {
+	return &commandOutput{
+		lines:      []container.OutputLine{},
+		partial:    map[string]string{},
+		outputLine: outputLine,
+	}
+}
+
+func (o *commandOutput) write(stream, text string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	text = o.partial[stream] + text
+	for {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			break
+		}
+		o.addLine(stream, text[:i+1])
+		text = text[i+1:]
+	}
+	o.partial[stream] = text
+}
+
+// flush adds the output written after the last newline once the command has ended, and returns the lines and the
+// number of lines dropped past the cap. The output written after the flush is dropped, e.g. by a background process of
+// a command that timed out.
+func (o *commandOutput) flush() ([]container.OutputLine, int) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for _, stream := range []string{container.StdoutType, container.StderrType} {
+		if o.partial[stream] != "" {
+			o.addLine(stream, o.partial[stream])
+			o.partial[stream] = ""
+		}
+	}
+
+	lines := o.lines
+	o.lines = nil
+	o.outputLine = nil
+	return lines, o.dropped
+}
+
+func (o *commandOutput) addLine(stream, line string) {
+	if o.lines == nil {
+		// flushed
+		return
+	}
+	if len(o.lines) >= constants.MaxCommandOutputLines {
+		o.dropped++
+		return
+	}
+
+	outputLine := container.OutputLine{Stream: stream, Line: line}
+	o.lines = append(o.lines, outputLine)
+	if o.outputLine != nil {
+		o.outputLine(outputLine)
+	}
+}
+
+func streamText(lines []container.OutputLine, stream string) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		if line.Stream == stream {
+			sb.WriteString(line.Line)
+		}
+	}
+	return sb.String()
+}
+
+type commandOutputWriter struct {
+	output *commandOutput
+	stream string
+}
+
+func (w commandOutputWriter) Write(p []byte) (int, error) {
+	w.output.write(w.stream, string(p))
+	return len(p), nil
+}
+
+// commandTimeout parses the timeout of the step, a duration string or a number of milliseconds. No timeout is 0.
+func commandTimeout(timeout interface{}) (time.Duration, error) {
+	var duration time.Duration
+	switch t := timeout.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		var err error
+		duration, err = time.ParseDuration(t)
+		if err != nil {
+			return 0, perr.BadRequestWithMessage("invalid timeout duration " + t)
+		}
+	case int:
+		duration = time.Duration(t) * time.Millisecond
+	case int64:
+		duration = time.Duration(t) * time.Millisecond
+	case float64:
+		duration = time.Duration(t) * time.Millisecond
+	default:
+		return 0, perr.BadRequestWithMessage("The attribute '" + schema.AttributeTypeTimeout + "' must be a string or a whole number")
+	}
+
+	if duration < 0 {
+		return 0, perr.BadRequestWithMessage("The attribute '" + schema.AttributeTypeTimeout + "' must be a positive duration")
+	}
+	return duration, nil
+}
+
+// toStringSlice converts the input as set by the step ([]string) or as decoded from the event ([]interface{})
+func toStringSlice(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case []string:
+		return v, true
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, s := range v {
+			str, ok := s.(string)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, str)
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// toStringMap converts the input as set by the step (map[string]string) or as decoded from the event
+// (map[string]interface{})
+func toStringMap(value interface{}) (map[string]string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case map[string]string:
+		return v, true
+	case map[string]interface{}:
+		result := make(map[string]string, len(v))
+		for k, s := range v {
+			str, ok := s.(string)
+			if !ok {
+				return nil, false
+			}
+			result[k] = str
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// toIntSlice converts the input as set by the step ([]int64) or as decoded from the event ([]interface{} of numbers)
+func toIntSlice(value interface{}) ([]int, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case []int64:
+		result := make([]int, 0, len(v))
+		for _, i := range v {
+			result = append(result, int(i))
+		}
+		return result, true
+	case []interface{}:
+		result := make([]int, 0, len(v))
+		for _, n := range v {
+			switch i := n.(type) {
+			case int:
+				result = append(result, i)
+			case int64:
+				result = append(result, int(i))
+			case float64:
+				if i != float64(int(i)) {
+					return nil, false
+				}
+				result = append(result, int(i))
+			default:
+				return nil, false
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
@@ -0,0 +1,242 @@
+package primitive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/container"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+func TestExecShellCommand(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	var streamed []container.OutputLine
+	e := Exec{
+		ModPath: t.TempDir(),
+		OutputLine: func(line container.OutputLine) {
+			streamed = append(streamed, line)
+		},
+	}
+
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "echo 'Line 1'; echo 'Line 2' >&2; printf 'Line 3'",
+	})
+
+	output, err := e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal(0, output.Get(schema.AttributeTypeExitCode))
+	assert.Equal("Line 1\nLine 3", output.Get(schema.AttributeTypeStdout))
+	assert.Equal("Line 2\n", output.Get(schema.AttributeTypeStderr))
+
+	lines, ok := output.Get(schema.AttributeTypeLines).([]container.OutputLine)
+	if !ok {
+		assert.Fail("Expected lines to be []container.OutputLine")
+		return
+	}
+	assert.Equal(3, len(lines))
+
+	// the order of the lines of the two streams isn't deterministic, the lines of a stream are in order
+	var stdoutLines, stderrLines []string
+	for _, line := range lines {
+		if line.Stream == container.StdoutType {
+			stdoutLines = append(stdoutLines, line.Line)
+		} else {
+			stderrLines = append(stderrLines, line.Line)
+		}
+	}
+	assert.Equal([]string{"Line 1\n", "Line 3"}, stdoutLines)
+	assert.Equal([]string{"Line 2\n"}, stderrLines)
+
+	// the lines are streamed as they are written
+	assert.Equal(lines, streamed)
+}
+
+func TestExecCommandWithArgs(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	e := Exec{ModPath: t.TempDir()}
+
+	// the args aren't interpreted by the shell
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "echo",
+		schema.AttributeTypeArgs:       []interface{}{"$HOME", "a;b"},
+	})
+
+	output, err := e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal("$HOME a;b\n", output.Get(schema.AttributeTypeStdout))
+}
+
+func TestExecCommandEnvWorkdirStdin(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	modPath := t.TempDir()
+	err := os.Mkdir(filepath.Join(modPath, "data"), 0755)
+	assert.Nil(err)
+	err = os.WriteFile(filepath.Join(modPath, "data", "file.txt"), []byte("content"), 0600)
+	assert.Nil(err)
+
+	e := Exec{ModPath: modPath}
+
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "cat file.txt; echo \" $GREETING\"; cat",
+		schema.AttributeTypeEnv:        map[string]string{"GREETING": "hello"},
+		schema.AttributeTypeWorkdir:    "data",
+		constants.AttributeTypeStdin:   "from stdin",
+	})
+
+	output, err := e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal("content hello\nfrom stdin", output.Get(schema.AttributeTypeStdout))
+}
+
+func TestExecCommandExitCode(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	e := Exec{ModPath: t.TempDir()}
+
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "echo 'not found' >&2; exit 3",
+	})
+
+	output, err := e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(3, output.Get(schema.AttributeTypeExitCode))
+	assert.Equal(1, len(output.Errors))
+	assert.Equal("Command exited with code 3: not found", output.Errors[0].Error.Detail)
+
+	// the exit code is allowed, as decoded from the event
+	input[constants.AttributeTypeAllowedExitCodes] = []interface{}{float64(0), float64(3)}
+
+	output, err = e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(3, output.Get(schema.AttributeTypeExitCode))
+	assert.Equal(0, len(output.Errors))
+}
+
+func TestExecCommandTimeout(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	e := Exec{ModPath: t.TempDir()}
+
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "sleep 10",
+		schema.AttributeTypeTimeout:    "200ms",
+	})
+
+	output, err := e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(-1, output.Get(schema.AttributeTypeExitCode))
+	assert.Equal(1, len(output.Errors))
+	assert.Equal("Command timed out after 200ms", output.Errors[0].Error.Detail)
+}
+
+func TestExecCommandCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	errStopped := errors.New("pipeline stopped")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(200*time.Millisecond, func() { cancel(errStopped) })
+
+	e := Exec{ModPath: t.TempDir()}
+
+	// the background process keeps stdout open, the command only returns before the wait delay if the process group
+	// is killed
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "echo started; sleep 10 & wait",
+	})
+
+	start := time.Now()
+	output, err := e.Run(ctx, input)
+	assert.Less(time.Since(start), constants.CommandWaitDelay)
+
+	// the cause of the cancellation is returned, e.g. to run the command again when the pipeline is resumed
+	assert.Equal(errStopped, err)
+	assert.Equal(-1, output.Get(schema.AttributeTypeExitCode))
+	assert.Equal("started\n", output.Get(schema.AttributeTypeStdout))
+}
+
+func TestExecCommandOutputCap(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	streamed := 0
+	e := Exec{
+		ModPath: t.TempDir(),
+		OutputLine: func(line container.OutputLine) {
+			streamed++
+		},
+	}
+
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "seq 1 " + strconv.Itoa(constants.MaxCommandOutputLines+10),
+	})
+
+	output, err := e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+
+	// the lines past the cap are neither kept nor streamed
+	lines := output.Get(schema.AttributeTypeLines).([]container.OutputLine)
+	assert.Equal(constants.MaxCommandOutputLines, len(lines))
+	assert.Equal(constants.MaxCommandOutputLines, streamed)
+	assert.Equal(strconv.Itoa(constants.MaxCommandOutputLines)+"\n", lines[len(lines)-1].Line)
+	assert.True(strings.HasSuffix(output.Get(schema.AttributeTypeStdout).(string), "\n"+strconv.Itoa(constants.MaxCommandOutputLines)+"\n"))
+}
+
+func TestExecCommandNotFound(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	e := Exec{ModPath: t.TempDir()}
+
+	input := resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "does-not-exist",
+		schema.AttributeTypeArgs:       []string{},
+	})
+
+	output, err := e.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(-1, output.Get(schema.AttributeTypeExitCode))
+	assert.Equal(1, len(output.Errors))
+	assert.Contains(output.Errors[0].Error.Detail, "Unable to run command")
+}
+
+func TestExecInvalidInput(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	e := Exec{}
+
+	_, err := e.Run(ctx, resources.Input(map[string]interface{}{}))
+	assert.NotNil(err)
+	fpErr, ok := err.(perr.ErrorModel)
+	assert.True(ok)
+	assert.Equal(400, fpErr.Status)
+
+	_, err = e.Run(ctx, resources.Input(map[string]interface{}{
+		constants.AttributeTypeCommand: "echo",
+		schema.AttributeTypeArgs:       []interface{}{1},
+	}))
+	assert.NotNil(err)
+	assert.Equal("Bad Request: Command attribute 'args' must be an array of strings", err.Error())
+}
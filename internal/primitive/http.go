@@ -3,8 +3,6 @@ package primitive
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -15,7 +13,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/schema"
@@ -23,6 +23,9 @@ import (
 
 type HTTPRequest struct {
 	Input resources.Input
+
+	// the relative paths of the multipart files and of the response file are relative to the mod
+	ModPath string
 }
 
 type HTTPInput struct {
@@ -33,6 +36,21 @@ type HTTPInput struct {
 	CaCertPem      string
 	Insecure       bool
 	Timeout        time.Duration
+	Pagination     *HTTPPagination
+
+	// the transport settings
+	ClientCertPem   string
+	ClientKeyPem    string
+	ProxyURL        *url.URL
+	ConnectTimeout  time.Duration
+	ReadTimeout     time.Duration
+	FollowRedirects bool
+	MaxRedirects    int
+	HTTP2           bool
+
+	// the parts of a multipart/form-data request body, and the file the response body is written to
+	Multipart    []HTTPMultipartPart
+	ResponseFile string
 }
 
 func (h *HTTPRequest) ValidateInput(ctx context.Context, i resources.Input) error {
@@ -92,7 +110,15 @@ func (h *HTTPRequest) ValidateInput(ctx context.Context, i resources.Input) erro
 		}
 	}
 
-	return nil
+	if err := setTransportInput(i, &HTTPInput{}); err != nil {
+		return err
+	}
+
+	if err := setFileInput(i, &HTTPInput{}, h.ModPath); err != nil {
+		return err
+	}
+
+	return validatePaginationInput(i)
 }
 
 func (h *HTTPRequest) Run(ctx context.Context, input resources.Input) (*resources.Output, error) {
@@ -106,11 +132,15 @@ func (h *HTTPRequest) Run(ctx context.Context, input resources.Input) (*resource
 	// * Compare to features in https://www.tines.com/docs/actions/types/http-request#configuration-options
 
 	// Constuct the input structure from the input parameters
-	httpInput, err := buildHTTPInput(input)
+	httpInput, err := buildHTTPInput(input, h.ModPath)
 	if err != nil {
 		return nil, err
 	}
 
+	if httpInput.Pagination != nil {
+		return doPaginatedRequest(ctx, httpInput)
+	}
+
 	// Make the HTTP request
 	output, err := doRequest(ctx, httpInput)
 	if err != nil {
@@ -121,44 +151,62 @@ func (h *HTTPRequest) Run(ctx context.Context, input resources.Input) (*resource
 }
 
 // doRequest performs the HTTP request based on the inputs provided and returns the output
-func doRequest(ctx context.Context, inputParams *HTTPInput) (*resources.Output, error) {
-	// Create the HTTP request
-	client := &http.Client{}
-	req, err := http.NewRequest(strings.ToUpper(inputParams.Method), inputParams.URL, bytes.NewBuffer([]byte(inputParams.RequestBody)))
+func doRequest(ctx context.Context, inputParams *HTTPInput) (output *resources.Output, err error) {
+	client, err := newHTTPClient(inputParams)
 	if err != nil {
-		return nil, perr.BadRequestWithMessage("Error creating request: " + err.Error())
+		return nil, err
 	}
 
-	// Set the request headers
-	for k, v := range inputParams.RequestHeaders {
-		req.Header.Set(k, v.(string))
+	// NOTE: the request doesn't use the context of the step, that context is cancelled once the step start command is
+	// handled rather than when the step ends
+	reqCtx := context.Background()
+	if inputParams.ReadTimeout > 0 {
+		var timer *readTimer
+		reqCtx, timer = withReadTimeout(reqCtx, inputParams.ReadTimeout)
+		defer timer.stop()
+
+		defer func() {
+			// the request cancelled by the read timeout fails with a context canceled error
+			if err != nil && timer.hasExpired() {
+				output = nil
+				err = perr.TimeoutWithMessage("HTTP request timed out reading the response after " + inputParams.ReadTimeout.String())
+			}
+		}()
 	}
 
-	// Initialize the TLSClientConfig with default settings
-	tlsConfig := &tls.Config{} // #nosec G402
-
-	// By default the client verifies the server's certificate chain and host name.
-	// If the insecure flag is set, the client skips this verification and accepts any certificate presented by the server and any host name in that certificate.
-	// Default value of insecure flag is false.
-	if inputParams.Insecure {
-		tlsConfig.InsecureSkipVerify = inputParams.Insecure
+	var requestBody io.Reader = bytes.NewBuffer([]byte(inputParams.RequestBody))
+	var multipartContentType string
+	var multipartLength int64
+	if len(inputParams.Multipart) > 0 {
+		var multipartReader io.ReadCloser
+		multipartReader, multipartContentType, multipartLength, err = multipartBody(inputParams.Multipart)
+		if err != nil {
+			return nil, err
+		}
+		defer multipartReader.Close()
+		requestBody = multipartReader
 	}
 
-	if inputParams.Timeout > 0 {
-		client.Timeout = inputParams.Timeout
+	// Create the HTTP request
+	req, err := http.NewRequestWithContext(reqCtx, strings.ToUpper(inputParams.Method), inputParams.URL, requestBody)
+	if err != nil {
+		return nil, perr.BadRequestWithMessage("Error creating request: " + err.Error())
 	}
 
-	// If the input parameter 'ca_cert_pem' is set, the client verifies the server's certificate chain using the provided PEM encoded CA certificates.
-	if inputParams.CaCertPem != "" {
-		caCertPool := x509.NewCertPool()
-		caCertPool.AppendCertsFromPEM([]byte(inputParams.CaCertPem))
+	// Set the request headers
+	for k, v := range inputParams.RequestHeaders {
+		req.Header.Set(k, v.(string))
+	}
 
-		tlsConfig.RootCAs = caCertPool
+	// the content type of the multipart body has its boundary
+	if len(inputParams.Multipart) > 0 {
+		req.Header.Set("Content-Type", multipartContentType)
+		req.ContentLength = multipartLength
 	}
 
-	// Configure the client's transport with the final TLSClientConfig
-	client.Transport = &http.Transport{
-		TLSClientConfig: tlsConfig,
+	// Propagate the trace of the step execution with the W3C traceparent header, unless set in the request headers
+	if req.Header.Get("traceparent") == "" {
+		tracing.Inject(ctx, req.Header)
 	}
 
 	start := time.Now().UTC()
@@ -173,7 +221,14 @@ func doRequest(ctx context.Context, inputParams *HTTPInput) (*resources.Output,
 		}
 	}()
 
-	body, err := io.ReadAll(resp.Body)
+	// the response body is written to the response file, unless it's an error response
+	var responseFile map[string]interface{}
+	var body []byte
+	if inputParams.ResponseFile != "" && resp.StatusCode < 400 {
+		responseFile, err = writeResponseFile(inputParams.ResponseFile, resp.Body)
+	} else {
+		body, err = io.ReadAll(resp.Body)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +239,7 @@ func doRequest(ctx context.Context, inputParams *HTTPInput) (*resources.Output,
 	headers := mapResponseHeaders(resp)
 
 	// Construct the output
-	output := resources.Output{
+	output = &resources.Output{
 		Data: map[string]interface{}{},
 	}
 	output.Data[schema.AttributeTypeStatus] = resp.Status
@@ -193,6 +248,10 @@ func doRequest(ctx context.Context, inputParams *HTTPInput) (*resources.Output,
 
 	output.Flowpipe = FlowpipeMetadataOutput(start, finish)
 
+	if responseFile != nil {
+		output.Data[constants.AttributeTypeResponseFile] = responseFile
+	}
+
 	var bodyString string
 
 	if body != nil {
@@ -220,11 +279,11 @@ func doRequest(ctx context.Context, inputParams *HTTPInput) (*resources.Output,
 		}
 	}
 
-	return &output, nil
+	return output, nil
 }
 
 // buildHTTPInput builds the HTTPInput struct from the input parameters
-func buildHTTPInput(input resources.Input) (*HTTPInput, error) {
+func buildHTTPInput(input resources.Input, modPath string) (*HTTPInput, error) {
 	// Check for method
 	method, ok := input[schema.AttributeTypeMethod].(string)
 	if !ok {
@@ -321,6 +380,22 @@ func buildHTTPInput(input resources.Input) (*HTTPInput, error) {
 		inputParams.Timeout = timeout
 	}
 
+	if err := setTransportInput(input, inputParams); err != nil {
+		return nil, err
+	}
+
+	if err := setFileInput(input, inputParams, modPath); err != nil {
+		return nil, err
+	}
+
+	if input[constants.BlockTypePagination] != nil {
+		pagination, err := buildHTTPPagination(input[constants.BlockTypePagination])
+		if err != nil {
+			return nil, err
+		}
+		inputParams.Pagination = pagination
+	}
+
 	return inputParams, nil
 }
 
@@ -0,0 +1,272 @@
+package primitive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+// HTTPMultipartPart is a part of the multipart/form-data request body, either a file or a content
+type HTTPMultipartPart struct {
+	Name        string
+	File        string
+	Content     string
+	Filename    string
+	ContentType string
+}
+
+func (p HTTPMultipartPart) isFile() bool {
+	return p.File != ""
+}
+
+// setFileInput sets the multipart parts and the response file of the http step from the input parameters, their
+// relative paths are relative to the mod
+func setFileInput(input resources.Input, inputParams *HTTPInput, modPath string) error {
+	if input[constants.BlockTypeMultipart] != nil {
+		if input[schema.AttributeTypeRequestBody] != nil {
+			return perr.BadRequestWithMessage("The attribute '" + schema.AttributeTypeRequestBody + "' can't be set with " + constants.BlockTypeMultipart + " parts")
+		}
+
+		parts, err := buildHTTPMultipart(input[constants.BlockTypeMultipart], modPath)
+		if err != nil {
+			return err
+		}
+		inputParams.Multipart = parts
+	}
+
+	if input[constants.AttributeTypeResponseFile] != nil {
+		responseFile, ok := input[constants.AttributeTypeResponseFile].(string)
+		if !ok || responseFile == "" {
+			return perr.BadRequestWithMessage("The attribute '" + constants.AttributeTypeResponseFile + "' must be a path")
+		}
+		if input[constants.BlockTypePagination] != nil {
+			return perr.BadRequestWithMessage("The attribute '" + constants.AttributeTypeResponseFile + "' can't be set with " + constants.BlockTypePagination)
+		}
+		inputParams.ResponseFile = modFilePath(modPath, responseFile)
+	}
+
+	return nil
+}
+
+// buildHTTPMultipart builds the parts from the multipart input, as set by the step or as decoded from the event. The
+// part of a file without content type has the content type of its extension.
+func buildHTTPMultipart(input interface{}, modPath string) ([]HTTPMultipartPart, error) {
+	partInputs, ok := input.([]interface{})
+	if !ok {
+		return nil, perr.BadRequestWithMessage("The " + constants.BlockTypeMultipart + " parts must be a list")
+	}
+
+	parts := []HTTPMultipartPart{}
+	for i, partInput := range partInputs {
+		partMap, ok := partInput.(map[string]interface{})
+		if !ok {
+			return nil, perr.BadRequestWithMessage(fmt.Sprintf("The %s part %d must be a map", constants.BlockTypeMultipart, i))
+		}
+
+		part := HTTPMultipartPart{}
+		attributes := []struct {
+			name  string
+			field *string
+		}{
+			{schema.AttributeTypeName, &part.Name},
+			{constants.AttributeTypeFile, &part.File},
+			{constants.AttributeTypeContent, &part.Content},
+			{constants.AttributeTypeFilename, &part.Filename},
+			{schema.AttributeTypeContentType, &part.ContentType},
+		}
+		for _, attribute := range attributes {
+			if partMap[attribute.name] == nil {
+				continue
+			}
+			value, ok := partMap[attribute.name].(string)
+			if !ok {
+				return nil, perr.BadRequestWithMessage(fmt.Sprintf("The %s part %d attribute '%s' must be a string", constants.BlockTypeMultipart, i, attribute.name))
+			}
+			*attribute.field = value
+		}
+
+		if part.Name == "" {
+			return nil, perr.BadRequestWithMessage(fmt.Sprintf("The %s part %d must define a name", constants.BlockTypeMultipart, i))
+		}
+		if (partMap[constants.AttributeTypeFile] == nil) == (partMap[constants.AttributeTypeContent] == nil) {
+			return nil, perr.BadRequestWithMessage("The " + constants.BlockTypeMultipart + " part " + part.Name + " must define either a " + constants.AttributeTypeFile + " or a " + constants.AttributeTypeContent)
+		}
+
+		if part.isFile() {
+			part.File = modFilePath(modPath, part.File)
+			info, err := os.Stat(part.File)
+			if err != nil {
+				return nil, perr.BadRequestWithMessage("The file of the " + constants.BlockTypeMultipart + " part " + part.Name + " can't be read: " + err.Error())
+			}
+			if !info.Mode().IsRegular() {
+				return nil, perr.BadRequestWithMessage("The file of the " + constants.BlockTypeMultipart + " part " + part.Name + " is not a regular file: " + part.File)
+			}
+
+			if part.Filename == "" {
+				part.Filename = filepath.Base(part.File)
+			}
+			if part.ContentType == "" {
+				part.ContentType = mime.TypeByExtension(filepath.Ext(part.File))
+			}
+			if part.ContentType == "" {
+				part.ContentType = constants.DefaultMultipartContentType
+			}
+		}
+
+		parts = append(parts, part)
+	}
+
+	return parts, nil
+}
+
+// modFilePath returns the path of the file, a relative path is relative to the mod
+func modFilePath(modPath, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(modPath, path)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// partHeader returns the header of the part, as the form fields and files of mime/multipart
+func partHeader(part HTTPMultipartPart) textproto.MIMEHeader {
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name))
+	if part.Filename != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(part.Filename))
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", disposition)
+	if part.ContentType != "" {
+		header.Set("Content-Type", part.ContentType)
+	}
+	return header
+}
+
+// writeMultipart writes the parts to the multipart writer, writeFile writes the file of a part
+func writeMultipart(mw *multipart.Writer, parts []HTTPMultipartPart, writeFile func(w io.Writer, part HTTPMultipartPart) error) error {
+	for _, part := range parts {
+		w, err := mw.CreatePart(partHeader(part))
+		if err != nil {
+			return err
+		}
+
+		if part.isFile() {
+			err = writeFile(w, part)
+		} else {
+			_, err = io.WriteString(w, part.Content)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	count int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.count += int64(len(p))
+	return len(p), nil
+}
+
+// multipartBody returns the multipart/form-data request body of the parts with its content type and length. The files
+// are streamed from disk as the body is read, the length is known up front as some servers refuse a chunked upload,
+// e.g. the presigned POST of S3.
+func multipartBody(parts []HTTPMultipartPart) (io.ReadCloser, string, int64, error) {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	// the length of the body, the files are counted by their size rather than read
+	counter := &countingWriter{}
+	mw := multipart.NewWriter(counter)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return nil, "", 0, err
+	}
+	err := writeMultipart(mw, parts, func(w io.Writer, part HTTPMultipartPart) error {
+		info, err := os.Stat(part.File)
+		if err != nil {
+			return err
+		}
+		counter.count += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, "", 0, perr.BadRequestWithMessage("Error creating multipart request body: " + err.Error())
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		mw := multipart.NewWriter(pw)
+		err := mw.SetBoundary(boundary)
+		if err == nil {
+			err = writeMultipart(mw, parts, func(w io.Writer, part HTTPMultipartPart) error {
+				file, err := os.Open(part.File)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+
+				_, err = io.Copy(w, file)
+				return err
+			})
+		}
+		// the request fails with the error of the body, if any
+		pw.CloseWithError(err)
+	}()
+
+	return pr, mw.FormDataContentType(), counter.count, nil
+}
+
+// writeResponseFile streams the response body to the file and returns the output of the file. The body is written to
+// a temporary file renamed once complete, the file is never left partially written.
+func writeResponseFile(path string, body io.Reader) (map[string]interface{}, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, perr.InternalWithMessage("Error creating the directory of the response file: " + err.Error())
+	}
+
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, perr.InternalWithMessage("Error creating the response file: " + err.Error())
+	}
+	defer func() {
+		// no-op once renamed
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, hash), body)
+	closeErr := tempFile.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, perr.InternalWithMessage("Error writing the response file: " + closeErr.Error())
+	}
+
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return nil, perr.InternalWithMessage("Error writing the response file: " + err.Error())
+	}
+
+	return map[string]interface{}{
+		constants.AttributeTypePath:   path,
+		constants.AttributeTypeSize:   size,
+		constants.AttributeTypeSha256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
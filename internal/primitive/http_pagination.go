@@ -0,0 +1,355 @@
+package primitive
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+// HTTPPagination is the pagination of the http step, the pages are requested one after the other until there's no next
+// page or a limit is reached
+type HTTPPagination struct {
+	Style       string
+	ItemsPath   string
+	TokenPath   string
+	TokenParam  string
+	OffsetParam string
+	LimitParam  string
+	Limit       int
+	// the pages requested by a step are capped, unless the step sets its own max_pages
+	MaxPages int
+	// no limit if 0
+	MaxItems int
+}
+
+// the links of a Link header (RFC 5988), e.g. <https://api.github.com/repositories?page=2>; rel="next"
+var linkHeaderRegex = regexp.MustCompile(`<([^>]*)>([^<]*)`)
+
+func validatePaginationInput(i resources.Input) error {
+	if i[constants.BlockTypePagination] == nil {
+		return nil
+	}
+
+	_, err := buildHTTPPagination(i[constants.BlockTypePagination])
+	return err
+}
+
+// buildHTTPPagination builds the pagination from the pagination input, as set by the step or as decoded from the event
+func buildHTTPPagination(input interface{}) (*HTTPPagination, error) {
+	paginationInput, ok := input.(map[string]interface{})
+	if !ok {
+		return nil, perr.BadRequestWithMessage("The " + constants.BlockTypePagination + " must be a map")
+	}
+
+	pagination := &HTTPPagination{
+		OffsetParam: constants.DefaultPaginationOffsetParam,
+		LimitParam:  constants.DefaultPaginationLimitParam,
+		Limit:       constants.DefaultPaginationLimit,
+		MaxPages:    constants.DefaultPaginationMaxPages,
+	}
+
+	stringAttributes := map[string]*string{
+		schema.AttributeTypeStyle:          &pagination.Style,
+		constants.AttributeTypeItemsPath:   &pagination.ItemsPath,
+		constants.AttributeTypeTokenPath:   &pagination.TokenPath,
+		constants.AttributeTypeTokenParam:  &pagination.TokenParam,
+		constants.AttributeTypeOffsetParam: &pagination.OffsetParam,
+		constants.AttributeTypeLimitParam:  &pagination.LimitParam,
+	}
+	for attributeName, field := range stringAttributes {
+		if paginationInput[attributeName] == nil {
+			continue
+		}
+		value, ok := paginationInput[attributeName].(string)
+		if !ok {
+			return nil, perr.BadRequestWithMessage("The pagination attribute '" + attributeName + "' must be a string")
+		}
+		*field = value
+	}
+
+	intAttributes := map[string]*int{
+		constants.AttributeTypeLimit:    &pagination.Limit,
+		constants.AttributeTypeMaxPages: &pagination.MaxPages,
+		constants.AttributeTypeMaxItems: &pagination.MaxItems,
+	}
+	for attributeName, field := range intAttributes {
+		if paginationInput[attributeName] == nil {
+			continue
+		}
+		value, ok := toInt(paginationInput[attributeName])
+		if !ok || value < 1 {
+			return nil, perr.BadRequestWithMessage("The pagination attribute '" + attributeName + "' must be a whole number greater than 0")
+		}
+		*field = value
+	}
+
+	if !slices.Contains(resources.ValidPaginationStyles, pagination.Style) {
+		return nil, perr.BadRequestWithMessage("The pagination style must be one of: " + strings.Join(resources.ValidPaginationStyles, ", "))
+	}
+
+	if pagination.Style == constants.PaginationStyleToken && (pagination.TokenPath == "" || pagination.TokenParam == "") {
+		return nil, perr.BadRequestWithMessage("The token pagination must define " + constants.AttributeTypeTokenPath + " and " + constants.AttributeTypeTokenParam)
+	}
+
+	return pagination, nil
+}
+
+// doPaginatedRequest requests the pages and collects their items in the response_body of the output, the status and
+// headers of the output are the ones of the last page. The metadata of each page is in the pages of the output.
+//
+// The pagination stops at the first page that fails, the output has the error of the page and the items of the pages
+// before it.
+func doPaginatedRequest(ctx context.Context, inputParams *HTTPInput) (*resources.Output, error) {
+	pagination := inputParams.Pagination
+
+	pageURL := inputParams.URL
+	if pagination.Style == constants.PaginationStyleOffset {
+		var err error
+		pageURL, err = withQueryParams(inputParams.URL, map[string]string{
+			pagination.OffsetParam: "0",
+			pagination.LimitParam:  strconv.Itoa(pagination.Limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := []interface{}{}
+	pages := []interface{}{}
+	offset := 0
+
+	var output *resources.Output
+	start := time.Now().UTC()
+	for pageNumber := 1; ; pageNumber++ {
+		pageInput := *inputParams
+		pageInput.URL = pageURL
+
+		var err error
+		output, err = doRequest(ctx, &pageInput)
+		if err != nil {
+			return nil, err
+		}
+
+		page := map[string]interface{}{
+			schema.AttributeTypeUrl:             pageURL,
+			schema.AttributeTypeStatusCode:      output.Data[schema.AttributeTypeStatusCode],
+			schema.AttributeTypeResponseHeaders: output.Data[schema.AttributeTypeResponseHeaders],
+		}
+		pages = append(pages, page)
+
+		if len(output.Errors) > 0 {
+			// the response body of the page is replaced by the items, the page keeps it, e.g. the error detail
+			page[schema.AttributeTypeResponseBody] = output.Data[schema.AttributeTypeResponseBody]
+			break
+		}
+
+		pageItems, err := paginationItems(output.Data[schema.AttributeTypeResponseBody], pagination.ItemsPath)
+		if err != nil {
+			output.Errors = []resources.StepError{
+				{
+					Error: perr.ExecutionErrorWithMessage(fmt.Sprintf("Unable to read the items of page %d: %s", pageNumber, err.Error())),
+				},
+			}
+			break
+		}
+		page["item_count"] = len(pageItems)
+		items = append(items, pageItems...)
+		offset += len(pageItems)
+
+		nextURL, err := nextPageURL(pagination, inputParams.URL, pageURL, output, len(pageItems), offset)
+		if err != nil {
+			output.Errors = []resources.StepError{
+				{
+					Error: perr.ExecutionErrorWithMessage(fmt.Sprintf("Unable to find the next page of page %d: %s", pageNumber, err.Error())),
+				},
+			}
+			break
+		}
+		if nextURL == "" {
+			break
+		}
+
+		// the page has a next page, it's not requested once a limit is reached
+		page["next_url"] = nextURL
+
+		if pagination.MaxItems > 0 && len(items) >= pagination.MaxItems {
+			break
+		}
+		if pageNumber >= pagination.MaxPages {
+			break
+		}
+
+		pageURL = nextURL
+	}
+	finish := time.Now().UTC()
+
+	if pagination.MaxItems > 0 && len(items) > pagination.MaxItems {
+		items = items[:pagination.MaxItems]
+	}
+
+	output.Data[schema.AttributeTypeResponseBody] = items
+	output.Data[constants.AttributeTypePages] = pages
+	output.Flowpipe = FlowpipeMetadataOutput(start, finish)
+
+	return output, nil
+}
+
+// nextPageURL returns the URL of the page after the page, empty if it's the last page. The next page is the rel="next"
+// URL of the Link header, the request with the next page token found in the response body or the request with the
+// next offset.
+func nextPageURL(pagination *HTTPPagination, requestURL, pageURL string, output *resources.Output, itemCount, offset int) (string, error) {
+	switch pagination.Style {
+	case constants.PaginationStyleLinkHeader:
+		headers, _ := output.Data[schema.AttributeTypeResponseHeaders].(map[string]interface{})
+		link, _ := headers["Link"].(string)
+		next := nextLink(link)
+		if next == "" {
+			return "", nil
+		}
+
+		// the link may be relative to the page
+		base, err := url.Parse(pageURL)
+		if err != nil {
+			return "", err
+		}
+		nextURL, err := base.Parse(next)
+		if err != nil {
+			return "", err
+		}
+		return nextURL.String(), nil
+
+	case constants.PaginationStyleToken:
+		token := valueAtPath(output.Data[schema.AttributeTypeResponseBody], pagination.TokenPath)
+		var tokenString string
+		switch t := token.(type) {
+		case nil:
+			return "", nil
+		case string:
+			tokenString = t
+		case float64:
+			tokenString = strconv.FormatFloat(t, 'f', -1, 64)
+		default:
+			return "", perr.BadRequestWithMessage("the next page token at '" + pagination.TokenPath + "' is not a string")
+		}
+		if tokenString == "" {
+			return "", nil
+		}
+		return withQueryParams(requestURL, map[string]string{pagination.TokenParam: tokenString})
+
+	case constants.PaginationStyleOffset:
+		// a page shorter than the limit is the last page
+		if itemCount < pagination.Limit {
+			return "", nil
+		}
+		return withQueryParams(requestURL, map[string]string{
+			pagination.OffsetParam: strconv.Itoa(offset),
+			pagination.LimitParam:  strconv.Itoa(pagination.Limit),
+		})
+	}
+
+	return "", nil
+}
+
+// nextLink returns the target of the rel="next" link of a Link header, the links of several Link headers are comma
+// separated
+func nextLink(header string) string {
+	for _, match := range linkHeaderRegex.FindAllStringSubmatch(header, -1) {
+		// the params of the link, without the comma before the next link
+		params := strings.TrimSuffix(strings.TrimSpace(match[2]), ",")
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+				continue
+			}
+			// the rel may be a space separated list of relations
+			for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+				if strings.EqualFold(rel, "next") {
+					return match[1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// paginationItems returns the items of the page, the response body is the list of items when there's no items path
+func paginationItems(responseBody interface{}, itemsPath string) ([]interface{}, error) {
+	value := responseBody
+	if itemsPath != "" {
+		value = valueAtPath(responseBody, itemsPath)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		// e.g. the last page without items
+		return []interface{}{}, nil
+	case []interface{}:
+		return v, nil
+	}
+
+	if itemsPath == "" {
+		return nil, perr.BadRequestWithMessage("the response body is not a list, the pagination must define " + constants.AttributeTypeItemsPath)
+	}
+	return nil, perr.BadRequestWithMessage("the value at '" + itemsPath + "' is not a list")
+}
+
+// valueAtPath returns the value at the dot separated path of a JSON value, nil if there's no value at the path
+func valueAtPath(value interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			value = v[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// withQueryParams sets the query params of the URL, the other params of the URL are kept
+func withQueryParams(rawURL string, params map[string]string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", perr.BadRequestWithMessage("invalid url: " + rawURL)
+	}
+
+	query := u.Query()
+	for name, value := range params {
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// toInt converts a whole number as set by the step (int or int64) or as decoded from the event (float64)
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
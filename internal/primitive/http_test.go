@@ -2,11 +2,34 @@ package primitive
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/pipe-fittings/schema"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // GET
@@ -511,3 +534,683 @@ func TestHTTPMethodGETWithTimeoutString(t *testing.T) {
 	assert.NotNil(err)
 	assert.Contains(err.Error(), "Client.Timeout exceeded")
 }
+
+func TestHTTPTraceparent(t *testing.T) {
+	assert := assert.New(t)
+
+	stopTracing, err := tracing.Start(context.Background(), "", tracing.WithExporter(tracetest.NewInMemoryExporter()))
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	defer func() {
+		_ = stopTracing(context.Background())
+	}()
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	tracing.StartPipeline("exec_1", "pexec_1", "", "local.pipeline.http")
+	tracing.StartStep("pexec_1", "sexec_1", "http.get", "http")
+	defer tracing.EndPipeline("pexec_1", nil)
+	defer tracing.EndStep("sexec_1", nil)
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: server.URL,
+	})
+
+	_, err = hr.Run(tracing.ContextWithStep(context.Background(), "sexec_1"), input)
+	assert.Nil(err)
+	assert.Regexp("^00-[0-9a-f]{32}-[0-9a-f]{16}-01$", traceparent)
+}
+
+// Pagination
+
+// paginatedServer serves the items 1 to 10 as JSON, with the pagination style of the test
+func paginatedServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, items []int)) *httptest.Server {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r, items)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPPaginationLinkHeader(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	// 3 items per page, the link to the next page is relative
+	server := paginatedServer(t, func(w http.ResponseWriter, r *http.Request, items []int) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		end := min(page*3, len(items))
+		if end < len(items) {
+			w.Header().Set("Link", fmt.Sprintf(`<https://example.com/first>; rel="first", </items?page=%d>; rel="next"`, page+1))
+		}
+		writeJSON(t, w, items[(page-1)*3:end])
+	})
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: server.URL + "/items",
+		constants.BlockTypePagination: map[string]interface{}{
+			schema.AttributeTypeStyle: constants.PaginationStyleLinkHeader,
+		},
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal(200, output.Get(schema.AttributeTypeStatusCode))
+	assert.Equal([]interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0}, output.Get(schema.AttributeTypeResponseBody))
+
+	pages := output.Get(constants.AttributeTypePages).([]interface{})
+	assert.Equal(4, len(pages))
+	assert.Equal(server.URL+"/items", pages[0].(map[string]interface{})[schema.AttributeTypeUrl])
+	assert.Equal(server.URL+"/items?page=2", pages[0].(map[string]interface{})["next_url"])
+	assert.Equal(3, pages[0].(map[string]interface{})["item_count"])
+	assert.Equal(server.URL+"/items?page=4", pages[3].(map[string]interface{})[schema.AttributeTypeUrl])
+	assert.Equal(1, pages[3].(map[string]interface{})["item_count"])
+	assert.Nil(pages[3].(map[string]interface{})["next_url"])
+}
+
+func TestHTTPPaginationToken(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	// 4 items per page, the token is the index of the first item of the next page
+	server := paginatedServer(t, func(w http.ResponseWriter, r *http.Request, items []int) {
+		assert.Equal("bar", r.URL.Query().Get("foo"))
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		end := min(start+4, len(items))
+		body := map[string]interface{}{
+			"data": map[string]interface{}{"items": items[start:end]},
+			"meta": map[string]interface{}{},
+		}
+		if end < len(items) {
+			body["meta"] = map[string]interface{}{"next": strconv.Itoa(end)}
+		}
+		writeJSON(t, w, body)
+	})
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: server.URL + "/items?foo=bar",
+		constants.BlockTypePagination: map[string]interface{}{
+			schema.AttributeTypeStyle:         constants.PaginationStyleToken,
+			constants.AttributeTypeItemsPath:  "data.items",
+			constants.AttributeTypeTokenPath:  "meta.next",
+			constants.AttributeTypeTokenParam: "cursor",
+		},
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal(10, len(output.Get(schema.AttributeTypeResponseBody).([]interface{})))
+
+	pages := output.Get(constants.AttributeTypePages).([]interface{})
+	assert.Equal(3, len(pages))
+	assert.Equal(server.URL+"/items?cursor=8&foo=bar", pages[2].(map[string]interface{})[schema.AttributeTypeUrl])
+}
+
+func TestHTTPPaginationOffset(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	server := paginatedServer(t, func(w http.ResponseWriter, r *http.Request, items []int) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := min(offset+limit, len(items))
+		writeJSON(t, w, map[string]interface{}{"results": items[offset:end]})
+	})
+
+	hr := HTTPRequest{}
+	// as decoded from the event
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: server.URL + "/items",
+		constants.BlockTypePagination: map[string]interface{}{
+			schema.AttributeTypeStyle:          constants.PaginationStyleOffset,
+			constants.AttributeTypeItemsPath:   "results",
+			constants.AttributeTypeOffsetParam: "skip",
+			constants.AttributeTypeLimit:       float64(5),
+		},
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal(10, len(output.Get(schema.AttributeTypeResponseBody).([]interface{})))
+
+	// the third page is empty
+	pages := output.Get(constants.AttributeTypePages).([]interface{})
+	assert.Equal(3, len(pages))
+	assert.Equal(server.URL+"/items?limit=5&skip=10", pages[2].(map[string]interface{})[schema.AttributeTypeUrl])
+	assert.Equal(0, pages[2].(map[string]interface{})["item_count"])
+}
+
+func TestHTTPPaginationLimits(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	server := paginatedServer(t, func(w http.ResponseWriter, r *http.Request, items []int) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := min(offset+limit, len(items))
+		writeJSON(t, w, items[offset:end])
+	})
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: server.URL,
+		constants.BlockTypePagination: map[string]interface{}{
+			schema.AttributeTypeStyle:       constants.PaginationStyleOffset,
+			constants.AttributeTypeLimit:    int64(2),
+			constants.AttributeTypeMaxPages: int64(2),
+		},
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal([]interface{}{1.0, 2.0, 3.0, 4.0}, output.Get(schema.AttributeTypeResponseBody))
+
+	// the last page requested has a next page
+	pages := output.Get(constants.AttributeTypePages).([]interface{})
+	assert.Equal(2, len(pages))
+	assert.Equal(server.URL+"?limit=2&offset=4", pages[1].(map[string]interface{})["next_url"])
+
+	input[constants.BlockTypePagination] = map[string]interface{}{
+		schema.AttributeTypeStyle:       constants.PaginationStyleOffset,
+		constants.AttributeTypeLimit:    int64(4),
+		constants.AttributeTypeMaxItems: int64(5),
+	}
+
+	output, err = hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(0, len(output.Errors))
+	assert.Equal([]interface{}{1.0, 2.0, 3.0, 4.0, 5.0}, output.Get(schema.AttributeTypeResponseBody))
+	assert.Equal(2, len(output.Get(constants.AttributeTypePages).([]interface{})))
+}
+
+func TestHTTPPaginationPageError(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	server := paginatedServer(t, func(w http.ResponseWriter, r *http.Request, items []int) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			writeJSON(t, w, map[string]interface{}{"error": "slow down"})
+			return
+		}
+		w.Header().Set("Link", `<?page=2>; rel="next"`)
+		writeJSON(t, w, items[:3])
+	})
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: server.URL,
+		constants.BlockTypePagination: map[string]interface{}{
+			schema.AttributeTypeStyle: constants.PaginationStyleLinkHeader,
+		},
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(1, len(output.Errors))
+	assert.Equal(429, output.Errors[0].Error.Status)
+	assert.Equal(429, output.Get(schema.AttributeTypeStatusCode))
+
+	// the items of the pages before the error
+	assert.Equal([]interface{}{1.0, 2.0, 3.0}, output.Get(schema.AttributeTypeResponseBody))
+	pages := output.Get(constants.AttributeTypePages).([]interface{})
+	assert.Equal(2, len(pages))
+	assert.Contains(pages[1].(map[string]interface{})[schema.AttributeTypeResponseBody], "slow down")
+}
+
+func TestHTTPPaginationInvalid(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: "http://localhost",
+		constants.BlockTypePagination: map[string]interface{}{
+			schema.AttributeTypeStyle: constants.PaginationStyleToken,
+		},
+	})
+
+	_, err := hr.Run(ctx, input)
+	assert.NotNil(err)
+	assert.Equal("Bad Request: The token pagination must define token_path and token_param", err.Error())
+
+	input[constants.BlockTypePagination] = map[string]interface{}{
+		schema.AttributeTypeStyle:       constants.PaginationStyleLinkHeader,
+		constants.AttributeTypeMaxPages: float64(0),
+	}
+
+	_, err = hr.Run(ctx, input)
+	assert.NotNil(err)
+	assert.Equal("Bad Request: The pagination attribute 'max_pages' must be a whole number greater than 0", err.Error())
+}
+
+func TestNextLink(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("https://api.github.com/repositories?since=367", nextLink(`<https://api.github.com/repositories?since=367>; rel="next", <https://api.github.com/repositories{?since}>; rel="first"`))
+	assert.Equal("/page/3", nextLink(`</page/1>; rel="prev first", </page/3>; rel=next`))
+	assert.Equal("", nextLink(`</page/1>; rel="prev"`))
+	assert.Equal("", nextLink(""))
+}
+
+// Transport
+
+func TestHTTPRedirects(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	// /1 redirects to /2 which redirects to /3
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/3" {
+			_, _ = w.Write([]byte("done"))
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Path[1:])
+		http.Redirect(w, r, fmt.Sprintf("/%d", page+1), http.StatusFound)
+	}))
+	defer server.Close()
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl: server.URL + "/1",
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(200, output.Get(schema.AttributeTypeStatusCode))
+	assert.Equal("done", output.Get(schema.AttributeTypeResponseBody))
+
+	// the redirect is the response
+	input[constants.AttributeTypeFollowRedirects] = false
+	output, err = hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(302, output.Get(schema.AttributeTypeStatusCode))
+	assert.Equal("/2", output.Get(schema.AttributeTypeResponseHeaders).(map[string]interface{})["Location"])
+
+	input[constants.AttributeTypeFollowRedirects] = true
+	input[constants.AttributeTypeMaxRedirects] = float64(2)
+	output, err = hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(200, output.Get(schema.AttributeTypeStatusCode))
+
+	input[constants.AttributeTypeMaxRedirects] = int64(1)
+	_, err = hr.Run(ctx, input)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "stopped after 1 redirects")
+}
+
+func TestHTTPReadTimeout(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		_, _ = w.Write([]byte("slow"))
+	}))
+	defer server.Close()
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl:               server.URL,
+		constants.AttributeTypeConnectTimeout: "1s",
+		constants.AttributeTypeReadTimeout:    "100ms",
+	})
+
+	_, err := hr.Run(ctx, input)
+	assert.NotNil(err)
+	assert.Equal("Timeout: HTTP request timed out reading the response after 100ms", err.Error())
+
+	input[constants.AttributeTypeReadTimeout] = float64(2000)
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal("slow", output.Get(schema.AttributeTypeResponseBody))
+}
+
+func TestHTTPProxy(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	// the proxy receives the request for the other host
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl:         "http://api.flowpipe.invalid/items",
+		constants.AttributeTypeProxyUrl: proxy.URL,
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal("proxied http://api.flowpipe.invalid/items", output.Get(schema.AttributeTypeResponseBody))
+
+	input[constants.AttributeTypeProxyUrl] = "ftp://proxy"
+	_, err = hr.Run(ctx, input)
+	assert.NotNil(err)
+	assert.Equal("Bad Request: invalid proxy url: ftp://proxy", err.Error())
+}
+
+// testCertificatePem creates a self signed certificate and its key
+func testCertificatePem(t *testing.T, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return string(certPem), string(keyPem)
+}
+
+func TestHTTPClientCertificate(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	certPem, keyPem := testCertificatePem(t, "flowpipe")
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(certPem))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello " + r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl:      server.URL,
+		schema.AttributeTypeInsecure: true,
+	})
+
+	// the server requires a client certificate
+	_, err := hr.Run(ctx, input)
+	assert.NotNil(err)
+
+	input[constants.AttributeTypeClientCertPem] = certPem
+	input[constants.AttributeTypeClientKeyPem] = keyPem
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal("hello flowpipe", output.Get(schema.AttributeTypeResponseBody))
+
+	delete(input, constants.AttributeTypeClientKeyPem)
+	_, err = hr.Run(ctx, input)
+	assert.NotNil(err)
+	assert.Equal("Bad Request: The attributes 'client_cert_pem' and 'client_key_pem' must be set together", err.Error())
+}
+
+func TestHTTPHttp2(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	hr := HTTPRequest{}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl:      server.URL,
+		schema.AttributeTypeInsecure: true,
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal("HTTP/1.1", output.Get(schema.AttributeTypeResponseBody))
+
+	input[constants.AttributeTypeHttp2] = true
+	output, err = hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal("HTTP/2.0", output.Get(schema.AttributeTypeResponseBody))
+}
+
+// Files
+
+func TestHTTPMultipart(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	modPath := t.TempDir()
+	err := os.WriteFile(filepath.Join(modPath, "report.pdf"), []byte("%PDF-1.4\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contentLength int64
+	var transferEncoding []string
+	parts := map[string]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		transferEncoding = r.TransferEncoding
+
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			content, _ := io.ReadAll(part)
+			parts[part.FormName()] = map[string]string{
+				"filename":     part.FileName(),
+				"content_type": part.Header.Get("Content-Type"),
+				"content":      string(content),
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	hr := HTTPRequest{ModPath: modPath}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl:    server.URL,
+		schema.AttributeTypeMethod: "post",
+		constants.BlockTypeMultipart: []interface{}{
+			map[string]interface{}{
+				schema.AttributeTypeName:       "key",
+				constants.AttributeTypeContent: "reports/report.pdf",
+			},
+			map[string]interface{}{
+				schema.AttributeTypeName:    "file",
+				constants.AttributeTypeFile: "report.pdf",
+			},
+			map[string]interface{}{
+				schema.AttributeTypeName:        "metadata",
+				constants.AttributeTypeContent:  `{"rows":1}`,
+				constants.AttributeTypeFilename: "metadata.json",
+				schema.AttributeTypeContentType: "application/json",
+			},
+		},
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(201, output.Get(schema.AttributeTypeStatusCode))
+
+	// the body isn't chunked
+	assert.Greater(contentLength, int64(0))
+	assert.Nil(transferEncoding)
+
+	assert.Equal(map[string]string{"filename": "", "content_type": "", "content": "reports/report.pdf"}, parts["key"])
+	assert.Equal("report.pdf", parts["file"]["filename"])
+	assert.Equal("application/pdf", parts["file"]["content_type"])
+	assert.Equal("%PDF-1.4\n", parts["file"]["content"])
+	assert.Equal(map[string]string{"filename": "metadata.json", "content_type": "application/json", "content": `{"rows":1}`}, parts["metadata"])
+}
+
+func TestHTTPMultipartInvalid(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	hr := HTTPRequest{ModPath: t.TempDir()}
+
+	tests := []struct {
+		name     string
+		input    resources.Input
+		expected string
+	}{
+		{
+			name: "missing file",
+			input: resources.Input{
+				constants.BlockTypeMultipart: []interface{}{
+					map[string]interface{}{schema.AttributeTypeName: "file", constants.AttributeTypeFile: "missing.csv"},
+				},
+			},
+			expected: "Bad Request: The file of the multipart part file can't be read",
+		},
+		{
+			name: "file and content",
+			input: resources.Input{
+				constants.BlockTypeMultipart: []interface{}{
+					map[string]interface{}{schema.AttributeTypeName: "file", constants.AttributeTypeFile: "a.csv", constants.AttributeTypeContent: "a"},
+				},
+			},
+			expected: "Bad Request: The multipart part file must define either a file or a content",
+		},
+		{
+			name: "missing name",
+			input: resources.Input{
+				constants.BlockTypeMultipart: []interface{}{
+					map[string]interface{}{constants.AttributeTypeContent: "a"},
+				},
+			},
+			expected: "Bad Request: The multipart part 0 must define a name",
+		},
+		{
+			name: "request body",
+			input: resources.Input{
+				schema.AttributeTypeRequestBody: "body",
+				constants.BlockTypeMultipart: []interface{}{
+					map[string]interface{}{schema.AttributeTypeName: "key", constants.AttributeTypeContent: "a"},
+				},
+			},
+			expected: "Bad Request: The attribute 'request_body' can't be set with multipart parts",
+		},
+		{
+			name: "response file with pagination",
+			input: resources.Input{
+				constants.AttributeTypeResponseFile: "out.bin",
+				constants.BlockTypePagination: map[string]interface{}{
+					schema.AttributeTypeStyle: constants.PaginationStyleLinkHeader,
+				},
+			},
+			expected: "Bad Request: The attribute 'response_file' can't be set with pagination",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.input[schema.AttributeTypeUrl] = "http://localhost/upload"
+			_, err := hr.Run(ctx, test.input)
+			if assert.NotNil(err) {
+				assert.True(strings.HasPrefix(err.Error(), test.expected), err.Error())
+			}
+		})
+	}
+}
+
+func TestHTTPResponseFile(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	artifact := []byte{0x1f, 0x8b, 0x08, 0x00, 0xff, 0x00, 0x7f}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "artifact not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		_, _ = w.Write(artifact)
+	}))
+	defer server.Close()
+
+	modPath := t.TempDir()
+	hr := HTTPRequest{ModPath: modPath}
+	input := resources.Input(map[string]interface{}{
+		schema.AttributeTypeUrl:             server.URL + "/artifact",
+		constants.AttributeTypeResponseFile: "artifacts/build.tar.gz",
+	})
+
+	output, err := hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(200, output.Get(schema.AttributeTypeStatusCode))
+	assert.Equal("", output.Get(schema.AttributeTypeResponseBody))
+
+	path := filepath.Join(modPath, "artifacts", "build.tar.gz")
+	checksum := sha256.Sum256(artifact)
+	assert.Equal(map[string]interface{}{
+		constants.AttributeTypePath:   path,
+		constants.AttributeTypeSize:   int64(len(artifact)),
+		constants.AttributeTypeSha256: hex.EncodeToString(checksum[:]),
+	}, output.Get(constants.AttributeTypeResponseFile))
+
+	content, err := os.ReadFile(path)
+	assert.Nil(err)
+	assert.Equal(artifact, content)
+
+	// the error response is the response body, the file isn't written
+	input[schema.AttributeTypeUrl] = server.URL + "/missing"
+	input[constants.AttributeTypeResponseFile] = "artifacts/missing.tar.gz"
+	output, err = hr.Run(ctx, input)
+	assert.Nil(err)
+	assert.Equal(404, output.Get(schema.AttributeTypeStatusCode))
+	assert.Equal("artifact not found\n", output.Get(schema.AttributeTypeResponseBody))
+	assert.Nil(output.Get(constants.AttributeTypeResponseFile))
+	assert.Equal(1, len(output.Errors))
+
+	entries, err := os.ReadDir(filepath.Join(modPath, "artifacts"))
+	assert.Nil(err)
+	assert.Equal(1, len(entries))
+}
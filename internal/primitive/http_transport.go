@@ -0,0 +1,259 @@
+package primitive
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+var validProxySchemes = []string{"http", "https", "socks5"}
+
+// setTransportInput sets the transport settings of the http step from the input parameters
+func setTransportInput(input resources.Input, inputParams *HTTPInput) error {
+	inputParams.FollowRedirects = constants.DefaultHttpFollowRedirects
+	// as the Go HTTP client
+	inputParams.MaxRedirects = constants.DefaultHttpMaxRedirects
+
+	for attributeName, field := range map[string]*string{
+		constants.AttributeTypeClientCertPem: &inputParams.ClientCertPem,
+		constants.AttributeTypeClientKeyPem:  &inputParams.ClientKeyPem,
+	} {
+		if input[attributeName] == nil {
+			continue
+		}
+		value, ok := input[attributeName].(string)
+		if !ok {
+			return perr.BadRequestWithMessage("The attribute '" + attributeName + "' must be a string")
+		}
+		*field = value
+	}
+
+	if (inputParams.ClientCertPem == "") != (inputParams.ClientKeyPem == "") {
+		return perr.BadRequestWithMessage("The attributes '" + constants.AttributeTypeClientCertPem + "' and '" + constants.AttributeTypeClientKeyPem + "' must be set together")
+	}
+	if inputParams.ClientCertPem != "" {
+		if _, err := tls.X509KeyPair([]byte(inputParams.ClientCertPem), []byte(inputParams.ClientKeyPem)); err != nil {
+			return perr.BadRequestWithMessage("invalid client certificate: " + err.Error())
+		}
+	}
+
+	if input[constants.AttributeTypeProxyUrl] != nil {
+		proxyURL, ok := input[constants.AttributeTypeProxyUrl].(string)
+		if !ok {
+			return perr.BadRequestWithMessage("The attribute '" + constants.AttributeTypeProxyUrl + "' must be a string")
+		}
+		u, err := url.Parse(proxyURL)
+		if err != nil || !slices.Contains(validProxySchemes, u.Scheme) || u.Host == "" {
+			return perr.BadRequestWithMessage("invalid proxy url: " + proxyURL)
+		}
+		inputParams.ProxyURL = u
+	}
+
+	for attributeName, field := range map[string]*time.Duration{
+		constants.AttributeTypeConnectTimeout: &inputParams.ConnectTimeout,
+		constants.AttributeTypeReadTimeout:    &inputParams.ReadTimeout,
+	} {
+		if input[attributeName] == nil {
+			continue
+		}
+		duration, err := httpDuration(attributeName, input[attributeName])
+		if err != nil {
+			return err
+		}
+		*field = duration
+	}
+
+	for attributeName, field := range map[string]*bool{
+		constants.AttributeTypeFollowRedirects: &inputParams.FollowRedirects,
+		constants.AttributeTypeHttp2:           &inputParams.HTTP2,
+	} {
+		if input[attributeName] == nil {
+			continue
+		}
+		value, ok := input[attributeName].(bool)
+		if !ok {
+			return perr.BadRequestWithMessage("The attribute '" + attributeName + "' must be a bool")
+		}
+		*field = value
+	}
+
+	if input[constants.AttributeTypeMaxRedirects] != nil {
+		maxRedirects, ok := toInt(input[constants.AttributeTypeMaxRedirects])
+		if !ok || maxRedirects < 0 {
+			return perr.BadRequestWithMessage("The attribute '" + constants.AttributeTypeMaxRedirects + "' must be a positive whole number")
+		}
+		inputParams.MaxRedirects = maxRedirects
+	}
+
+	return nil
+}
+
+// httpDuration parses a duration string or a number of milliseconds
+func httpDuration(attributeName string, value interface{}) (time.Duration, error) {
+	var duration time.Duration
+	switch d := value.(type) {
+	case string:
+		var err error
+		duration, err = time.ParseDuration(d)
+		if err != nil {
+			return 0, perr.BadRequestWithMessage("invalid " + attributeName + " duration " + d)
+		}
+	case int:
+		duration = time.Duration(d) * time.Millisecond
+	case int64:
+		duration = time.Duration(d) * time.Millisecond
+	case float64:
+		duration = time.Duration(d) * time.Millisecond
+	default:
+		return 0, perr.BadRequestWithMessage("The attribute '" + attributeName + "' must be a string or a whole number")
+	}
+
+	if duration < 0 {
+		return 0, perr.BadRequestWithMessage("The attribute '" + attributeName + "' must be a positive duration")
+	}
+	return duration, nil
+}
+
+// newHTTPClient creates the client of the request with its transport settings. The connect timeout covers the
+// connection and its TLS handshake, the read timeout covers the wait for the response and the read of its body. The
+// requests use HTTP/1.1 unless http2 is set.
+func newHTTPClient(inputParams *HTTPInput) (*http.Client, error) {
+	client := &http.Client{}
+
+	// Initialize the TLSClientConfig with default settings
+	tlsConfig := &tls.Config{} // #nosec G402
+
+	// By default the client verifies the server's certificate chain and host name.
+	// If the insecure flag is set, the client skips this verification and accepts any certificate presented by the server and any host name in that certificate.
+	// Default value of insecure flag is false.
+	if inputParams.Insecure {
+		tlsConfig.InsecureSkipVerify = inputParams.Insecure
+	}
+
+	if inputParams.Timeout > 0 {
+		client.Timeout = inputParams.Timeout
+	}
+
+	// If the input parameter 'ca_cert_pem' is set, the client verifies the server's certificate chain using the provided PEM encoded CA certificates.
+	if inputParams.CaCertPem != "" {
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM([]byte(inputParams.CaCertPem))
+
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// The client certificate is presented to the servers that request one, i.e. mutual TLS
+	if inputParams.ClientCertPem != "" {
+		clientCert, err := tls.X509KeyPair([]byte(inputParams.ClientCertPem), []byte(inputParams.ClientKeyPem))
+		if err != nil {
+			return nil, perr.BadRequestWithMessage("invalid client certificate: " + err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	// Configure the client's transport with the final TLSClientConfig
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+
+		// a transport with its own TLS config only uses HTTP/2 when forced to
+		ForceAttemptHTTP2: inputParams.HTTP2,
+	}
+
+	if inputParams.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(inputParams.ProxyURL)
+	}
+
+	if inputParams.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: inputParams.ConnectTimeout}).DialContext
+		transport.TLSHandshakeTimeout = inputParams.ConnectTimeout
+	}
+
+	client.Transport = transport
+
+	followRedirects := inputParams.FollowRedirects
+	maxRedirects := inputParams.MaxRedirects
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !followRedirects {
+			// the redirect response is the response of the step
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+
+	return client, nil
+}
+
+// readTimer cancels the request when its response isn't read within the read timeout, the timer starts once the
+// request is written, i.e. once connected, and restarts with each redirect
+type readTimer struct {
+	mutex   sync.Mutex
+	timeout time.Duration
+	cancel  context.CancelFunc
+	timer   *time.Timer
+	expired bool
+}
+
+// withReadTimeout returns the context of a request cancelled when the request times out reading its response
+func withReadTimeout(ctx context.Context, timeout time.Duration) (context.Context, *readTimer) {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &readTimer{
+		timeout: timeout,
+		cancel:  cancel,
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.start()
+		},
+	})
+
+	return ctx, t
+}
+
+func (t *readTimer) start() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.timer = time.AfterFunc(t.timeout, func() {
+		t.mutex.Lock()
+		t.expired = true
+		t.mutex.Unlock()
+		t.cancel()
+	})
+}
+
+// stop stops the timer and releases the context of the request
+func (t *readTimer) stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.cancel()
+}
+
+func (t *readTimer) hasExpired() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.expired
+}
@@ -3,6 +3,7 @@ package primitive
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	_ "github.com/marcboeker/go-duckdb"
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/pipe-fittings/perr"
 	"github.com/turbot/pipe-fittings/schema"
@@ -35,7 +37,15 @@ func (e *Query) ValidateInput(ctx context.Context, i resources.Input) error {
 		return perr.BadRequestWithMessage("Query input must define database")
 	}
 
-	if i[schema.AttributeTypeSql] == nil {
+	// the statements run in a transaction rather than the sql
+	if i[constants.AttributeTypeStatements] != nil {
+		if i[schema.AttributeTypeSql] != nil {
+			return perr.BadRequestWithMessage("Query input must define either sql or statements, not both")
+		}
+		if _, err := queryStatements(i[constants.AttributeTypeStatements]); err != nil {
+			return err
+		}
+	} else if i[schema.AttributeTypeSql] == nil {
 		return perr.BadRequestWithMessage("Query input must define sql")
 	}
 
@@ -68,14 +78,6 @@ func (e *Query) RunWithMetadata(ctx context.Context, input resources.Input) (*re
 		return nil, nil, err
 	}
 
-	// Get the inputs
-	queryString := input[schema.AttributeTypeSql].(string)
-
-	var args []interface{}
-	if input[schema.AttributeTypeArgs] != nil {
-		args = input[schema.AttributeTypeArgs].([]interface{})
-	}
-
 	var timeout time.Duration
 	if input[schema.AttributeTypeTimeout] != nil {
 		switch timeoutDuration := input[schema.AttributeTypeTimeout].(type) {
@@ -96,6 +98,20 @@ func (e *Query) RunWithMetadata(ctx context.Context, input resources.Input) (*re
 	e.QueryReader = queryReader
 	defer queryReader.Close()
 
+	if input[constants.AttributeTypeStatements] != nil {
+		statements, _ := queryStatements(input[constants.AttributeTypeStatements])
+		output, err := runTransaction(queryReader, statements, timeout)
+		return output, nil, err
+	}
+
+	// Get the inputs
+	queryString := input[schema.AttributeTypeSql].(string)
+
+	var args []interface{}
+	if input[schema.AttributeTypeArgs] != nil {
+		args = input[schema.AttributeTypeArgs].([]interface{})
+	}
+
 	var results []map[string]interface{}
 	var md map[string]*sql.ColumnType
 
@@ -140,6 +156,89 @@ func (e *Query) Run(ctx context.Context, input resources.Input) (*resources.Outp
 	return output, err
 }
 
+// runTransaction runs the statements in a transaction, the output has the rows affected and the last insert id of each
+// statement
+func runTransaction(queryReader QueryReader, statements []QueryStatement, timeout time.Duration) (*resources.Output, error) {
+	// as the query, the step context isn't used to run the statements
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now().UTC()
+	results, err := queryReader.Transaction(ctx, statements)
+	if err != nil {
+		return nil, err
+	}
+	finish := time.Now().UTC()
+
+	statementOutputs := []interface{}{}
+	for _, result := range results {
+		statementOutput := map[string]interface{}{
+			constants.AttributeTypeRowsAffected: result.RowsAffected,
+			constants.AttributeTypeLastInsertId: nil,
+		}
+		if result.LastInsertId != nil {
+			statementOutput[constants.AttributeTypeLastInsertId] = *result.LastInsertId
+		}
+		statementOutputs = append(statementOutputs, statementOutput)
+	}
+
+	output := &resources.Output{
+		Data: map[string]interface{}{},
+	}
+	output.Data[constants.AttributeTypeStatements] = statementOutputs
+	output.Flowpipe = FlowpipeMetadataOutput(start, finish)
+
+	return output, nil
+}
+
+// queryStatements returns the statements of the statements input, a statement is either its sql or an object with its
+// sql and args
+func queryStatements(input interface{}) ([]QueryStatement, error) {
+	statementInputs, ok := input.([]interface{})
+	if !ok || len(statementInputs) == 0 {
+		return nil, perr.BadRequestWithMessage("The attribute '" + constants.AttributeTypeStatements + "' must be a non-empty list of statements")
+	}
+
+	statements := []QueryStatement{}
+	for i, statementInput := range statementInputs {
+		switch s := statementInput.(type) {
+		case string:
+			statements = append(statements, QueryStatement{Sql: s})
+
+		case map[string]interface{}:
+			sqlString, ok := s[schema.AttributeTypeSql].(string)
+			if !ok || sqlString == "" {
+				return nil, perr.BadRequestWithMessage(fmt.Sprintf("The statement %d must define sql", i+1))
+			}
+
+			var args []interface{}
+			if s[schema.AttributeTypeArgs] != nil {
+				args, ok = s[schema.AttributeTypeArgs].([]interface{})
+				if !ok {
+					return nil, perr.BadRequestWithMessage(fmt.Sprintf("The args of statement %d must be a list", i+1))
+				}
+			}
+
+			for name := range s {
+				if name != schema.AttributeTypeSql && name != schema.AttributeTypeArgs {
+					return nil, perr.BadRequestWithMessage(fmt.Sprintf("Unsupported attribute '%s' in statement %d", name, i+1))
+				}
+			}
+
+			statements = append(statements, QueryStatement{Sql: sqlString, Args: args})
+
+		default:
+			return nil, perr.BadRequestWithMessage(fmt.Sprintf("The statement %d must be a string or an object with sql and args", i+1))
+		}
+	}
+
+	return statements, nil
+}
+
 func mapScan(r *sql.Rows, columns []string, dest map[string]interface{}) error {
 	// ignore r.started, since we needn't use reflect for anything.
 	values := make([]interface{}, len(columns))
@@ -73,10 +73,24 @@ type QueryReader interface {
 	GetConnectionString() string
 	Initialize() error
 	Query(context.Context, string, ...interface{}) ([]map[string]interface{}, map[string]*sql.ColumnType, error)
+	Transaction(context.Context, []QueryStatement) ([]StatementResult, error)
 	RowsToCty(rows []map[string]interface{}, columnTypes map[string]*sql.ColumnType) ([]cty.Value, error)
 	Close()
 }
 
+// QueryStatement is a statement of a transaction
+type QueryStatement struct {
+	Sql  string
+	Args []interface{}
+}
+
+// StatementResult is the result of a statement of a transaction, the last insert id is nil if the database doesn't
+// report it
+type StatementResult struct {
+	RowsAffected int64
+	LastInsertId *int64
+}
+
 type QueryReaderImpl struct {
 	connectionString string
 	db               *sql.DB
@@ -135,6 +149,55 @@ func (q *QueryReaderImpl) Query(ctx context.Context, queryString string, args ..
 	return q.queryRows(rows)
 }
 
+func (q *QueryReaderImpl) Transaction(ctx context.Context, statements []QueryStatement) ([]StatementResult, error) {
+	return q.transaction(ctx, statements, true)
+}
+
+// transaction runs the statements in a transaction, the transaction is committed if all the statements succeed and
+// rolled back otherwise
+func (q *QueryReaderImpl) transaction(ctx context.Context, statements []QueryStatement, hasLastInsertId bool) ([]StatementResult, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, perr.InternalWithMessage("Error starting transaction: " + err.Error())
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op once committed
+
+	results := []StatementResult{}
+	for i, statement := range statements {
+		result, err := tx.ExecContext(ctx, statement.Sql, statement.Args...)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, perr.TimeoutWithMessage("Query execution exceeded timeout")
+			}
+			return nil, perr.InternalWithMessage(fmt.Sprintf("Error executing statement %d: %s", i+1, err.Error()))
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, perr.InternalWithMessage(fmt.Sprintf("Error getting the rows affected by statement %d: %s", i+1, err.Error()))
+		}
+
+		statementResult := StatementResult{RowsAffected: rowsAffected}
+		if hasLastInsertId {
+			lastInsertId, err := result.LastInsertId()
+			if err != nil {
+				return nil, perr.InternalWithMessage(fmt.Sprintf("Error getting the last insert id of statement %d: %s", i+1, err.Error()))
+			}
+			statementResult.LastInsertId = &lastInsertId
+		}
+		results = append(results, statementResult)
+	}
+
+	if err := tx.Commit(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, perr.TimeoutWithMessage("Query execution exceeded timeout")
+		}
+		return nil, perr.InternalWithMessage("Error committing transaction: " + err.Error())
+	}
+
+	return results, nil
+}
+
 func (q *QueryReaderImpl) RowsToCty(rows []map[string]interface{}, columnTypes map[string]*sql.ColumnType) ([]cty.Value, error) {
 	var rowsCty []cty.Value
 	for _, r := range rows {
@@ -162,6 +225,12 @@ func (p *PostgresQueryReader) Initialize() error {
 	return err
 }
 
+// Transaction runs the statements in a transaction, Postgres doesn't report the last insert id, the ids are returned
+// by the RETURNING clause of a query
+func (p *PostgresQueryReader) Transaction(ctx context.Context, statements []QueryStatement) ([]StatementResult, error) {
+	return p.transaction(ctx, statements, false)
+}
+
 type MySQLQueryReader struct {
 	QueryReaderImpl
 }
@@ -252,6 +321,11 @@ func (s *FileBasedQueryReader) Initialize() error {
 	return err
 }
 
+// Transaction runs the statements in a transaction, DuckDB doesn't report the last insert id
+func (s *FileBasedQueryReader) Transaction(ctx context.Context, statements []QueryStatement) ([]StatementResult, error) {
+	return s.transaction(ctx, statements, false)
+}
+
 type RowReader interface {
 	Read(*sql.Rows, map[string]*sql.ColumnType) ([]map[string]interface{}, error)
 	RowToCty(row map[string]interface{}, columnTypes map[string]*sql.ColumnType) (cty.Value, error)
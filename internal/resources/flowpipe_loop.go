@@ -4,6 +4,7 @@ import (
 	"log/slog"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
@@ -57,6 +58,10 @@ func GetLoopDefn(stepType string, p *PipelineStepBase, hclRange *hcl.Range) Loop
 		return &LoopContainerStep{
 			LoopStep: loopStep,
 		}
+	case constants.BlockTypePipelineStepCommand:
+		return &LoopCommandStep{
+			LoopStep: loopStep,
+		}
 	case schema.BlockTypePipelineStepInput:
 		return &LoopInputStep{
 			LoopStep: loopStep,
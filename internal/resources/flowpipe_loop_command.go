@@ -0,0 +1,152 @@
+package resources
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/iancoleman/strcase"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type LoopCommandStep struct {
+	LoopStep
+
+	Command *string            `json:"command,omitempty" hcl:"command,optional" cty:"command"`
+	Args    *[]string          `json:"args,omitempty" hcl:"args,optional" cty:"args"`
+	Env     *map[string]string `json:"env,omitempty" hcl:"env,optional" cty:"env"`
+	Workdir *string            `json:"workdir,omitempty" hcl:"workdir,optional" cty:"workdir"`
+	Stdin   *string            `json:"stdin,omitempty" hcl:"stdin,optional" cty:"stdin"`
+}
+
+func (l *LoopCommandStep) Equals(other LoopDefn) bool {
+	if l == nil && helpers.IsNil(other) {
+		return true
+	}
+
+	if l == nil && !helpers.IsNil(other) || l != nil && helpers.IsNil(other) {
+		return false
+	}
+
+	otherLoopCommandStep, ok := other.(*LoopCommandStep)
+	if !ok {
+		return false
+	}
+
+	if !l.LoopStep.Equals(otherLoopCommandStep.LoopStep) {
+		return false
+	}
+
+	if l.Args == nil && otherLoopCommandStep.Args != nil || l.Args != nil && otherLoopCommandStep.Args == nil {
+		return false
+	} else if l.Args != nil {
+		if !slices.Equal(*l.Args, *otherLoopCommandStep.Args) {
+			return false
+		}
+	}
+
+	// compare env using reflection
+	if !reflect.DeepEqual(l.Env, otherLoopCommandStep.Env) {
+		return false
+	}
+
+	return utils.BoolPtrEqual(l.Until, otherLoopCommandStep.Until) &&
+		utils.PtrEqual(l.Command, otherLoopCommandStep.Command) &&
+		utils.PtrEqual(l.Workdir, otherLoopCommandStep.Workdir) &&
+		utils.PtrEqual(l.Stdin, otherLoopCommandStep.Stdin)
+}
+
+func (*LoopCommandStep) GetType() string {
+	return constants.BlockTypePipelineStepCommand
+}
+
+func (l *LoopCommandStep) UpdateInput(input Input, evalContext *hcl.EvalContext) (Input, error) {
+
+	result, diags := simpleTypeInputFromAttribute(l.GetUnresolvedAttributes(), input, evalContext, constants.AttributeTypeCommand, l.Command)
+	if len(diags) > 0 {
+		return nil, error_helpers.BetterHclDiagsToError("command", diags)
+	}
+
+	result, diags = simpleTypeInputFromAttribute(l.GetUnresolvedAttributes(), result, evalContext, schema.AttributeTypeWorkdir, l.Workdir)
+	if len(diags) > 0 {
+		return nil, error_helpers.BetterHclDiagsToError("command", diags)
+	}
+
+	result, diags = simpleTypeInputFromAttribute(l.GetUnresolvedAttributes(), result, evalContext, constants.AttributeTypeStdin, l.Stdin)
+	if len(diags) > 0 {
+		return nil, error_helpers.BetterHclDiagsToError("command", diags)
+	}
+
+	result, diags = stringSliceInputFromAttribute(l.GetUnresolvedAttributes(), result, evalContext, schema.AttributeTypeArgs, l.Args)
+	if len(diags) > 0 {
+		return nil, error_helpers.BetterHclDiagsToError("command", diags)
+	}
+
+	result, diags = stringMapInputFromAttribute(l.GetUnresolvedAttributes(), result, evalContext, schema.AttributeTypeEnv, l.Env)
+	if len(diags) > 0 {
+		return nil, error_helpers.BetterHclDiagsToError("command", diags)
+	}
+
+	return result, nil
+}
+
+func (l *LoopCommandStep) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := l.LoopStep.SetAttributes(hclAttributes, evalContext)
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case constants.AttributeTypeCommand, schema.AttributeTypeWorkdir, constants.AttributeTypeStdin:
+			fieldName := strcase.ToCamel(name)
+			stepDiags := setStringAttributeWithResultReference(attr, evalContext, l, fieldName, true, true)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+			}
+		case schema.AttributeTypeArgs:
+			fieldName := strcase.ToCamel(name)
+			stepDiags := setStringSliceAttributeWithResultReference(attr, evalContext, l, fieldName, true, true)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+			}
+		case schema.AttributeTypeEnv:
+			val, stepDiags := dependsOnFromExpressionsWithResultControl(attr, evalContext, l, true)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+			}
+
+			if val == cty.NilVal {
+				continue
+			}
+
+			env, err := hclhelpers.CtyToGoMapString(val)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid env",
+					Detail:   "Invalid env in the step loop block",
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+
+			l.Env = &env
+
+		case schema.AttributeTypeUntil:
+			// already handled in SetAttributes
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid attribute",
+				Detail:   "Invalid attribute '" + name + "' in the step loop block",
+				Subject:  &attr.Range,
+			})
+		}
+
+	}
+	return diags
+}
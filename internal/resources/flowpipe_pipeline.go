@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/hclhelpers"
 	"github.com/turbot/pipe-fittings/options"
@@ -84,6 +85,7 @@ type Pipeline struct {
 	Steps           []PipelineStep   `json:"steps,omitempty"`
 	OutputConfig    []PipelineOutput `json:"outputs,omitempty"`
 	Params          []PipelineParam  `json:"params,omitempty"`
+	OnRestart       *string          `json:"on_restart,omitempty"`
 	FileName        string           `json:"file_name"`
 	StartLineNumber int              `json:"start_line_number"`
 	EndLineNumber   int              `json:"end_line_number"`
@@ -102,6 +104,15 @@ func (p *Pipeline) GetParam(paramName string) *PipelineParam {
 	return nil
 }
 
+// GetOnRestart returns what to do with the in-flight executions of this pipeline when the server restarts after an
+// unclean shutdown
+func (p *Pipeline) GetOnRestart() string {
+	if p.OnRestart == nil {
+		return constants.DefaultOnRestart
+	}
+	return *p.OnRestart
+}
+
 func (p *Pipeline) SetFileReference(fileName string, startLineNumber int, endLineNumber int) {
 	p.FileName = fileName
 	p.StartLineNumber = startLineNumber
@@ -253,6 +264,13 @@ func (p *Pipeline) UnmarshalJSON(data []byte) error {
 					return err
 				}
 
+			case constants.BlockTypePipelineStepCommand:
+				var step PipelineStepCommand
+				if err := json.Unmarshal(stepData, &step); err != nil {
+					return err
+				}
+				p.Steps = append(p.Steps, &step)
+
 			case schema.BlockTypePipelineStepInput:
 				var step PipelineStepInput
 				if err := json.Unmarshal(stepData, &step); err != nil {
@@ -357,6 +375,7 @@ func (p *Pipeline) Equals(other *Pipeline) bool {
 	}
 
 	return p.FullName == other.FullName &&
+		p.GetOnRestart() == other.GetOnRestart() &&
 		p.GetMetadata().ModFullName == other.GetMetadata().ModFullName
 }
 
@@ -431,6 +450,23 @@ func (p *Pipeline) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.
 				mcInt := int(*maxConcurrency)
 				p.MaxConcurrency = &mcInt
 			}
+		case constants.AttributeTypeOnRestart:
+			onRestart, moreDiags := hclhelpers.AttributeToString(attr, nil, false)
+			if moreDiags != nil && moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			if *onRestart != constants.OnRestartResume && *onRestart != constants.OnRestartFail {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid value for on_restart: " + *onRestart,
+					Detail:   "The on_restart value must be one of: " + constants.OnRestartResume + ", " + constants.OnRestartFail,
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+			p.OnRestart = onRestart
 		default:
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
@@ -2,6 +2,7 @@ package resources
 
 import (
 	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/pipe-fittings/schema"
 )
 
@@ -134,12 +135,21 @@ var TriggerScheduleBlockSchema = &hcl.BodySchema{
 			Name:     schema.AttributeTypePipeline,
 			Required: true,
 		},
+		{
+			Name: constants.AttributeTypeTimezone,
+		},
+		{
+			Name: constants.AttributeTypeCatchUp,
+		},
 		{
 			Name: schema.AttributeTypeArgs,
 		},
 		{
 			Name: schema.AttributeTypeEnabled,
 		},
+		{
+			Name: constants.AttributeTypeOverlap,
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
@@ -184,6 +194,208 @@ var TriggerIntervalBlockSchema = &hcl.BodySchema{
 	},
 }
 
+var TriggerFileBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeDescription,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTitle,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeDocumentation,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTags,
+			Required: false,
+		},
+		{
+			Name:     constants.AttributeTypePath,
+			Required: true,
+		},
+		{
+			Name: constants.AttributeTypeEvents,
+		},
+		{
+			Name: constants.AttributeTypeDebounce,
+		},
+		{
+			Name:     schema.AttributeTypePipeline,
+			Required: true,
+		},
+		{
+			Name: schema.AttributeTypeArgs,
+		},
+		{
+			Name: schema.AttributeTypeEnabled,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeParam,
+			LabelNames: []string{schema.LabelName},
+		},
+	},
+}
+
+var TriggerQueueBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeDescription,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTitle,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeDocumentation,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTags,
+			Required: false,
+		},
+		{
+			Name: schema.AttributeTypeUrl,
+		},
+		{
+			Name:     schema.AttributeTypeSubject,
+			Required: true,
+		},
+		{
+			Name: constants.AttributeTypeStream,
+		},
+		{
+			Name: constants.AttributeTypeConsumer,
+		},
+		{
+			Name:     schema.AttributeTypePipeline,
+			Required: true,
+		},
+		{
+			Name: schema.AttributeTypeArgs,
+		},
+		{
+			Name: schema.AttributeTypeEnabled,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeParam,
+			LabelNames: []string{schema.LabelName},
+		},
+	},
+}
+
+var TriggerPipelineBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeDescription,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTitle,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeDocumentation,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTags,
+			Required: false,
+		},
+		{
+			Name:     constants.AttributeTypeUpstream,
+			Required: true,
+		},
+		{
+			Name: constants.AttributeTypeStatuses,
+		},
+		{
+			Name:     schema.AttributeTypePipeline,
+			Required: true,
+		},
+		{
+			Name: schema.AttributeTypeArgs,
+		},
+		{
+			Name: schema.AttributeTypeEnabled,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeParam,
+			LabelNames: []string{schema.LabelName},
+		},
+	},
+}
+
+var TriggerEmailBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     schema.AttributeTypeDescription,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTitle,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeDocumentation,
+			Required: false,
+		},
+		{
+			Name:     schema.AttributeTypeTags,
+			Required: false,
+		},
+		{
+			Name: schema.AttributeTypeSchedule,
+		},
+		{
+			Name:     schema.AttributeTypeUrl,
+			Required: true,
+		},
+		{
+			Name:     schema.AttributeTypeUsername,
+			Required: true,
+		},
+		{
+			Name:     schema.AttributeTypePassword,
+			Required: true,
+		},
+		{
+			Name: constants.AttributeTypeMailbox,
+		},
+		{
+			Name: constants.AttributeTypeMoveTo,
+		},
+		{
+			Name:     schema.AttributeTypePipeline,
+			Required: true,
+		},
+		{
+			Name: schema.AttributeTypeArgs,
+		},
+		{
+			Name: schema.AttributeTypeEnabled,
+		},
+		{
+			Name: constants.AttributeTypeOverlap,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       schema.BlockTypeParam,
+			LabelNames: []string{schema.LabelName},
+		},
+	},
+}
+
 var TriggerQueryBlockSchema = &hcl.BodySchema{
 	Attributes: []hcl.AttributeSchema{
 		{
@@ -213,6 +425,9 @@ var TriggerQueryBlockSchema = &hcl.BodySchema{
 		{
 			Name: schema.AttributeTypePrimaryKey,
 		},
+		{
+			Name: constants.AttributeTypeCursorColumn,
+		},
 		{
 			Name:     schema.AttributeTypeDatabase,
 			Required: false,
@@ -220,6 +435,9 @@ var TriggerQueryBlockSchema = &hcl.BodySchema{
 		{
 			Name: schema.AttributeTypeEnabled,
 		},
+		{
+			Name: constants.AttributeTypeOverlap,
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
@@ -269,6 +487,9 @@ var TriggerHttpBlockSchema = &hcl.BodySchema{
 			Type:       schema.BlockTypeMethod,
 			LabelNames: []string{schema.LabelName},
 		},
+		{
+			Type: constants.BlockTypeSignature,
+		},
 		{
 			Type:       schema.BlockTypeParam,
 			LabelNames: []string{schema.LabelName},
@@ -276,6 +497,28 @@ var TriggerHttpBlockSchema = &hcl.BodySchema{
 	},
 }
 
+var TriggerHttpSignatureBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     constants.AttributeTypeSecret,
+			Required: true,
+		},
+		{
+			Name:     constants.AttributeTypeHeader,
+			Required: true,
+		},
+		{
+			Name: constants.AttributeTypeAlgorithm,
+		},
+		{
+			Name: schema.AttributeTypeFormat,
+		},
+		{
+			Name: constants.AttributeTypeTolerance,
+		},
+	},
+}
+
 var PipelineBlockSchema = &hcl.BodySchema{
 	Attributes: []hcl.AttributeSchema{
 		{
@@ -297,6 +540,9 @@ var PipelineBlockSchema = &hcl.BodySchema{
 		{
 			Name: schema.AttributeTypeMaxConcurrency,
 		},
+		{
+			Name: constants.AttributeTypeOnRestart,
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
@@ -396,6 +642,33 @@ var PipelineStepHttpBlockSchema = &hcl.BodySchema{
 		{
 			Name: schema.AttributeTypeRequestHeaders,
 		},
+		{
+			Name: constants.AttributeTypeClientCertPem,
+		},
+		{
+			Name: constants.AttributeTypeClientKeyPem,
+		},
+		{
+			Name: constants.AttributeTypeProxyUrl,
+		},
+		{
+			Name: constants.AttributeTypeConnectTimeout,
+		},
+		{
+			Name: constants.AttributeTypeReadTimeout,
+		},
+		{
+			Name: constants.AttributeTypeFollowRedirects,
+		},
+		{
+			Name: constants.AttributeTypeMaxRedirects,
+		},
+		{
+			Name: constants.AttributeTypeHttp2,
+		},
+		{
+			Name: constants.AttributeTypeResponseFile,
+		},
 		{
 			Name: schema.AttributeTypeMaxConcurrency,
 		},
@@ -411,6 +684,12 @@ var PipelineStepHttpBlockSchema = &hcl.BodySchema{
 		{
 			Type: schema.BlockTypePipelineBasicAuth,
 		},
+		{
+			Type: constants.BlockTypePagination,
+		},
+		{
+			Type: constants.BlockTypeMultipart,
+		},
 		{
 			Type: schema.BlockTypeLoop,
 		},
@@ -593,6 +872,9 @@ var PipelineStepQueryBlockSchema = &hcl.BodySchema{
 		{
 			Name: schema.AttributeTypeArgs,
 		},
+		{
+			Name: constants.AttributeTypeStatements,
+		},
 		{
 			Name: schema.AttributeTypeMaxConcurrency,
 		},
@@ -856,6 +1138,69 @@ var PipelineStepContainerBlockSchema = &hcl.BodySchema{
 	},
 }
 
+var PipelineStepCommandBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: schema.AttributeTypeTitle,
+		},
+		{
+			Name: schema.AttributeTypeDescription,
+		},
+		{
+			Name: schema.AttributeTypeTimeout,
+		},
+		{
+			Name: schema.AttributeTypeForEach,
+		},
+		{
+			Name: schema.AttributeTypeDependsOn,
+		},
+		{
+			Name: schema.AttributeTypeIf,
+		},
+		{
+			Name:     constants.AttributeTypeCommand,
+			Required: true,
+		},
+		{
+			Name: schema.AttributeTypeArgs,
+		},
+		{
+			Name: schema.AttributeTypeEnv,
+		},
+		{
+			Name: schema.AttributeTypeWorkdir,
+		},
+		{
+			Name: constants.AttributeTypeStdin,
+		},
+		{
+			Name: constants.AttributeTypeAllowedExitCodes,
+		},
+		{
+			Name: schema.AttributeTypeMaxConcurrency,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: schema.BlockTypeError,
+		},
+		{
+			Type:       schema.BlockTypePipelineOutput,
+			LabelNames: []string{schema.LabelName},
+		},
+		{
+			Type: schema.BlockTypeLoop,
+		},
+		{
+			Type: schema.BlockTypeRetry,
+		},
+		{
+			Type: schema.BlockTypeThrow,
+		},
+	},
+}
+
 var PipelineStepInputBlockSchema = &hcl.BodySchema{
 	Attributes: []hcl.AttributeSchema{
 		{
@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/error_helpers"
@@ -259,6 +260,8 @@ func NewPipelineStep(stepType, stepName string, pipeline *Pipeline) PipelineStep
 		step = &PipelineStepFunction{}
 	case schema.BlockTypePipelineStepContainer:
 		step = &PipelineStepContainer{}
+	case localconstants.BlockTypePipelineStepCommand:
+		step = &PipelineStepCommand{}
 	case schema.BlockTypePipelineStepInput:
 		step = &PipelineStepInput{}
 	case schema.BlockTypePipelineStepMessage:
@@ -0,0 +1,233 @@
+package resources
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PipelineStepCommand runs a local command. Without args the command is run by the shell, with args the command is
+// the program to run.
+type PipelineStepCommand struct {
+	PipelineStepBase
+
+	Command          *string           `json:"command"`
+	Args             []string          `json:"args"`
+	Env              map[string]string `json:"env"`
+	Workdir          *string           `json:"workdir"`
+	Stdin            *string           `json:"stdin"`
+	AllowedExitCodes []int64           `json:"allowed_exit_codes"`
+}
+
+func (p *PipelineStepCommand) Equals(iOther PipelineStep) bool {
+	// If both pointers are nil, they are considered equal
+	if p == nil && helpers.IsNil(iOther) {
+		return true
+	}
+
+	if p == nil && !helpers.IsNil(iOther) || p != nil && helpers.IsNil(iOther) {
+		return false
+	}
+
+	other, ok := iOther.(*PipelineStepCommand)
+	if !ok {
+		return false
+	}
+
+	if !p.PipelineStepBase.Equals(&other.PipelineStepBase) {
+		return false
+	}
+
+	return utils.PtrEqual(p.Command, other.Command) &&
+		slices.Equal(p.Args, other.Args) &&
+		reflect.DeepEqual(p.Env, other.Env) &&
+		utils.PtrEqual(p.Workdir, other.Workdir) &&
+		utils.PtrEqual(p.Stdin, other.Stdin) &&
+		slices.Equal(p.AllowedExitCodes, other.AllowedExitCodes)
+}
+
+func (p *PipelineStepCommand) GetInputs(evalContext *hcl.EvalContext) (map[string]interface{}, error) {
+	res, _, err := p.GetInputs2(evalContext)
+	return res, err
+}
+
+func (p *PipelineStepCommand) GetInputs2(evalContext *hcl.EvalContext) (map[string]interface{}, []ConnectionDependency, error) {
+
+	results, err := p.GetBaseInputs(evalContext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var allConnectionDependencies []ConnectionDependency
+
+	// command
+	commandValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, constants.AttributeTypeCommand, p.Command)
+	if len(diags) > 0 {
+		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	results[constants.AttributeTypeCommand] = commandValue
+	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+
+	// args
+	argsValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, schema.AttributeTypeArgs, p.Args)
+	if len(diags) > 0 {
+		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	results[schema.AttributeTypeArgs] = argsValue
+	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+
+	// env
+	envValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, schema.AttributeTypeEnv, p.Env)
+	if len(diags) > 0 {
+		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	results[schema.AttributeTypeEnv] = envValue
+	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+
+	// workdir
+	workdirValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, schema.AttributeTypeWorkdir, p.Workdir)
+	if len(diags) > 0 {
+		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	results[schema.AttributeTypeWorkdir] = workdirValue
+	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+
+	// stdin
+	stdinValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, constants.AttributeTypeStdin, p.Stdin)
+	if len(diags) > 0 {
+		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	results[constants.AttributeTypeStdin] = stdinValue
+	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+
+	// allowed_exit_codes
+	allowedExitCodesValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, constants.AttributeTypeAllowedExitCodes, p.AllowedExitCodes)
+	if len(diags) > 0 {
+		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	results[constants.AttributeTypeAllowedExitCodes] = allowedExitCodesValue
+	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+
+	return results, allConnectionDependencies, nil
+}
+
+func (p *PipelineStepCommand) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := p.SetBaseAttributes(hclAttributes, evalContext)
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case constants.AttributeTypeCommand, schema.AttributeTypeWorkdir, constants.AttributeTypeStdin:
+			structFieldName := utils.CapitalizeFirst(name)
+			stepDiags := setStringAttribute(attr, evalContext, p, structFieldName, true)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+		case schema.AttributeTypeArgs:
+			val, stepDiags := dependsOnFromExpressions(attr, evalContext, p)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+			if val != cty.NilVal {
+				args, moreErr := hclhelpers.CtyToGoStringSlice(val, val.Type())
+				if moreErr != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unable to parse '" + schema.AttributeTypeArgs + "' attribute to string slice",
+						Subject:  &attr.Range,
+					})
+					continue
+				}
+				p.Args = args
+			}
+
+		case schema.AttributeTypeEnv:
+			val, stepDiags := dependsOnFromExpressions(attr, evalContext, p)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+			if val != cty.NilVal {
+				env, moreErr := hclhelpers.CtyToGoMapString(val)
+				if moreErr != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unable to parse '" + schema.AttributeTypeEnv + "' attribute to string map",
+						Subject:  &attr.Range,
+					})
+					continue
+				}
+				p.Env = env
+			}
+
+		case constants.AttributeTypeAllowedExitCodes:
+			val, stepDiags := dependsOnFromExpressions(attr, evalContext, p)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+			if val != cty.NilVal {
+				exitCodes, moreDiags := ctyToInt64Slice(val)
+				if moreDiags.HasErrors() {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unable to parse '" + constants.AttributeTypeAllowedExitCodes + "' attribute to integer slice",
+						Subject:  &attr.Range,
+					})
+					continue
+				}
+				p.AllowedExitCodes = exitCodes
+			}
+
+		default:
+			if !p.IsBaseAttribute(name) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unsupported attribute for " + constants.BlockTypePipelineStepCommand + " Step: " + attr.Name,
+					Subject:  &attr.Range,
+				})
+			}
+		}
+	}
+
+	return diags
+}
+
+func (p *PipelineStepCommand) Validate() hcl.Diagnostics {
+	// validate the base attributes
+	diags := p.ValidateBaseAttributes()
+	return diags
+}
+
+// ctyToInt64Slice converts a list (or tuple) of whole numbers
+func ctyToInt64Slice(val cty.Value) ([]int64, hcl.Diagnostics) {
+	if !val.Type().IsListType() && !val.Type().IsTupleType() && !val.Type().IsSetType() {
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Value must be a list of whole numbers",
+		}}
+	}
+
+	result := []int64{}
+	for _, v := range val.AsValueSlice() {
+		i, diags := hclhelpers.CtyToInt64(v)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		result = append(result, *i)
+	}
+	return result, nil
+}
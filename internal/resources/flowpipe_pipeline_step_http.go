@@ -6,6 +6,8 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/iancoleman/strcase"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/go-kit/types"
 	"github.com/turbot/pipe-fittings/error_helpers"
@@ -41,6 +43,17 @@ type PipelineStepHttp struct {
 	RequestBody     *string                `json:"request_body,omitempty"`
 	RequestHeaders  map[string]interface{} `json:"request_headers,omitempty"`
 	BasicAuthConfig *BasicAuthConfig       `json:"basic_auth,omitempty"`
+	Pagination      *PaginationConfig      `json:"pagination,omitempty"`
+	ClientCertPem   *string                `json:"client_cert_pem,omitempty"`
+	ClientKeyPem    *string                `json:"client_key_pem,omitempty"`
+	ProxyUrl        *string                `json:"proxy_url,omitempty"`
+	ConnectTimeout  interface{}            `json:"connect_timeout,omitempty"`
+	ReadTimeout     interface{}            `json:"read_timeout,omitempty"`
+	FollowRedirects *bool                  `json:"follow_redirects,omitempty"`
+	MaxRedirects    *int64                 `json:"max_redirects,omitempty"`
+	Http2           *bool                  `json:"http2,omitempty"`
+	Multipart       []*MultipartConfig     `json:"multipart,omitempty"`
+	ResponseFile    *string                `json:"response_file,omitempty"`
 }
 
 func (p *PipelineStepHttp) Equals(iOther PipelineStep) bool {
@@ -68,12 +81,34 @@ func (p *PipelineStepHttp) Equals(iOther PipelineStep) bool {
 		return false
 	}
 
+	if !p.Pagination.Equals(other.Pagination) {
+		return false
+	}
+
+	if len(p.Multipart) != len(other.Multipart) {
+		return false
+	}
+	for i := range p.Multipart {
+		if !p.Multipart[i].Equals(other.Multipart[i]) {
+			return false
+		}
+	}
+
 	return utils.PtrEqual(p.Url, other.Url) &&
 		utils.PtrEqual(p.Method, other.Method) &&
 		utils.PtrEqual(p.CaCertPem, other.CaCertPem) &&
 		utils.BoolPtrEqual(p.Insecure, other.Insecure) &&
 		utils.PtrEqual(p.RequestBody, other.RequestBody) &&
-		reflect.DeepEqual(p.RequestHeaders, other.RequestHeaders)
+		reflect.DeepEqual(p.RequestHeaders, other.RequestHeaders) &&
+		utils.PtrEqual(p.ClientCertPem, other.ClientCertPem) &&
+		utils.PtrEqual(p.ClientKeyPem, other.ClientKeyPem) &&
+		utils.PtrEqual(p.ProxyUrl, other.ProxyUrl) &&
+		reflect.DeepEqual(p.ConnectTimeout, other.ConnectTimeout) &&
+		reflect.DeepEqual(p.ReadTimeout, other.ReadTimeout) &&
+		utils.BoolPtrEqual(p.FollowRedirects, other.FollowRedirects) &&
+		utils.PtrEqual(p.MaxRedirects, other.MaxRedirects) &&
+		utils.BoolPtrEqual(p.Http2, other.Http2) &&
+		utils.PtrEqual(p.ResponseFile, other.ResponseFile)
 }
 
 func (p *PipelineStepHttp) GetInputs(evalContext *hcl.EvalContext) (map[string]interface{}, error) {
@@ -139,6 +174,32 @@ func (p *PipelineStepHttp) GetInputs2(evalContext *hcl.EvalContext) (map[string]
 	results[schema.AttributeTypeRequestHeaders] = requestHeadersValue
 	allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
 
+	// the transport settings and the response file
+	transportAttributes := []struct {
+		name  string
+		value any
+	}{
+		{constants.AttributeTypeClientCertPem, p.ClientCertPem},
+		{constants.AttributeTypeClientKeyPem, p.ClientKeyPem},
+		{constants.AttributeTypeProxyUrl, p.ProxyUrl},
+		{constants.AttributeTypeConnectTimeout, p.ConnectTimeout},
+		{constants.AttributeTypeReadTimeout, p.ReadTimeout},
+		{constants.AttributeTypeFollowRedirects, p.FollowRedirects},
+		{constants.AttributeTypeMaxRedirects, p.MaxRedirects},
+		{constants.AttributeTypeHttp2, p.Http2},
+		{constants.AttributeTypeResponseFile, p.ResponseFile},
+	}
+	for _, attribute := range transportAttributes {
+		value, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, attribute.name, attribute.value)
+		if len(diags) > 0 {
+			return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+		}
+		if value != nil {
+			results[attribute.name] = value
+		}
+		allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+	}
+
 	if p.BasicAuthConfig != nil {
 		basicAuth, diags := p.BasicAuthConfig.GetInputs(evalContext, p.UnresolvedAttributes)
 		if diags.HasErrors() {
@@ -149,6 +210,29 @@ func (p *PipelineStepHttp) GetInputs2(evalContext *hcl.EvalContext) (map[string]
 		basicAuthMap["Password"] = basicAuth.Password
 		results[schema.BlockTypePipelineBasicAuth] = basicAuthMap
 	}
+
+	if p.Pagination != nil {
+		pagination, connectionDependencies, diags := p.Pagination.GetInputs(evalContext, p.Name)
+		if diags.HasErrors() {
+			return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+		}
+		results[constants.BlockTypePagination] = pagination
+		allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+	}
+
+	if len(p.Multipart) > 0 {
+		parts := []interface{}{}
+		for _, multipart := range p.Multipart {
+			part, connectionDependencies, diags := multipart.GetInputs(evalContext, p.Name)
+			if diags.HasErrors() {
+				return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+			}
+			parts = append(parts, part)
+			allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+		}
+		results[constants.BlockTypeMultipart] = parts
+	}
+
 	results[schema.AttributeTypeStepName] = p.Name
 
 	return results, allConnectionDependencies, nil
@@ -241,6 +325,54 @@ func (p *PipelineStepHttp) SetAttributes(hclAttributes hcl.Attributes, evalConte
 					continue
 				}
 			}
+
+		case constants.AttributeTypeClientCertPem, constants.AttributeTypeClientKeyPem, constants.AttributeTypeProxyUrl,
+			constants.AttributeTypeResponseFile:
+			stepDiags := setStringAttribute(attr, evalContext, p, strcase.ToCamel(name), true)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+		case constants.AttributeTypeFollowRedirects, constants.AttributeTypeHttp2:
+			stepDiags := setBoolAttributeWithResultReference(attr, evalContext, p, strcase.ToCamel(name), true, false)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+		case constants.AttributeTypeMaxRedirects:
+			stepDiags := setInt64AttributeWithResultReference(attr, evalContext, p, strcase.ToCamel(name), true, false)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+		case constants.AttributeTypeConnectTimeout, constants.AttributeTypeReadTimeout:
+			val, stepDiags := dependsOnFromExpressions(attr, evalContext, p)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+			if val != cty.NilVal {
+				duration, err := hclhelpers.CtyToGo(val)
+				if err != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unable to parse '" + name + "' attribute to interface",
+						Subject:  &attr.Range,
+					})
+					continue
+				}
+
+				if name == constants.AttributeTypeConnectTimeout {
+					p.ConnectTimeout = duration
+				} else {
+					p.ReadTimeout = duration
+				}
+			}
+
 		default:
 			if !p.IsBaseAttribute(name) {
 				diags = append(diags, &hcl.Diagnostic{
@@ -323,11 +455,109 @@ func (p *PipelineStepHttp) SetBlockConfig(blocks hcl.Blocks, evalContext *hcl.Ev
 		p.BasicAuthConfig = basicAuthConfig
 	}
 
+	if paginationBlocks := blocks.ByType()[constants.BlockTypePagination]; len(paginationBlocks) > 0 {
+		if len(paginationBlocks) > 1 {
+			return hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Multiple pagination blocks found for step http",
+				Subject:  &paginationBlocks[1].DefRange,
+			}}
+		}
+
+		attributes, moreDiags := paginationBlocks[0].Body.JustAttributes()
+		if len(moreDiags) > 0 {
+			return append(diags, moreDiags...)
+		}
+
+		pagination := NewPaginationConfig(&p.PipelineStepBase)
+		moreDiags = pagination.SetAttributes(attributes, evalContext)
+		if len(moreDiags) > 0 {
+			return append(diags, moreDiags...)
+		}
+
+		p.Pagination = pagination
+	}
+
+	for _, multipartBlock := range blocks.ByType()[constants.BlockTypeMultipart] {
+		attributes, moreDiags := multipartBlock.Body.JustAttributes()
+		if len(moreDiags) > 0 {
+			return append(diags, moreDiags...)
+		}
+
+		multipart := NewMultipartConfig(&p.PipelineStepBase)
+		moreDiags = multipart.SetAttributes(attributes, evalContext)
+		if len(moreDiags) > 0 {
+			return append(diags, moreDiags...)
+		}
+
+		p.Multipart = append(p.Multipart, multipart)
+	}
+
 	return diags
 }
 
 func (p *PipelineStepHttp) Validate() hcl.Diagnostics {
 	diags := p.ValidateBaseAttributes()
+
+	timeouts := []struct {
+		name  string
+		value interface{}
+	}{
+		{constants.AttributeTypeConnectTimeout, p.ConnectTimeout},
+		{constants.AttributeTypeReadTimeout, p.ReadTimeout},
+	}
+	for _, timeout := range timeouts {
+		switch timeout.value.(type) {
+		case nil, string, int:
+			// valid duration
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Value of the attribute '" + timeout.name + "' must be a string or a whole number: " + p.GetFullyQualifiedName(),
+				Subject:  p.Range,
+			})
+		}
+	}
+
+	// the client certificate and its key are set together
+	hasClientCert := p.ClientCertPem != nil || p.UnresolvedAttributes[constants.AttributeTypeClientCertPem] != nil
+	hasClientKey := p.ClientKeyPem != nil || p.UnresolvedAttributes[constants.AttributeTypeClientKeyPem] != nil
+	if hasClientCert != hasClientKey {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The attributes '" + constants.AttributeTypeClientCertPem + "' and '" + constants.AttributeTypeClientKeyPem + "' must be set together: " + p.GetFullyQualifiedName(),
+			Subject:  p.Range,
+		})
+	}
+
+	// the multipart parts are the request body
+	hasRequestBody := p.RequestBody != nil || p.UnresolvedAttributes[schema.AttributeTypeRequestBody] != nil
+	if hasRequestBody && len(p.Multipart) > 0 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The attribute '" + schema.AttributeTypeRequestBody + "' can't be set with " + constants.BlockTypeMultipart + " blocks: " + p.GetFullyQualifiedName(),
+			Subject:  p.Range,
+		})
+	}
+
+	// the items of the pages are collected from their JSON response body
+	hasResponseFile := p.ResponseFile != nil || p.UnresolvedAttributes[constants.AttributeTypeResponseFile] != nil
+	if hasResponseFile && p.Pagination != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The attribute '" + constants.AttributeTypeResponseFile + "' can't be set with a " + constants.BlockTypePagination + " block: " + p.GetFullyQualifiedName(),
+			Subject:  p.Range,
+		})
+	}
+
+	if p.MaxRedirects != nil && *p.MaxRedirects < 0 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Value of the attribute '" + constants.AttributeTypeMaxRedirects + "' must be a positive whole number: " + p.GetFullyQualifiedName(),
+			Subject:  p.Range,
+		})
+	}
+
 	return diags
 }
 
@@ -0,0 +1,156 @@
+package resources
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/iancoleman/strcase"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+// MultipartConfig is a multipart block of the http step, the parts of the step are sent in the order of their blocks
+// as a multipart/form-data request body.
+//
+// The part is either a file, relative to the mod if it's a relative path, or a content.
+type MultipartConfig struct {
+	// circular link to its "parent"
+	PipelineStepBase *PipelineStepBase `json:"-"`
+
+	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
+
+	Name        *string `json:"name"`
+	File        *string `json:"file,omitempty"`
+	Content     *string `json:"content,omitempty"`
+	Filename    *string `json:"filename,omitempty"`
+	ContentType *string `json:"content_type,omitempty"`
+}
+
+func NewMultipartConfig(p *PipelineStepBase) *MultipartConfig {
+	return &MultipartConfig{
+		PipelineStepBase:     p,
+		UnresolvedAttributes: make(map[string]hcl.Expression),
+	}
+}
+
+func (c *MultipartConfig) Equals(other *MultipartConfig) bool {
+	if c == nil && other == nil {
+		return true
+	}
+
+	if c == nil && other != nil || c != nil && other == nil {
+		return false
+	}
+
+	if len(c.UnresolvedAttributes) != len(other.UnresolvedAttributes) {
+		return false
+	}
+
+	for key, expr := range c.UnresolvedAttributes {
+		otherExpr, ok := other.UnresolvedAttributes[key]
+		if !ok || !hclhelpers.ExpressionsEqual(expr, otherExpr) {
+			return false
+		}
+	}
+
+	return utils.PtrEqual(c.Name, other.Name) &&
+		utils.PtrEqual(c.File, other.File) &&
+		utils.PtrEqual(c.Content, other.Content) &&
+		utils.PtrEqual(c.Filename, other.Filename) &&
+		utils.PtrEqual(c.ContentType, other.ContentType)
+}
+
+func (c *MultipartConfig) AppendDependsOn(dependsOn ...string) {
+	c.PipelineStepBase.AppendDependsOn(dependsOn...)
+}
+
+func (c *MultipartConfig) AppendCredentialDependsOn(credentialDependsOn ...string) {
+	c.PipelineStepBase.AppendCredentialDependsOn(credentialDependsOn...)
+}
+
+func (c *MultipartConfig) AppendConnectionDependsOn(connectionDependsOn ...string) {
+	c.PipelineStepBase.AppendConnectionDependsOn(connectionDependsOn...)
+}
+
+func (c *MultipartConfig) AddUnresolvedAttribute(name string, expr hcl.Expression) {
+	c.UnresolvedAttributes[name] = expr
+}
+
+func (c *MultipartConfig) GetPipeline() *Pipeline {
+	return c.PipelineStepBase.GetPipeline()
+}
+
+func (c *MultipartConfig) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeName, constants.AttributeTypeFile, constants.AttributeTypeContent,
+			constants.AttributeTypeFilename, schema.AttributeTypeContentType:
+			stepDiags := setStringAttribute(attr, evalContext, c, strcase.ToCamel(name), true)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid attribute",
+				Detail:   "Unsupported attribute '" + name + "' in multipart block",
+				Subject:  &attr.Range,
+			})
+		}
+	}
+
+	if _, ok := hclAttributes[schema.AttributeTypeName]; !ok {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing name",
+			Detail:   "The multipart block must define the name of its part",
+			Subject:  c.PipelineStepBase.Range,
+		})
+	}
+
+	_, hasFile := hclAttributes[constants.AttributeTypeFile]
+	_, hasContent := hclAttributes[constants.AttributeTypeContent]
+	if hasFile == hasContent {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid multipart block",
+			Detail:   "The multipart block must define either a " + constants.AttributeTypeFile + " or a " + constants.AttributeTypeContent,
+			Subject:  c.PipelineStepBase.Range,
+		})
+	}
+
+	return diags
+}
+
+// GetInputs resolves the multipart block to the input of its part
+func (c *MultipartConfig) GetInputs(evalContext *hcl.EvalContext, stepName string) (map[string]interface{}, []ConnectionDependency, hcl.Diagnostics) {
+	results := map[string]interface{}{}
+	var allConnectionDependencies []ConnectionDependency
+
+	attributes := []struct {
+		name  string
+		value *string
+	}{
+		{schema.AttributeTypeName, c.Name},
+		{constants.AttributeTypeFile, c.File},
+		{constants.AttributeTypeContent, c.Content},
+		{constants.AttributeTypeFilename, c.Filename},
+		{schema.AttributeTypeContentType, c.ContentType},
+	}
+	for _, attribute := range attributes {
+		value, connectionDependencies, diags := decodeStepAttribute(c.UnresolvedAttributes, evalContext, stepName, attribute.name, attribute.value)
+		if len(diags) > 0 {
+			return nil, nil, diags
+		}
+		if value != nil {
+			results[attribute.name] = value
+		}
+		allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+	}
+
+	return results, allConnectionDependencies, nil
+}
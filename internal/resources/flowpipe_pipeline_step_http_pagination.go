@@ -0,0 +1,235 @@
+package resources
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/iancoleman/strcase"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+var ValidPaginationStyles = []string{
+	constants.PaginationStyleLinkHeader,
+	constants.PaginationStyleToken,
+	constants.PaginationStyleOffset,
+}
+
+// PaginationConfig is the pagination block of the http step, the step requests the pages one after the other and
+// collects their items in its response_body.
+//
+// The paths are dot separated paths in the JSON response body, e.g. "data.items", a number is the index of a list.
+type PaginationConfig struct {
+	// circular link to its "parent"
+	PipelineStepBase *PipelineStepBase `json:"-"`
+
+	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
+
+	Style       *string `json:"style"`
+	ItemsPath   *string `json:"items_path,omitempty"`
+	TokenPath   *string `json:"token_path,omitempty"`
+	TokenParam  *string `json:"token_param,omitempty"`
+	OffsetParam *string `json:"offset_param,omitempty"`
+	LimitParam  *string `json:"limit_param,omitempty"`
+	Limit       *int64  `json:"limit,omitempty"`
+	MaxPages    *int64  `json:"max_pages,omitempty"`
+	MaxItems    *int64  `json:"max_items,omitempty"`
+}
+
+func NewPaginationConfig(p *PipelineStepBase) *PaginationConfig {
+	return &PaginationConfig{
+		PipelineStepBase:     p,
+		UnresolvedAttributes: make(map[string]hcl.Expression),
+	}
+}
+
+func (c *PaginationConfig) Equals(other *PaginationConfig) bool {
+	if c == nil && other == nil {
+		return true
+	}
+
+	if c == nil && other != nil || c != nil && other == nil {
+		return false
+	}
+
+	if len(c.UnresolvedAttributes) != len(other.UnresolvedAttributes) {
+		return false
+	}
+
+	for key, expr := range c.UnresolvedAttributes {
+		otherExpr, ok := other.UnresolvedAttributes[key]
+		if !ok || !hclhelpers.ExpressionsEqual(expr, otherExpr) {
+			return false
+		}
+	}
+
+	return utils.PtrEqual(c.Style, other.Style) &&
+		utils.PtrEqual(c.ItemsPath, other.ItemsPath) &&
+		utils.PtrEqual(c.TokenPath, other.TokenPath) &&
+		utils.PtrEqual(c.TokenParam, other.TokenParam) &&
+		utils.PtrEqual(c.OffsetParam, other.OffsetParam) &&
+		utils.PtrEqual(c.LimitParam, other.LimitParam) &&
+		utils.PtrEqual(c.Limit, other.Limit) &&
+		utils.PtrEqual(c.MaxPages, other.MaxPages) &&
+		utils.PtrEqual(c.MaxItems, other.MaxItems)
+}
+
+func (c *PaginationConfig) AppendDependsOn(dependsOn ...string) {
+	c.PipelineStepBase.AppendDependsOn(dependsOn...)
+}
+
+func (c *PaginationConfig) AppendCredentialDependsOn(credentialDependsOn ...string) {
+	c.PipelineStepBase.AppendCredentialDependsOn(credentialDependsOn...)
+}
+
+func (c *PaginationConfig) AppendConnectionDependsOn(connectionDependsOn ...string) {
+	c.PipelineStepBase.AppendConnectionDependsOn(connectionDependsOn...)
+}
+
+func (c *PaginationConfig) AddUnresolvedAttribute(name string, expr hcl.Expression) {
+	c.UnresolvedAttributes[name] = expr
+}
+
+func (c *PaginationConfig) GetPipeline() *Pipeline {
+	return c.PipelineStepBase.GetPipeline()
+}
+
+func (c *PaginationConfig) SetAttributes(hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeStyle, constants.AttributeTypeItemsPath, constants.AttributeTypeTokenPath,
+			constants.AttributeTypeTokenParam, constants.AttributeTypeOffsetParam, constants.AttributeTypeLimitParam:
+			stepDiags := setStringAttribute(attr, evalContext, c, strcase.ToCamel(name), true)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+		case constants.AttributeTypeLimit, constants.AttributeTypeMaxPages, constants.AttributeTypeMaxItems:
+			stepDiags := setInt64AttributeWithResultReference(attr, evalContext, c, strcase.ToCamel(name), true, false)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid attribute",
+				Detail:   "Unsupported attribute '" + name + "' in pagination block",
+				Subject:  &attr.Range,
+			})
+		}
+	}
+
+	if _, ok := hclAttributes[schema.AttributeTypeStyle]; !ok {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing style",
+			Detail:   "The pagination block must define a style, one of: " + strings.Join(ValidPaginationStyles, ", "),
+			Subject:  c.PipelineStepBase.Range,
+		})
+	}
+
+	if len(diags) > 0 {
+		return diags
+	}
+
+	return c.Validate()
+}
+
+// Validate validates the attributes resolved when the pipeline is loaded, the attributes resolved when the step runs
+// are validated by the http primitive
+func (c *PaginationConfig) Validate() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	if c.Style != nil && !slices.Contains(ValidPaginationStyles, *c.Style) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid pagination style: " + *c.Style,
+			Detail:   "The pagination style must be one of: " + strings.Join(ValidPaginationStyles, ", "),
+			Subject:  c.PipelineStepBase.Range,
+		})
+	}
+
+	if c.Style != nil && *c.Style == constants.PaginationStyleToken {
+		if c.TokenPath == nil && c.UnresolvedAttributes[constants.AttributeTypeTokenPath] == nil ||
+			c.TokenParam == nil && c.UnresolvedAttributes[constants.AttributeTypeTokenParam] == nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing " + constants.AttributeTypeTokenPath + " or " + constants.AttributeTypeTokenParam,
+				Detail:   "The token pagination must define " + constants.AttributeTypeTokenPath + " and " + constants.AttributeTypeTokenParam,
+				Subject:  c.PipelineStepBase.Range,
+			})
+		}
+	}
+
+	limits := []struct {
+		name  string
+		value *int64
+	}{
+		{constants.AttributeTypeLimit, c.Limit},
+		{constants.AttributeTypeMaxPages, c.MaxPages},
+		{constants.AttributeTypeMaxItems, c.MaxItems},
+	}
+	for _, limit := range limits {
+		if limit.value != nil && *limit.value < 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid " + limit.name,
+				Detail:   limit.name + " must be greater than 0",
+				Subject:  c.PipelineStepBase.Range,
+			})
+		}
+	}
+
+	return diags
+}
+
+// GetInputs resolves the pagination block to the pagination input of the http step
+func (c *PaginationConfig) GetInputs(evalContext *hcl.EvalContext, stepName string) (map[string]interface{}, []ConnectionDependency, hcl.Diagnostics) {
+	results := map[string]interface{}{}
+	var allConnectionDependencies []ConnectionDependency
+
+	stringAttributes := map[string]*string{
+		schema.AttributeTypeStyle:          c.Style,
+		constants.AttributeTypeItemsPath:   c.ItemsPath,
+		constants.AttributeTypeTokenPath:   c.TokenPath,
+		constants.AttributeTypeTokenParam:  c.TokenParam,
+		constants.AttributeTypeOffsetParam: c.OffsetParam,
+		constants.AttributeTypeLimitParam:  c.LimitParam,
+	}
+	for attributeName, fieldValue := range stringAttributes {
+		value, connectionDependencies, diags := decodeStepAttribute(c.UnresolvedAttributes, evalContext, stepName, attributeName, fieldValue)
+		if len(diags) > 0 {
+			return nil, nil, diags
+		}
+		if value != nil {
+			results[attributeName] = value
+		}
+		allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+	}
+
+	intAttributes := map[string]*int64{
+		constants.AttributeTypeLimit:    c.Limit,
+		constants.AttributeTypeMaxPages: c.MaxPages,
+		constants.AttributeTypeMaxItems: c.MaxItems,
+	}
+	for attributeName, fieldValue := range intAttributes {
+		value, connectionDependencies, diags := decodeStepAttribute(c.UnresolvedAttributes, evalContext, stepName, attributeName, fieldValue)
+		if len(diags) > 0 {
+			return nil, nil, diags
+		}
+		if value != nil {
+			results[attributeName] = value
+		}
+		allConnectionDependencies = append(allConnectionDependencies, connectionDependencies...)
+	}
+
+	return results, allConnectionDependencies, nil
+}
@@ -2,8 +2,12 @@ package resources
 
 import (
 	"fmt"
+	"log/slog"
+	"reflect"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/app_specific_connection"
 	"github.com/turbot/pipe-fittings/connection"
@@ -13,7 +17,6 @@ import (
 	"github.com/turbot/pipe-fittings/schema"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/zclconf/go-cty/cty"
-	"log/slog"
 )
 
 type PipelineStepQuery struct {
@@ -22,6 +25,9 @@ type PipelineStepQuery struct {
 	ConnectionString *string       `json:"database"`
 	Sql              *string       `json:"sql"`
 	Args             []interface{} `json:"args"`
+
+	// the statements run in a transaction, a statement is either its sql or an object with its sql and args
+	Statements []interface{} `json:"statements,omitempty"`
 }
 
 func (p *PipelineStepQuery) Equals(iOther PipelineStep) bool {
@@ -53,7 +59,8 @@ func (p *PipelineStepQuery) Equals(iOther PipelineStep) bool {
 	}
 
 	return utils.PtrEqual(p.Database, other.Database) &&
-		utils.PtrEqual(p.Sql, other.Sql)
+		utils.PtrEqual(p.Sql, other.Sql) &&
+		reflect.DeepEqual(p.Statements, other.Statements)
 }
 
 func (p *PipelineStepQuery) GetInputs2(evalContext *hcl.EvalContext) (map[string]interface{}, []ConnectionDependency, error) {
@@ -126,6 +133,16 @@ func (p *PipelineStepQuery) GetInputs2(evalContext *hcl.EvalContext) (map[string
 	results[schema.AttributeTypeArgs] = argsValue
 	allConnnectionDependencies = append(allConnnectionDependencies, connectionDependencies...)
 
+	// statements
+	statementsValue, connectionDependencies, diags := decodeStepAttribute(p.UnresolvedAttributes, evalContext, p.Name, constants.AttributeTypeStatements, p.Statements)
+	if len(diags) > 0 {
+		return nil, nil, error_helpers.BetterHclDiagsToError(p.Name, diags)
+	}
+	if statementsValue != nil {
+		results[constants.AttributeTypeStatements] = statementsValue
+	}
+	allConnnectionDependencies = append(allConnnectionDependencies, connectionDependencies...)
+
 	// if p.UnresolvedAttributes[schema.AttributeTypeArgs] != nil {
 	// 	var args cty.Value
 	// 	diags := gohcl.DecodeExpression(p.UnresolvedAttributes[schema.AttributeTypeArgs], evalContext, &args)
@@ -184,6 +201,27 @@ func (p *PipelineStepQuery) SetAttributes(hclAttributes hcl.Attributes, evalCont
 				p.Args = goVals
 			}
 
+		case constants.AttributeTypeStatements:
+			val, stepDiags := dependsOnFromExpressions(attr, evalContext, p)
+			if stepDiags.HasErrors() {
+				diags = append(diags, stepDiags...)
+				continue
+			}
+
+			if val != cty.NilVal {
+				goVal, err := hclhelpers.CtyToGo(val)
+				statements, ok := goVal.([]interface{})
+				if err != nil || !ok {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unable to parse '" + constants.AttributeTypeStatements + "' attribute to a list of statements",
+						Subject:  &attr.Range,
+					})
+					continue
+				}
+				p.Statements = statements
+			}
+
 		default:
 			if !p.IsBaseAttribute(name) {
 				diags = append(diags, &hcl.Diagnostic{
@@ -201,5 +239,41 @@ func (p *PipelineStepQuery) SetAttributes(hclAttributes hcl.Attributes, evalCont
 func (p *PipelineStepQuery) Validate() hcl.Diagnostics {
 	// validate the base attributes
 	diags := p.ValidateBaseAttributes()
+
+	// the args of the statements are set by statement
+	hasStatements := p.Statements != nil || p.UnresolvedAttributes[constants.AttributeTypeStatements] != nil
+	if hasStatements {
+		hasSql := p.Sql != nil || p.UnresolvedAttributes[schema.AttributeTypeSql] != nil
+		hasArgs := p.Args != nil || p.UnresolvedAttributes[schema.AttributeTypeArgs] != nil
+		if hasSql || hasArgs {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "The attributes '" + schema.AttributeTypeSql + "' and '" + schema.AttributeTypeArgs + "' can't be set with '" + constants.AttributeTypeStatements + "': " + p.GetFullyQualifiedName(),
+				Subject:  p.Range,
+			})
+		}
+	}
+
+	for i, statement := range p.Statements {
+		switch s := statement.(type) {
+		case string:
+			// the sql of the statement
+		case map[string]interface{}:
+			if _, ok := s[schema.AttributeTypeSql].(string); !ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("The statement %d must define sql: %s", i+1, p.GetFullyQualifiedName()),
+					Subject:  p.Range,
+				})
+			}
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("The statement %d must be a string or an object with sql and args: %s", i+1, p.GetFullyQualifiedName()),
+				Subject:  p.Range,
+			})
+		}
+	}
+
 	return diags
 }
@@ -3,12 +3,20 @@ package resources
 import (
 	"github.com/turbot/pipe-fittings/modconfig"
 	"log/slog"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/schedule"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/app_specific_connection"
 	"github.com/turbot/pipe-fittings/connection"
@@ -51,6 +59,10 @@ type Trigger struct {
 	RawBody hcl.Body      `json:"-" hcl:",remain"`
 	Config  TriggerConfig `json:"-"`
 	Enabled *bool         `json:"-"`
+
+	// What to do when a schedule or query trigger fires while its previous execution is still running: allow, skip,
+	// queue or cancel_previous
+	Overlap string `json:"overlap"`
 }
 
 // Implements the ModTreeItem interface
@@ -120,6 +132,10 @@ func (t *Trigger) Equals(other *Trigger) bool {
 		return false
 	}
 
+	if t.Overlap != other.Overlap {
+		return false
+	}
+
 	if t.Pipeline.Equals(other.Pipeline).False() {
 		return false
 	}
@@ -174,8 +190,11 @@ var ValidBaseTriggerAttributes = []string{
 	schema.AttributeTypeDocumentation,
 	schema.AttributeTypeTags,
 	schema.AttributeTypeEnabled,
+	constants.AttributeTypeOverlap,
 }
 
+var validOverlaps = []string{constants.OverlapAllow, constants.OverlapSkip, constants.OverlapQueue, constants.OverlapCancelPrevious}
+
 func (t *Trigger) IsBaseAttribute(name string) bool {
 	return slices.Contains[[]string, string](ValidBaseTriggerAttributes, name)
 }
@@ -255,6 +274,22 @@ func (t *Trigger) SetBaseAttributes(mod *modconfig.Mod, hclAttributes hcl.Attrib
 		}
 	}
 
+	if attr, exists := hclAttributes[constants.AttributeTypeOverlap]; exists {
+		overlap, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+		if moreDiags != nil && moreDiags.HasErrors() {
+			diags = append(diags, moreDiags...)
+		} else if !slices.Contains(validOverlaps, *overlap) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid overlap",
+				Detail:   "The overlap must be one of: " + strings.Join(validOverlaps, ","),
+				Subject:  &attr.Range,
+			})
+		} else {
+			t.Overlap = *overlap
+		}
+	}
+
 	if attr, exists := hclAttributes[schema.AttributeTypeArgs]; exists {
 		if attr.Expr != nil {
 			t.ArgsRaw = attr.Expr
@@ -275,7 +310,14 @@ type TriggerConfig interface {
 }
 
 type TriggerSchedule struct {
-	Schedule             string                    `json:"schedule"`
+	Schedule string `json:"schedule"`
+
+	// The IANA time zone of the schedule, e.g. America/New_York, the schedule is in UTC if not set
+	Timezone string `json:"timezone,omitempty"`
+
+	// Which of the runs missed while the server was down are run when it starts: none, latest or all
+	CatchUp string `json:"catch_up"`
+
 	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
 	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
 }
@@ -352,7 +394,28 @@ func (t *TriggerSchedule) Equals(other TriggerConfig) bool {
 		}
 	}
 
-	return t.Schedule == otherTrigger.Schedule
+	return t.Schedule == otherTrigger.Schedule &&
+		t.Timezone == otherTrigger.Timezone &&
+		t.CatchUp == otherTrigger.CatchUp
+}
+
+// GetCronExpression returns the cron expression of the schedule in the trigger time zone, an interval such as hourly is
+// converted to a cron expression distributed by the trigger name
+func (t *TriggerSchedule) GetCronExpression(triggerName string) (string, error) {
+	cronExpression := t.Schedule
+	if slices.Contains(validIntervals, t.Schedule) {
+		var err error
+		cronExpression, err = schedule.IntervalToCronExpression(triggerName, t.Schedule)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if t.Timezone != "" {
+		cronExpression = "CRON_TZ=" + t.Timezone + " " + cronExpression
+	}
+
+	return cronExpression, nil
 }
 
 func (t *TriggerSchedule) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
@@ -397,6 +460,41 @@ func (t *TriggerSchedule) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hc
 					Subject:  &attr.Range,
 				})
 			}
+
+		case constants.AttributeTypeTimezone:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Timezone = *val
+			if _, err := time.LoadLocation(t.Timezone); err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid timezone: " + t.Timezone + ". Specify an IANA time zone, e.g. America/New_York",
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+			}
+
+		case constants.AttributeTypeCatchUp:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.CatchUp = *val
+			if !slices.Contains(validCatchUps, t.CatchUp) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid catch_up",
+					Detail:   "The catch_up must be one of: " + strings.Join(validCatchUps, ","),
+					Subject:  &attr.Range,
+				})
+			}
+
 		default:
 			if !trigger.IsBaseAttribute(name) {
 				diags = append(diags, &hcl.Diagnostic{
@@ -415,6 +513,8 @@ func (t *TriggerSchedule) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlo
 	return diags
 }
 
+var validCatchUps = []string{constants.CatchUpNone, constants.CatchUpLatest, constants.CatchUpAll}
+
 var validIntervals = []string{"hourly", "daily", "weekly", "5m", "10m", "15m", "30m", "60m", "1h", "2h", "4h", "6h", "12h", "24h"}
 
 type TriggerQuery struct {
@@ -424,6 +524,10 @@ type TriggerQuery struct {
 	PrimaryKey string                          `json:"primary_key"`
 	Captures   map[string]*TriggerQueryCapture `json:"captures"`
 
+	// In cursor mode the trigger only captures the rows inserted since the last run, the last value of the cursor
+	// column is passed to the sql as a parameter
+	CursorColumn string `json:"cursor_column,omitempty"`
+
 	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
 	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
 }
@@ -529,11 +633,12 @@ func (t *TriggerQuery) GetConfig(evalContext *hcl.EvalContext, mod *modconfig.Mo
 	}
 
 	newT := &TriggerQuery{
-		Sql:        sql,
-		Schedule:   schedule,
-		Database:   database,
-		PrimaryKey: primaryKey,
-		Captures:   make(map[string]*TriggerQueryCapture),
+		Sql:          sql,
+		Schedule:     schedule,
+		Database:     database,
+		PrimaryKey:   primaryKey,
+		CursorColumn: t.CursorColumn,
+		Captures:     make(map[string]*TriggerQueryCapture),
 	}
 
 	for key, value := range t.Captures {
@@ -577,6 +682,10 @@ func (t *TriggerQuery) Equals(other TriggerConfig) bool {
 		return false
 	}
 
+	if t.CursorColumn != otherTrigger.CursorColumn {
+		return false
+	}
+
 	if len(t.Captures) != len(otherTrigger.Captures) {
 		return false
 	}
@@ -731,6 +840,15 @@ func (t *TriggerQuery) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAt
 				t.PrimaryKey = primaryKey
 			}
 
+		case constants.AttributeTypeCursorColumn:
+			// the cursor column identifies the stored cursor, it can't be a param
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, false)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+			t.CursorColumn = *val
+
 		default:
 			if !trigger.IsBaseAttribute(name) {
 				diags = append(diags, &hcl.Diagnostic{
@@ -779,6 +897,17 @@ func (t *TriggerQuery) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlocks
 			continue
 		}
 
+		// the rows past the cursor are new rows, the updated and deleted rows are only found by comparing all the rows
+		if t.CursorColumn != "" && captureBlockType != "insert" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid capture block type",
+				Detail:   "Only the insert capture block is supported with " + constants.AttributeTypeCursorColumn + ", remove " + constants.AttributeTypeCursorColumn + " to capture updated and deleted rows",
+				Subject:  &captureBlock.DefRange,
+			})
+			continue
+		}
+
 		hclAttributes, moreDiags := captureBlock.Body.JustAttributes()
 		if moreDiags != nil && moreDiags.HasErrors() {
 			diags = append(diags, moreDiags...)
@@ -861,22 +990,26 @@ func (c *TriggerQueryCapture) GetArgs(evalContext *hcl.EvalContext) (Input, hcl.
 	return retVal, diags
 }
 
-type TriggerHttp struct {
-	Url           string                        `json:"url"`
-	ExecutionMode string                        `json:"execution_mode"`
-	Methods       map[string]*TriggerHTTPMethod `json:"methods"`
+type TriggerFile struct {
+	Path     string   `json:"path"`
+	Events   []string `json:"events"`
+	Debounce string   `json:"debounce"`
 
 	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
 	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
 }
 
-func (t *TriggerHttp) AppendsDependsOn(dependsOn ...string) {
+func (t *TriggerFile) GetConfig(evalContext *hcl.EvalContext, mod *modconfig.Mod) (TriggerConfig, error) {
+	return t, nil
 }
 
-func (t *TriggerHttp) AppendCredentialDependsOn(...string) {
+func (t *TriggerFile) AppendDependsOn(...string) {
 }
 
-func (t *TriggerHttp) AppendConnectionDependsOn(connectionDependsOn ...string) {
+func (t *TriggerFile) AppendCredentialDependsOn(...string) {
+}
+
+func (t *TriggerFile) AppendConnectionDependsOn(connectionDependsOn ...string) {
 	// Use map to track existing DependsOn, this will make the lookup below much faster
 	// rather than using nested loops
 	existingDeps := make(map[string]struct{}, len(t.ConnectionDependsOn))
@@ -892,32 +1025,38 @@ func (t *TriggerHttp) AppendConnectionDependsOn(connectionDependsOn ...string) {
 	}
 }
 
-func (t *TriggerHttp) GetConnectionDependsOn() []string {
+func (t *TriggerFile) GetConnectionDependsOn() []string {
 	return t.ConnectionDependsOn
 }
 
-func (t *TriggerHttp) AddUnresolvedAttribute(key string, value hcl.Expression) {
+func (t *TriggerFile) AddUnresolvedAttribute(key string, value hcl.Expression) {
 	t.UnresolvedAttributes[key] = value
 }
 
-func (t *TriggerHttp) GetPipeline() *Pipeline {
+func (t *TriggerFile) GetPipeline() *Pipeline {
 	return nil
 }
 
-func (t *TriggerHttp) GetUnresolvedAttributes() map[string]hcl.Expression {
+func (t *TriggerFile) GetUnresolvedAttributes() map[string]hcl.Expression {
 	return t.UnresolvedAttributes
 }
 
-func (t *TriggerHttp) GetType() string {
-	return schema.TriggerTypeHttp
+func (t *TriggerFile) GetType() string {
+	return constants.TriggerTypeFile
 }
 
-func (t *TriggerHttp) GetConfig(*hcl.EvalContext, *modconfig.Mod) (TriggerConfig, error) {
-	return t, nil
+// GetDebounce returns the debounce window, the changes to a file within the window run the pipeline once
+func (t *TriggerFile) GetDebounce() time.Duration {
+	debounce, err := time.ParseDuration(t.Debounce)
+	if err != nil {
+		// validated when the trigger is parsed
+		debounce, _ = time.ParseDuration(constants.DefaultFileTriggerDebounce)
+	}
+	return debounce
 }
 
-func (t *TriggerHttp) Equals(other TriggerConfig) bool {
-	otherTrigger, ok := other.(*TriggerHttp)
+func (t *TriggerFile) Equals(other TriggerConfig) bool {
+	otherTrigger, ok := other.(*TriggerFile)
 	if !ok {
 		return false
 	}
@@ -930,18 +1069,6 @@ func (t *TriggerHttp) Equals(other TriggerConfig) bool {
 		return true
 	}
 
-	if t.Url != otherTrigger.Url {
-		return false
-	}
-
-	if t.ExecutionMode != otherTrigger.ExecutionMode {
-		return false
-	}
-
-	if len(t.Methods) != len(otherTrigger.Methods) {
-		return false
-	}
-
 	// Compare UnresolvedAttributes (map comparison)
 	if len(t.UnresolvedAttributes) != len(other.GetUnresolvedAttributes()) {
 		return false
@@ -954,66 +1081,85 @@ func (t *TriggerHttp) Equals(other TriggerConfig) bool {
 		}
 	}
 
-	for key, value := range t.Methods {
-		otherValue, exists := otherTrigger.Methods[key]
-		if !exists {
-			return false
-		}
-
-		if !value.Equals(otherValue) {
-			return false
-		}
-	}
-
-	return true
+	return t.Path == otherTrigger.Path &&
+		slices.Equal(t.Events, otherTrigger.Events) &&
+		t.Debounce == otherTrigger.Debounce
 }
 
-type TriggerHTTPMethod struct {
-	Type          string
-	ExecutionMode string
-	Pipeline      cty.Value
-	ArgsRaw       hcl.Expression
-}
+var validFileEvents = []string{constants.FileEventCreate, constants.FileEventModify, constants.FileEventDelete}
 
-func (c *TriggerHTTPMethod) Equals(other *TriggerHTTPMethod) bool {
-	if c == nil && other == nil {
-		return true
+func (t *TriggerFile) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := trigger.SetBaseAttributes(mod, hclAttributes, evalContext)
+	if diags.HasErrors() {
+		return diags
 	}
 
-	if c == nil && other != nil || c != nil && other == nil {
-		return false
-	}
+	// react to all the events by default
+	t.Events = slices.Clone(validFileEvents)
+	t.Debounce = constants.DefaultFileTriggerDebounce
 
-	if c.Type != other.Type || c.ExecutionMode != other.ExecutionMode {
-		return false
-	}
+	for name, attr := range hclAttributes {
+		switch name {
+		case constants.AttributeTypePath:
+			// the path is watched when the trigger is loaded, it needs to be fully resolved
+			val, moreDiags := attr.Expr.Value(evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
 
-	if c.Pipeline.Equals(other.Pipeline).False() {
-		return false
-	}
+			if val.Type() != cty.String {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "The given path is not a string",
+					Detail:   "The given path is not a string",
+					Subject:  &attr.Range,
+				})
+				continue
+			}
 
-	if !reflect.DeepEqual(c.ArgsRaw, other.ArgsRaw) {
-		return false
-	}
+			t.Path = val.AsString()
 
-	return true
-}
+			if _, err := filepath.Match(t.Path, ""); err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid path glob: " + t.Path,
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+			}
 
-var validExecutionMode = []string{"synchronous", "asynchronous"}
-var validMethodBlockTypes = []string{"post", "get"}
+		case constants.AttributeTypeEvents:
+			val, moreDiags := attr.Expr.Value(evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
 
-func (t *TriggerHttp) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+			events, err := hclhelpers.CtyToGoStringSlice(val, val.Type())
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unable to parse " + constants.AttributeTypeEvents + " attribute to a list of strings",
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+				continue
+			}
 
-	// None of the Trigger Http attributes should be unresolved at parse time. It doesn't make sense to have params for the URL for example
+			for _, e := range events {
+				if !slices.Contains(validFileEvents, e) {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Invalid file event: " + e + ". Specify " + strings.Join(validFileEvents, ", "),
+						Subject:  &attr.Range,
+					})
+				}
+			}
 
-	diags := trigger.SetBaseAttributes(mod, hclAttributes, evalContext)
-	if diags.HasErrors() {
-		return diags
-	}
+			t.Events = events
 
-	for name, attr := range hclAttributes {
-		switch name {
-		case schema.AttributeTypeExecutionMode:
+		case constants.AttributeTypeDebounce:
 			val, moreDiags := attr.Expr.Value(evalContext)
 			if len(moreDiags) > 0 {
 				diags = append(diags, moreDiags...)
@@ -1023,20 +1169,20 @@ func (t *TriggerHttp) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAtt
 			if val.Type() != cty.String {
 				diags = append(diags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
-					Summary:  "The given execution mode is not a string",
-					Detail:   "The given execution mode is not a string",
+					Summary:  "The given debounce is not a string",
+					Detail:   "The given debounce is not a string",
 					Subject:  &attr.Range,
 				})
 				continue
 			}
 
-			t.ExecutionMode = val.AsString()
+			t.Debounce = val.AsString()
 
-			if !slices.Contains(validExecutionMode, t.ExecutionMode) {
+			if _, err := time.ParseDuration(t.Debounce); err != nil {
 				diags = append(diags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
-					Summary:  "Invalid execution mode",
-					Detail:   "The execution mode must be one of: " + strings.Join(validExecutionMode, ","),
+					Summary:  "Invalid debounce: " + t.Debounce + ". Specify a duration, e.g. 500ms or 5s",
+					Detail:   err.Error(),
 					Subject:  &attr.Range,
 				})
 			}
@@ -1045,70 +1191,1002 @@ func (t *TriggerHttp) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAtt
 			if !trigger.IsBaseAttribute(name) {
 				diags = append(diags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
-					Summary:  "Unsupported attribute for Trigger Interval: " + attr.Name,
+					Summary:  "Unsupported attribute for Trigger File: " + attr.Name,
 					Subject:  &attr.Range,
 				})
 			}
 		}
 	}
-
 	return diags
 }
 
-func (t *TriggerHttp) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
+func (t *TriggerFile) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
 	diags := hcl.Diagnostics{}
+	return diags
+}
 
-	t.Methods = make(map[string]*TriggerHTTPMethod)
+// TriggerQueue runs its pipeline for each message published to a NATS subject. With a consumer the messages are
+// received from the durable JetStream consumer, they are acked once the pipeline has finished and redelivered if it
+// fails. Without a consumer the messages of the subject are received while the server is running and are not acked.
+type TriggerQueue struct {
+	Url     string `json:"url"`
+	Subject string `json:"subject"`
 
-	// If no method blocks appear, only 'post' is supported, and the top-level `pipeline`, `args` and `execution_mode` will be applied
-	if len(hclBlocks) == 0 {
-		triggerMethod := &TriggerHTTPMethod{
-			Type: HttpMethodPost,
-		}
+	// The JetStream stream and the durable consumer of the messages, the stream is looked up by the subject if not set
+	Stream   string `json:"stream,omitempty"`
+	Consumer string `json:"consumer,omitempty"`
 
-		// Get the top-level pipeline
-		pipeline := trigger.GetPipeline()
-		if pipeline == cty.NilVal {
-			diags = append(diags, &hcl.Diagnostic{
-				Severity: hcl.DiagError,
-				Summary:  "Bad Request",
-				Detail:   "Missing required attribute 'pipeline'",
-			})
-			return diags
-		}
-		triggerMethod.Pipeline = pipeline
+	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
+	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
+}
 
-		// Get the top-level args
-		pipelineArgs := trigger.ArgsRaw
-		if pipelineArgs != nil {
-			triggerMethod.ArgsRaw = pipelineArgs
-		}
+func (t *TriggerQueue) GetConfig(evalContext *hcl.EvalContext, mod *modconfig.Mod) (TriggerConfig, error) {
+	return t, nil
+}
 
-		// Get the top-level execution_mode
-		if t.ExecutionMode != "" {
-			triggerMethod.ExecutionMode = t.ExecutionMode
-		}
+func (t *TriggerQueue) AppendDependsOn(...string) {
+}
 
-		t.Methods[HttpMethodPost] = triggerMethod
+func (t *TriggerQueue) AppendCredentialDependsOn(...string) {
+}
 
-		return diags
+func (t *TriggerQueue) AppendConnectionDependsOn(connectionDependsOn ...string) {
+	// Use map to track existing DependsOn, this will make the lookup below much faster
+	// rather than using nested loops
+	existingDeps := make(map[string]struct{}, len(t.ConnectionDependsOn))
+	for _, dep := range t.ConnectionDependsOn {
+		existingDeps[dep] = struct{}{}
 	}
 
-	// If the method blocks provided, we will consider the configuration provided in the method block
-	for _, methodBlock := range hclBlocks {
-
-		if len(methodBlock.Labels) != 1 {
-			diags = append(diags, &hcl.Diagnostic{
-				Severity: hcl.DiagError,
-				Summary:  "Invalid method block",
-				Detail:   "Method block must have a single label",
-				Subject:  &methodBlock.DefRange,
-			})
-			continue
+	for _, dep := range connectionDependsOn {
+		if _, exists := existingDeps[dep]; !exists {
+			t.ConnectionDependsOn = append(t.ConnectionDependsOn, dep)
+			existingDeps[dep] = struct{}{}
 		}
+	}
+}
 
-		methodBlockType := methodBlock.Labels[0]
-		if !slices.Contains(validMethodBlockTypes, methodBlockType) {
+func (t *TriggerQueue) GetConnectionDependsOn() []string {
+	return t.ConnectionDependsOn
+}
+
+func (t *TriggerQueue) AddUnresolvedAttribute(key string, value hcl.Expression) {
+	t.UnresolvedAttributes[key] = value
+}
+
+func (t *TriggerQueue) GetPipeline() *Pipeline {
+	return nil
+}
+
+func (t *TriggerQueue) GetUnresolvedAttributes() map[string]hcl.Expression {
+	return t.UnresolvedAttributes
+}
+
+func (t *TriggerQueue) GetType() string {
+	return constants.TriggerTypeQueue
+}
+
+// IsJetStream returns true if the messages are received from a JetStream consumer, i.e. they are acked
+func (t *TriggerQueue) IsJetStream() bool {
+	return t.Consumer != ""
+}
+
+func (t *TriggerQueue) Equals(other TriggerConfig) bool {
+	otherTrigger, ok := other.(*TriggerQueue)
+	if !ok {
+		return false
+	}
+
+	if t == nil && !helpers.IsNil(otherTrigger) || t != nil && helpers.IsNil(otherTrigger) {
+		return false
+	}
+
+	if t == nil && helpers.IsNil(otherTrigger) {
+		return true
+	}
+
+	// Compare UnresolvedAttributes (map comparison)
+	if len(t.UnresolvedAttributes) != len(other.GetUnresolvedAttributes()) {
+		return false
+	}
+
+	for key, expr := range t.UnresolvedAttributes {
+		otherExpr, ok := other.GetUnresolvedAttributes()[key]
+		if !ok || !hclhelpers.ExpressionsEqual(expr, otherExpr) {
+			return false
+		}
+	}
+
+	return t.Url == otherTrigger.Url &&
+		t.Subject == otherTrigger.Subject &&
+		t.Stream == otherTrigger.Stream &&
+		t.Consumer == otherTrigger.Consumer
+}
+
+func (t *TriggerQueue) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := trigger.SetBaseAttributes(mod, hclAttributes, evalContext)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	t.Url = constants.DefaultQueueTriggerUrl
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeUrl:
+			// the subscription is started when the trigger is loaded, it needs to be fully resolved
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Url = *val
+
+			if u, err := url.Parse(t.Url); err != nil || u.Host == "" {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid url: " + t.Url + ". Specify the url of the NATS server, e.g. nats://localhost:4222",
+					Subject:  &attr.Range,
+				})
+			}
+
+		case schema.AttributeTypeSubject:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Subject = *val
+
+			if t.Subject == "" {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "The subject of the queue trigger must not be empty",
+					Subject:  &attr.Range,
+				})
+			}
+
+		case constants.AttributeTypeStream:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Stream = *val
+
+		case constants.AttributeTypeConsumer:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Consumer = *val
+
+		default:
+			if !trigger.IsBaseAttribute(name) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unsupported attribute for Trigger Queue: " + attr.Name,
+					Subject:  &attr.Range,
+				})
+			}
+		}
+	}
+
+	if t.Stream != "" && t.Consumer == "" {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The stream of the queue trigger requires a consumer",
+			Detail:   "The messages of a JetStream stream are received from a durable consumer, specify the consumer",
+			Subject:  &trigger.DeclRange,
+		})
+	}
+
+	return diags
+}
+
+func (t *TriggerQueue) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	return diags
+}
+
+// TriggerPipeline runs its pipeline when a root pipeline execution of the upstream pipeline ends with one of the
+// statuses, i.e. not when the upstream pipeline is run by a pipeline step
+type TriggerPipeline struct {
+	Upstream string   `json:"upstream"`
+	Statuses []string `json:"statuses"`
+
+	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
+	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
+}
+
+func (t *TriggerPipeline) GetConfig(evalContext *hcl.EvalContext, mod *modconfig.Mod) (TriggerConfig, error) {
+	return t, nil
+}
+
+func (t *TriggerPipeline) AppendDependsOn(...string) {
+}
+
+func (t *TriggerPipeline) AppendCredentialDependsOn(...string) {
+}
+
+func (t *TriggerPipeline) AppendConnectionDependsOn(connectionDependsOn ...string) {
+	// Use map to track existing DependsOn, this will make the lookup below much faster
+	// rather than using nested loops
+	existingDeps := make(map[string]struct{}, len(t.ConnectionDependsOn))
+	for _, dep := range t.ConnectionDependsOn {
+		existingDeps[dep] = struct{}{}
+	}
+
+	for _, dep := range connectionDependsOn {
+		if _, exists := existingDeps[dep]; !exists {
+			t.ConnectionDependsOn = append(t.ConnectionDependsOn, dep)
+			existingDeps[dep] = struct{}{}
+		}
+	}
+}
+
+func (t *TriggerPipeline) GetConnectionDependsOn() []string {
+	return t.ConnectionDependsOn
+}
+
+func (t *TriggerPipeline) AddUnresolvedAttribute(key string, value hcl.Expression) {
+	t.UnresolvedAttributes[key] = value
+}
+
+func (t *TriggerPipeline) GetPipeline() *Pipeline {
+	return nil
+}
+
+func (t *TriggerPipeline) GetUnresolvedAttributes() map[string]hcl.Expression {
+	return t.UnresolvedAttributes
+}
+
+func (t *TriggerPipeline) GetType() string {
+	return constants.TriggerTypePipeline
+}
+
+// FiresOn returns true if the trigger runs its pipeline when the pipeline ends with the status
+func (t *TriggerPipeline) FiresOn(pipelineName, status string) bool {
+	return t.Upstream == pipelineName && slices.Contains(t.Statuses, status)
+}
+
+// ValidatePipelineTriggers checks that the pipeline triggers don't run each other's upstream pipelines in a cycle,
+// e.g. a trigger on pipeline a running pipeline b and a trigger on pipeline b running pipeline a: the pipelines
+// would run one after the other forever. The disabled triggers are ignored.
+func ValidatePipelineTriggers(triggers map[string]*Trigger) error {
+	// the pipelines run when each pipeline ends, with the trigger running them
+	type edge struct {
+		upstream string
+		pipeline string
+		trigger  string
+	}
+	graph := map[string][]edge{}
+
+	triggerNames := slices.Sorted(maps.Keys(triggers))
+	for _, name := range triggerNames {
+		t := triggers[name]
+		config, ok := t.Config.(*TriggerPipeline)
+		if !ok || (t.Enabled != nil && !*t.Enabled) {
+			continue
+		}
+		if t.Pipeline.IsNull() || !t.Pipeline.Type().IsObjectType() || !t.Pipeline.Type().HasAttribute("name") {
+			continue
+		}
+
+		graph[config.Upstream] = append(graph[config.Upstream], edge{upstream: config.Upstream, pipeline: t.Pipeline.GetAttr("name").AsString(), trigger: name})
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []edge
+
+	var visit func(pipeline string) []edge
+	visit = func(pipeline string) []edge {
+		state[pipeline] = visiting
+		for _, e := range graph[pipeline] {
+			path = append(path, e)
+			switch state[e.pipeline] {
+			case visiting:
+				// the cycle starts with the trigger on e.pipeline
+				i := slices.IndexFunc(path, func(p edge) bool {
+					return p.upstream == e.pipeline
+				})
+				return path[i:]
+			case 0:
+				if cycle := visit(e.pipeline); cycle != nil {
+					return cycle
+				}
+			}
+			path = path[:len(path)-1]
+		}
+		state[pipeline] = visited
+		return nil
+	}
+
+	for _, pipeline := range slices.Sorted(maps.Keys(graph)) {
+		if state[pipeline] != 0 {
+			continue
+		}
+
+		path = nil
+		cycle := visit(pipeline)
+		if cycle == nil {
+			continue
+		}
+
+		var cycleTriggers []string
+		for _, e := range cycle {
+			declRange := triggers[e.trigger].DeclRange
+			cycleTriggers = append(cycleTriggers, fmt.Sprintf("%s (%s:%d)", e.trigger, declRange.Filename, declRange.Start.Line))
+		}
+		return perr.BadRequestWithMessage("The pipeline triggers run their upstream pipelines in a cycle: " + strings.Join(cycleTriggers, ", "))
+	}
+
+	return nil
+}
+
+func (t *TriggerPipeline) Equals(other TriggerConfig) bool {
+	otherTrigger, ok := other.(*TriggerPipeline)
+	if !ok {
+		return false
+	}
+
+	if t == nil && !helpers.IsNil(otherTrigger) || t != nil && helpers.IsNil(otherTrigger) {
+		return false
+	}
+
+	if t == nil && helpers.IsNil(otherTrigger) {
+		return true
+	}
+
+	// Compare UnresolvedAttributes (map comparison)
+	if len(t.UnresolvedAttributes) != len(other.GetUnresolvedAttributes()) {
+		return false
+	}
+
+	for key, expr := range t.UnresolvedAttributes {
+		otherExpr, ok := other.GetUnresolvedAttributes()[key]
+		if !ok || !hclhelpers.ExpressionsEqual(expr, otherExpr) {
+			return false
+		}
+	}
+
+	return t.Upstream == otherTrigger.Upstream &&
+		slices.Equal(t.Statuses, otherTrigger.Statuses)
+}
+
+// the statuses of the pipeline execution (canceled) rather than of the execution (cancelled)
+var validPipelineStatuses = []string{constants.PipelineStatusFinished, constants.PipelineStatusFailed, constants.PipelineStatusCanceled}
+
+func (t *TriggerPipeline) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := trigger.SetBaseAttributes(mod, hclAttributes, evalContext)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	// fire on all the statuses by default
+	t.Statuses = slices.Clone(validPipelineStatuses)
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case constants.AttributeTypeUpstream:
+			val, moreDiags := attr.Expr.Value(evalContext)
+			if len(moreDiags) > 0 {
+				// the upstream pipeline may not be parsed yet, see the pipeline attribute in SetBaseAttributes
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			if !val.Type().IsObjectType() || !val.Type().HasAttribute("name") {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "The upstream of the pipeline trigger must be a pipeline",
+					Detail:   "Specify the upstream pipeline, e.g. upstream = pipeline.etl",
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+
+			t.Upstream = val.GetAttr("name").AsString()
+
+			// the trigger would run its pipeline over and over again
+			if !trigger.Pipeline.IsNull() && trigger.Pipeline.Type().IsObjectType() && trigger.Pipeline.Type().HasAttribute("name") &&
+				trigger.Pipeline.GetAttr("name").AsString() == t.Upstream {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "The pipeline trigger can't run its upstream pipeline: " + t.Upstream,
+					Subject:  &attr.Range,
+				})
+			}
+
+		case constants.AttributeTypeStatuses:
+			val, moreDiags := attr.Expr.Value(evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			statuses, err := hclhelpers.CtyToGoStringSlice(val, val.Type())
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unable to parse " + constants.AttributeTypeStatuses + " attribute to a list of strings",
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+
+			for _, s := range statuses {
+				if !slices.Contains(validPipelineStatuses, s) {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Invalid pipeline status: " + s + ". Specify " + strings.Join(validPipelineStatuses, ", "),
+						Subject:  &attr.Range,
+					})
+				}
+			}
+
+			t.Statuses = statuses
+
+		default:
+			if !trigger.IsBaseAttribute(name) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unsupported attribute for Trigger Pipeline: " + attr.Name,
+					Subject:  &attr.Range,
+				})
+			}
+		}
+	}
+	return diags
+}
+
+func (t *TriggerPipeline) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	return diags
+}
+
+// TriggerEmail polls an IMAP mailbox on its schedule and runs its pipeline for each unseen message. The processed
+// messages are flagged as seen, or moved to the move_to mailbox, so that they are not processed again.
+type TriggerEmail struct {
+	Schedule string `json:"schedule"`
+
+	// The url of the IMAP server, imaps://host for TLS (port 993 by default) or imap://host (port 143 by default)
+	Url      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"-"`
+
+	Mailbox string `json:"mailbox"`
+	MoveTo  string `json:"move_to,omitempty"`
+
+	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
+	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
+}
+
+func (t *TriggerEmail) GetConfig(evalContext *hcl.EvalContext, mod *modconfig.Mod) (TriggerConfig, error) {
+	return t, nil
+}
+
+func (t *TriggerEmail) AppendDependsOn(...string) {
+}
+
+func (t *TriggerEmail) AppendCredentialDependsOn(...string) {
+}
+
+func (t *TriggerEmail) AppendConnectionDependsOn(connectionDependsOn ...string) {
+	// Use map to track existing DependsOn, this will make the lookup below much faster
+	// rather than using nested loops
+	existingDeps := make(map[string]struct{}, len(t.ConnectionDependsOn))
+	for _, dep := range t.ConnectionDependsOn {
+		existingDeps[dep] = struct{}{}
+	}
+
+	for _, dep := range connectionDependsOn {
+		if _, exists := existingDeps[dep]; !exists {
+			t.ConnectionDependsOn = append(t.ConnectionDependsOn, dep)
+			existingDeps[dep] = struct{}{}
+		}
+	}
+}
+
+func (t *TriggerEmail) GetConnectionDependsOn() []string {
+	return t.ConnectionDependsOn
+}
+
+func (t *TriggerEmail) AddUnresolvedAttribute(key string, value hcl.Expression) {
+	t.UnresolvedAttributes[key] = value
+}
+
+func (t *TriggerEmail) GetPipeline() *Pipeline {
+	return nil
+}
+
+func (t *TriggerEmail) GetUnresolvedAttributes() map[string]hcl.Expression {
+	return t.UnresolvedAttributes
+}
+
+func (t *TriggerEmail) GetType() string {
+	return constants.TriggerTypeEmail
+}
+
+// GetAddress returns the host:port of the IMAP server and whether the connection uses TLS
+func (t *TriggerEmail) GetAddress() (string, bool, error) {
+	u, err := url.Parse(t.Url)
+	if err != nil {
+		return "", false, err
+	}
+
+	useTLS := u.Scheme == "imaps"
+	port := u.Port()
+	if port == "" {
+		port = "143"
+		if useTLS {
+			port = "993"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+func (t *TriggerEmail) Equals(other TriggerConfig) bool {
+	otherTrigger, ok := other.(*TriggerEmail)
+	if !ok {
+		return false
+	}
+
+	if t == nil && !helpers.IsNil(otherTrigger) || t != nil && helpers.IsNil(otherTrigger) {
+		return false
+	}
+
+	if t == nil && helpers.IsNil(otherTrigger) {
+		return true
+	}
+
+	// Compare UnresolvedAttributes (map comparison)
+	if len(t.UnresolvedAttributes) != len(other.GetUnresolvedAttributes()) {
+		return false
+	}
+
+	for key, expr := range t.UnresolvedAttributes {
+		otherExpr, ok := other.GetUnresolvedAttributes()[key]
+		if !ok || !hclhelpers.ExpressionsEqual(expr, otherExpr) {
+			return false
+		}
+	}
+
+	return t.Schedule == otherTrigger.Schedule &&
+		t.Url == otherTrigger.Url &&
+		t.Username == otherTrigger.Username &&
+		t.Password == otherTrigger.Password &&
+		t.Mailbox == otherTrigger.Mailbox &&
+		t.MoveTo == otherTrigger.MoveTo
+}
+
+var validEmailSchemes = []string{"imap", "imaps"}
+
+func (t *TriggerEmail) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := trigger.SetBaseAttributes(mod, hclAttributes, evalContext)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	t.Mailbox = constants.DefaultEmailTriggerMailbox
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeSchedule:
+			// schedule should never be an unresolved variable, it needs to be fully resolved
+			val, moreDiags := attr.Expr.Value(evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			if val.Type() != cty.String {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "The given schedule is not a string",
+					Detail:   "The given schedule is not a string",
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+
+			t.Schedule = val.AsString()
+
+			if slices.Contains(validIntervals, t.Schedule) {
+				continue
+			}
+
+			// if it's not an interval, assume it's a cron and attempt to validate the cron expression
+			_, err := cron.ParseStandard(t.Schedule)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid cron expression: " + t.Schedule + ". Specify valid intervals hourly, daily, weekly, monthly or valid cron expression",
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+			}
+
+		case schema.AttributeTypeUrl:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Url = *val
+
+			if u, err := url.Parse(t.Url); err != nil || u.Host == "" || !slices.Contains(validEmailSchemes, u.Scheme) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid url: " + t.Url + ". Specify the url of the IMAP server, e.g. imaps://imap.example.com",
+					Subject:  &attr.Range,
+				})
+			}
+
+		case schema.AttributeTypeUsername:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Username = *val
+
+		case schema.AttributeTypePassword:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Password = *val
+
+		case constants.AttributeTypeMailbox:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.Mailbox = *val
+
+			if t.Mailbox == "" {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "The mailbox of the email trigger must not be empty",
+					Subject:  &attr.Range,
+				})
+			}
+
+		case constants.AttributeTypeMoveTo:
+			val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+			if moreDiags.HasErrors() {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			t.MoveTo = *val
+
+		default:
+			if !trigger.IsBaseAttribute(name) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unsupported attribute for Trigger Email: " + attr.Name,
+					Subject:  &attr.Range,
+				})
+			}
+		}
+	}
+
+	if t.MoveTo != "" && t.MoveTo == t.Mailbox {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The email trigger can't move the processed messages to its mailbox: " + t.Mailbox,
+			Subject:  &trigger.DeclRange,
+		})
+	}
+
+	return diags
+}
+
+func (t *TriggerEmail) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	return diags
+}
+
+type TriggerHttp struct {
+	Url           string                        `json:"url"`
+	ExecutionMode string                        `json:"execution_mode"`
+	Methods       map[string]*TriggerHTTPMethod `json:"methods"`
+	Signature     *TriggerHTTPSignature         `json:"signature,omitempty"`
+
+	UnresolvedAttributes map[string]hcl.Expression `json:"-"`
+	ConnectionDependsOn  []string                  `json:"connection_depends_on,omitempty"`
+}
+
+func (t *TriggerHttp) AppendsDependsOn(dependsOn ...string) {
+}
+
+func (t *TriggerHttp) AppendCredentialDependsOn(...string) {
+}
+
+func (t *TriggerHttp) AppendConnectionDependsOn(connectionDependsOn ...string) {
+	// Use map to track existing DependsOn, this will make the lookup below much faster
+	// rather than using nested loops
+	existingDeps := make(map[string]struct{}, len(t.ConnectionDependsOn))
+	for _, dep := range t.ConnectionDependsOn {
+		existingDeps[dep] = struct{}{}
+	}
+
+	for _, dep := range connectionDependsOn {
+		if _, exists := existingDeps[dep]; !exists {
+			t.ConnectionDependsOn = append(t.ConnectionDependsOn, dep)
+			existingDeps[dep] = struct{}{}
+		}
+	}
+}
+
+func (t *TriggerHttp) GetConnectionDependsOn() []string {
+	return t.ConnectionDependsOn
+}
+
+func (t *TriggerHttp) AddUnresolvedAttribute(key string, value hcl.Expression) {
+	t.UnresolvedAttributes[key] = value
+}
+
+func (t *TriggerHttp) GetPipeline() *Pipeline {
+	return nil
+}
+
+func (t *TriggerHttp) GetUnresolvedAttributes() map[string]hcl.Expression {
+	return t.UnresolvedAttributes
+}
+
+func (t *TriggerHttp) GetType() string {
+	return schema.TriggerTypeHttp
+}
+
+func (t *TriggerHttp) GetConfig(*hcl.EvalContext, *modconfig.Mod) (TriggerConfig, error) {
+	return t, nil
+}
+
+func (t *TriggerHttp) Equals(other TriggerConfig) bool {
+	otherTrigger, ok := other.(*TriggerHttp)
+	if !ok {
+		return false
+	}
+
+	if t == nil && !helpers.IsNil(otherTrigger) || t != nil && helpers.IsNil(otherTrigger) {
+		return false
+	}
+
+	if t == nil && helpers.IsNil(otherTrigger) {
+		return true
+	}
+
+	if t.Url != otherTrigger.Url {
+		return false
+	}
+
+	if t.ExecutionMode != otherTrigger.ExecutionMode {
+		return false
+	}
+
+	if len(t.Methods) != len(otherTrigger.Methods) {
+		return false
+	}
+
+	if !t.Signature.Equals(otherTrigger.Signature) {
+		return false
+	}
+
+	// Compare UnresolvedAttributes (map comparison)
+	if len(t.UnresolvedAttributes) != len(other.GetUnresolvedAttributes()) {
+		return false
+	}
+
+	for key, expr := range t.UnresolvedAttributes {
+		otherExpr, ok := other.GetUnresolvedAttributes()[key]
+		if !ok || !hclhelpers.ExpressionsEqual(expr, otherExpr) {
+			return false
+		}
+	}
+
+	for key, value := range t.Methods {
+		otherValue, exists := otherTrigger.Methods[key]
+		if !exists {
+			return false
+		}
+
+		if !value.Equals(otherValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type TriggerHTTPMethod struct {
+	Type          string
+	ExecutionMode string
+	Pipeline      cty.Value
+	ArgsRaw       hcl.Expression
+}
+
+func (c *TriggerHTTPMethod) Equals(other *TriggerHTTPMethod) bool {
+	if c == nil && other == nil {
+		return true
+	}
+
+	if c == nil && other != nil || c != nil && other == nil {
+		return false
+	}
+
+	if c.Type != other.Type || c.ExecutionMode != other.ExecutionMode {
+		return false
+	}
+
+	if c.Pipeline.Equals(other.Pipeline).False() {
+		return false
+	}
+
+	if !reflect.DeepEqual(c.ArgsRaw, other.ArgsRaw) {
+		return false
+	}
+
+	return true
+}
+
+var validExecutionMode = []string{"synchronous", "asynchronous"}
+var validMethodBlockTypes = []string{"post", "get"}
+
+func (t *TriggerHttp) SetAttributes(mod *modconfig.Mod, trigger *Trigger, hclAttributes hcl.Attributes, evalContext *hcl.EvalContext) hcl.Diagnostics {
+
+	// None of the Trigger Http attributes should be unresolved at parse time. It doesn't make sense to have params for the URL for example
+
+	diags := trigger.SetBaseAttributes(mod, hclAttributes, evalContext)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for name, attr := range hclAttributes {
+		switch name {
+		case schema.AttributeTypeExecutionMode:
+			val, moreDiags := attr.Expr.Value(evalContext)
+			if len(moreDiags) > 0 {
+				diags = append(diags, moreDiags...)
+				continue
+			}
+
+			if val.Type() != cty.String {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "The given execution mode is not a string",
+					Detail:   "The given execution mode is not a string",
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+
+			t.ExecutionMode = val.AsString()
+
+			if !slices.Contains(validExecutionMode, t.ExecutionMode) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid execution mode",
+					Detail:   "The execution mode must be one of: " + strings.Join(validExecutionMode, ","),
+					Subject:  &attr.Range,
+				})
+			}
+
+		default:
+			if !trigger.IsBaseAttribute(name) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unsupported attribute for Trigger Interval: " + attr.Name,
+					Subject:  &attr.Range,
+				})
+			}
+		}
+	}
+
+	return diags
+}
+
+func (t *TriggerHttp) SetBlocks(mod *modconfig.Mod, trigger *Trigger, hclBlocks hcl.Blocks, evalContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	t.Methods = make(map[string]*TriggerHTTPMethod)
+
+	var methodBlocks hcl.Blocks
+	for _, block := range hclBlocks {
+		if block.Type != constants.BlockTypeSignature {
+			methodBlocks = append(methodBlocks, block)
+			continue
+		}
+
+		if t.Signature != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate signature block",
+				Subject:  &block.DefRange,
+			})
+			continue
+		}
+
+		signature, moreDiags := decodeTriggerHTTPSignature(block, evalContext)
+		if moreDiags.HasErrors() {
+			diags = append(diags, moreDiags...)
+			continue
+		}
+		t.Signature = signature
+	}
+
+	if diags.HasErrors() {
+		return diags
+	}
+
+	// If no method blocks appear, only 'post' is supported, and the top-level `pipeline`, `args` and `execution_mode` will be applied
+	if len(methodBlocks) == 0 {
+		triggerMethod := &TriggerHTTPMethod{
+			Type: HttpMethodPost,
+		}
+
+		// Get the top-level pipeline
+		pipeline := trigger.GetPipeline()
+		if pipeline == cty.NilVal {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Bad Request",
+				Detail:   "Missing required attribute 'pipeline'",
+			})
+			return diags
+		}
+		triggerMethod.Pipeline = pipeline
+
+		// Get the top-level args
+		pipelineArgs := trigger.ArgsRaw
+		if pipelineArgs != nil {
+			triggerMethod.ArgsRaw = pipelineArgs
+		}
+
+		// Get the top-level execution_mode
+		if t.ExecutionMode != "" {
+			triggerMethod.ExecutionMode = t.ExecutionMode
+		}
+
+		t.Methods[HttpMethodPost] = triggerMethod
+
+		return diags
+	}
+
+	// If the method blocks provided, we will consider the configuration provided in the method block
+	for _, methodBlock := range methodBlocks {
+
+		if len(methodBlock.Labels) != 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid method block",
+				Detail:   "Method block must have a single label",
+				Subject:  &methodBlock.DefRange,
+			})
+			continue
+		}
+
+		methodBlockType := methodBlock.Labels[0]
+		if !slices.Contains(validMethodBlockTypes, methodBlockType) {
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Invalid method block type",
@@ -1250,12 +2328,14 @@ func NewTrigger(block *hcl.Block, mod *modconfig.Mod, triggerType, triggerName s
 			DeclRange:       block.DefRange,
 			BlockType:       block.Type,
 		},
-		mod: mod,
+		mod:     mod,
+		Overlap: constants.DefaultOverlap,
 	}
 
 	switch triggerType {
 	case schema.TriggerTypeSchedule:
 		trigger.Config = &TriggerSchedule{
+			CatchUp:              constants.DefaultCatchUp,
 			UnresolvedAttributes: make(map[string]hcl.Expression),
 		}
 	case schema.TriggerTypeQuery:
@@ -1266,6 +2346,22 @@ func NewTrigger(block *hcl.Block, mod *modconfig.Mod, triggerType, triggerName s
 		trigger.Config = &TriggerHttp{
 			UnresolvedAttributes: make(map[string]hcl.Expression),
 		}
+	case constants.TriggerTypeFile:
+		trigger.Config = &TriggerFile{
+			UnresolvedAttributes: make(map[string]hcl.Expression),
+		}
+	case constants.TriggerTypeQueue:
+		trigger.Config = &TriggerQueue{
+			UnresolvedAttributes: make(map[string]hcl.Expression),
+		}
+	case constants.TriggerTypePipeline:
+		trigger.Config = &TriggerPipeline{
+			UnresolvedAttributes: make(map[string]hcl.Expression),
+		}
+	case constants.TriggerTypeEmail:
+		trigger.Config = &TriggerEmail{
+			UnresolvedAttributes: make(map[string]hcl.Expression),
+		}
 	default:
 		return nil
 	}
@@ -1282,6 +2378,14 @@ func GetTriggerTypeFromTriggerConfig(config TriggerConfig) string {
 		return schema.TriggerTypeQuery
 	case *TriggerHttp:
 		return schema.TriggerTypeHttp
+	case *TriggerFile:
+		return constants.TriggerTypeFile
+	case *TriggerQueue:
+		return constants.TriggerTypeQueue
+	case *TriggerPipeline:
+		return constants.TriggerTypePipeline
+	case *TriggerEmail:
+		return constants.TriggerTypeEmail
 	}
 
 	return ""
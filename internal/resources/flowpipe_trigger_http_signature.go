@@ -0,0 +1,208 @@
+package resources
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // sha1 HMAC signatures are still sent by some webhook providers
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+var validSignatureAlgorithms = []string{constants.SignatureAlgorithmSha1, constants.SignatureAlgorithmSha256}
+var validSignatureFormats = []string{constants.SignatureFormatHex, constants.SignatureFormatGithub, constants.SignatureFormatStripe}
+
+// TriggerHTTPSignature is the HMAC signature of the requests of an HTTP trigger, computed with the shared secret over
+// the raw request body
+type TriggerHTTPSignature struct {
+	Secret    string `json:"-"`
+	Header    string `json:"header"`
+	Algorithm string `json:"algorithm"`
+	Format    string `json:"format"`
+	Tolerance string `json:"tolerance"`
+}
+
+func (s *TriggerHTTPSignature) Equals(other *TriggerHTTPSignature) bool {
+	if s == nil && other == nil {
+		return true
+	}
+
+	if s == nil && other != nil || s != nil && other == nil {
+		return false
+	}
+
+	return s.Secret == other.Secret &&
+		s.Header == other.Header &&
+		s.Algorithm == other.Algorithm &&
+		s.Format == other.Format &&
+		s.Tolerance == other.Tolerance
+}
+
+// GetTolerance returns how far the timestamp of a signed request can be from now, the requests signed with an older
+// (or newer) timestamp are rejected to prevent replay attacks
+func (s *TriggerHTTPSignature) GetTolerance() time.Duration {
+	tolerance, err := time.ParseDuration(s.Tolerance)
+	if err != nil {
+		// validated when the trigger is parsed
+		tolerance, _ = time.ParseDuration(constants.DefaultSignatureTolerance)
+	}
+	return tolerance
+}
+
+// Verify checks the signature header of the request against the HMAC of the raw request body, the returned error is
+// an unauthorized error. The signature header is either the hex digest, the digest prefixed with the algorithm as sent
+// by GitHub (sha256=<hex>) or the timestamped digest as sent by Stripe (t=<unix>,v1=<hex>).
+func (s *TriggerHTTPSignature) Verify(header http.Header, body []byte, now time.Time) error {
+	value := strings.TrimSpace(header.Get(s.Header))
+	if value == "" {
+		return perr.UnauthorizedWithMessage("missing signature header " + s.Header)
+	}
+
+	switch s.Format {
+	case constants.SignatureFormatGithub:
+		prefix := s.Algorithm + "="
+		if !strings.HasPrefix(value, prefix) || !s.validSignature(body, strings.TrimPrefix(value, prefix)) {
+			return perr.UnauthorizedWithMessage("invalid signature")
+		}
+
+	case constants.SignatureFormatStripe:
+		var timestamp string
+		var signatures []string
+		for _, part := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = val
+			case "v1":
+				// there are more than one signature while the secret is rolled
+				signatures = append(signatures, val)
+			}
+		}
+
+		unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return perr.UnauthorizedWithMessage("invalid signature timestamp")
+		}
+
+		age := now.Sub(time.Unix(unixTime, 0))
+		if age < 0 {
+			age = -age
+		}
+		if age > s.GetTolerance() {
+			return perr.UnauthorizedWithMessage("signature timestamp is outside the tolerance")
+		}
+
+		// the timestamp is signed with the body
+		payload := append([]byte(timestamp+"."), body...)
+		if !slices.ContainsFunc(signatures, func(signature string) bool {
+			return s.validSignature(payload, signature)
+		}) {
+			return perr.UnauthorizedWithMessage("invalid signature")
+		}
+
+	default:
+		if !s.validSignature(body, value) {
+			return perr.UnauthorizedWithMessage("invalid signature")
+		}
+	}
+
+	return nil
+}
+
+func (s *TriggerHTTPSignature) validSignature(payload []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(s.Sign(payload), expected)
+}
+
+// Sign returns the HMAC of the payload
+func (s *TriggerHTTPSignature) Sign(payload []byte) []byte {
+	var hashFunc func() hash.Hash = sha256.New
+	if s.Algorithm == constants.SignatureAlgorithmSha1 {
+		hashFunc = sha1.New
+	}
+
+	mac := hmac.New(hashFunc, []byte(s.Secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func decodeTriggerHTTPSignature(block *hcl.Block, evalContext *hcl.EvalContext) (*TriggerHTTPSignature, hcl.Diagnostics) {
+	signature := &TriggerHTTPSignature{
+		Algorithm: constants.DefaultSignatureAlgorithm,
+		Format:    constants.DefaultSignatureFormat,
+		Tolerance: constants.DefaultSignatureTolerance,
+	}
+
+	content, diags := block.Body.Content(TriggerHttpSignatureBlockSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	for name, attr := range content.Attributes {
+		val, moreDiags := hclhelpers.AttributeToString(attr, evalContext, true)
+		if moreDiags.HasErrors() {
+			diags = append(diags, moreDiags...)
+			continue
+		}
+
+		switch name {
+		case constants.AttributeTypeSecret:
+			signature.Secret = *val
+		case constants.AttributeTypeHeader:
+			signature.Header = *val
+		case constants.AttributeTypeAlgorithm:
+			signature.Algorithm = *val
+			if !slices.Contains(validSignatureAlgorithms, signature.Algorithm) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid signature algorithm",
+					Detail:   "The signature algorithm must be one of: " + strings.Join(validSignatureAlgorithms, ","),
+					Subject:  &attr.Range,
+				})
+			}
+		case schema.AttributeTypeFormat:
+			signature.Format = *val
+			if !slices.Contains(validSignatureFormats, signature.Format) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid signature format",
+					Detail:   "The signature format must be one of: " + strings.Join(validSignatureFormats, ","),
+					Subject:  &attr.Range,
+				})
+			}
+		case constants.AttributeTypeTolerance:
+			signature.Tolerance = *val
+			if _, err := time.ParseDuration(signature.Tolerance); err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid signature tolerance: " + signature.Tolerance + ". Specify a duration, e.g. 5m",
+					Detail:   err.Error(),
+					Subject:  &attr.Range,
+				})
+			}
+		}
+	}
+
+	if signature.Secret == "" && !diags.HasErrors() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The signature secret must not be empty",
+			Subject:  &block.DefRange,
+		})
+	}
+
+	return signature, diags
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/flowpipe/internal/es/db"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/service/api/common"
+	"github.com/turbot/flowpipe/internal/trigger"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// @Summary Dry run a trigger
+// @Description Resolve the pipelines a trigger would queue and their args, without queueing them
+// @ID   trigger_dry_run
+// @Tags Trigger
+// @Accept json
+// @Produce json
+// / ...
+// @Param trigger_name path string true "The name of the trigger" format(^[a-z_]{0,32}$)
+// @Param request body types.CmdDryRun true "Trigger args."
+// ...
+// @Success 200 {object} types.FpTriggerDryRun
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /trigger/{trigger_name}/dry_run [post]
+func (api *APIService) dryRunTrigger(c *gin.Context) {
+	var uri types.TriggerRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	var input types.CmdDryRun
+	if err := c.ShouldBindJSON(&input); err != nil {
+		slog.Error("error binding input", "error", err)
+		common.AbortWithError(c, perr.BadRequestWithMessage(err.Error()))
+		return
+	}
+
+	result, err := DryRunTrigger(c, input, ConstructTriggerFullyQualifiedName(uri.TriggerName))
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DryRunTrigger runs the trigger without queueing its pipelines nor changing its state, e.g. a query trigger compares
+// the rows returned by its query with the captured rows but doesn't capture them
+func DryRunTrigger(ctx context.Context, input types.CmdDryRun, triggerName string) (*types.FpTriggerDryRun, error) {
+	if len(input.Args) > 0 && len(input.ArgsString) > 0 {
+		return nil, perr.BadRequestWithMessage("args and args_string are mutually exclusive")
+	}
+
+	trg, err := db.GetTrigger(triggerName)
+	if err != nil {
+		if perr.IsNotFound(err) {
+			return nil, perr.NotFoundWithMessage("unable to find trigger " + triggerName)
+		}
+		return nil, err
+	}
+
+	triggerRunner := trigger.NewTriggerRunner(trg, util.NewExecutionId(), util.NewTriggerExecutionId())
+	if triggerRunner == nil {
+		return nil, perr.BadRequestWithMessage("unable to dry run trigger " + triggerName)
+	}
+	triggerRunner.SetDryRun(true)
+
+	pipelineCmds, err := triggerRunner.GetPipelineQueuesWithArgs(ctx, input.Args, input.ArgsString)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.FpTriggerDryRun{
+		Name:      trg.Name(),
+		Type:      trg.Config.GetType(),
+		Pipelines: []types.FpTriggerDryRunPipeline{},
+	}
+
+	for _, cmd := range pipelineCmds {
+		result.Pipelines = append(result.Pipelines, types.FpTriggerDryRunPipeline{
+			Pipeline: cmd.Name,
+			Capture:  cmd.TriggerCapture,
+			Args:     cmd.Args,
+		})
+	}
+
+	if queryTriggerRunner, ok := triggerRunner.(*trigger.TriggerRunnerQuery); ok {
+		result.QueryStat = queryTriggerRunner.QueryStat
+		if result.QueryStat == nil {
+			// the query returned no rows
+			result.QueryStat = map[string]int{"insert": 0, "update": 0, "delete": 0}
+		}
+	}
+
+	return result, nil
+}
+
+// @Summary Dry run a pipeline
+// @Description Validate the pipeline args and resolve the steps the pipeline would run, without running them
+// @ID   pipeline_dry_run
+// @Tags Pipeline
+// @Accept json
+// @Produce json
+// / ...
+// @Param pipeline_name path string true "The name of the pipeline" format(^[a-z_]{0,32}$)
+// @Param request body types.CmdDryRun true "Pipeline args."
+// ...
+// @Success 200 {object} types.FpPipelineDryRun
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /pipeline/{pipeline_name}/dry_run [post]
+func (api *APIService) dryRunPipeline(c *gin.Context) {
+	var uri types.PipelineRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	var input types.CmdDryRun
+	if err := c.ShouldBindJSON(&input); err != nil {
+		slog.Error("error binding input", "error", err)
+		common.AbortWithError(c, perr.BadRequestWithMessage(err.Error()))
+		return
+	}
+
+	result, err := DryRunPipeline(input, ConstructPipelineFullyQualifiedName(uri.PipelineName))
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DryRunPipeline validates the pipeline args and resolves the steps the pipeline would run without running them. The
+// pipeline is evaluated as it is when it starts, i.e. with its params, variables and locals but without any step
+// output.
+func DryRunPipeline(input types.CmdDryRun, pipelineName string) (*types.FpPipelineDryRun, error) {
+	if len(input.Args) > 0 && len(input.ArgsString) > 0 {
+		return nil, perr.BadRequestWithMessage("args and args_string are mutually exclusive")
+	}
+
+	pipelineDefn, err := db.GetPipeline(pipelineName)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := validatePipelineArgs(pipelineDefn, input.Args, input.ArgsString)
+	if err != nil {
+		return nil, err
+	}
+
+	pe := &execution.PipelineExecution{
+		ID:         util.NewPipelineExecutionId(),
+		Name:       pipelineDefn.Name(),
+		Args:       args,
+		StepStatus: map[string]map[string]*execution.StepStatus{},
+	}
+	ex := &execution.Execution{
+		ID:                 util.NewExecutionId(),
+		PipelineExecutions: map[string]*execution.PipelineExecution{pe.ID: pe},
+	}
+
+	evalContext, err := ex.BuildEvalContext(pipelineDefn, pe)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := pipelineStepStages(pipelineDefn)
+
+	result := &types.FpPipelineDryRun{
+		Pipeline: pipelineDefn.Name(),
+		Args:     args,
+		Steps:    []types.FpPipelineDryRunStep{},
+	}
+
+	for _, stepDefn := range pipelineDefn.Steps {
+		step := types.FpPipelineDryRunStep{
+			Name:      stepDefn.GetFullyQualifiedName(),
+			Type:      stepDefn.GetType(),
+			Stage:     stages[stepDefn.GetFullyQualifiedName()],
+			DependsOn: stepDependencies(pipelineDefn, stepDefn),
+			ForEach:   !helpers.IsNil(stepDefn.GetForEach()),
+		}
+
+		switch {
+		case step.ForEach:
+			step.Unresolved = "the input is resolved for each item when the pipeline runs"
+		case len(stepDefn.GetCredentialDependsOn()) > 0:
+			step.Unresolved = "the input uses credentials resolved when the pipeline runs"
+		default:
+			if expr := stepDefn.GetUnresolvedAttributes()[schema.AttributeTypeIf]; expr != nil {
+				val, diags := expr.Value(evalContext)
+				if diags.HasErrors() {
+					step.Unresolved = "the if condition is resolved when the pipeline runs: " + error_helpers.HclDiagsToError(step.Name, diags).Error()
+					break
+				}
+				if val.IsKnown() && !val.IsNull() && val.Type() == cty.Bool && val.False() {
+					step.Skip = true
+					break
+				}
+			}
+
+			stepInputs, connDepend, err := stepDefn.GetInputs2(evalContext)
+			if err != nil {
+				// the output of the other steps is not in the eval context
+				if len(step.DependsOn) > 0 {
+					step.Unresolved = "the input uses the output of " + strings.Join(step.DependsOn, ", ") + " resolved when the pipeline runs"
+				} else {
+					step.Unresolved = "the input is resolved when the pipeline runs: " + err.Error()
+				}
+				break
+			}
+			if len(connDepend) > 0 {
+				step.Unresolved = "the input uses connections resolved when the pipeline runs"
+				break
+			}
+			step.Input = stepInputs
+		}
+
+		result.Steps = append(result.Steps, step)
+	}
+
+	// the steps in the order they would start, the steps of a stage in the order they are defined
+	sort.SliceStable(result.Steps, func(i, j int) bool {
+		return result.Steps[i].Stage < result.Steps[j].Stage
+	})
+
+	return result, nil
+}
+
+// stepDependencies returns the steps of the pipeline the step depends on
+func stepDependencies(pipelineDefn *resources.Pipeline, stepDefn resources.PipelineStep) []string {
+	var dependsOn []string
+	for _, dep := range stepDefn.GetDependsOn() {
+		// the pipeline planner ignores the step itself and the unknown steps
+		if dep == stepDefn.GetFullyQualifiedName() || pipelineDefn.GetStep(dep) == nil {
+			continue
+		}
+		dependsOn = append(dependsOn, dep)
+	}
+	sort.Strings(dependsOn)
+	return dependsOn
+}
+
+// pipelineStepStages returns the stage of each step of the pipeline, starting at 1 for the steps without dependencies.
+// A step starts once all the steps it depends on are complete, i.e. in the stage after its latest dependency.
+func pipelineStepStages(pipelineDefn *resources.Pipeline) map[string]int {
+	stages := map[string]int{}
+	visiting := map[string]bool{}
+
+	var stageOf func(stepDefn resources.PipelineStep) int
+	stageOf = func(stepDefn resources.PipelineStep) int {
+		name := stepDefn.GetFullyQualifiedName()
+		if stage, ok := stages[name]; ok {
+			return stage
+		}
+
+		// a dependency cycle never starts, don't recurse forever
+		if visiting[name] {
+			return 0
+		}
+		visiting[name] = true
+
+		stage := 1
+		for _, dep := range stepDependencies(pipelineDefn, stepDefn) {
+			if depStage := stageOf(pipelineDefn.GetStep(dep)) + 1; depStage > stage {
+				stage = depStage
+			}
+		}
+
+		stages[name] = stage
+		return stage
+	}
+
+	for _, stepDefn := range pipelineDefn.Steps {
+		stageOf(stepDefn)
+	}
+
+	return stages
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/tests/test_init"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+// loadDryRunPipelines caches the pipelines of the dry run test file as the mod loader does
+func loadDryRunPipelines(t *testing.T) {
+	test_init.SetAppSpecificConstants()
+
+	pipelines, _, err := parse.LoadPipelines(context.Background(), "./pipelines/dry_run.fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pipelineNames []string
+	for name, p := range pipelines {
+		cache.GetCache().SetWithTTL(name, p, 10*time.Minute)
+		pipelineNames = append(pipelineNames, name)
+	}
+	cache.GetCache().SetWithTTL("#pipeline.names", pipelineNames, 10*time.Minute)
+	cache.GetCache().SetWithTTL("#rootmod.name", "local", 10*time.Minute)
+}
+
+// stepStages returns the stage of each step of the dry run in the order of the dry run
+func stepStages(result *types.FpPipelineDryRun) ([]string, map[string]int) {
+	var names []string
+	stages := map[string]int{}
+	for _, step := range result.Steps {
+		names = append(names, step.Name)
+		stages[step.Name] = step.Stage
+	}
+	return names, stages
+}
+
+func TestPipelineStepStages(t *testing.T) {
+	assert := assert.New(t)
+
+	loadDryRunPipelines(t)
+
+	result, err := DryRunPipeline(types.CmdDryRun{}, "local.pipeline.etl")
+	if !assert.Nil(err) {
+		return
+	}
+
+	names, stages := stepStages(result)
+
+	// a step is in the stage after its latest dependency, explicit with depends_on or implicit with the output of a step
+	assert.Equal(map[string]int{
+		"transform.extract": 1,
+		"transform.load":    2,
+		"transform.report":  3,
+		"transform.audit":   4,
+		"transform.notify":  1,
+		"transform.cleanup": 1,
+	}, stages)
+
+	// the steps in the order they would start, the steps of a stage in the order they are defined
+	assert.Equal([]string{
+		"transform.extract",
+		"transform.notify",
+		"transform.cleanup",
+		"transform.load",
+		"transform.report",
+		"transform.audit",
+	}, names)
+
+	steps := map[string]types.FpPipelineDryRunStep{}
+	for _, step := range result.Steps {
+		steps[step.Name] = step
+	}
+
+	assert.Nil(steps["transform.extract"].DependsOn)
+	assert.Equal([]string{"transform.extract"}, steps["transform.load"].DependsOn)
+	assert.Equal([]string{"transform.load"}, steps["transform.report"].DependsOn)
+	assert.Equal([]string{"transform.extract", "transform.report"}, steps["transform.audit"].DependsOn)
+}
+
+func TestDryRunPipeline(t *testing.T) {
+	assert := assert.New(t)
+
+	loadDryRunPipelines(t)
+
+	result, err := DryRunPipeline(types.CmdDryRun{}, "local.pipeline.etl")
+	if !assert.Nil(err) {
+		return
+	}
+
+	assert.Equal("local.pipeline.etl", result.Pipeline)
+	assert.Empty(result.Args)
+
+	steps := map[string]types.FpPipelineDryRunStep{}
+	for _, step := range result.Steps {
+		steps[step.Name] = step
+	}
+
+	// the input of a step that only refers to the params is resolved
+	assert.Equal("extracting orders, customers", steps["transform.extract"].Input["value"])
+	assert.Empty(steps["transform.extract"].Unresolved)
+
+	// the input of a for_each step is resolved for each item
+	assert.True(steps["transform.load"].ForEach)
+	assert.Nil(steps["transform.load"].Input)
+	assert.Equal("the input is resolved for each item when the pipeline runs", steps["transform.load"].Unresolved)
+
+	// the input that refers to the output of another step is unresolved
+	assert.Nil(steps["transform.report"].Input)
+	assert.Equal("the input uses the output of transform.load resolved when the pipeline runs", steps["transform.report"].Unresolved)
+
+	// the if condition is false with the default args
+	assert.True(steps["transform.notify"].Skip)
+
+	// the args of the dry run are validated and used to resolve the steps
+	result, err = DryRunPipeline(types.CmdDryRun{ArgsString: map[string]string{"notify": "true"}}, "local.pipeline.etl")
+	if !assert.Nil(err) {
+		return
+	}
+	assert.Equal(true, result.Args["notify"])
+	for _, step := range result.Steps {
+		if step.Name == "transform.notify" {
+			assert.False(step.Skip)
+			assert.Equal("done", step.Input["value"])
+		}
+	}
+
+	_, err = DryRunPipeline(types.CmdDryRun{Args: map[string]interface{}{"notify": "yes"}}, "local.pipeline.etl")
+	assert.NotNil(err)
+
+	_, err = DryRunPipeline(types.CmdDryRun{Args: map[string]interface{}{"notify": true}, ArgsString: map[string]string{"notify": "true"}}, "local.pipeline.etl")
+	if assert.NotNil(err) {
+		assert.Equal("args and args_string are mutually exclusive", err.(perr.ErrorModel).Detail)
+	}
+}
+
+func TestDryRunPipelineEndpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	loadDryRunPipelines(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := &APIService{}
+	api.PipelineRegisterAPI(router.Group("/api/v0"))
+
+	dryRun := func(pipelineName string, input types.CmdDryRun) *httptest.ResponseRecorder {
+		body, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v0/pipeline/"+pipelineName+"/dry_run", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// the pipeline name is qualified with the root mod
+	w := dryRun("etl", types.CmdDryRun{ArgsString: map[string]string{"notify": "true"}})
+	if !assert.Equal(http.StatusOK, w.Code) {
+		return
+	}
+
+	var result types.FpPipelineDryRun
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal("local.pipeline.etl", result.Pipeline)
+
+	names, stages := stepStages(&result)
+	assert.Equal([]string{"transform.extract", "transform.notify", "transform.cleanup", "transform.load", "transform.report", "transform.audit"}, names)
+	assert.Equal(4, stages["transform.audit"])
+
+	w = dryRun("missing", types.CmdDryRun{})
+	assert.Equal(http.StatusNotFound, w.Code)
+
+	w = dryRun("etl", types.CmdDryRun{Args: map[string]interface{}{"notify": true}, ArgsString: map[string]string{"notify": "true"}})
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
@@ -200,6 +200,8 @@ func (api *APIService) Start() error {
 	api.ModRegisterAPI(apiPrefixGroup)
 	api.IntegrationRegisterAPI(apiPrefixGroup)
 	api.NotifierRegisterAPI(apiPrefixGroup)
+	api.WorkerRegisterAPI(apiPrefixGroup)
+	api.MetricsRegisterAPI(apiPrefixGroup)
 
 	api.apiPrefixGroup = apiPrefixGroup
 	api.router = router
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/flowpipe/internal/metrics"
+)
+
+func (api *APIService) MetricsRegisterAPI(router *gin.RouterGroup) {
+	router.GET("/metrics", api.getMetrics)
+}
+
+// @Summary Get metrics
+// @Description Get the execution, step and trigger metrics in the Prometheus text format.
+// @ID   metrics_get
+// @Tags Metrics
+// @Produce plain
+// / ...
+// ...
+// @Success 200 {string} string
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /metrics [get]
+func (api *APIService) getMetrics(c *gin.Context) {
+	metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
@@ -33,6 +33,7 @@ func (api *APIService) PipelineRegisterAPI(router *gin.RouterGroup) {
 	router.GET("/pipeline", api.listPipelines)
 	router.GET("/pipeline/:pipeline_name", api.getPipeline)
 	router.POST("/pipeline/:pipeline_name/command", api.cmdPipeline)
+	router.POST("/pipeline/:pipeline_name/dry_run", api.dryRunPipeline)
 }
 
 // @Summary List pipelines
@@ -309,27 +310,11 @@ func ExecutePipeline(input types.CmdPipeline, executionId, pipelineName string,
 	if input.Command == "run" {
 		executionCmd := event.NewExecutionQueueForPipeline(executionId, pipelineDefn.Name())
 
-		evalContext, err := buildTempEvalContextForApi()
+		args, err := validatePipelineArgs(pipelineDefn, input.Args, input.ArgsString)
 		if err != nil {
 			return response, nil, err
 		}
-
-		if len(input.Args) > 0 || len(input.ArgsString) == 0 {
-			errs := fparse.ValidateParams(pipelineDefn, input.Args, evalContext)
-			if len(errs) > 0 {
-				errStrs := error_helpers.MergeErrors(errs)
-				return response, nil, perr.BadRequestWithMessage(strings.Join(errStrs, "; "))
-			}
-			executionCmd.PipelineQueue.Args = input.Args
-
-		} else if len(input.ArgsString) > 0 {
-			args, errs := fparse.CoerceParams(pipelineDefn, input.ArgsString, evalContext)
-			if len(errs) > 0 {
-				errStrs := error_helpers.MergeErrors(errs)
-				return response, nil, perr.BadRequestWithMessage(strings.Join(errStrs, "; "))
-			}
-			executionCmd.PipelineQueue.Args = args
-		}
+		executionCmd.PipelineQueue.Args = args
 
 		if err := esService.Send(executionCmd); err != nil {
 			return response, nil, err
@@ -348,6 +333,31 @@ func ExecutePipeline(input types.CmdPipeline, executionId, pipelineName string,
 	return response, nil, perr.BadRequestWithMessage("invalid command")
 }
 
+// validatePipelineArgs validates the args of the pipeline, the args given as strings are coerced to the types of the
+// pipeline params
+func validatePipelineArgs(pipelineDefn *resources.Pipeline, args map[string]interface{}, argsString map[string]string) (map[string]interface{}, error) {
+	evalContext, err := buildTempEvalContextForApi()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 || len(argsString) == 0 {
+		errs := fparse.ValidateParams(pipelineDefn, args, evalContext)
+		if len(errs) > 0 {
+			errStrs := error_helpers.MergeErrors(errs)
+			return nil, perr.BadRequestWithMessage(strings.Join(errStrs, "; "))
+		}
+		return args, nil
+	}
+
+	coercedArgs, errs := fparse.CoerceParams(pipelineDefn, argsString, evalContext)
+	if len(errs) > 0 {
+		errStrs := error_helpers.MergeErrors(errs)
+		return nil, perr.BadRequestWithMessage(strings.Join(errStrs, "; "))
+	}
+	return coercedArgs, nil
+}
+
 func ConstructPipelineFullyQualifiedName(pipelineName string) string {
 	return ConstructFullyQualifiedName("pipeline", 1, pipelineName)
 }
@@ -0,0 +1,40 @@
+pipeline "etl" {
+
+  param "tables" {
+    type    = list(string)
+    default = ["orders", "customers"]
+  }
+
+  param "notify" {
+    type    = bool
+    default = false
+  }
+
+  step "transform" "extract" {
+    value = "extracting ${join(", ", param.tables)}"
+  }
+
+  step "transform" "load" {
+    depends_on = [step.transform.extract]
+    for_each   = param.tables
+    value      = "loading ${each.value}"
+  }
+
+  step "transform" "report" {
+    value = "loaded ${length(step.transform.load)} tables"
+  }
+
+  step "transform" "audit" {
+    depends_on = [step.transform.extract, step.transform.report]
+    value      = "audited"
+  }
+
+  step "transform" "notify" {
+    if    = param.notify
+    value = "done"
+  }
+
+  step "transform" "cleanup" {
+    value = "cleaned up"
+  }
+}
@@ -2,21 +2,30 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"log/slog"
 	"net/http"
+	"slices"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
 	"github.com/turbot/flowpipe/internal/metrics"
+	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/flowpipe/internal/service/api/common"
+	serviceconfig "github.com/turbot/flowpipe/internal/service/config"
 	"github.com/turbot/flowpipe/internal/service/es"
 	"github.com/turbot/flowpipe/internal/store"
 	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/pipe-fittings/cache"
+	pfconstants "github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
 )
 
 func (api *APIService) ProcessRegisterAPI(router *gin.RouterGroup) {
@@ -28,7 +37,7 @@ func (api *APIService) ProcessRegisterAPI(router *gin.RouterGroup) {
 }
 
 // @Summary List processs
-// @Description Lists processs
+// @Description Lists processs, latest first unless sorted in ascending order
 // @ID   process_list
 // @Tags Process
 // @Accept json
@@ -36,6 +45,13 @@ func (api *APIService) ProcessRegisterAPI(router *gin.RouterGroup) {
 // / ...
 // @Param limit query int false "The max number of items to fetch per page of data, subject to a min and max of 1 and 100 respectively. If not specified will default to 25." default(25) minimum(1) maximum(100)
 // @Param next_token query string false "When list results are truncated, next_token will be returned, which is a cursor to fetch the next page of data. Pass next_token to the subsequent list request to fetch the next page of data."
+// @Param pipeline query string false "Only the processes of the pipeline (fully qualified name)"
+// @Param status query string false "Only the processes in the status" Enums(queued, started, finished, failed, cancelled)
+// @Param trigger query string false "Only the processes run by the trigger (fully qualified name)"
+// @Param started_before query string false "Only the processes started before the time (RFC 3339)"
+// @Param started_after query string false "Only the processes started after the time (RFC 3339)"
+// @Param failed_step query bool false "Only the processes with (true) or without (false) a failed step"
+// @Param sort query string false "Sort order of the processes by start time" Enums(asc, desc) default(desc)
 // ...
 // @Success 200 {object} types.ListProcessResponse
 // @Failure 400 {object} perr.ErrorModel
@@ -52,9 +68,15 @@ func (api *APIService) listProcess(c *gin.Context) {
 		return
 	}
 
+	var query types.ListProcessRequestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
 	slog.Info("received list process request", "next_token", nextToken, "limit", limit)
 
-	result, err := ListProcesses()
+	result, err := ListProcesses(query, nextToken, limit)
 	if err != nil {
 		common.AbortWithError(c, err)
 		return
@@ -63,63 +85,143 @@ func (api *APIService) listProcess(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-func ListProcesses() (*types.ListProcessResponse, error) {
+// ListProcesses lists a page of the processes matching the query, the next token is the ID of the last process of
+// the previous page
+func ListProcesses(query types.ListProcessRequestQuery, nextToken string, limit int) (*types.ListProcessResponse, error) {
+	// the paging boundaries are only configured for the server, the local process list uses their defaults
+	limitMin, limitMax := viper.GetInt("api.list.limit.min"), viper.GetInt("api.list.limit.max")
+	if limitMax == 0 {
+		limitMin, limitMax = serviceconfig.ConfigDefaults["api.list.limit.min"].(int), serviceconfig.ConfigDefaults["api.list.limit.max"].(int)
+	}
+	limit = max(limit, limitMin)
+	limit = min(limit, limitMax)
+
+	// the executions are only recorded in pipeline_run when the processes are kept
+	if viper.GetInt(pfconstants.ArgProcessRetention) == 0 {
+		return listProcessesFromEventLog(query, limit)
+	}
+
+	filter := store.PipelineRunFilter{
+		Pipeline:      query.Pipeline,
+		State:         query.Status,
+		Trigger:       query.Trigger,
+		StartedBefore: query.StartedBefore,
+		StartedAfter:  query.StartedAfter,
+		FailedStep:    query.FailedStep,
+		Ascending:     query.Sort == "asc",
+		Limit:         limit,
+	}
+
+	if nextToken != "" {
+		after, err := strconv.ParseInt(nextToken, 10, 64)
+		if err != nil {
+			return nil, perr.BadRequestWithMessage("invalid next_token")
+		}
+		filter.After = after
+	}
+
+	runs, more, err := store.ListPipelineRuns(filter)
+	if err != nil {
+		slog.Error("Error listing processes", "error", err)
+		return nil, err
+	}
+
+	processList := []types.Process{}
+	for _, run := range runs {
+		processList = append(processList, types.Process{
+			ID:         run.ExecutionID,
+			Pipeline:   run.Pipeline,
+			Trigger:    run.Trigger,
+			Status:     run.State,
+			FailedStep: run.FailedStep,
+			CreatedAt:  run.StartedAt,
+		})
+	}
+
+	result := &types.ListProcessResponse{
+		Items: processList,
+	}
+
+	if more {
+		token := base64.StdEncoding.EncodeToString([]byte(strconv.FormatInt(runs[len(runs)-1].ID, 10)))
+		result.NextToken = &token
+	}
+
+	return result, nil
+}
+
+// listProcessesFromEventLog lists the running executions and the executions of the event log, for the servers that
+// don't keep the processes in pipeline_run. The processes are filtered here and listed in a single page.
+func listProcessesFromEventLog(query types.ListProcessRequestQuery, limit int) (*types.ListProcessResponse, error) {
 	var processList []types.Process
+	listed := map[string]bool{}
 
-	allExec := metrics.RunMetricInstance.RunningExecutions()
-	for _, exMetric := range allExec {
-		slog.Debug("allExec", "ex", exMetric)
+	for _, exMetric := range metrics.RunMetricInstance.RunningExecutions() {
 		ex, err := execution.GetExecution(exMetric.ExecutionID)
 		if err != nil {
-			slog.Error("Error loading execution", "error", err)
-			return nil, err
+			// the execution ended since the running executions were listed, it's listed from the event log below
+			slog.Debug("Running execution not found", "execution_id", exMetric.ExecutionID, "error", err)
+			continue
 		}
 
-		processList = append(processList, types.Process{
+		process := types.Process{
 			ID:        ex.ID,
 			Pipeline:  exMetric.Pipeline,
 			CreatedAt: exMetric.StartTimestamp,
-			Status:    "started", // We assume started as the finished pipeline shouldn't be in the Metrics instance``
-		})
-
+			Status:    ex.Status,
+		}
+		if process.Status == "" {
+			process.Status = "started"
+		}
+		if matchProcess(query, process, &ex.Execution) {
+			processList = append(processList, process)
+		}
+		listed[ex.ID] = true
 	}
 
-	// Extract the execution IDs from the log file names
 	executionIDs, err := store.ListExecutionIDs()
 	if err != nil {
 		slog.Error("Error listing execution IDs", "error", err)
 		return nil, perr.InternalWithMessage("Error listing execution IDs")
 	}
 
-	// Get the log entries using the execution ID and extract the pipeline name
-
-	for _, execID := range executionIDs {
-
-		evt := &event.Event{
-			ExecutionID: execID,
+	for _, executionID := range executionIDs {
+		if listed[executionID] {
+			continue
 		}
 
-		// TODO .. we need to skip if execution is for a different mod, but how do we know?
-		ex, err := execution.NewExecution(context.Background(), execution.WithEvent(evt))
+		ex, err := execution.NewExecution(context.Background(), execution.WithEvent(&event.Event{ExecutionID: executionID}))
 		if err != nil {
 			continue
 		}
 
-		// get outer pipeline (not child)
-		var outerPipeline execution.PipelineExecution
-		for _, pipeline := range ex.PipelineExecutions {
-			if pipeline.ParentExecutionID == "" && pipeline.ParentStepExecutionID == "" {
-				outerPipeline = *pipeline
+		for _, rootPipelineExecutionID := range ex.RootPipelines {
+			pex := ex.PipelineExecutions[rootPipelineExecutionID]
+			if pex == nil {
 				continue
 			}
+
+			process := types.Process{
+				ID:        ex.ID,
+				Pipeline:  pex.Name,
+				Status:    ex.Status,
+				CreatedAt: pex.StartTime,
+			}
+			if matchProcess(query, process, ex) {
+				processList = append(processList, process)
+			}
+			break
 		}
+	}
 
-		processList = append(processList, types.Process{
-			ID:        ex.ID,
-			Pipeline:  outerPipeline.Name,
-			Status:    outerPipeline.Status,
-			CreatedAt: outerPipeline.StartTime,
-		})
+	sort.Slice(processList, func(i, j int) bool {
+		if query.Sort == "asc" {
+			return processList[i].CreatedAt.Before(processList[j].CreatedAt)
+		}
+		return processList[i].CreatedAt.After(processList[j].CreatedAt)
+	})
+	if len(processList) > limit {
+		processList = processList[:limit]
 	}
 
 	return &types.ListProcessResponse{
@@ -127,6 +229,40 @@ func ListProcesses() (*types.ListProcessResponse, error) {
 	}, nil
 }
 
+// matchProcess returns true if the process matches the query. The trigger of the execution isn't known here, no
+// process matches a trigger query.
+func matchProcess(query types.ListProcessRequestQuery, process types.Process, ex *execution.Execution) bool {
+	if query.Pipeline != "" && query.Pipeline != process.Pipeline {
+		return false
+	}
+	if query.Status != "" && query.Status != process.Status {
+		return false
+	}
+	if query.Trigger != "" {
+		return false
+	}
+	if query.StartedBefore != nil && !process.CreatedAt.Before(*query.StartedBefore) {
+		return false
+	}
+	if query.StartedAfter != nil && !process.CreatedAt.After(*query.StartedAfter) {
+		return false
+	}
+	if query.FailedStep != nil {
+		failedStep := false
+		for _, pex := range ex.PipelineExecutions {
+			for _, stepExecution := range pex.StepExecutions {
+				if stepExecution.Status == localconstants.StateFailed {
+					failedStep = true
+				}
+			}
+		}
+		if failedStep != *query.FailedStep {
+			return false
+		}
+	}
+	return true
+}
+
 // @Summary Get process
 // @Description Get process
 // @ID   process_get
@@ -187,6 +323,8 @@ func GetProcess(executionId string) (*types.Process, error) {
 			Pipeline:  outerPipeline.Name,
 			CreatedAt: outerPipeline.StartTime,
 			Status:    outerPipeline.Status,
+			RetryOf:   ex.RetryOf,
+			Attempt:   ex.Attempt,
 		}
 
 		return &process, nil
@@ -218,6 +356,8 @@ func GetProcess(executionId string) (*types.Process, error) {
 		ID:        exFile.ID,
 		Pipeline:  outerPipeline.Name,
 		Status:    outerPipeline.Status,
+		RetryOf:   exFile.RetryOf,
+		Attempt:   exFile.Attempt,
 		CreatedAt: outerPipeline.StartTime,
 	}
 
@@ -363,20 +503,69 @@ func (api *APIService) cmdProcess(c *gin.Context) {
 		return
 	}
 
-	if input.Command != "resume" {
-		common.AbortWithError(c, perr.BadRequestWithMessage("Invalid command"))
-	}
-
 	executionId := uri.ProcessId
 
-	// TODO: return result when the time come
-	_, _, err := ResumeProcess(executionId, api.EsService)
-	if err != nil {
-		common.AbortWithError(c, err)
-		return
-	}
+	switch input.Command {
+	case "resume":
+		_, _, err := ResumeProcess(executionId, api.EsService)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+
+		process, err := GetProcess(executionId)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, process)
+	case "pause":
+		_, _, err := PauseProcess(executionId, input.Reason, api.EsService)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+
+		process, err := GetProcess(executionId)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
 
-	c.JSON(http.StatusOK, nil)
+		c.JSON(http.StatusOK, process)
+	case "retry":
+		// the retry is a new execution
+		retryExecutionId, _, _, err := RetryProcess(executionId, api.EsService)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+
+		process, err := GetProcess(retryExecutionId)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, process)
+	case "cancel":
+		_, _, err := CancelProcess(executionId, input.Reason, api.EsService)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+
+		process, err := GetProcess(executionId)
+		if err != nil {
+			common.AbortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, process)
+	default:
+		common.AbortWithError(c, perr.BadRequestWithMessage("Invalid command"))
+	}
 }
 
 func reorderStepExecutions(pex map[string]*execution.StepExecution) []*execution.StepExecution {
@@ -455,24 +644,8 @@ func requeueQueuedButNotStartedSteps(ex *execution.ExecutionInMemory, pex *execu
 				}
 			}
 		} else if stepExecution.Status == "queued" {
-			// Requeue the step
-			cmd := &event.StepQueue{
-				Event: &event.Event{
-					ExecutionID: ex.ID,
-					CreatedAt:   time.Now().UTC(),
-				},
-				PipelineExecutionID: pex.ID,
-				StepExecutionID:     stepExecution.ID,
-				StepName:            stepExecution.Name,
-				StepInput:           stepExecution.Input,
-				StepForEach:         stepExecution.StepForEach,
-				StepLoop:            stepExecution.StepLoop,
-				NextStepAction:      stepExecution.NextStepAction,
-				MaxConcurrency:      stepExecution.MaxConcurrency,
-			}
-			err := esService.Send(cmd)
+			err := requeueStep(ex, pex, stepExecution, esService)
 			if err != nil {
-				slog.Error("Error requeueing step", "error", err)
 				return err
 			}
 		}
@@ -481,6 +654,29 @@ func requeueQueuedButNotStartedSteps(ex *execution.ExecutionInMemory, pex *execu
 	return nil
 }
 
+func requeueStep(ex *execution.ExecutionInMemory, pex *execution.PipelineExecution, stepExecution *execution.StepExecution, esService *es.ESService) error {
+	cmd := &event.StepQueue{
+		Event: &event.Event{
+			ExecutionID: ex.ID,
+			CreatedAt:   time.Now().UTC(),
+		},
+		PipelineExecutionID: pex.ID,
+		StepExecutionID:     stepExecution.ID,
+		StepName:            stepExecution.Name,
+		StepInput:           stepExecution.Input,
+		StepForEach:         stepExecution.StepForEach,
+		StepLoop:            stepExecution.StepLoop,
+		NextStepAction:      stepExecution.NextStepAction,
+		MaxConcurrency:      stepExecution.MaxConcurrency,
+	}
+	err := esService.Send(cmd)
+	if err != nil {
+		slog.Error("Error requeueing step", "error", err)
+		return err
+	}
+	return nil
+}
+
 func ResumeProcess(executionId string, esService *es.ESService) (pipelineExecutionId, pipelineName string, err error) {
 
 	evt := &event.Event{
@@ -559,3 +755,406 @@ func ResumeProcess(executionId string, esService *es.ESService) (pipelineExecuti
 
 	return pipelineExecutionId, pipelineName, nil
 }
+
+// CancelProcess cancels every pipeline execution in the given execution, including child pipelines started by
+// pipeline steps. Steps that are already running are allowed to finish but no new steps will be planned.
+func CancelProcess(executionId, reason string, esService *es.ESService) (pipelineExecutionId, pipelineName string, err error) {
+
+	ex, err := execution.GetExecution(executionId)
+	if err != nil && !perr.IsNotFound(err) {
+		return "", "", err
+	}
+
+	if ex == nil {
+		// The execution is not running in this process (i.e. it's paused), load it from the database so we
+		// can record the cancellation
+		evt := &event.Event{
+			ExecutionID: executionId,
+		}
+
+		ex, err = execution.LoadExecutionFromProcessDB(evt)
+		if err != nil {
+			return "", "", err
+		}
+
+		if ex == nil || len(ex.PipelineExecutions) == 0 {
+			return "", "", perr.NotFoundWithMessage("execution not found")
+		}
+
+		// Effectively forever
+		ok := cache.GetCache().SetWithTTL(executionId, ex, 10*365*24*time.Hour)
+		if !ok {
+			slog.Error("Error setting execution in cache", "execution_id", executionId)
+			return "", "", perr.InternalWithMessage("Error setting execution in cache")
+		}
+	}
+
+	if slices.Contains(event.EndEvents, ex.Status) {
+		return "", "", perr.BadRequestWithMessage("execution " + executionId + " has already " + ex.Status)
+	}
+
+	slog.Info("Cancelling execution", "execution_id", executionId, "reason", reason)
+
+	// Only the root pipelines need to be cancelled, the child pipelines are cancelled together with their parent
+	for _, rootPipelineExecutionId := range ex.RootPipelines {
+		pex, ok := ex.PipelineExecutions[rootPipelineExecutionId]
+		if !ok || slices.Contains(event.EndEvents, pex.Status) {
+			continue
+		}
+
+		cmd, err := event.NewPipelineCancel(ex.ID, pex.ID, event.WithPipelineCancelReason(reason))
+		if err != nil {
+			return "", "", err
+		}
+
+		err = esService.Send(cmd)
+		if err != nil {
+			return "", "", err
+		}
+
+		pipelineExecutionId = pex.ID
+		pipelineName = pex.Name
+	}
+
+	if pipelineExecutionId == "" {
+		return "", "", perr.BadRequestWithMessage("execution " + executionId + " has no running pipeline to cancel")
+	}
+
+	return pipelineExecutionId, pipelineName, nil
+}
+
+// PauseProcess pauses every running pipeline execution in the given execution. Steps that are already running are
+// allowed to finish but no new steps will be planned until the process is resumed.
+func PauseProcess(executionId, reason string, esService *es.ESService) (pipelineExecutionId, pipelineName string, err error) {
+
+	ex, err := execution.GetExecution(executionId)
+	if err != nil && !perr.IsNotFound(err) {
+		return "", "", err
+	}
+
+	if ex == nil {
+		// The execution is not running in this process (i.e. it was interrupted), load it from the database so we
+		// can record the pause
+		evt := &event.Event{
+			ExecutionID: executionId,
+		}
+
+		ex, err = execution.LoadExecutionFromProcessDB(evt)
+		if err != nil {
+			return "", "", err
+		}
+
+		if ex == nil || len(ex.PipelineExecutions) == 0 {
+			return "", "", perr.NotFoundWithMessage("execution not found")
+		}
+
+		// Effectively forever
+		ok := cache.GetCache().SetWithTTL(executionId, ex, 10*365*24*time.Hour)
+		if !ok {
+			slog.Error("Error setting execution in cache", "execution_id", executionId)
+			return "", "", perr.InternalWithMessage("Error setting execution in cache")
+		}
+	}
+
+	if ex.IsPaused() || slices.Contains(event.EndEvents, ex.Status) {
+		return "", "", perr.BadRequestWithMessage("execution " + executionId + " has already " + ex.Status)
+	}
+
+	slog.Info("Pausing execution", "execution_id", executionId, "reason", reason)
+
+	// Only the root pipelines need to be paused, the child pipelines are paused together with their parent
+	for _, rootPipelineExecutionId := range ex.RootPipelines {
+		pex, ok := ex.PipelineExecutions[rootPipelineExecutionId]
+		if !ok || (pex.Status != "started" && pex.Status != "queued") {
+			continue
+		}
+
+		cmd, err := event.NewPipelinePause(ex.ID, pex.ID, event.WithPipelinePauseReason(reason))
+		if err != nil {
+			return "", "", err
+		}
+
+		err = esService.Send(cmd)
+		if err != nil {
+			return "", "", err
+		}
+
+		pipelineExecutionId = pex.ID
+		pipelineName = pex.Name
+	}
+
+	if pipelineExecutionId == "" {
+		return "", "", perr.BadRequestWithMessage("execution " + executionId + " has no running pipeline to pause")
+	}
+
+	return pipelineExecutionId, pipelineName, nil
+}
+
+// RetryProcess retries a failed execution from the steps that failed in a new execution, linked to the failed
+// execution by its retry_of and attempt. The outputs of the steps that completed are kept, only the failed and never
+// started steps are queued again. The failed execution is left as it is.
+func RetryProcess(executionId string, esService *es.ESService) (retryExecutionId, pipelineExecutionId, pipelineName string, err error) {
+
+	evt := &event.Event{
+		ExecutionID: executionId,
+	}
+
+	ex, err := execution.LoadExecutionFromProcessDB(evt)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if ex == nil || len(ex.PipelineExecutions) == 0 {
+		return "", "", "", perr.NotFoundWithMessage("execution not found")
+	}
+
+	// All the pipelines must have ended, we can't retry an execution that is still running
+	for _, pex := range ex.PipelineExecutions {
+		if !slices.Contains(event.EndEvents, pex.Status) {
+			return "", "", "", perr.BadRequestWithMessage("execution " + executionId + " is still running")
+		}
+	}
+
+	// Only the root pipelines need to be retried, the failed child pipelines are retried together with their parent
+	for _, rootPipelineExecutionId := range ex.RootPipelines {
+		pex, ok := ex.PipelineExecutions[rootPipelineExecutionId]
+		if !ok || !pex.IsFail() {
+			continue
+		}
+
+		// Just pick the last one, as for resume
+		pipelineExecutionId = pex.ID
+		pipelineName = pex.Name
+	}
+
+	if pipelineExecutionId == "" {
+		return "", "", "", perr.BadRequestWithMessage("execution " + executionId + " has no failed pipeline to retry")
+	}
+
+	// the execution that was first run is attempt 1
+	attempt := max(ex.Attempt, 1) + 1
+
+	snapshot, err := ex.RetrySnapshot()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	cmd := event.NewExecutionRetry(executionId, attempt, pipelineName, snapshot)
+	slog.Info("Retrying execution", "execution_id", executionId, "retry_execution_id", cmd.Event.ExecutionID, "attempt", attempt)
+
+	err = esService.Send(cmd)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return cmd.Event.ExecutionID, pipelineExecutionId, pipelineName, nil
+}
+
+// RecoverProcess resumes or fails an execution that was still running when the server stopped, according to the
+// on_restart policy of its root pipeline. Child pipelines follow the policy of the root pipeline.
+func RecoverProcess(executionId string, esService *es.ESService) error {
+
+	evt := &event.Event{
+		ExecutionID: executionId,
+	}
+
+	ex, err := execution.LoadExecutionFromProcessDB(evt)
+	if err != nil {
+		return err
+	}
+
+	if ex == nil || len(ex.PipelineExecutions) == 0 {
+		// The server stopped before the pipeline was queued, there's nothing to resume
+		slog.Warn("Execution has no pipeline to recover", "execution_id", executionId)
+		return store.UpdatePipelineState(executionId, "failed")
+	}
+
+	if ex.IsPaused() {
+		slog.Info("Execution is paused, leaving it to be resumed", "execution_id", executionId)
+		return nil
+	}
+
+	// Effectively forever
+	ok := cache.GetCache().SetWithTTL(executionId, ex, 10*365*24*time.Hour)
+	if !ok {
+		slog.Error("Error setting execution in cache", "execution_id", executionId)
+		return perr.InternalWithMessage("Error setting execution in cache")
+	}
+
+	for _, rootPipelineExecutionId := range ex.RootPipelines {
+		pex, ok := ex.PipelineExecutions[rootPipelineExecutionId]
+		if !ok {
+			continue
+		}
+
+		pipelineDefn, err := ex.PipelineDefinition(pex.ID)
+		if err != nil {
+			return err
+		}
+
+		// The deepest child pipelines first, a child pipeline completes the pipeline step of its parent
+		pipelineExecutions := append(ex.ChildPipelineExecutions(pex.ID), pex)
+
+		onRestart := pipelineDefn.GetOnRestart()
+		slog.Info("Recovering execution", "execution_id", executionId, "pipeline_execution_id", pex.ID, "on_restart", onRestart)
+
+		switch onRestart {
+		case localconstants.OnRestartFail:
+			err = failInterruptedPipelines(ex, pipelineExecutions, esService)
+		default:
+			err = resumeInterruptedPipelines(ex, pipelineExecutions, esService)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resumeInterruptedPipelines re-queues the steps that were queued or running when the server stopped and plans the
+// pipelines again so the remaining steps are started.
+func resumeInterruptedPipelines(ex *execution.ExecutionInMemory, pipelineExecutions []*execution.PipelineExecution, esService *es.ESService) error {
+	for _, pex := range pipelineExecutions {
+		if pex.IsPaused() || slices.Contains(event.EndEvents, pex.Status) {
+			continue
+		}
+
+		pipelineDefn, err := ex.PipelineDefinition(pex.ID)
+		if err != nil {
+			return err
+		}
+
+		var cmd event.CommandEvent
+		if pex.Status == "queued" {
+			// The pipeline was waiting for its turn, load it again once it has the semaphore
+			cmd = &event.PipelineLoad{
+				Event:               event.NewEventForExecutionID(ex.ID),
+				PipelineExecutionID: pex.ID,
+			}
+		} else {
+			err := requeueInterruptedSteps(ex, pex, esService)
+			if err != nil {
+				return err
+			}
+
+			cmd = &event.PipelinePlan{
+				Event:               event.NewEventForExecutionID(ex.ID),
+				PipelineExecutionID: pex.ID,
+			}
+		}
+
+		// The pipeline semaphore was held by the server that stopped, acquire it again before the pipeline
+		// carries on
+		go func() {
+			err := execution.GetPipelineSemaphore(pipelineDefn)
+			if err != nil {
+				slog.Error("Error acquiring pipeline semaphore", "pipeline_execution_id", pex.ID, "error", err)
+				return
+			}
+
+			err = esService.Send(cmd)
+			if err != nil {
+				slog.Error("Error resuming pipeline", "pipeline_execution_id", pex.ID, "error", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// requeueInterruptedSteps re-queues the steps that were queued or running when the server stopped. Unlike
+// requeueQueuedButNotStartedSteps the started steps are queued again, they were running in the server that stopped.
+// Pipeline steps are not requeued, their child pipeline is recovered instead, and neither are input steps that are
+// waiting for a response.
+func requeueInterruptedSteps(ex *execution.ExecutionInMemory, pex *execution.PipelineExecution, esService *es.ESService) error {
+
+	stepExecs := reorderStepExecutions(pex.StepExecutions)
+	for _, stepExecution := range stepExecs {
+
+		stepDefn, err := ex.StepDefinition(pex.ID, stepExecution.ID)
+		if err != nil {
+			slog.Error("Error getting step definition", "error", err)
+			return err
+		}
+
+		if stepDefn == nil {
+			continue
+		}
+
+		switch stepExecution.Status {
+		case "queueing", "queued":
+			err := requeueStep(ex, pex, stepExecution, esService)
+			if err != nil {
+				return err
+			}
+
+		case "starting", "started":
+			waiting := (stepDefn.GetType() == schema.BlockTypePipelineStepPipeline && ex.FindPipelineExecutionByItsParentStepExecution(stepExecution.ID) != nil) ||
+				(stepDefn.GetType() == schema.BlockTypePipelineStepInput && stepExecution.Status == "started")
+
+			if !waiting {
+				err := requeueStep(ex, pex, stepExecution, esService)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			if stepExecution.MaxConcurrency != nil {
+				// The step keeps its semaphore while it's waiting, replay the acquire that was done when the step
+				// was first queued
+				err := execution.GetPipelineExecutionStepSemaphoreMaxConcurrency(pex.ID, stepDefn, stepExecution.MaxConcurrency, true)
+				if err != nil {
+					slog.Error("Error getting step type semaphore", "error", err)
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// failInterruptedPipelines fails the pipelines that were running when the server stopped, the deepest child
+// pipelines first.
+func failInterruptedPipelines(ex *execution.ExecutionInMemory, pipelineExecutions []*execution.PipelineExecution, esService *es.ESService) error {
+	var pipelineDefns []*resources.Pipeline
+	var cmds []*event.PipelineFail
+	for _, pex := range pipelineExecutions {
+		if pex.IsPaused() || slices.Contains(event.EndEvents, pex.Status) {
+			continue
+		}
+
+		pipelineDefn, err := ex.PipelineDefinition(pex.ID)
+		if err != nil {
+			return err
+		}
+
+		pipelineDefns = append(pipelineDefns, pipelineDefn)
+		cmds = append(cmds, &event.PipelineFail{
+			Event:               event.NewEventForExecutionID(ex.ID),
+			PipelineExecutionID: pex.ID,
+			Error: &resources.StepError{
+				PipelineExecutionID: pex.ID,
+				Pipeline:            pex.Name,
+				Error:               perr.InternalWithMessage("pipeline execution was interrupted by a server restart"),
+			},
+		})
+	}
+
+	// The semaphore is released when the pipeline fails, it needs to be acquired again first
+	go func() {
+		for i, cmd := range cmds {
+			err := execution.GetPipelineSemaphore(pipelineDefns[i])
+			if err != nil {
+				slog.Error("Error acquiring pipeline semaphore", "pipeline_execution_id", cmd.PipelineExecutionID, "error", err)
+				return
+			}
+
+			err = esService.Send(cmd)
+			if err != nil {
+				slog.Error("Error failing pipeline", "pipeline_execution_id", cmd.PipelineExecutionID, "error", err)
+			}
+		}
+	}()
+	return nil
+}
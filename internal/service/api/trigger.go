@@ -16,6 +16,8 @@ import (
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/flowpipe/internal/service/api/common"
 	"github.com/turbot/flowpipe/internal/service/es"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/trigger"
 	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/pipe-fittings/cache"
 	"github.com/turbot/pipe-fittings/perr"
@@ -26,6 +28,9 @@ func (api *APIService) TriggerRegisterAPI(router *gin.RouterGroup) {
 	router.GET("/trigger", api.listTriggers)
 	router.GET("/trigger/:trigger_name", api.getTrigger)
 	router.POST("/trigger/:trigger_name/command", api.cmdTrigger)
+	router.POST("/trigger/:trigger_name/dry_run", api.dryRunTrigger)
+	router.GET("/trigger/:trigger_name/state", api.getTriggerState)
+	router.POST("/trigger/:trigger_name/state/command", api.cmdTriggerState)
 	// router.GET("/trigger/:trigger_name/key", api.listTriggerKeys)
 }
 
@@ -168,9 +173,46 @@ func GetTrigger(triggerName string, rootMod string) (*types.FpTrigger, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	fpTrigger.Events, err = getTriggerEvents(trigger.Name())
+	if err != nil {
+		return nil, err
+	}
+
 	return fpTrigger, nil
 }
 
+// getTriggerEvents returns the latest events of the trigger, e.g. the fires skipped by its overlap policy
+func getTriggerEvents(triggerName string) ([]types.FpTriggerEvent, error) {
+	flowpipeDb, err := store.OpenFlowpipeDB()
+	if err != nil {
+		return nil, err
+	}
+	defer flowpipeDb.Close()
+
+	err = store.CreateTriggerEventTable(flowpipeDb)
+	if err != nil {
+		return nil, err
+	}
+
+	triggerEvents, err := store.ListTriggerEvents(flowpipeDb, triggerName, localconstants.TriggerShowEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []types.FpTriggerEvent
+	for _, e := range triggerEvents {
+		events = append(events, types.FpTriggerEvent{
+			Type:        e.Type,
+			ExecutionID: e.ExecutionID,
+			Reason:      e.Reason,
+			CreatedAt:   e.CreatedAt,
+		})
+	}
+
+	return events, nil
+}
+
 func ConstructTriggerFullyQualifiedName(triggerName string) string {
 	return ConstructFullyQualifiedName("trigger", 2, triggerName)
 }
@@ -275,6 +317,210 @@ func (api *APIService) cmdTrigger(c *gin.Context) {
 	c.JSON(http.StatusOK, triggerExecutionResponse)
 }
 
+// @Summary Get trigger state
+// @Description Get the rows known by a query trigger and its last poll
+// @ID   trigger_state_get
+// @Tags Trigger
+// @Accept json
+// @Produce json
+// / ...
+// @Param trigger_name path string true "The name of the trigger" format(^[a-z_]{0,32}$)
+// ...
+// @Success 200 {object} types.FpTriggerState
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /trigger/{trigger_name}/state [get]
+func (api *APIService) getTriggerState(c *gin.Context) {
+	var uri types.TriggerRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	triggerState, err := GetTriggerState(ConstructTriggerFullyQualifiedName(uri.TriggerName))
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, triggerState)
+}
+
+// @Summary Execute a trigger state command
+// @Description Reset the state of a query trigger, or seed it with the rows currently returned by its query
+// @ID   trigger_state_command
+// @Tags Trigger
+// @Accept json
+// @Produce json
+// / ...
+// @Param trigger_name path string true "The name of the trigger" format(^[a-z_]{0,32}$)
+// @Param request body types.CmdTriggerState true "Trigger state command."
+// ...
+// @Success 200 {object} types.FpTriggerState
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 403 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /trigger/{trigger_name}/state/command [post]
+func (api *APIService) cmdTriggerState(c *gin.Context) {
+	var uri types.TriggerRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	var input types.CmdTriggerState
+	if err := c.ShouldBindJSON(&input); err != nil {
+		slog.Error("error binding input", "error", err)
+		common.AbortWithError(c, perr.BadRequestWithMessage(err.Error()))
+		return
+	}
+
+	triggerName := ConstructTriggerFullyQualifiedName(uri.TriggerName)
+
+	var triggerState *types.FpTriggerState
+	var err error
+	switch input.Command {
+	case "reset":
+		triggerState, err = ResetTriggerState(triggerName)
+	case "seed":
+		triggerState, err = SeedTriggerState(c, triggerName)
+	}
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, triggerState)
+}
+
+// getQueryTrigger returns the trigger, only the query triggers have a state
+func getQueryTrigger(triggerName string) (*resources.Trigger, error) {
+	trg, err := db.GetTrigger(triggerName)
+	if err != nil {
+		if perr.IsNotFound(err) {
+			return nil, perr.NotFoundWithMessage("unable to find trigger " + triggerName)
+		}
+		return nil, err
+	}
+
+	if _, ok := trg.Config.(*resources.TriggerQuery); !ok {
+		return nil, perr.BadRequestWithMessage("trigger " + triggerName + " is not a query trigger, only query triggers have a state")
+	}
+
+	return trg, nil
+}
+
+func GetTriggerState(triggerName string) (*types.FpTriggerState, error) {
+	trg, err := getQueryTrigger(triggerName)
+	if err != nil {
+		return nil, err
+	}
+
+	flowpipeDb, err := store.OpenFlowpipeDB()
+	if err != nil {
+		return nil, err
+	}
+	defer flowpipeDb.Close()
+
+	err = store.CreateQueryTriggerCursorTable(flowpipeDb)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.CreateQueryTriggerRunTable(flowpipeDb)
+	if err != nil {
+		return nil, err
+	}
+
+	triggerState := &types.FpTriggerState{
+		Name: trg.Name(),
+		Type: trg.Config.GetType(),
+	}
+
+	if trg.Config.(*resources.TriggerQuery).CursorColumn != "" {
+		cursor, err := store.GetQueryTriggerCursor(flowpipeDb, trg.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		var cursorString string
+		if cursor != nil {
+			cursorString = fmt.Sprintf("%v", cursor)
+		}
+		triggerState.Cursor = &cursorString
+	} else {
+		triggerState.Rows, err = store.CountQueryTriggerCapturedRows(flowpipeDb, trg.FullName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	run, err := store.GetQueryTriggerRun(flowpipeDb, trg.Name())
+	if err != nil {
+		return nil, err
+	}
+	if run != nil {
+		triggerState.LastPolledAt = &run.PolledAt
+		triggerState.Inserted = run.Inserted
+		triggerState.Updated = run.Updated
+		triggerState.Deleted = run.Deleted
+	}
+
+	return triggerState, nil
+}
+
+// ResetTriggerState forgets the rows known by the query trigger, its next poll captures all the rows returned by the
+// query as inserted
+func ResetTriggerState(triggerName string) (*types.FpTriggerState, error) {
+	trg, err := getQueryTrigger(triggerName)
+	if err != nil {
+		return nil, err
+	}
+
+	flowpipeDb, err := store.OpenFlowpipeDB()
+	if err != nil {
+		return nil, err
+	}
+	defer flowpipeDb.Close()
+
+	err = store.ResetQueryTriggerState(flowpipeDb, trg.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Trigger state reset", "trigger", trg.Name())
+	return GetTriggerState(triggerName)
+}
+
+// SeedTriggerState records the rows currently returned by the query as known by the query trigger without running its
+// pipelines
+func SeedTriggerState(ctx context.Context, triggerName string) (*types.FpTriggerState, error) {
+	trg, err := getQueryTrigger(triggerName)
+	if err != nil {
+		return nil, err
+	}
+
+	triggerRunner, ok := trigger.NewTriggerRunner(trg, "", "").(*trigger.TriggerRunnerQuery)
+	if !ok {
+		return nil, perr.InternalWithMessage("unable to create the trigger runner of query trigger " + triggerName)
+	}
+
+	err = triggerRunner.Seed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Trigger state seeded", "trigger", trg.Name())
+	return GetTriggerState(triggerName)
+}
+
 func (api *APIService) processTriggerExecutionResult(c *gin.Context, triggerExecutionResponse types.TriggerExecutionResponse, pipelineCmd event.PipelineQueue, err error) {
 
 	if err != nil {
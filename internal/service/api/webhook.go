@@ -12,12 +12,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/spf13/viper"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/db"
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/metrics"
 	"github.com/turbot/flowpipe/internal/output"
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/flowpipe/internal/service/api/common"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/flowpipe/internal/util"
 	"github.com/turbot/pipe-fittings/cache"
@@ -113,14 +116,24 @@ func (api *APIService) runTriggerHook(c *gin.Context) {
 		return
 	}
 
-	body := ""
+	var bodyBytes []byte
 	if c.Request.Body != nil {
-		bodyBytes, err := io.ReadAll(c.Request.Body)
+		bodyBytes, err = io.ReadAll(c.Request.Body)
 		if err != nil {
 			common.AbortWithError(c, err)
 			return
 		}
-		body = string(bodyBytes)
+	}
+	body := string(bodyBytes)
+
+	// The signature is computed over the raw request body, a request that isn't signed with the trigger secret is
+	// rejected before its pipeline is queued
+	if httpTriggerConfig.Signature != nil {
+		if err := httpTriggerConfig.Signature.Verify(c.Request.Header, bodyBytes, time.Now()); err != nil {
+			slog.Warn("Invalid HTTP trigger signature", "trigger", t.Name(), "error", err)
+			common.AbortWithError(c, err)
+			return
+		}
 	}
 	data := map[string]interface{}{}
 
@@ -189,6 +202,7 @@ func (api *APIService) runTriggerHook(c *gin.Context) {
 		Event:               event.NewFlowEvent(executionCmd.Event),
 		PipelineExecutionID: util.NewPipelineExecutionId(),
 		Name:                pipelineName,
+		Trigger:             t.Name(),
 	}
 
 	pipelineCmd.Args = pipelineArgs
@@ -199,7 +213,11 @@ func (api *APIService) runTriggerHook(c *gin.Context) {
 
 	executionCmd.PipelineQueue = &pipelineCmd
 
+	metrics.TriggerFired(t.Name(), t.Config.GetType())
+	tracing.StartTrigger(tracing.Extract(c, c.Request.Header), pipelineCmd.Event.ExecutionID, t.Name(), t.Config.GetType())
 	if err := api.EsService.Send(executionCmd); err != nil {
+		tracing.EndTrigger(pipelineCmd.Event.ExecutionID, err)
+		metrics.TriggerFailed(t.Name(), t.Config.GetType())
 		common.AbortWithError(c, err)
 		return
 	}
@@ -249,7 +267,7 @@ func (api *APIService) waitForPipeline(pipelineCmd event.PipelineQueue, waitRetr
 		}
 
 		// Wait for the execution to finish
-		if ex.Status == expectedState || ex.Status == "failed" || ex.Status == "finished" || ex.Status == "paused" {
+		if ex.Status == expectedState || ex.Status == "failed" || ex.Status == "finished" || ex.Status == "paused" || ex.Status == localconstants.StateCancelled {
 			break
 		}
 	}
@@ -306,14 +324,14 @@ func WaitForTrigger(triggerName, executionId string, waitRetry int) (types.Trigg
 
 		// Wait for the execution to finish
 		slog.Info("Waiting for trigger execution", "trigger", triggerName, "execution_id", executionId, "retry", i, "status", ex.Status)
-		if ex.Status == expectedState || ex.Status == "failed" || ex.Status == "finished" || ex.Status == "paused" {
+		if ex.Status == expectedState || ex.Status == "failed" || ex.Status == "finished" || ex.Status == "paused" || ex.Status == localconstants.StateCancelled {
 			if ex.Status == "failed" {
 				lastStatus = event.HandlerExecutionFailed
 			} else if ex.Status == "finished" {
 				lastStatus = event.HandlerExecutionFinished
 			} else if ex.Status == "paused" {
 				lastStatus = event.HandlerExecutionPaused
-			} else if ex.Status == "cancelled" {
+			} else if ex.Status == localconstants.StateCancelled {
 				lastStatus = event.HandlerExecutionCancelled
 			}
 			break
@@ -361,7 +379,8 @@ func WaitForTrigger(triggerName, executionId string, waitRetry int) (types.Trigg
 				pipelineResponse.Errors = pipelineOutput["errors"].([]resources.StepError)
 			}
 
-			if trg.Config.GetType() == "schedule" {
+			if trg.Config.GetType() == "schedule" || trg.Config.GetType() == localconstants.TriggerTypeFile || trg.Config.GetType() == localconstants.TriggerTypeQueue ||
+				trg.Config.GetType() == localconstants.TriggerTypePipeline || trg.Config.GetType() == localconstants.TriggerTypeEmail {
 				response.Results[trg.Config.GetType()] = pipelineResponse
 			} else {
 				response.Results[pex.TriggerCapture] = pipelineResponse
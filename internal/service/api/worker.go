@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/service/api/common"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+// how long a claim waits for a step before returning an empty response, the worker then claims again
+const workerClaimWait = 20 * time.Second
+
+// WorkerRegisterAPI registers the API used by the workers, the API is only available when the server dispatches steps
+// to workers
+func (api *APIService) WorkerRegisterAPI(router *gin.RouterGroup) {
+	if api.EsService == nil || api.EsService.Workers == nil {
+		return
+	}
+
+	group := router.Group("/worker", validateWorkerToken)
+	group.POST("/claim", api.claimWorkerStep)
+	group.POST("/step/:step_execution_id/heartbeat", api.heartbeatWorkerStep)
+	group.POST("/step/:step_execution_id/complete", api.completeWorkerStep)
+}
+
+// validateWorkerToken checks the request is authenticated with the worker token
+func validateWorkerToken(c *gin.Context) {
+	token := viper.GetString(localconstants.ArgWorkerToken)
+	bearer, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" || !found || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+		common.AbortWithError(c, perr.UnauthorizedWithMessage("invalid worker token"))
+		return
+	}
+	c.Next()
+}
+
+// @Summary Claim a step
+// @Description Waits for a step dispatched to the workers and assigns it to the worker.
+// @ID   worker_claim
+// @Tags Worker
+// @Accept json
+// @Produce json
+// / ...
+// @Param request body types.WorkerClaimRequest true "The worker claiming the step"
+// ...
+// @Success 200 {object} types.WorkerJob
+// @Success 204
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /worker/claim [post]
+func (api *APIService) claimWorkerStep(c *gin.Context) {
+	var input types.WorkerClaimRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), workerClaimWait)
+	defer cancel()
+
+	job := api.EsService.Workers.Claim(ctx, input.WorkerID, input.StepTypes)
+	if job == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// @Summary Heartbeat a step
+// @Description Extends the lease of the worker on the step it's running.
+// @ID   worker_heartbeat
+// @Tags Worker
+// @Accept json
+// @Produce json
+// / ...
+// @Param step_execution_id path string true "The step execution claimed by the worker"
+// @Param request body types.WorkerHeartbeatRequest true "The worker running the step"
+// ...
+// @Success 200
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /worker/step/{step_execution_id}/heartbeat [post]
+func (api *APIService) heartbeatWorkerStep(c *gin.Context) {
+	var uri types.WorkerStepRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	var input types.WorkerHeartbeatRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	err := api.EsService.Workers.Heartbeat(uri.StepExecutionID, input.WorkerID)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// @Summary Complete a step
+// @Description Reports the output of the step run by the worker.
+// @ID   worker_complete
+// @Tags Worker
+// @Accept json
+// @Produce json
+// / ...
+// @Param step_execution_id path string true "The step execution claimed by the worker"
+// @Param request body types.WorkerCompleteRequest true "The output of the step"
+// ...
+// @Success 200
+// @Failure 400 {object} perr.ErrorModel
+// @Failure 401 {object} perr.ErrorModel
+// @Failure 404 {object} perr.ErrorModel
+// @Failure 409 {object} perr.ErrorModel
+// @Failure 429 {object} perr.ErrorModel
+// @Failure 500 {object} perr.ErrorModel
+// @Router /worker/step/{step_execution_id}/complete [post]
+func (api *APIService) completeWorkerStep(c *gin.Context) {
+	var uri types.WorkerStepRequestURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	var input types.WorkerCompleteRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	if input.Output == nil && input.Error == nil {
+		common.AbortWithError(c, perr.BadRequestWithMessage("the step output or error is required"))
+		return
+	}
+
+	var primitiveError error
+	if input.Error != nil {
+		primitiveError = *input.Error
+	}
+
+	err := api.EsService.Workers.Complete(uri.StepExecutionID, input.WorkerID, input.Output, primitiveError)
+	if err != nil {
+		common.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
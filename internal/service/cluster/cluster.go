@@ -0,0 +1,316 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/turbot/flowpipe/internal/service/fsm"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+// the FSM key holding the ID of the node that runs the scheduled triggers
+const schedulerKey = "scheduler"
+
+// the FSM keys holding the scheduled time of the last run of each schedule trigger
+const scheduleTriggerRunKeyPrefix = "schedule_trigger_run/"
+
+// the raft leader is elected by a majority of the nodes, the cluster needs 3 nodes to elect a new leader when one of
+// them dies
+const minClusterNodes = 3
+
+// ClusterService runs a raft node. The nodes of the cluster elect a leader, only the leader runs the scheduled
+// and query triggers so they fire once per cluster rather than once per server.
+//
+// The raft log is kept in memory: the cluster only agrees on who the leader is and on the last run of the schedule
+// triggers, the executions are still owned by the server that started them.
+//
+// The cluster has at least 3 nodes. The survivor of a 2 node cluster can't elect itself without a majority, so the
+// scheduled triggers would stop with the leader rather than be handed over.
+type ClusterService struct {
+	ctx context.Context
+
+	NodeID  string
+	Address string
+	Peers   []raft.Server
+
+	raft      *raft.Raft
+	transport *raft.NetworkTransport
+	fsm       *fsm.KeyValue
+
+	// notified with true when this node becomes the leader and with false when it stops being the leader
+	leaderCh chan bool
+	closing  chan struct{}
+	wg       sync.WaitGroup
+
+	Status    string     `json:"status"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+}
+
+// ClusterServiceOption defines a type of function to configures the ClusterService.
+type ClusterServiceOption func(*ClusterService) error
+
+// WithPeers sets the nodes of the cluster, each peer is in the form <node id>=<host>:<port>. This node is added to
+// the peers if it's not listed.
+func WithPeers(peers []string) ClusterServiceOption {
+	return func(c *ClusterService) error {
+		for _, peer := range peers {
+			peer = strings.TrimSpace(peer)
+			if peer == "" {
+				continue
+			}
+
+			id, address, ok := strings.Cut(peer, "=")
+			if !ok || id == "" || address == "" {
+				return perr.BadRequestWithMessage("invalid cluster peer " + peer + ", expected <node id>=<host>:<port>")
+			}
+
+			c.Peers = append(c.Peers, raft.Server{
+				ID:      raft.ServerID(id),
+				Address: raft.ServerAddress(address),
+			})
+		}
+		return nil
+	}
+}
+
+func NewClusterService(ctx context.Context, nodeID, address string, opts ...ClusterServiceOption) (*ClusterService, error) {
+	if nodeID == "" {
+		return nil, perr.BadRequestWithMessage("cluster node ID is required")
+	}
+
+	if address == "" {
+		return nil, perr.BadRequestWithMessage("cluster address is required")
+	}
+
+	// Defaults
+	c := &ClusterService{
+		ctx:      ctx,
+		NodeID:   nodeID,
+		Address:  address,
+		fsm:      fsm.NewKeyValue(),
+		leaderCh: make(chan bool, 1),
+		closing:  make(chan struct{}),
+		Status:   "initialized",
+	}
+	// Set options
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	found := false
+	for _, peer := range c.Peers {
+		if peer.ID == raft.ServerID(nodeID) {
+			if peer.Address != raft.ServerAddress(address) {
+				return nil, perr.BadRequestWithMessage("cluster peer " + nodeID + " address " + string(peer.Address) + " does not match the cluster address " + address)
+			}
+			found = true
+		}
+	}
+	if !found {
+		c.Peers = append(c.Peers, raft.Server{
+			ID:      raft.ServerID(nodeID),
+			Address: raft.ServerAddress(address),
+		})
+	}
+
+	if len(c.Peers) < minClusterNodes {
+		return nil, perr.BadRequestWithMessage(fmt.Sprintf("the cluster has %d nodes, it needs at least %d nodes to elect a new leader when a node dies", len(c.Peers), minClusterNodes))
+	}
+
+	return c, nil
+}
+
+func (c *ClusterService) Start() error {
+	slog.Debug("Cluster service starting", "node_id", c.NodeID, "address", c.Address)
+	defer slog.Debug("Cluster service started", "node_id", c.NodeID)
+
+	advertise, err := net.ResolveTCPAddr("tcp", c.Address)
+	if err != nil {
+		return perr.BadRequestWithMessage("invalid cluster address " + c.Address + ": " + err.Error())
+	}
+
+	transport, err := raft.NewTCPTransport(c.Address, advertise, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return perr.InternalWithMessage("unable to start the cluster transport: " + err.Error())
+	}
+
+	notifyCh := make(chan bool, 1)
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(c.NodeID)
+	config.NotifyCh = notifyCh
+	config.LogLevel = "ERROR"
+	config.LogOutput = os.Stderr
+
+	logStore := raft.NewInmemStore()
+	snapshotStore := raft.NewInmemSnapshotStore()
+
+	// Every node bootstraps with the same peers, the nodes agree on the configuration and elect a leader
+	err = raft.BootstrapCluster(config, logStore, logStore, snapshotStore, transport, raft.Configuration{Servers: c.Peers})
+	if err != nil && err != raft.ErrCantBootstrap {
+		transport.Close()
+		return perr.InternalWithMessage("unable to bootstrap the cluster: " + err.Error())
+	}
+
+	r, err := raft.NewRaft(config, c.fsm, logStore, logStore, snapshotStore, transport)
+	if err != nil {
+		transport.Close()
+		return perr.InternalWithMessage("unable to start the cluster node: " + err.Error())
+	}
+
+	c.raft = r
+	c.transport = transport
+
+	// raft blocks until the leadership change is received, always drain the notify channel
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			var isLeader bool
+			select {
+			case isLeader = <-notifyCh:
+			case <-c.closing:
+				return
+			}
+
+			slog.Info("Cluster leadership changed", "node_id", c.NodeID, "leader", isLeader)
+			if isLeader {
+				c.claimScheduler()
+			}
+
+			// only the latest leadership state matters, drop the previous one if it hasn't been read
+			select {
+			case <-c.leaderCh:
+			default:
+			}
+			c.leaderCh <- isLeader
+		}
+	}()
+
+	c.Status = "running"
+	c.StartedAt = utils.TimeNow()
+	return nil
+}
+
+// claimScheduler records in the cluster state that this node is running the scheduled triggers
+func (c *ClusterService) claimScheduler() {
+	err := c.set(schedulerKey, c.NodeID)
+	if err != nil {
+		slog.Error("Error updating cluster state", "node_id", c.NodeID, "error", err)
+	}
+}
+
+// set updates the cluster state, only the leader can update it
+func (c *ClusterService) set(key, value string) error {
+	data, err := json.Marshal(fsm.KeyValueOperation{
+		Key:       key,
+		Value:     value,
+		Operation: "set",
+	})
+	if err != nil {
+		return perr.InternalWithMessage("unable to encode the cluster state: " + err.Error())
+	}
+
+	return c.raft.Apply(data, 10*time.Second).Error()
+}
+
+// ScheduleTriggerLastRun returns the scheduled time of the last run of the schedule trigger by any leader of the
+// cluster, nil if the trigger hasn't run since the cluster started
+func (c *ClusterService) ScheduleTriggerLastRun(triggerName string) *time.Time {
+	value, err := c.fsm.Get(scheduleTriggerRunKeyPrefix + triggerName)
+	if err != nil {
+		return nil
+	}
+
+	lastRun, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		slog.Error("Error parsing schedule trigger run", "trigger", triggerName, "value", value, "error", err)
+		return nil
+	}
+	return &lastRun
+}
+
+// SetScheduleTriggerLastRun records the scheduled time of the run of the schedule trigger, the next leader catches up
+// from it rather than from the runs recorded by its own server
+func (c *ClusterService) SetScheduleTriggerLastRun(triggerName string, scheduledTime time.Time) error {
+	if c.raft == nil {
+		return perr.InternalWithMessage("the cluster service is not started")
+	}
+	return c.set(scheduleTriggerRunKeyPrefix+triggerName, scheduledTime.UTC().Format(time.RFC3339))
+}
+
+// LeaderCh returns a channel that is notified with true when this node becomes the leader and false when it stops
+// being the leader.
+func (c *ClusterService) LeaderCh() <-chan bool {
+	return c.leaderCh
+}
+
+func (c *ClusterService) IsLeader() bool {
+	if c.raft == nil {
+		return false
+	}
+	return c.raft.State() == raft.Leader
+}
+
+// Leader returns the ID of the current leader, empty if there's no leader
+func (c *ClusterService) Leader() string {
+	if c.raft == nil {
+		return ""
+	}
+	_, id := c.raft.LeaderWithID()
+	return string(id)
+}
+
+// Scheduler returns the ID of the node that is running the scheduled triggers as agreed by the cluster
+func (c *ClusterService) Scheduler() string {
+	nodeID, err := c.fsm.Get(schedulerKey)
+	if err != nil {
+		return ""
+	}
+	return nodeID
+}
+
+func (c *ClusterService) Stop() error {
+	if c.raft == nil {
+		return nil
+	}
+
+	// Hand over the leadership before leaving so the other nodes don't have to wait for the election timeout
+	if c.IsLeader() && len(c.Peers) > 1 {
+		err := c.raft.LeadershipTransfer().Error()
+		if err != nil {
+			slog.Warn("Error transferring cluster leadership", "node_id", c.NodeID, "error", err)
+		}
+	}
+
+	err := c.raft.Shutdown().Error()
+	if err != nil {
+		return err
+	}
+
+	close(c.closing)
+	c.wg.Wait()
+	close(c.leaderCh)
+
+	err = c.transport.Close()
+	if err != nil {
+		return err
+	}
+
+	c.Status = "stopped"
+	c.StoppedAt = utils.TimeNow()
+	return nil
+}
@@ -0,0 +1,281 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestClusterHelperProcess is not a real test, it runs a cluster node in its own process for TestClusterFailover
+func TestClusterHelperProcess(t *testing.T) {
+	nodeID := os.Getenv("FLOWPIPE_CLUSTER_TEST_NODE_ID")
+	if nodeID == "" {
+		return
+	}
+
+	c, err := NewClusterService(context.Background(), nodeID, os.Getenv("FLOWPIPE_CLUSTER_TEST_ADDRESS"), WithPeers(strings.Split(os.Getenv("FLOWPIPE_CLUSTER_TEST_PEERS"), ",")))
+	if err != nil {
+		fmt.Println("error", err)
+		os.Exit(1)
+	}
+
+	err = c.Start()
+	if err != nil {
+		fmt.Println("error", err)
+		os.Exit(1)
+	}
+
+	// runs until the test kills the process. The first leader records a run of a schedule trigger, the next leaders
+	// report the run they find in the cluster state
+	for isLeader := range c.LeaderCh() {
+		lastRun := c.ScheduleTriggerLastRun(testTriggerName)
+		if isLeader && lastRun == nil {
+			err := c.SetScheduleTriggerLastRun(testTriggerName, testTriggerRun)
+			if err != nil {
+				fmt.Println("error", err)
+				os.Exit(1)
+			}
+			lastRun = c.ScheduleTriggerLastRun(testTriggerName)
+		}
+
+		lastRunText := "none"
+		if lastRun != nil {
+			lastRunText = lastRun.Format(time.RFC3339)
+		}
+		fmt.Println("leader", isLeader, c.Scheduler(), lastRunText)
+	}
+}
+
+const testTriggerName = "local.trigger.schedule.nightly"
+
+var testTriggerRun = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+type testNode struct {
+	id  string
+	cmd *exec.Cmd
+}
+
+// leaderEvent is a leadership change reported by a node, or the error the node failed to start with
+type leaderEvent struct {
+	nodeID    string
+	isLeader  bool
+	scheduler string
+	lastRun   string
+	err       string
+}
+
+func startTestNode(t *testing.T, id, address, peers string, events chan<- leaderEvent) *testNode {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestClusterHelperProcess$") //nolint:gosec // test binary
+	cmd.Env = append(os.Environ(),
+		"FLOWPIPE_CLUSTER_TEST_NODE_ID="+id,
+		"FLOWPIPE_CLUSTER_TEST_ADDRESS="+address,
+		"FLOWPIPE_CLUSTER_TEST_PEERS="+peers,
+	)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if msg, ok := strings.CutPrefix(line, "error "); ok {
+				events <- leaderEvent{nodeID: id, err: msg}
+				continue
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) == 0 || fields[0] != "leader" {
+				continue
+			}
+
+			e := leaderEvent{
+				nodeID:   id,
+				isLeader: len(fields) > 1 && fields[1] == "true",
+			}
+			if len(fields) > 2 {
+				e.scheduler = fields[2]
+			}
+			if len(fields) > 3 {
+				e.lastRun = fields[3]
+			}
+			events <- e
+		}
+	}()
+
+	return &testNode{
+		id:  id,
+		cmd: cmd,
+	}
+}
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// waitForLeader returns the first node, other than the excluded node, that reports it's the leader
+func waitForLeader(events <-chan leaderEvent, exclude string, timeout time.Duration) *leaderEvent {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case e := <-events:
+			if e.isLeader && e.nodeID != exclude {
+				return &e
+			}
+		case <-deadline:
+			return nil
+		}
+	}
+}
+
+// TestClusterFailover runs a three node cluster, each node in its own process, and checks that the scheduler and the
+// runs of the schedule triggers are handed over to a new leader when the leader dies
+func TestClusterFailover(t *testing.T) {
+	assert := assert.New(t)
+
+	if testing.Short() {
+		t.Skip("skipping multi-process cluster test in short mode")
+	}
+
+	ids := []string{"node1", "node2", "node3"}
+	addresses := map[string]string{}
+	var peers []string
+	for _, id := range ids {
+		addresses[id] = freeAddress(t)
+		peers = append(peers, id+"="+addresses[id])
+	}
+
+	events := make(chan leaderEvent, 100)
+
+	nodes := map[string]*testNode{}
+	for _, id := range ids {
+		nodes[id] = startTestNode(t, id, addresses[id], strings.Join(peers, ","), events)
+	}
+	defer func() {
+		for _, node := range nodes {
+			_ = node.cmd.Process.Kill()
+			_ = node.cmd.Wait()
+		}
+	}()
+
+	leader := waitForLeader(events, "", 30*time.Second)
+	if leader == nil {
+		assert.FailNow("no leader elected")
+		return
+	}
+	assert.Equal(leader.nodeID, leader.scheduler)
+
+	// kill the leader, one of the remaining nodes must take over the scheduler
+	err := nodes[leader.nodeID].cmd.Process.Kill()
+	assert.Nil(err)
+
+	newLeader := waitForLeader(events, leader.nodeID, 30*time.Second)
+	if newLeader == nil {
+		assert.FailNow("no new leader elected after the leader died")
+		return
+	}
+	assert.NotEqual(leader.nodeID, newLeader.nodeID)
+	assert.Equal(newLeader.nodeID, newLeader.scheduler)
+
+	// the new leader catches up the schedule triggers from the runs of the dead leader
+	assert.Equal(testTriggerRun.Format(time.RFC3339), leader.lastRun)
+	assert.Equal(leader.lastRun, newLeader.lastRun)
+}
+
+// TestClusterFailoverTwoServers checks that a two node cluster is rejected: once one of the two nodes dies, the other
+// can't get a majority to elect itself and no node would run the scheduler
+func TestClusterFailoverTwoServers(t *testing.T) {
+	assert := assert.New(t)
+
+	if testing.Short() {
+		t.Skip("skipping multi-process cluster test in short mode")
+	}
+
+	ids := []string{"node1", "node2"}
+	addresses := map[string]string{}
+	var peers []string
+	for _, id := range ids {
+		addresses[id] = freeAddress(t)
+		peers = append(peers, id+"="+addresses[id])
+	}
+
+	events := make(chan leaderEvent, 100)
+
+	nodes := map[string]*testNode{}
+	for _, id := range ids {
+		nodes[id] = startTestNode(t, id, addresses[id], strings.Join(peers, ","), events)
+	}
+
+	// neither node starts, both exit with the error
+	failed := map[string]bool{}
+	deadline := time.After(10 * time.Second)
+	for len(failed) < len(ids) {
+		select {
+		case e := <-events:
+			assert.False(e.isLeader, "node %s should not be elected", e.nodeID)
+			if e.err != "" {
+				assert.Contains(e.err, "the cluster has 2 nodes, it needs at least 3 nodes")
+				failed[e.nodeID] = true
+			}
+		case <-deadline:
+			for _, node := range nodes {
+				_ = node.cmd.Process.Kill()
+				_ = node.cmd.Wait()
+			}
+			assert.FailNow("the nodes did not report the error", "failed: %v", failed)
+			return
+		}
+	}
+
+	for _, id := range ids {
+		err := nodes[id].cmd.Wait()
+		assert.NotNil(err, "node %s should not start", id)
+	}
+}
+
+func TestNewClusterServicePeers(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := NewClusterService(context.Background(), "node1", "127.0.0.1:7201", WithPeers([]string{"node2=127.0.0.1:7202", " node3=127.0.0.1:7203 ", ""}))
+	assert.Nil(err)
+	assert.Equal(3, len(c.Peers))
+	assert.Equal("node1", string(c.Peers[2].ID))
+
+	_, err = NewClusterService(context.Background(), "node1", "127.0.0.1:7201", WithPeers([]string{"node2"}))
+	assert.NotNil(err)
+
+	_, err = NewClusterService(context.Background(), "node1", "127.0.0.1:7201", WithPeers([]string{"node1=127.0.0.1:7209"}))
+	assert.NotNil(err)
+
+	// a single node or two nodes can't elect a new leader when a node dies
+	_, err = NewClusterService(context.Background(), "node1", "127.0.0.1:7201")
+	assert.NotNil(err)
+
+	_, err = NewClusterService(context.Background(), "node1", "127.0.0.1:7201", WithPeers([]string{"node2=127.0.0.1:7202"}))
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "the cluster has 2 nodes, it needs at least 3 nodes")
+	}
+
+	_, err = NewClusterService(context.Background(), "", "127.0.0.1:7201")
+	assert.NotNil(err)
+}
@@ -2,6 +2,7 @@ package es
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"os"
 	"time"
@@ -11,12 +12,17 @@ import (
 	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
 	slogwatermill "github.com/denisss025/slog-watermill"
 	_ "github.com/garsue/watermillzap"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/es/command"
+	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/execution"
 	"github.com/turbot/flowpipe/internal/es/handler"
 	"github.com/turbot/flowpipe/internal/log"
 	"github.com/turbot/flowpipe/internal/service/es/middleware"
+	"github.com/turbot/flowpipe/internal/store"
 	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/flowpipe/internal/worker"
+	"github.com/turbot/pipe-fittings/cache"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/perr"
 )
@@ -28,17 +34,46 @@ type ESService struct {
 	EventBus   command.FpEventBus
 	router     *message.Router
 
+	// The transport used by the command and event buses, see constants.MessageBusMemory and constants.MessageBusSQLite
+	messageBus     string
+	durablePubSubs []*durablePubSub
+	replayed       chan struct{}
+
+	// The queue of the steps run by the workers, nil if the steps are only run by this process
+	Workers *worker.Queue
+
 	RootMod   *modconfig.Mod
 	Status    string     `json:"status"`
 	StartedAt *time.Time `json:"started_at,omitempty"`
 	StoppedAt *time.Time `json:"stopped_at,omitempty"`
 }
 
-func NewESService(ctx context.Context) (*ESService, error) {
+// ESServiceOption defines a type of function to configures the ESService.
+type ESServiceOption func(*ESService)
+
+func WithMessageBus(messageBus string) ESServiceOption {
+	return func(es *ESService) {
+		es.messageBus = messageBus
+	}
+}
+
+// WithWorkers dispatches the step types handled by the queue to the workers
+func WithWorkers(workers *worker.Queue) ESServiceOption {
+	return func(es *ESService) {
+		es.Workers = workers
+	}
+}
+
+func NewESService(ctx context.Context, opts ...ESServiceOption) (*ESService, error) {
 	// Defaults
 	es := &ESService{
-		ctx:    ctx,
-		Status: "initialized",
+		ctx:        ctx,
+		Status:     "initialized",
+		messageBus: constants.DefaultMessageBus,
+	}
+	// Set options
+	for _, opt := range opts {
+		opt(es)
 	}
 	return es, nil
 }
@@ -75,8 +110,27 @@ func (es *ESService) Start() error {
 		// Persistent:          true,
 	}
 	wLogger := slogwatermill.New(log.FlowpipeLogger())
-	commandsPubSub := gochannel.NewGoChannel(goChannelConfig, wLogger)
-	eventsPubSub := gochannel.NewGoChannel(goChannelConfig, wLogger)
+
+	var commandsPubSub, eventsPubSub pubSub
+	switch es.messageBus {
+	case constants.MessageBusMemory:
+		commandsPubSub = gochannel.NewGoChannel(goChannelConfig, wLogger)
+		eventsPubSub = gochannel.NewGoChannel(goChannelConfig, wLogger)
+	case constants.MessageBusSQLite:
+		durableCommandsPubSub, err := newDurablePubSub("command", goChannelConfig, wLogger)
+		if err != nil {
+			return err
+		}
+		durableEventsPubSub, err := newDurablePubSub("event", goChannelConfig, wLogger)
+		if err != nil {
+			return err
+		}
+		es.durablePubSubs = []*durablePubSub{durableCommandsPubSub, durableEventsPubSub}
+		commandsPubSub = durableCommandsPubSub
+		eventsPubSub = durableEventsPubSub
+	default:
+		return perr.BadRequestWithMessage("invalid message bus " + es.messageBus + ", must be one of: " + constants.MessageBusMemory + ", " + constants.MessageBusSQLite)
+	}
 
 	// CQRS is built on messages router. Detailed documentation: https://watermill.io/docs/messages-router/
 	router, err := message.NewRouter(message.RouterConfig{
@@ -130,13 +184,14 @@ func (es *ESService) Start() error {
 				command.PipelineStartHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.StepPipelineFinishHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.StepQueueHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
-				command.StepStartHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
+				command.StepStartHandler{EventBus: &command.FpEventBusImpl{Eb: eb}, Workers: es.Workers},
 				command.StepForEachPlanHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.ExecutionQueueHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.ExecutionStartHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.ExecutionPlanHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.ExecutionFinishHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.ExecutionFailHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
+				command.ExecutionRetryHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.TriggerQueueHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.TriggerStartHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
 				command.TriggerFinishHandler{EventBus: &command.FpEventBusImpl{Eb: eb}},
@@ -160,6 +215,7 @@ func (es *ESService) Start() error {
 				handler.PipelinePlanned{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.PipelineQueued{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.PipelineResumed{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
+				handler.PipelineRetried{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.PipelineStarted{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.StepFinished{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.StepQueued{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
@@ -171,6 +227,7 @@ func (es *ESService) Start() error {
 				handler.ExecutionFinished{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.ExecutionFailed{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.ExecutionPaused{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
+				handler.ExecutionCancelled{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.TriggerQueued{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.TriggerStarted{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
 				handler.TriggerFailed{CommandBus: &handler.FpCommandBusImpl{Cb: cb}},
@@ -211,6 +268,92 @@ func (es *ESService) Start() error {
 		}
 	}()
 
+	es.replayed = make(chan struct{})
+	if len(es.durablePubSubs) > 0 {
+		go func() {
+			defer close(es.replayed)
+
+			// the Go Channel pub/sub drops messages published to a topic without subscribers, wait for the
+			// handlers to be subscribed before replaying
+			<-router.Running()
+			err := es.replayMessages()
+			if err != nil {
+				slog.Error("Error replaying unacknowledged messages", "error", err)
+			}
+		}()
+	} else {
+		close(es.replayed)
+	}
+
+	return nil
+}
+
+// Replayed returns a channel that is closed once the unacknowledged messages have been replayed, the executions
+// they belong to are in the cache by then.
+func (es *ESService) Replayed() <-chan struct{} {
+	return es.replayed
+}
+
+// pubSub is the transport behind the command and event buses
+type pubSub interface {
+	message.Publisher
+	message.Subscriber
+}
+
+// replayMessages re-publishes the commands and events that were not acknowledged before the server stopped. The
+// executions they belong to are loaded from the event log first so the handlers can find them.
+func (es *ESService) replayMessages() error {
+	for _, p := range es.durablePubSubs {
+		messages, err := store.ListMessages(p.db, p.bus)
+		if err != nil {
+			return err
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		slog.Info("Replaying unacknowledged messages", "bus", p.bus, "count", len(messages))
+
+		for _, m := range messages {
+			var payload struct {
+				Event *event.Event `json:"event"`
+			}
+			err := json.Unmarshal(m.Payload, &payload)
+			if err != nil || payload.Event == nil || payload.Event.ExecutionID == "" {
+				continue
+			}
+
+			err = loadExecution(payload.Event.ExecutionID)
+			if err != nil {
+				slog.Error("Error loading execution for message replay", "execution_id", payload.Event.ExecutionID, "error", err)
+			}
+		}
+
+		err = p.Replay(messages)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadExecution loads the execution from the event log into the cache unless it's already there
+func loadExecution(executionID string) error {
+	if _, found := cache.GetCache().Get(executionID); found {
+		return nil
+	}
+
+	ex, err := execution.LoadExecutionFromProcessDB(&event.Event{ExecutionID: executionID})
+	if err != nil {
+		return err
+	}
+
+	// Effectively forever
+	ok := cache.GetCache().SetWithTTL(executionID, ex, 10*365*24*time.Hour)
+	if !ok {
+		return perr.InternalWithMessage("Error setting execution in cache")
+	}
 	return nil
 }
 
@@ -219,5 +362,21 @@ func (es *ESService) Stop() error {
 	slog.Debug("ES stopping")
 	defer slog.Debug("ES stopped")
 
-	return es.router.Close()
+	// the steps waiting for a worker are left unfinished, they are recovered when the server restarts
+	if es.Workers != nil {
+		es.Workers.Close()
+	}
+
+	err := es.router.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range es.durablePubSubs {
+		err := p.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
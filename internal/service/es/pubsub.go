@@ -0,0 +1,165 @@
+package es
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/turbot/flowpipe/internal/es/command"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/store"
+)
+
+// durablePubSub is a Go Channel pub/sub that records every published message in the flowpipe.db message table until
+// the message has been acknowledged by its handler. Messages that were not acknowledged before the server stopped
+// (or crashed) are replayed on the next start.
+//
+// Flowpipe has a single handler per command and event topic, so the message is considered delivered as soon as
+// the first subscriber acknowledges it. The exception is the step start command: its handler runs the step in the
+// background, the message is only removed once the step is done and its follow-up event has been saved.
+type durablePubSub struct {
+	bus    string
+	db     *sql.DB
+	pubSub *gochannel.GoChannel
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	// the messages waiting to be acknowledged by their handler
+	delivering sync.WaitGroup
+}
+
+// the command topics are named after the command struct, see GenerateCommandsTopic
+var stepStartTopic = cqrs.FullyQualifiedStructName(&event.StepStart{})
+
+func newDurablePubSub(bus string, config gochannel.Config, logger watermill.LoggerAdapter) (*durablePubSub, error) {
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.CreateMessageTable(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &durablePubSub{
+		bus:     bus,
+		db:      db,
+		pubSub:  gochannel.NewGoChannel(config, logger),
+		closing: make(chan struct{}),
+	}, nil
+}
+
+func (p *durablePubSub) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return err
+		}
+
+		err = store.SaveMessage(p.db, p.bus, topic, msg.UUID, msg.Payload, string(metadata))
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.pubSub.Publish(topic, messages...)
+}
+
+func (p *durablePubSub) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	in, err := p.pubSub.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *message.Message)
+	go func() {
+		defer close(out)
+		for msg := range in {
+			if topic == stepStartTopic {
+				msg.SetContext(command.WithStepDone(msg.Context(), func() {
+					p.ack(msg.UUID)
+				}))
+			} else {
+				p.delivering.Add(1)
+				go p.ackOnDelivery(msg)
+			}
+
+			select {
+			case out <- msg:
+			case <-p.closing:
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// ackOnDelivery removes the message from the message table once the handler acknowledges it. A nacked message is
+// redelivered by the Go Channel pub/sub so it stays in the table.
+func (p *durablePubSub) ackOnDelivery(msg *message.Message) {
+	defer p.delivering.Done()
+
+	select {
+	case <-msg.Acked():
+		p.ack(msg.UUID)
+	case <-msg.Nacked():
+	case <-p.closing:
+		// the router waits for the running handlers before the pub/sub is closed, the message may have been
+		// acknowledged by then
+		select {
+		case <-msg.Acked():
+			p.ack(msg.UUID)
+		default:
+		}
+	}
+}
+
+func (p *durablePubSub) ack(uuid string) {
+	err := store.AckMessage(p.db, p.bus, uuid)
+	if err != nil {
+		slog.Error("Error acknowledging message", "bus", p.bus, "uuid", uuid, "error", err)
+	}
+}
+
+// Replay re-publishes the messages that have not been acknowledged. It must be called once the router is running,
+// the Go Channel pub/sub drops messages published to a topic without subscribers.
+func (p *durablePubSub) Replay(messages []store.Message) error {
+	for _, m := range messages {
+		msg := message.NewMessage(m.UUID, m.Payload)
+		if m.Metadata != "" {
+			err := json.Unmarshal([]byte(m.Metadata), &msg.Metadata)
+			if err != nil {
+				slog.Error("Error reading message metadata", "bus", p.bus, "uuid", m.UUID, "error", err)
+			}
+		}
+
+		slog.Info("Replaying message", "bus", p.bus, "topic", m.Topic, "uuid", m.UUID)
+		err := p.pubSub.Publish(m.Topic, msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *durablePubSub) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
+	p.delivering.Wait()
+
+	err := p.pubSub.Close()
+	if err != nil {
+		return err
+	}
+	return p.db.Close()
+}
@@ -54,14 +54,23 @@ func (m *Manager) modUpdated() {
 	m.rootModLoadLock.Lock()
 	defer m.rootModLoadLock.Unlock()
 
-	// At this point the w.Mod has already been updated, the code that does it is in pipe-fittings handleFileWatcherEvent function
+	// At this point the w.Mod has already been updated, the code that does it is in pipe-fittings handleFileWatcherEvent function.
+	// The pipelines and triggers of the previous load are kept if the pipeline triggers are invalid.
+	err := resources.ValidatePipelineTriggers(resources.GetModResources(m.workspace.Mod).Triggers)
+	if err != nil {
+		slog.Error("error validating the pipeline triggers", "error", err)
+		if output.IsServerMode {
+			output.RenderServerOutput(m.ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "Failed loading the pipeline triggers", err))
+		}
+		return
+	}
+
 	m.RootMod = m.workspace.Mod
 
 	// get resources from mod
 	modResources := resources.GetModResources(m.RootMod)
 
 	var serverOutput []sanitize.SanitizedStringer
-	var err error
 	slog.Info("caching pipelines and triggers")
 	serverOutput = append(serverOutput, types.NewServerOutputLoaded(types.NewServerOutputPrefix(time.Now(), "flowpipe"), m.RootMod.Name(), true))
 	m.triggers = modResources.Triggers
@@ -165,6 +174,10 @@ func (m *Manager) loadMod() error {
 	}
 
 	m.triggers = workspace.GetWorkspaceResourcesOfType[*resources.Trigger](w)
+	err = resources.ValidatePipelineTriggers(m.triggers)
+	if err != nil {
+		return err
+	}
 
 	cache.GetCache().SetWithTTL("#rootmod.name", mod.ShortName, 24*7*52*99*time.Hour)
 	err = m.cacheModData(mod)
@@ -24,11 +24,14 @@ import (
 	fparse "github.com/turbot/flowpipe/internal/parse"
 	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/flowpipe/internal/service/api"
+	"github.com/turbot/flowpipe/internal/service/cluster"
 	"github.com/turbot/flowpipe/internal/service/es"
 	"github.com/turbot/flowpipe/internal/service/scheduler"
 	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/tracing"
 	"github.com/turbot/flowpipe/internal/types"
 	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/flowpipe/internal/worker"
 	"github.com/turbot/go-kit/files"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/pipe-fittings/app_specific"
@@ -65,9 +68,13 @@ type Manager struct {
 	ESService        *es.ESService
 	apiService       *api.APIService
 	schedulerService *scheduler.SchedulerService
+	clusterService   *cluster.ClusterService
 
 	triggers map[string]*resources.Trigger
 
+	// flushes and stops the tracing exporter
+	stopTracing func(context.Context) error
+
 	HTTPAddress string
 	HTTPPort    int
 
@@ -114,7 +121,13 @@ func (m *Manager) Start() (*Manager, error) {
 	}
 
 	if m.shouldStartES() {
-		err := m.startESService()
+		stopTracing, err := tracing.Start(m.ctx, viper.GetString(fpconstants.ArgOtelEndpoint))
+		if err != nil {
+			return nil, err
+		}
+		m.stopTracing = stopTracing
+
+		err = m.startESService()
 		if err != nil {
 			return nil, err
 		}
@@ -134,12 +147,23 @@ func (m *Manager) Start() (*Manager, error) {
 		if err := m.startAPIService(); err != nil {
 			return nil, err
 		}
+
+		// only the server recovers the executions, a one-off local run would pick up the executions of a server
+		// that is still running. In a cluster every node recovers the executions it started, the executions are
+		// kept in the db and event logs of their node
+		m.recoverExecutions()
 	}
 
 	if m.shouldStartScheduler() {
 		if err := m.startSchedulerService(); err != nil {
 			return nil, err
 		}
+
+		if m.isClustered() {
+			if err := m.startClusterService(); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	m.StartedAt = utils.TimeNow()
@@ -288,7 +312,21 @@ func (m *Manager) initializeResources() error {
 
 func (m *Manager) startESService() error {
 	// start event sourcing service
-	esService, err := es.NewESService(m.ctx)
+	var opts []es.ESServiceOption
+	// only the server persists the bus messages, one-off local runs have nothing to recover after a restart
+	if m.shouldStartAPI() && viper.IsSet(fpconstants.ArgMessageBus) {
+		opts = append(opts, es.WithMessageBus(viper.GetString(fpconstants.ArgMessageBus)))
+	}
+	// the workers claim the steps from the server API
+	if m.shouldStartAPI() && viper.GetString(fpconstants.ArgWorkerToken) != "" {
+		stepTypes, err := worker.ParseStepTypes(viper.GetString(fpconstants.ArgWorkerStepTypes))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, es.WithWorkers(worker.NewQueue(stepTypes)))
+	}
+
+	esService, err := es.NewESService(m.ctx, opts...)
 	if err != nil {
 		return err
 	}
@@ -304,6 +342,30 @@ func (m *Manager) startESService() error {
 	return nil
 }
 
+// recoverExecutions resumes or fails (according to the pipeline on_restart policy) the executions that were running
+// when the server stopped
+func (m *Manager) recoverExecutions() {
+	// executions with unacknowledged messages are carried on by the replay
+	<-m.ESService.Replayed()
+
+	executionIDs, err := store.ListInFlightExecutionIDs()
+	if err != nil {
+		slog.Error("Error listing in-flight executions", "error", err)
+		return
+	}
+
+	for _, executionID := range executionIDs {
+		if _, found := cache.GetCache().Get(executionID); found {
+			continue
+		}
+
+		err := api.RecoverProcess(executionID, m.ESService)
+		if err != nil {
+			slog.Error("Error recovering execution", "execution_id", executionID, "error", err)
+		}
+	}
+}
+
 func (m *Manager) startAPIService() error {
 	// Define the API service
 	apiService, err := api.NewAPIService(m.ctx, m.ESService,
@@ -319,8 +381,17 @@ func (m *Manager) startAPIService() error {
 	return apiService.Start()
 }
 
+func (m *Manager) isClustered() bool {
+	return viper.GetString(fpconstants.ArgClusterAddress) != ""
+}
+
 func (m *Manager) startSchedulerService() error {
 	s := scheduler.NewSchedulerService(m.ctx, m.ESService, m.triggers)
+	if m.isClustered() {
+		// the triggers are scheduled once this server is elected leader
+		s.Standby()
+	}
+
 	if err := s.Start(); err != nil {
 		slog.Error("error starting scheduler service", "error", err)
 		return err
@@ -335,6 +406,44 @@ func (m *Manager) startSchedulerService() error {
 	return nil
 }
 
+func (m *Manager) startClusterService() error {
+	c, err := cluster.NewClusterService(m.ctx,
+		viper.GetString(fpconstants.ArgClusterNodeID),
+		viper.GetString(fpconstants.ArgClusterAddress),
+		cluster.WithPeers(strings.Split(viper.GetString(fpconstants.ArgClusterPeers), ",")))
+	if err != nil {
+		return err
+	}
+
+	// the leader catches up the schedule triggers from the runs of the previous leader
+	m.schedulerService.ClusterRuns = c
+
+	if err := c.Start(); err != nil {
+		slog.Error("error starting cluster service", "error", err)
+		return err
+	}
+
+	// only the leader runs the scheduled and query triggers, hand them over when the leadership changes
+	go func() {
+		for isLeader := range c.LeaderCh() {
+			if !isLeader {
+				slog.Info("Cluster leadership lost, scheduled triggers are run by the new leader", "node_id", c.NodeID)
+				m.schedulerService.Standby()
+				continue
+			}
+
+			slog.Info("Cluster leadership acquired, running scheduled triggers", "node_id", c.NodeID)
+			err := m.schedulerService.Activate()
+			if err != nil {
+				slog.Error("error scheduling triggers", "error", err)
+			}
+		}
+	}()
+
+	m.clusterService = c
+	return nil
+}
+
 // Stop stops services managed by the Manager.
 func (m *Manager) Stop() error {
 	slog.Debug("manager stopping")
@@ -346,6 +455,13 @@ func (m *Manager) Stop() error {
 		// _ = slog.Sync()
 	}()
 
+	if m.clusterService != nil {
+		if err := m.clusterService.Stop(); err != nil {
+			// Log and continue stopping other services
+			slog.Error("error stopping cluster service", "error", err)
+		}
+	}
+
 	if m.apiService != nil {
 		if err := m.apiService.Stop(); err != nil {
 			// Log and continue stopping other services
@@ -360,6 +476,12 @@ func (m *Manager) Stop() error {
 		}
 	}
 
+	if m.stopTracing != nil {
+		if err := m.stopTracing(context.Background()); err != nil {
+			slog.Error("error stopping tracing", "error", err)
+		}
+	}
+
 	// Cleanup docker artifacts
 	// TODO - Can we remove this since we cleanup per function etc?
 	if docker.GlobalDockerClient != nil {
@@ -626,6 +748,9 @@ func renderServerTriggers(triggers map[string]*resources.Trigger) []sanitize.San
 		case schema.TriggerTypeSchedule:
 			if tc, ok := t.Config.(*resources.TriggerSchedule); ok {
 				o.Schedule = &tc.Schedule
+				if tc.Timezone != "" {
+					o.Timezone = &tc.Timezone
+				}
 				outputs = append(outputs, o)
 			}
 		case schema.TriggerTypeQuery:
@@ -634,6 +759,27 @@ func renderServerTriggers(triggers map[string]*resources.Trigger) []sanitize.San
 				o.Sql = &tc.Sql
 				outputs = append(outputs, o)
 			}
+		case fpconstants.TriggerTypeFile:
+			if tc, ok := t.Config.(*resources.TriggerFile); ok {
+				o.Path = &tc.Path
+				outputs = append(outputs, o)
+			}
+		case fpconstants.TriggerTypeQueue:
+			if tc, ok := t.Config.(*resources.TriggerQueue); ok {
+				o.Subject = &tc.Subject
+				outputs = append(outputs, o)
+			}
+		case fpconstants.TriggerTypePipeline:
+			if tc, ok := t.Config.(*resources.TriggerPipeline); ok {
+				o.Upstream = &tc.Upstream
+				outputs = append(outputs, o)
+			}
+		case fpconstants.TriggerTypeEmail:
+			if tc, ok := t.Config.(*resources.TriggerEmail); ok {
+				o.Schedule = &tc.Schedule
+				o.Mailbox = &tc.Mailbox
+				outputs = append(outputs, o)
+			}
 		}
 	}
 
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/store"
+)
+
+// ScheduleTriggerRuns keeps the last run of each schedule trigger in the cluster state. A server elected leader
+// catches up from the runs of the previous leader, its own runs are out of date once it has been a follower.
+type ScheduleTriggerRuns interface {
+	ScheduleTriggerLastRun(triggerName string) *time.Time
+	SetScheduleTriggerLastRun(triggerName string, scheduledTime time.Time) error
+}
+
+// saveScheduleTriggerRun records the scheduled time of the run of a schedule trigger, the runs after it that are
+// missed while the server is down are run according to the catch_up policy of the trigger when it starts
+func saveScheduleTriggerRun(triggerName string, scheduledTime time.Time, clusterRuns ScheduleTriggerRuns) {
+	if clusterRuns != nil {
+		err := clusterRuns.SetScheduleTriggerLastRun(triggerName, scheduledTime)
+		if err != nil {
+			slog.Error("Error saving schedule trigger run in the cluster", "trigger", triggerName, "error", err)
+		}
+	}
+
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return
+	}
+	defer db.Close()
+
+	err = store.CreateScheduleTriggerRunTable(db)
+	if err != nil {
+		return
+	}
+
+	err = store.SetScheduleTriggerLastRun(db, triggerName, scheduledTime)
+	if err != nil {
+		slog.Error("Error saving schedule trigger run", "trigger", triggerName, "error", err)
+	}
+}
+
+// catchUpScheduleTriggers runs the schedule triggers that missed their runs while the server was down, the pipelines
+// are run with the time the trigger was scheduled to run
+func (s *SchedulerService) catchUpScheduleTriggers() {
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return
+	}
+	defer db.Close()
+
+	err = store.CreateScheduleTriggerRunTable(db)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	for _, t := range s.Triggers {
+		config, ok := t.Config.(*resources.TriggerSchedule)
+		if !ok || (t.Enabled != nil && !*t.Enabled) {
+			continue
+		}
+
+		lastRun, err := store.GetScheduleTriggerLastRun(db, t.FullName)
+		if err != nil {
+			continue
+		}
+
+		// the cluster knows of the runs of the previous leaders, the runs of this server are only used when the whole
+		// cluster was down
+		if s.ClusterRuns != nil {
+			lastRun = latestRun(lastRun, s.ClusterRuns.ScheduleTriggerLastRun(t.FullName))
+		}
+
+		// nothing is known about the runs before this one, the runs are caught up from now on
+		if lastRun == nil {
+			s.saveCatchUpRun(db, t, now)
+			continue
+		}
+
+		cronExpression, err := config.GetCronExpression(t.FullName)
+		if err != nil {
+			slog.Error("Error getting cron expression of trigger", "trigger", t.Name(), "error", err)
+			continue
+		}
+
+		missedRuns, err := missedScheduleRuns(cronExpression, *lastRun, now)
+		if err != nil {
+			slog.Error("Error finding missed runs of trigger", "trigger", t.Name(), "error", err)
+			continue
+		}
+
+		if len(missedRuns) == 0 {
+			continue
+		}
+
+		var catchUpRuns []time.Time
+		switch config.CatchUp {
+		case constants.CatchUpAll:
+			catchUpRuns = missedRuns
+		case constants.CatchUpLatest:
+			catchUpRuns = missedRuns[len(missedRuns)-1:]
+		}
+
+		slog.Info("Trigger missed runs", "trigger", t.Name(), "catch_up", config.CatchUp, "missed", len(missedRuns), "runs", len(catchUpRuns), "last_run", lastRun)
+
+		for _, scheduledTime := range catchUpRuns {
+			scheduledTime := scheduledTime
+			executionCmd := event.NewExecutionQueueForTrigger("", t.Name())
+			executionCmd.TriggerQueue.ScheduledTime = &scheduledTime
+
+			if err := sendTriggerExecution(s.ctx, s.esService.CommandBus, t, executionCmd); err != nil {
+				slog.Error("Error sending trigger command", "trigger", t.Name(), "scheduled_time", scheduledTime, "error", err)
+			}
+		}
+
+		// the missed runs are not run again, whatever the policy
+		s.saveCatchUpRun(db, t, missedRuns[len(missedRuns)-1])
+	}
+}
+
+func (s *SchedulerService) saveCatchUpRun(db *sql.DB, t *resources.Trigger, scheduledTime time.Time) {
+	if s.ClusterRuns != nil {
+		err := s.ClusterRuns.SetScheduleTriggerLastRun(t.FullName, scheduledTime)
+		if err != nil {
+			slog.Error("Error saving schedule trigger run in the cluster", "trigger", t.Name(), "error", err)
+		}
+	}
+
+	err := store.SetScheduleTriggerLastRun(db, t.FullName, scheduledTime)
+	if err != nil {
+		slog.Error("Error saving schedule trigger run", "trigger", t.Name(), "error", err)
+	}
+}
+
+// latestRun returns the later of the two runs, either may be nil
+func latestRun(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
+
+// missedScheduleRuns returns the times the cron expression was due after the last run and before now, at most the
+// latest MaxCatchUpRuns of them, e.g. a trigger that runs every 5 minutes on a server that was down for a week only
+// runs the latest ones
+func missedScheduleRuns(cronExpression string, lastRun, now time.Time) ([]time.Time, error) {
+	cronSchedule, err := cron.ParseStandard(cronExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	var missed []time.Time
+	// the next time is zero if the cron expression is never due, e.g. 30 February
+	for next := cronSchedule.Next(lastRun); !next.IsZero() && next.Before(now); next = cronSchedule.Next(next) {
+		missed = append(missed, next)
+		if len(missed) > constants.MaxCatchUpRuns {
+			missed = missed[1:]
+		}
+	}
+
+	return missed, nil
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissedScheduleRuns(t *testing.T) {
+	assert := assert.New(t)
+
+	lastRun := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 4, 8, 30, 0, 0, time.UTC)
+
+	missed, err := missedScheduleRuns("0 9 * * *", lastRun, now)
+	assert.Nil(err)
+	assert.Equal([]time.Time{
+		time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 3, 9, 0, 0, 0, time.UTC),
+	}, missed)
+
+	// 9am in New York is 2pm UTC
+	missed, err = missedScheduleRuns("CRON_TZ=America/New_York 0 9 * * *", lastRun, now)
+	assert.Nil(err)
+	assert.Equal(3, len(missed))
+	assert.Equal(time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC), missed[0].UTC())
+	assert.Equal(time.Date(2024, 3, 3, 14, 0, 0, 0, time.UTC), missed[2].UTC())
+
+	// nothing missed
+	missed, err = missedScheduleRuns("0 9 * * *", lastRun, lastRun.Add(time.Hour))
+	assert.Nil(err)
+	assert.Nil(missed)
+
+	// only the latest runs of a frequent schedule
+	missed, err = missedScheduleRuns("* * * * *", lastRun, lastRun.Add(24*time.Hour))
+	assert.Nil(err)
+	assert.Equal(100, len(missed))
+	assert.Equal(lastRun.Add(24*time.Hour-time.Minute), missed[99])
+
+	// never due
+	missed, err = missedScheduleRuns("0 0 30 2 *", lastRun, now)
+	assert.Nil(err)
+	assert.Nil(missed)
+
+	_, err = missedScheduleRuns("every day", lastRun, now)
+	assert.NotNil(err)
+}
+
+func TestLatestRun(t *testing.T) {
+	assert := assert.New(t)
+
+	earlier := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	later := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// the runs of the cluster are later than the runs of a server that was a follower
+	assert.Equal(&later, latestRun(&earlier, &later))
+	assert.Equal(&later, latestRun(&later, &earlier))
+	assert.Equal(&earlier, latestRun(nil, &earlier))
+	assert.Equal(&earlier, latestRun(&earlier, nil))
+	assert.Nil(latestRun(nil, nil))
+}
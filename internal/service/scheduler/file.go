@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/metrics"
+	"github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/tracing"
+	"github.com/turbot/flowpipe/internal/trigger"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/util"
+)
+
+type fileTriggerWatch struct {
+	config  *resources.TriggerFile
+	watcher *trigger.FileWatcher
+}
+
+// watchFileTriggers watches the files of the enabled file triggers. The watches of the triggers that have been
+// removed, disabled or changed are stopped.
+func (s *SchedulerService) watchFileTriggers() {
+	if s.fileWatches == nil {
+		s.fileWatches = map[string]*fileTriggerWatch{}
+	}
+
+	for name, watch := range s.fileWatches {
+		t := s.Triggers[name]
+		if t == nil || (t.Enabled != nil && !*t.Enabled) || !watch.config.Equals(t.Config) {
+			slog.Info("Removing file trigger", "name", name)
+			watch.watcher.Stop()
+			delete(s.fileWatches, name)
+		}
+	}
+
+	for name, t := range s.Triggers {
+		config, ok := t.Config.(*resources.TriggerFile)
+		if !ok || s.fileWatches[name] != nil {
+			continue
+		}
+
+		if t.Enabled != nil && !*t.Enabled {
+			slog.Debug("Trigger is disabled", "name", t.Name())
+			continue
+		}
+
+		triggerName := name
+		watcher := trigger.NewFileWatcher(config, func(path, fileEvent string) {
+			s.runFileTrigger(triggerName, path, fileEvent)
+		})
+
+		// a missing directory shouldn't stop the other triggers, the watch is retried when the mod is reloaded
+		if err := watcher.Start(); err != nil {
+			slog.Error("Error watching files of trigger", "name", t.Name(), "path", config.Path, "error", err)
+			if output.IsServerMode {
+				output.RenderServerOutput(s.ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "watching files of trigger "+t.Name(), err))
+			}
+			continue
+		}
+
+		slog.Info("Watching files", "name", t.Name(), "path", config.Path, "events", config.Events, "debounce", config.Debounce)
+		s.fileWatches[name] = &fileTriggerWatch{
+			config:  config,
+			watcher: watcher,
+		}
+	}
+}
+
+func (s *SchedulerService) stopFileWatches() {
+	for name, watch := range s.fileWatches {
+		slog.Info("Removing file trigger", "name", name)
+		watch.watcher.Stop()
+		delete(s.fileWatches, name)
+	}
+}
+
+// runFileTrigger queues the pipeline of the file trigger with the changed file
+func (s *SchedulerService) runFileTrigger(triggerName, path, fileEvent string) {
+	s.mu.Lock()
+	t := s.Triggers[triggerName]
+	s.mu.Unlock()
+
+	if t == nil {
+		return
+	}
+
+	executionID := util.NewExecutionId()
+	triggerRunner := trigger.NewTriggerRunnerFile(t, executionID, "", path, fileEvent)
+
+	metrics.TriggerFired(t.Name(), t.Config.GetType())
+	tracing.StartTrigger(context.Background(), executionID, t.Name(), t.Config.GetType())
+
+	cmds, err := triggerRunner.GetPipelineQueuesWithArgs(s.ctx, nil, nil)
+	if err != nil {
+		slog.Error("Error executing trigger", "trigger", t.Name(), "path", path, "error", err)
+		tracing.EndTrigger(executionID, err)
+		metrics.TriggerFailed(t.Name(), t.Config.GetType())
+		if output.IsServerMode {
+			output.RenderServerOutput(s.ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "executing trigger", err))
+		}
+		return
+	}
+
+	for _, cmd := range cmds {
+		executionCmd := &event.ExecutionQueue{
+			Event:         event.NewEventForExecutionID(executionID),
+			PipelineQueue: cmd,
+		}
+
+		if err := s.esService.Send(executionCmd); err != nil {
+			slog.Error("Error sending pipeline command", "trigger", t.Name(), "error", err)
+			tracing.EndTrigger(executionID, err)
+			metrics.TriggerFailed(t.Name(), t.Config.GetType())
+		}
+	}
+}
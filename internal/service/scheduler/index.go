@@ -6,6 +6,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
@@ -24,6 +25,20 @@ type SchedulerService struct {
 	Triggers      map[string]*resources.Trigger
 	esService     *es.ESService
 	cronScheduler *gocron.Scheduler
+
+	// the file triggers aren't scheduled, their files are watched
+	fileWatches map[string]*fileTriggerWatch
+
+	// the queue triggers aren't scheduled either, their subjects are subscribed to
+	queueSubscriptions map[string]*queueTriggerSubscription
+
+	// In standby the scheduled and query triggers are not run, i.e. this server is not the cluster leader. The
+	// core services are always run.
+	standby bool
+	mu      *sync.Mutex
+
+	// the runs of the schedule triggers shared by the nodes of the cluster, nil when the server isn't clustered
+	ClusterRuns ScheduleTriggerRuns
 }
 
 func NewSchedulerService(ctx context.Context, esService *es.ESService, triggers map[string]*resources.Trigger) *SchedulerService {
@@ -31,11 +46,55 @@ func NewSchedulerService(ctx context.Context, esService *es.ESService, triggers
 		ctx:       ctx,
 		esService: esService,
 		Triggers:  triggers,
+		mu:        &sync.Mutex{},
 	}
 }
 
-func (s *SchedulerService) RescheduleTriggers() error {
+// Standby stops running the scheduled, query, file and queue triggers until the scheduler is activated
+func (s *SchedulerService) Standby() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.standby = true
+	s.stopFileWatches()
+	s.stopQueueSubscriptions()
 	if s.cronScheduler == nil {
+		return
+	}
+
+	for _, job := range s.cronScheduler.Jobs() {
+		jobTags := job.Tags()
+		if len(jobTags) > 0 && strings.HasPrefix(jobTags[0], "id:") {
+			slog.Info("Removing trigger", "name", jobTags[0])
+			s.cronScheduler.RemoveByReference(job)
+		}
+	}
+}
+
+// Activate schedules the triggers of a scheduler in standby
+func (s *SchedulerService) Activate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.standby = false
+	err := s.rescheduleTriggers()
+	if err != nil {
+		return err
+	}
+
+	s.catchUpScheduleTriggers()
+	return nil
+}
+
+func (s *SchedulerService) RescheduleTriggers() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rescheduleTriggers()
+}
+
+func (s *SchedulerService) rescheduleTriggers() error {
+	if s.cronScheduler == nil || s.standby {
 		return nil
 	}
 
@@ -45,13 +104,23 @@ func (s *SchedulerService) RescheduleTriggers() error {
 		var scheduleString string
 		switch config := t.Config.(type) {
 		case *resources.TriggerSchedule:
-			scheduleString = config.Schedule
+			// the cron expression has the time zone of the schedule, a change of time zone reschedules the trigger
+			var err error
+			scheduleString, err = config.GetCronExpression(t.FullName)
+			if err != nil {
+				return err
+			}
 		case *resources.TriggerQuery:
 			scheduleString = config.Schedule
 			if scheduleString == "" {
 				scheduleString = "hourly"
 			}
-		case *resources.TriggerHttp:
+		case *resources.TriggerEmail:
+			scheduleString = config.Schedule
+			if scheduleString == "" {
+				scheduleString = "hourly"
+			}
+		case *resources.TriggerHttp, *resources.TriggerFile, *resources.TriggerQueue, *resources.TriggerPipeline:
 			continue
 		}
 
@@ -120,6 +189,9 @@ func (s *SchedulerService) RescheduleTriggers() error {
 		}
 	}
 
+	s.watchFileTriggers()
+	s.subscribeQueueTriggers()
+
 	return nil
 }
 
@@ -129,14 +201,23 @@ func (s *SchedulerService) scheduleTrigger(t *resources.Trigger) error {
 
 	switch config := t.Config.(type) {
 	case *resources.TriggerSchedule:
-		scheduleString = config.Schedule
+		var err error
+		scheduleString, err = config.GetCronExpression(t.FullName)
+		if err != nil {
+			return err
+		}
 	case *resources.TriggerQuery:
 		scheduleString = config.Schedule
 		if scheduleString == "" {
 			scheduleString = "hourly"
 		}
+	case *resources.TriggerEmail:
+		scheduleString = config.Schedule
+		if scheduleString == "" {
+			scheduleString = "hourly"
+		}
 	default:
-		// can't schedule HTTP Trigger
+		// can't schedule HTTP and File Trigger
 		return nil
 	}
 
@@ -173,6 +254,7 @@ func (s *SchedulerService) scheduleTrigger(t *resources.Trigger) error {
 	scheduledTriggerRunner := TriggerScheduleRunner{
 		TriggerRunner: triggerRunner,
 		CommandBus:    s.esService.CommandBus,
+		ClusterRuns:   s.ClusterRuns,
 	}
 
 	_, err := s.cronScheduler.Cron(scheduleString).Tag(tags...).Do(scheduledTriggerRunner.Run)
@@ -192,15 +274,28 @@ func (s *SchedulerService) scheduleTrigger(t *resources.Trigger) error {
 }
 
 func (s *SchedulerService) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.cronScheduler = gocron.NewScheduler(time.UTC)
 
 	for _, t := range s.Triggers {
+		if s.standby {
+			break
+		}
+
 		err := s.scheduleTrigger(t)
 		if err != nil {
 			return err
 		}
 	}
 
+	if !s.standby {
+		s.watchFileTriggers()
+		s.subscribeQueueTriggers()
+		s.catchUpScheduleTriggers()
+	}
+
 	s.cronScheduler.StartAsync()
 	return nil
 }
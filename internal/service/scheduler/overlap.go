@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/execution"
+	"github.com/turbot/flowpipe/internal/es/handler"
+	"github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/trigger"
+	"github.com/turbot/flowpipe/internal/types"
+)
+
+// sendTriggerExecution sends the execution of a fire of the trigger according to the overlap policy of the trigger,
+// the skipped fires and the cancelled executions are recorded as trigger events
+func sendTriggerExecution(ctx context.Context, commandBus handler.FpCommandBus, t *resources.Trigger, executionCmd *event.ExecutionQueue) error {
+	executionID := executionCmd.Event.ExecutionID
+
+	outcome, running := trigger.StartExecution(t.Name(), t.Overlap, executionCmd)
+	switch outcome {
+	case trigger.OverlapSkipped:
+		reason := "the previous execution " + strings.Join(running, ", ") + " is still running"
+		slog.Info("Trigger skipped", "trigger", t.Name(), "reason", reason)
+		if output.IsServerMode {
+			output.RenderServerOutput(ctx, types.NewServerOutput(time.Now(), "flowpipe", "Trigger "+t.Name()+" skipped, "+reason))
+		}
+		saveTriggerEvent(t.Name(), constants.TriggerEventSkipped, "", reason)
+		return nil
+	case trigger.OverlapQueued:
+		slog.Info("Trigger queued", "trigger", t.Name(), "execution_id", executionID, "running", running)
+		return nil
+	}
+
+	// the running executions are replaced with cancel_previous, there are none otherwise
+	for _, previousExecutionID := range running {
+		reason := "cancelled by the execution " + executionID + " of the trigger"
+		if !cancelTriggerExecution(ctx, commandBus, previousExecutionID, reason) {
+			continue
+		}
+		saveTriggerEvent(t.Name(), constants.TriggerEventCancelled, previousExecutionID, reason)
+	}
+
+	err := commandBus.Send(ctx, executionCmd)
+	if err != nil {
+		// no end event takes the execution that was never sent out of the running executions of the trigger, the
+		// later fires would be skipped or queued behind it forever
+		handler.SendQueuedTriggerExecution(ctx, commandBus, executionID)
+		return err
+	}
+	return nil
+}
+
+// cancelTriggerExecution cancels the root pipelines of the execution, it returns false if the execution is no longer
+// running or has no pipeline to cancel yet
+func cancelTriggerExecution(ctx context.Context, commandBus handler.FpCommandBus, executionID, reason string) bool {
+	ex, err := execution.GetExecution(executionID)
+	if err != nil {
+		slog.Warn("Unable to cancel the previous execution of the trigger", "execution_id", executionID, "error", err)
+		return false
+	}
+
+	cancelled := false
+	for _, rootPipelineExecutionID := range ex.RootPipelines {
+		pex, ok := ex.PipelineExecutions[rootPipelineExecutionID]
+		if !ok || slices.Contains(event.EndEvents, pex.Status) {
+			continue
+		}
+
+		cmd, err := event.NewPipelineCancel(executionID, pex.ID, event.WithPipelineCancelReason(reason))
+		if err != nil {
+			slog.Error("Error creating pipeline cancel command", "execution_id", executionID, "error", err)
+			continue
+		}
+
+		if err := commandBus.Send(ctx, cmd); err != nil {
+			slog.Error("Error sending pipeline cancel command", "execution_id", executionID, "error", err)
+			continue
+		}
+		cancelled = true
+	}
+
+	return cancelled
+}
+
+// saveTriggerEvent records an event of a trigger that didn't run its pipeline as usual, the events are shown by trigger
+// show
+func saveTriggerEvent(triggerName, eventType, executionID, reason string) {
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return
+	}
+	defer db.Close()
+
+	err = store.CreateTriggerEventTable(db)
+	if err != nil {
+		return
+	}
+
+	err = store.SaveTriggerEvent(db, triggerName, eventType, executionID, reason)
+	if err != nil {
+		slog.Error("Error saving trigger event", "trigger", triggerName, "type", eventType, "error", err)
+	}
+}
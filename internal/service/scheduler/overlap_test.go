@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/es/handler"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// switchCommandBus records the commands it sends, or fails to send them
+type switchCommandBus struct {
+	fail bool
+	sent []string
+}
+
+func (c *switchCommandBus) Send(ctx context.Context, cmd interface{}) error {
+	if c.fail {
+		return errors.New("command bus closed")
+	}
+	c.sent = append(c.sent, cmd.(*event.ExecutionQueue).Event.ExecutionID)
+	return nil
+}
+
+func setupOverlapTest(t *testing.T) {
+	viper.Set(constants.ArgDataDir, t.TempDir())
+	t.Cleanup(func() { viper.Set(constants.ArgDataDir, "") })
+
+	err := store.InitializeFlowpipeDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendTriggerExecutionFailed(t *testing.T) {
+	setupOverlapTest(t)
+
+	for _, overlap := range []string{"skip", "queue"} {
+		t.Run(overlap, func(t *testing.T) {
+			assert := assert.New(t)
+
+			trg := &resources.Trigger{
+				HclResourceImpl: modconfig.HclResourceImpl{FullName: "local.trigger.schedule.send_failed_" + overlap},
+				Overlap:         overlap,
+			}
+			commandBus := &switchCommandBus{fail: true}
+
+			// the fire that isn't sent isn't left running
+			failed := event.NewExecutionQueueForTrigger("", trg.Name())
+			err := sendTriggerExecution(context.Background(), commandBus, trg, failed)
+			assert.NotNil(err)
+
+			commandBus.fail = false
+			next := event.NewExecutionQueueForTrigger("", trg.Name())
+			err = sendTriggerExecution(context.Background(), commandBus, trg, next)
+			assert.Nil(err)
+			assert.Equal([]string{next.Event.ExecutionID}, commandBus.sent)
+		})
+	}
+}
+
+func TestSendQueuedTriggerExecutionFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	setupOverlapTest(t)
+
+	trg := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{FullName: "local.trigger.schedule.queued_send_failed"},
+		Overlap:         "queue",
+	}
+	commandBus := &switchCommandBus{}
+
+	running := event.NewExecutionQueueForTrigger("", trg.Name())
+	assert.Nil(sendTriggerExecution(context.Background(), commandBus, trg, running))
+
+	queued := event.NewExecutionQueueForTrigger("", trg.Name())
+	assert.Nil(sendTriggerExecution(context.Background(), commandBus, trg, queued))
+	assert.Equal([]string{running.Event.ExecutionID}, commandBus.sent)
+
+	// the queued fire can't be sent once the running execution ends, it isn't left running either
+	commandBus.fail = true
+	handler.SendQueuedTriggerExecution(context.Background(), commandBus, running.Event.ExecutionID)
+
+	commandBus.fail = false
+	next := event.NewExecutionQueueForTrigger("", trg.Name())
+	assert.Nil(sendTriggerExecution(context.Background(), commandBus, trg, next))
+	assert.Equal([]string{running.Event.ExecutionID, next.Event.ExecutionID}, commandBus.sent)
+}
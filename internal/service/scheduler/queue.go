@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/metrics"
+	"github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/tracing"
+	"github.com/turbot/flowpipe/internal/trigger"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/util"
+)
+
+type queueTriggerSubscription struct {
+	config     *resources.TriggerQueue
+	subscriber *trigger.QueueSubscriber
+}
+
+// subscribeQueueTriggers subscribes to the subjects of the enabled queue triggers. The subscriptions of the triggers
+// that have been removed, disabled or changed are stopped.
+func (s *SchedulerService) subscribeQueueTriggers() {
+	if s.queueSubscriptions == nil {
+		s.queueSubscriptions = map[string]*queueTriggerSubscription{}
+	}
+
+	for name, sub := range s.queueSubscriptions {
+		t := s.Triggers[name]
+		if t == nil || (t.Enabled != nil && !*t.Enabled) || !sub.config.Equals(t.Config) {
+			slog.Info("Removing queue trigger", "name", name)
+			sub.subscriber.Stop()
+			delete(s.queueSubscriptions, name)
+		}
+	}
+
+	for name, t := range s.Triggers {
+		config, ok := t.Config.(*resources.TriggerQueue)
+		if !ok || s.queueSubscriptions[name] != nil {
+			continue
+		}
+
+		if t.Enabled != nil && !*t.Enabled {
+			slog.Debug("Trigger is disabled", "name", t.Name())
+			continue
+		}
+
+		triggerName := name
+		subscriber := trigger.NewQueueSubscriber(config, func(msg *nats.Msg) {
+			s.runQueueTrigger(triggerName, msg)
+		})
+
+		// an unavailable NATS server shouldn't stop the other triggers, the subscription is retried when the mod is
+		// reloaded
+		if err := subscriber.Start(); err != nil {
+			slog.Error("Error subscribing to the subject of trigger", "name", t.Name(), "subject", config.Subject, "error", err)
+			if output.IsServerMode {
+				output.RenderServerOutput(s.ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "subscribing to the subject of trigger "+t.Name(), err))
+			}
+			continue
+		}
+
+		slog.Info("Subscribed to queue", "name", t.Name(), "url", config.Url, "subject", config.Subject, "consumer", config.Consumer)
+		s.queueSubscriptions[name] = &queueTriggerSubscription{
+			config:     config,
+			subscriber: subscriber,
+		}
+	}
+}
+
+func (s *SchedulerService) stopQueueSubscriptions() {
+	for name, sub := range s.queueSubscriptions {
+		slog.Info("Removing queue trigger", "name", name)
+		sub.subscriber.Stop()
+		delete(s.queueSubscriptions, name)
+	}
+}
+
+// runQueueTrigger queues the pipeline of the queue trigger with the received message. The message of a JetStream
+// consumer is acked when the execution ends, it is redelivered if the execution can't be sent.
+func (s *SchedulerService) runQueueTrigger(triggerName string, msg *nats.Msg) {
+	s.mu.Lock()
+	t := s.Triggers[triggerName]
+	s.mu.Unlock()
+
+	if t == nil {
+		return
+	}
+
+	config, ok := t.Config.(*resources.TriggerQueue)
+	if !ok {
+		return
+	}
+
+	executionID := util.NewExecutionId()
+	triggerRunner := trigger.NewTriggerRunnerQueue(t, executionID, "", msg)
+
+	metrics.TriggerFired(t.Name(), t.Config.GetType())
+	tracing.StartTrigger(context.Background(), executionID, t.Name(), t.Config.GetType())
+
+	cmds, err := triggerRunner.GetPipelineQueuesWithArgs(s.ctx, nil, nil)
+	if err != nil {
+		slog.Error("Error executing trigger", "trigger", t.Name(), "subject", msg.Subject, "error", err)
+		tracing.EndTrigger(executionID, err)
+		metrics.TriggerFailed(t.Name(), t.Config.GetType())
+		if output.IsServerMode {
+			output.RenderServerOutput(s.ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "executing trigger", err))
+		}
+		if config.IsJetStream() {
+			// the args of the message can't be evaluated, redelivering it wouldn't help
+			if err := msg.Term(); err != nil {
+				slog.Error("Error terminating queue message", "trigger", t.Name(), "error", err)
+			}
+		}
+		return
+	}
+
+	// tracked before the execution is sent, the execution may end before the send returns
+	if config.IsJetStream() {
+		trigger.TrackQueueMessage(executionID, msg)
+	}
+
+	for _, cmd := range cmds {
+		executionCmd := &event.ExecutionQueue{
+			Event:         event.NewEventForExecutionID(executionID),
+			PipelineQueue: cmd,
+		}
+
+		if err := s.esService.Send(executionCmd); err != nil {
+			slog.Error("Error sending pipeline command", "trigger", t.Name(), "error", err)
+			tracing.EndTrigger(executionID, err)
+			metrics.TriggerFailed(t.Name(), t.Config.GetType())
+			trigger.EndQueueMessage(executionID, constants.StateFailed)
+		}
+	}
+}
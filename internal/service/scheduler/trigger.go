@@ -3,24 +3,35 @@ package scheduler
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/turbot/flowpipe/internal/es/event"
 	"github.com/turbot/flowpipe/internal/es/handler"
+	"github.com/turbot/flowpipe/internal/resources"
 	"github.com/turbot/flowpipe/internal/trigger"
 )
 
 type TriggerScheduleRunner struct {
 	TriggerRunner trigger.TriggerRunner
 	CommandBus    handler.FpCommandBus
+	ClusterRuns   ScheduleTriggerRuns
 }
 
 func (s *TriggerScheduleRunner) Run() {
-	triggerName := s.TriggerRunner.GetTrigger().Name()
+	t := s.TriggerRunner.GetTrigger()
+	triggerName := t.Name()
 
 	executionCmd := event.NewExecutionQueueForTrigger("", triggerName)
 
-	// Send the trigger command
-	err := s.CommandBus.Send(context.TODO(), executionCmd)
+	// the time the schedule trigger is due, the cron expressions are to the minute
+	if _, ok := t.Config.(*resources.TriggerSchedule); ok {
+		scheduledTime := time.Now().UTC().Truncate(time.Minute)
+		executionCmd.TriggerQueue.ScheduledTime = &scheduledTime
+		saveScheduleTriggerRun(triggerName, scheduledTime, s.ClusterRuns)
+	}
+
+	// Send the trigger command, unless the previous execution of the trigger is still running
+	err := sendTriggerExecution(context.TODO(), s.CommandBus, t, executionCmd)
 	if err != nil {
 		slog.Error("Error sending trigger command", "trigger", triggerName, "error", err)
 	}
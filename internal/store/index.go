@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/turbot/flowpipe/internal/filepaths"
@@ -159,7 +160,7 @@ func UpgradeFlowpipeDB2() error {
 	}
 	defer rows.Close()
 
-	if currentDbVersion == "2.0" {
+	if currentDbVersion == "2.0" || currentDbVersion == "2.1" {
 		return nil
 	}
 	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{})
@@ -245,6 +246,106 @@ func UpgradeFlowpipeDB2() error {
 	return nil
 }
 
+// createPipelineRunSearchIndexes creates the indexes used to search the processes, each filter is paired with the id
+// used as the pagination cursor
+func createPipelineRunSearchIndexes(tx *sql.Tx) error {
+	indexSqls := []string{
+		`create index if not exists idx_pipeline_run_pipeline on pipeline_run (pipeline, id)`,
+		`create index if not exists idx_pipeline_run_state on pipeline_run (state, id)`,
+		`create index if not exists idx_pipeline_run_trigger_name on pipeline_run (trigger_name, id)`,
+		`create index if not exists idx_pipeline_run_failed_step on pipeline_run (failed_step, id)`,
+		`create index if not exists idx_pipeline_run_started_at on pipeline_run (started_at)`,
+	}
+
+	for _, indexSql := range indexSqls {
+		_, err := tx.Exec(indexSql)
+		if err != nil {
+			slog.Error("error creating pipeline_run index", "error", err)
+			return perr.InternalWithMessage("error creating pipeline_run index")
+		}
+	}
+
+	return nil
+}
+
+// the database is opened (and upgraded) concurrently by the event handlers
+var upgradeFlowpipeDB21Lock sync.Mutex
+
+// UpgradeFlowpipeDB21 adds the trigger_name and failed_step columns (and the search indexes) to the pipeline_run
+// table. The columns are not back-filled for the existing runs.
+func UpgradeFlowpipeDB21() error {
+	upgradeFlowpipeDB21Lock.Lock()
+	defer upgradeFlowpipeDB21Lock.Unlock()
+
+	dbPath := filepaths.FlowpipeDBFileName()
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var currentDbVersion string
+	err = db.QueryRow(`select value from internal where name = 'db_version'`).Scan(&currentDbVersion)
+	if err != nil {
+		slog.Error("error getting current db_version", "error", err)
+		return perr.InternalWithMessage("error getting current db_version")
+	}
+
+	if currentDbVersion == "2.1" {
+		return nil
+	}
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{})
+	if err != nil {
+		slog.Error("error starting transaction", "error", err)
+		return perr.InternalWithMessage("error starting transaction")
+	}
+
+	commited := false
+	defer func() {
+		if !commited {
+			err := tx.Rollback()
+			if err != nil {
+				slog.Error("error rolling back transaction", "error", err)
+			}
+		}
+	}()
+
+	alterTableSqls := []string{
+		`alter table pipeline_run add column trigger_name text`,
+		`alter table pipeline_run add column failed_step integer not null default 0`,
+	}
+	for _, alterTableSql := range alterTableSqls {
+		_, err = tx.Exec(alterTableSql)
+		if err != nil {
+			slog.Error("error altering pipeline_run table", "error", err)
+			return perr.InternalWithMessage("error altering pipeline_run table")
+		}
+	}
+
+	err = createPipelineRunSearchIndexes(tx)
+	if err != nil {
+		return err
+	}
+
+	updateMetadata := `update internal set value = '2.1', updated_at = datetime('now') where name = 'db_version'`
+	_, err = tx.Exec(updateMetadata)
+	if err != nil {
+		slog.Error("error updating metadata", "error", err)
+		return perr.InternalWithMessage("error updating metadata")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.Error("error committing transaction", "error", err)
+		return perr.InternalWithMessage("error committing transaction")
+	}
+	commited = true
+
+	return nil
+}
+
 func InitializeFlowpipeDB() error {
 
 	err := moveFlowpipeDbFromModDirToFlowpipeModDir()
@@ -290,7 +391,9 @@ func InitializeFlowpipeDB() error {
 		pipeline text,
 		state text,
 		started_at datetime,
-		updated_at datetime
+		updated_at datetime,
+		trigger_name text,
+		failed_step integer not null default 0
 	)`
 
 	_, err = tx.Exec(createTableSQL)
@@ -306,6 +409,11 @@ func InitializeFlowpipeDB() error {
 		return perr.InternalWithMessage("error creating pipeline_run index")
 	}
 
+	err = createPipelineRunSearchIndexes(tx)
+	if err != nil {
+		return err
+	}
+
 	err = createEventTable(tx)
 	if err != nil {
 		slog.Error("error creating event table", "error", err)
@@ -357,7 +465,7 @@ func InitializeFlowpipeDB() error {
 		return perr.InternalWithMessage("error creating internal index")
 	}
 
-	updateMetadata := `insert into internal (name, value, created_at, updated_at) values ('db_version', '2.0', datetime('now'), datetime('now'))`
+	updateMetadata := `insert into internal (name, value, created_at, updated_at) values ('db_version', '2.1', datetime('now'), datetime('now'))`
 	_, err = tx.Exec(updateMetadata)
 	if err != nil {
 		slog.Error("error updating metadata", "error", err)
@@ -393,6 +501,12 @@ func OpenFlowpipeDB() (*sql.DB, error) {
 			slog.Error("error upgrading flowpipe.db", "error", err)
 			return nil, err
 		}
+
+		err = UpgradeFlowpipeDB21()
+		if err != nil {
+			slog.Error("error upgrading flowpipe.db", "error", err)
+			return nil, err
+		}
 	}
 
 	db, err := sql.Open("sqlite3", dbPath)
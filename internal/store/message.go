@@ -0,0 +1,98 @@
+package store
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/pipe-fittings/perr"
+	putils "github.com/turbot/pipe-fittings/utils"
+)
+
+// Message is a command or event that has been published to the message bus but not yet acknowledged by its handler
+type Message struct {
+	ID       int64
+	Bus      string
+	Topic    string
+	UUID     string
+	Payload  []byte
+	Metadata string
+}
+
+func CreateMessageTable(db *sql.DB) error {
+	createTableSQL := `
+	create table if not exists message (
+		id integer primary key autoincrement,
+		bus text,
+		topic text,
+		uuid text,
+		payload blob,
+		metadata text,
+		created_at datetime
+	)`
+
+	_, err := db.Exec(createTableSQL)
+	if err != nil {
+		slog.Error("error creating message table", "error", err)
+		return perr.InternalWithMessage("error creating message table")
+	}
+
+	indexSql := `create index if not exists idx_message_bus_uuid on message (bus, uuid);`
+	_, err = db.Exec(indexSql)
+	if err != nil {
+		slog.Error("error creating message index", "error", err)
+		return perr.InternalWithMessage("error creating message index")
+	}
+
+	return nil
+}
+
+func SaveMessage(db *sql.DB, bus, topic, uuid string, payload []byte, metadata string) error {
+	currentTimeString := time.Now().UTC().Format(putils.RFC3339WithMS)
+
+	statement := `insert into message (bus, topic, uuid, payload, metadata, created_at) values (?, ?, ?, ?, ?, ?)`
+	_, err := db.Exec(statement, bus, topic, uuid, payload, metadata, currentTimeString)
+	if err != nil {
+		slog.Error("error saving message", "bus", bus, "topic", topic, "uuid", uuid, "error", err)
+		return perr.InternalWithMessage("error saving message " + err.Error())
+	}
+	return nil
+}
+
+// AckMessage removes the message once it has been acknowledged by its handler, it will not be replayed
+func AckMessage(db *sql.DB, bus, uuid string) error {
+	_, err := db.Exec(`delete from message where bus = ? and uuid = ?`, bus, uuid)
+	if err != nil {
+		slog.Error("error deleting message", "bus", bus, "uuid", uuid, "error", err)
+		return perr.InternalWithMessage("error deleting message " + err.Error())
+	}
+	return nil
+}
+
+// ListMessages returns the unacknowledged messages of the given bus in the order they were published
+func ListMessages(db *sql.DB, bus string) ([]Message, error) {
+	rows, err := db.Query(`select id, bus, topic, uuid, payload, metadata from message where bus = ? order by id asc`, bus)
+	if err != nil {
+		slog.Error("error querying message", "error", err)
+		return nil, perr.InternalWithMessage("error querying message")
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var m Message
+		err = rows.Scan(&m.ID, &m.Bus, &m.Topic, &m.UUID, &m.Payload, &m.Metadata)
+		if err != nil {
+			slog.Error("error scanning message", "error", err)
+			return nil, perr.InternalWithMessage("error scanning message")
+		}
+		messages = append(messages, m)
+	}
+
+	if rows.Err() != nil {
+		slog.Error("error iterating message table", "error", rows.Err())
+		return nil, perr.InternalWithMessage("error iterating message table")
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageAck(t *testing.T) {
+	assert := assert.New(t)
+
+	err := copyNewFlowpipeDbCleanFile("./clean_test_files/flowpipe_clean.db")
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+	defer db.Close()
+
+	err = CreateMessageTable(db)
+	assert.Nil(err)
+
+	assert.Nil(SaveMessage(db, "command", "command.pipeline_plan", "uuid_1", []byte(`{"event":{"execution_id":"exec_1"}}`), "{}"))
+	assert.Nil(SaveMessage(db, "command", "command.step_start", "uuid_2", []byte(`{"event":{"execution_id":"exec_1"}}`), "{}"))
+	assert.Nil(SaveMessage(db, "event", "handler.pipeline_planned", "uuid_3", []byte(`{"event":{"execution_id":"exec_1"}}`), "{}"))
+
+	messages, err := ListMessages(db, "command")
+	assert.Nil(err)
+	assert.Equal(2, len(messages))
+	assert.Equal("uuid_1", messages[0].UUID)
+	assert.Equal("command.pipeline_plan", messages[0].Topic)
+	assert.Equal("uuid_2", messages[1].UUID)
+
+	assert.Nil(AckMessage(db, "command", "uuid_1"))
+
+	messages, err = ListMessages(db, "command")
+	assert.Nil(err)
+	assert.Equal(1, len(messages))
+	assert.Equal("uuid_2", messages[0].UUID)
+
+	// acknowledging a command must not affect the event bus
+	messages, err = ListMessages(db, "event")
+	assert.Nil(err)
+	assert.Equal(1, len(messages))
+	assert.Equal(`{"event":{"execution_id":"exec_1"}}`, string(messages[0].Payload))
+}
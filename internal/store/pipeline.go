@@ -1,7 +1,10 @@
 package store
 
 import (
+	"database/sql"
+	"errors"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/mattn/go-sqlite3"
@@ -11,7 +14,9 @@ import (
 	putils "github.com/turbot/pipe-fittings/utils"
 )
 
-func StartPipeline(executionId, pipelineName string) error {
+// StartPipeline records the execution in the pipeline_run table. The pipeline of a trigger execution is only known
+// once the trigger has queued it, see SetPipelineRunPipeline.
+func StartPipeline(executionId, pipelineName, triggerName string) error {
 	retentionInSecond := viper.GetInt(constants.ArgProcessRetention)
 	if retentionInSecond == 0 {
 		return nil
@@ -24,7 +29,7 @@ func StartPipeline(executionId, pipelineName string) error {
 	defer db.Close()
 
 	// Prepare the insert statement
-	stmt, err := db.Prepare("insert into pipeline_run(execution_id, pipeline, state, started_at, updated_at) values(?, ?, ?, ?, ?)")
+	stmt, err := db.Prepare("insert into pipeline_run(execution_id, pipeline, trigger_name, state, started_at, updated_at) values(?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		slog.Error("error preparing statement", "error", err)
 		return perr.InternalWithMessage("error preparing statement " + err.Error())
@@ -34,7 +39,7 @@ func StartPipeline(executionId, pipelineName string) error {
 	// Execute the statement
 	currentTime := time.Now().UTC()
 	currentTimeString := currentTime.Format(putils.RFC3339WithMS)
-	_, err = stmt.Exec(executionId, pipelineName, "queued", currentTimeString, currentTimeString)
+	_, err = stmt.Exec(executionId, pipelineName, triggerName, "queued", currentTimeString, currentTimeString)
 	if err != nil {
 		sqlIteErr, ok := err.(sqlite3.Error)
 		if ok && sqlIteErr.Code == sqlite3.ErrConstraint && sqlIteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -80,3 +85,249 @@ func UpdatePipelineState(executionID, newState string) error {
 
 	return nil
 }
+
+// ListInFlightExecutionIDs returns the executions that have not reached their end state, i.e. the executions that
+// were running when the server stopped
+func ListInFlightExecutionIDs() ([]string, error) {
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select execution_id from pipeline_run where state in ('queued', 'started') order by id asc")
+	if err != nil {
+		slog.Error("error querying pipeline_run", "error", err)
+		return nil, perr.InternalWithMessage("error querying pipeline_run")
+	}
+	defer rows.Close()
+
+	var executionIDs []string
+	for rows.Next() {
+		var executionID string
+		err = rows.Scan(&executionID)
+		if err != nil {
+			slog.Error("error scanning pipeline_run", "error", err)
+			return nil, perr.InternalWithMessage("error scanning pipeline_run")
+		}
+		executionIDs = append(executionIDs, executionID)
+	}
+
+	if rows.Err() != nil {
+		slog.Error("error iterating pipeline_run", "error", rows.Err())
+		return nil, perr.InternalWithMessage("error iterating pipeline_run")
+	}
+
+	return executionIDs, nil
+}
+
+// ListRunningTriggerExecutionIDs returns the executions of the trigger that have not reached their end state, the
+// state of a paused execution stays started
+func ListRunningTriggerExecutionIDs(triggerName string) ([]string, error) {
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select execution_id from pipeline_run where trigger_name = ? and state in ('queued', 'started') order by id asc", triggerName)
+	if err != nil {
+		slog.Error("error querying pipeline_run", "error", err)
+		return nil, perr.InternalWithMessage("error querying pipeline_run")
+	}
+	defer rows.Close()
+
+	var executionIDs []string
+	for rows.Next() {
+		var executionID string
+		err = rows.Scan(&executionID)
+		if err != nil {
+			slog.Error("error scanning pipeline_run", "error", err)
+			return nil, perr.InternalWithMessage("error scanning pipeline_run")
+		}
+		executionIDs = append(executionIDs, executionID)
+	}
+
+	if rows.Err() != nil {
+		slog.Error("error iterating pipeline_run", "error", rows.Err())
+		return nil, perr.InternalWithMessage("error iterating pipeline_run")
+	}
+
+	return executionIDs, nil
+}
+
+// GetPipelineRunTrigger returns the trigger of the execution, empty if the execution wasn't started by a trigger or
+// isn't recorded
+func GetPipelineRunTrigger(executionID string) (string, error) {
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var triggerName string
+	err = db.QueryRow("select coalesce(trigger_name, '') from pipeline_run where execution_id = ?", executionID).Scan(&triggerName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		slog.Error("error querying pipeline_run", "error", err)
+		return "", perr.InternalWithMessage("error querying pipeline_run")
+	}
+
+	return triggerName, nil
+}
+
+// SetPipelineRunPipeline sets the pipeline of a trigger execution, the first pipeline queued by the trigger is kept
+func SetPipelineRunPipeline(executionID, pipelineName string) error {
+	retentionInSecond := viper.GetInt(constants.ArgProcessRetention)
+	if retentionInSecond == 0 {
+		return nil
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("update pipeline_run set pipeline = ? where execution_id = ? and (pipeline is null or pipeline = '')", pipelineName, executionID)
+	if err != nil {
+		slog.Error("error updating pipeline_run pipeline", "error", err)
+		return perr.InternalWithMessage("error updating pipeline_run pipeline " + err.Error())
+	}
+
+	return nil
+}
+
+// SetPipelineRunFailedStep records that a step of the execution failed
+func SetPipelineRunFailedStep(executionID string) error {
+	retentionInSecond := viper.GetInt(constants.ArgProcessRetention)
+	if retentionInSecond == 0 {
+		return nil
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("update pipeline_run set failed_step = 1 where execution_id = ? and failed_step = 0", executionID)
+	if err != nil {
+		slog.Error("error updating pipeline_run failed step", "error", err)
+		return perr.InternalWithMessage("error updating pipeline_run failed step " + err.Error())
+	}
+
+	return nil
+}
+
+// PipelineRun is an execution recorded in the pipeline_run table
+type PipelineRun struct {
+	ID          int64
+	ExecutionID string
+	Pipeline    string
+	Trigger     string
+	State       string
+	StartedAt   time.Time
+	FailedStep  bool
+}
+
+// PipelineRunFilter filters and pages the executions listed by ListPipelineRuns, the zero value lists the latest
+// executions first
+type PipelineRunFilter struct {
+	Pipeline      string
+	State         string
+	Trigger       string
+	StartedBefore *time.Time
+	StartedAfter  *time.Time
+	FailedStep    *bool
+
+	// Ascending lists the oldest executions first
+	Ascending bool
+	// After is the ID of the last execution of the previous page
+	After int64
+	Limit int
+}
+
+// ListPipelineRuns returns a page of the executions matching the filter and whether there are more executions to list
+func ListPipelineRuns(filter PipelineRunFilter) ([]PipelineRun, bool, error) {
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		return nil, false, err
+	}
+	defer db.Close()
+
+	var conditions []string
+	var args []any
+
+	if filter.Pipeline != "" {
+		conditions = append(conditions, "pipeline = ?")
+		args = append(args, filter.Pipeline)
+	}
+	if filter.State != "" {
+		conditions = append(conditions, "state = ?")
+		args = append(args, filter.State)
+	}
+	if filter.Trigger != "" {
+		conditions = append(conditions, "trigger_name = ?")
+		args = append(args, filter.Trigger)
+	}
+	// started_at is stored as text in UTC, the comparisons are done on the same format
+	if filter.StartedBefore != nil {
+		conditions = append(conditions, "started_at < ?")
+		args = append(args, filter.StartedBefore.UTC().Format(putils.RFC3339WithMS))
+	}
+	if filter.StartedAfter != nil {
+		conditions = append(conditions, "started_at > ?")
+		args = append(args, filter.StartedAfter.UTC().Format(putils.RFC3339WithMS))
+	}
+	if filter.FailedStep != nil {
+		conditions = append(conditions, "failed_step = ?")
+		args = append(args, *filter.FailedStep)
+	}
+
+	order := "desc"
+	if filter.Ascending {
+		order = "asc"
+	}
+	if filter.After != 0 {
+		if filter.Ascending {
+			conditions = append(conditions, "id > ?")
+		} else {
+			conditions = append(conditions, "id < ?")
+		}
+		args = append(args, filter.After)
+	}
+
+	query := "select id, execution_id, coalesce(pipeline, ''), coalesce(trigger_name, ''), state, started_at, failed_step from pipeline_run"
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	// fetch one more row to know if there is a next page
+	query += " order by id " + order + " limit ?"
+	args = append(args, filter.Limit+1)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		slog.Error("error querying pipeline_run", "error", err)
+		return nil, false, perr.InternalWithMessage("error querying pipeline_run")
+	}
+	defer rows.Close()
+
+	var runs []PipelineRun
+	for rows.Next() {
+		var run PipelineRun
+		err = rows.Scan(&run.ID, &run.ExecutionID, &run.Pipeline, &run.Trigger, &run.State, &run.StartedAt, &run.FailedStep)
+		if err != nil {
+			slog.Error("error scanning pipeline_run", "error", err)
+			return nil, false, perr.InternalWithMessage("error scanning pipeline_run")
+		}
+		runs = append(runs, run)
+	}
+
+	if len(runs) > filter.Limit {
+		return runs[:filter.Limit], true, nil
+	}
+	return runs, false, nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+func executionIDs(runs []PipelineRun) []string {
+	var ids []string
+	for _, run := range runs {
+		ids = append(ids, run.ExecutionID)
+	}
+	return ids
+}
+
+func TestListPipelineRuns(t *testing.T) {
+	assert := assert.New(t)
+
+	// the clean database predates the search columns, it's upgraded when opened
+	err := copyNewFlowpipeDbCleanFile("./clean_test_files/flowpipe_clean.db")
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+
+	viper.Set(constants.ArgProcessRetention, 604800)
+	defer viper.Set(constants.ArgProcessRetention, nil)
+
+	start := time.Now().UTC()
+
+	assert.Nil(StartPipeline("exec_run_1", "local.pipeline.one", ""))
+	assert.Nil(StartPipeline("exec_run_2", "local.pipeline.two", "local.trigger.http.hook"))
+	assert.Nil(StartPipeline("exec_run_3", "", "local.trigger.schedule.hourly"))
+	assert.Nil(StartPipeline("exec_run_4", "local.pipeline.one", ""))
+
+	// the pipeline of a trigger execution is set once the trigger queues it
+	assert.Nil(SetPipelineRunPipeline("exec_run_3", "local.pipeline.one"))
+	assert.Nil(SetPipelineRunPipeline("exec_run_3", "local.pipeline.two"))
+
+	assert.Nil(UpdatePipelineState("exec_run_1", "failed"))
+	assert.Nil(SetPipelineRunFailedStep("exec_run_1"))
+	assert.Nil(UpdatePipelineState("exec_run_2", "finished"))
+	assert.Nil(SetPipelineRunFailedStep("exec_run_2"))
+	assert.Nil(UpdatePipelineState("exec_run_3", "finished"))
+
+	// the clean database has 3 older runs
+	runs, more, err := ListPipelineRuns(PipelineRunFilter{StartedAfter: &start, Limit: 25})
+	assert.Nil(err)
+	assert.False(more)
+	assert.Equal([]string{"exec_run_4", "exec_run_3", "exec_run_2", "exec_run_1"}, executionIDs(runs))
+	assert.Equal("local.pipeline.one", runs[1].Pipeline)
+	assert.Equal("local.trigger.schedule.hourly", runs[1].Trigger)
+	assert.Equal("queued", runs[0].State)
+	assert.True(runs[3].FailedStep)
+	assert.False(runs[1].FailedStep)
+	assert.WithinDuration(time.Now(), runs[0].StartedAt, time.Minute)
+
+	runs, _, err = ListPipelineRuns(PipelineRunFilter{Pipeline: "local.pipeline.one", Limit: 25})
+	assert.Nil(err)
+	assert.Equal([]string{"exec_run_4", "exec_run_3", "exec_run_1"}, executionIDs(runs))
+
+	runs, _, err = ListPipelineRuns(PipelineRunFilter{State: "finished", StartedAfter: &start, Limit: 25})
+	assert.Nil(err)
+	assert.Equal([]string{"exec_run_3", "exec_run_2"}, executionIDs(runs))
+
+	runs, _, err = ListPipelineRuns(PipelineRunFilter{Trigger: "local.trigger.http.hook", Limit: 25})
+	assert.Nil(err)
+	assert.Equal([]string{"exec_run_2"}, executionIDs(runs))
+
+	failedStep := true
+	runs, _, err = ListPipelineRuns(PipelineRunFilter{FailedStep: &failedStep, Pipeline: "local.pipeline.one", Limit: 25})
+	assert.Nil(err)
+	assert.Equal([]string{"exec_run_1"}, executionIDs(runs))
+
+	runs, _, err = ListPipelineRuns(PipelineRunFilter{StartedBefore: &start, Limit: 25})
+	assert.Nil(err)
+	assert.Equal(3, len(runs))
+
+	// pages in ascending order
+	runs, more, err = ListPipelineRuns(PipelineRunFilter{StartedAfter: &start, Ascending: true, Limit: 3})
+	assert.Nil(err)
+	assert.True(more)
+	assert.Equal([]string{"exec_run_1", "exec_run_2", "exec_run_3"}, executionIDs(runs))
+
+	runs, more, err = ListPipelineRuns(PipelineRunFilter{StartedAfter: &start, Ascending: true, Limit: 3, After: runs[2].ID})
+	assert.Nil(err)
+	assert.False(more)
+	assert.Equal([]string{"exec_run_4"}, executionIDs(runs))
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/perr"
+	putils "github.com/turbot/pipe-fittings/utils"
+)
+
+func CreateQueryTriggerCursorTable(db *sql.DB) error {
+	createTableSQL := `
+	create table if not exists query_trigger_cursor (
+		trigger_name text primary key,
+		cursor_value text,
+		cursor_type text,
+		updated_at text
+	)`
+
+	_, err := db.Exec(createTableSQL)
+	if err != nil {
+		slog.Error("error creating query_trigger_cursor table", "error", err)
+		return perr.InternalWithMessage("error creating query_trigger_cursor table")
+	}
+
+	return nil
+}
+
+// GetQueryTriggerCursor returns the last value of the cursor column seen by the query trigger, nil if the trigger
+// hasn't seen any row yet. The value is returned with the type it was read with so it can be passed back to the query.
+func GetQueryTriggerCursor(db *sql.DB, triggerName string) (interface{}, error) {
+	var cursorValue, cursorType string
+	err := db.QueryRow(`select cursor_value, cursor_type from query_trigger_cursor where trigger_name = ?`, triggerName).Scan(&cursorValue, &cursorType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("error reading query trigger cursor", "trigger", triggerName, "error", err)
+		return nil, perr.InternalWithMessage("error reading query trigger cursor " + err.Error())
+	}
+
+	var value interface{}
+	switch cursorType {
+	case "integer":
+		value, err = strconv.ParseInt(cursorValue, 10, 64)
+	case "float":
+		value, err = strconv.ParseFloat(cursorValue, 64)
+	case "time":
+		value, err = time.Parse(time.RFC3339Nano, cursorValue)
+	default:
+		value = cursorValue
+	}
+	if err != nil {
+		slog.Error("error decoding query trigger cursor", "trigger", triggerName, "cursor", cursorValue, "type", cursorType, "error", err)
+		return nil, perr.InternalWithMessage("error decoding query trigger cursor " + err.Error())
+	}
+
+	return value, nil
+}
+
+// SetQueryTriggerCursor saves the last value of the cursor column seen by the query trigger
+func SetQueryTriggerCursor(db *sql.DB, triggerName string, value interface{}) error {
+	var cursorValue, cursorType string
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		cursorValue, cursorType = fmt.Sprintf("%d", v), "integer"
+	case float32, float64:
+		cursorValue, cursorType = fmt.Sprintf("%v", v), "float"
+	case time.Time:
+		cursorValue, cursorType = v.Format(time.RFC3339Nano), "time"
+	case []byte:
+		cursorValue, cursorType = string(v), "string"
+	default:
+		cursorValue, cursorType = fmt.Sprintf("%v", v), "string"
+	}
+
+	currentTimeString := time.Now().UTC().Format(putils.RFC3339WithMS)
+
+	statement := `insert into query_trigger_cursor (trigger_name, cursor_value, cursor_type, updated_at) values (?, ?, ?, ?)
+		on conflict (trigger_name) do update set cursor_value = excluded.cursor_value, cursor_type = excluded.cursor_type, updated_at = excluded.updated_at`
+	_, err := db.Exec(statement, triggerName, cursorValue, cursorType, currentTimeString)
+	if err != nil {
+		slog.Error("error saving query trigger cursor", "trigger", triggerName, "error", err)
+		return perr.InternalWithMessage("error saving query trigger cursor " + err.Error())
+	}
+
+	return nil
+}
+
+// QueryTriggerCapturedRowName returns the trigger name the rows captured by the query trigger are kept under in the
+// query_trigger_captured_row table
+func QueryTriggerCapturedRowName(triggerName string) string {
+	return strings.ReplaceAll(triggerName, ".", "_")
+}
+
+func CreateQueryTriggerRunTable(db *sql.DB) error {
+	createTableSQL := `
+	create table if not exists query_trigger_run (
+		trigger_name text primary key,
+		polled_at text,
+		inserted integer,
+		updated integer,
+		deleted integer
+	)`
+
+	_, err := db.Exec(createTableSQL)
+	if err != nil {
+		slog.Error("error creating query_trigger_run table", "error", err)
+		return perr.InternalWithMessage("error creating query_trigger_run table")
+	}
+
+	return nil
+}
+
+// QueryTriggerRun is the last poll of a query trigger, with the number of rows it has captured
+type QueryTriggerRun struct {
+	PolledAt time.Time
+	Inserted int
+	Updated  int
+	Deleted  int
+}
+
+// GetQueryTriggerRun returns the last poll of the query trigger, nil if the trigger hasn't polled yet
+func GetQueryTriggerRun(db *sql.DB, triggerName string) (*QueryTriggerRun, error) {
+	var polledAt string
+	run := &QueryTriggerRun{}
+	err := db.QueryRow(`select polled_at, inserted, updated, deleted from query_trigger_run where trigger_name = ?`, triggerName).Scan(&polledAt, &run.Inserted, &run.Updated, &run.Deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("error reading query trigger run", "trigger", triggerName, "error", err)
+		return nil, perr.InternalWithMessage("error reading query trigger run " + err.Error())
+	}
+
+	run.PolledAt, err = time.Parse(putils.RFC3339WithMS, polledAt)
+	if err != nil {
+		slog.Error("error parsing query trigger run", "trigger", triggerName, "polled_at", polledAt, "error", err)
+		return nil, perr.InternalWithMessage("error parsing query trigger run " + err.Error())
+	}
+
+	return run, nil
+}
+
+// SetQueryTriggerRun saves the poll of the query trigger that has just run
+func SetQueryTriggerRun(db *sql.DB, triggerName string, inserted, updated, deleted int) error {
+	currentTimeString := time.Now().UTC().Format(putils.RFC3339WithMS)
+
+	statement := `insert into query_trigger_run (trigger_name, polled_at, inserted, updated, deleted) values (?, ?, ?, ?, ?)
+		on conflict (trigger_name) do update set polled_at = excluded.polled_at, inserted = excluded.inserted, updated = excluded.updated, deleted = excluded.deleted`
+	_, err := db.Exec(statement, triggerName, currentTimeString, inserted, updated, deleted)
+	if err != nil {
+		slog.Error("error saving query trigger run", "trigger", triggerName, "error", err)
+		return perr.InternalWithMessage("error saving query trigger run " + err.Error())
+	}
+
+	return nil
+}
+
+// CountQueryTriggerCapturedRows returns the number of rows the query trigger knows of, i.e. the rows it won't capture
+// as inserted
+func CountQueryTriggerCapturedRows(db *sql.DB, triggerName string) (int, error) {
+	var count int
+	err := db.QueryRow(`select count(*) from query_trigger_captured_row where trigger_name = ?`, QueryTriggerCapturedRowName(triggerName)).Scan(&count)
+	if err != nil {
+		slog.Error("error counting query trigger captured rows", "trigger", triggerName, "error", err)
+		return 0, perr.InternalWithMessage("error counting query trigger captured rows " + err.Error())
+	}
+
+	return count, nil
+}
+
+// ResetQueryTriggerState deletes the captured rows, the cursor and the last poll of the query trigger, the next poll
+// captures all the rows returned by the query as inserted
+func ResetQueryTriggerState(db *sql.DB, triggerName string) error {
+	err := CreateQueryTriggerCursorTable(db)
+	if err != nil {
+		return err
+	}
+
+	err = CreateQueryTriggerRunTable(db)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return perr.InternalWithMessage("error starting transaction " + err.Error())
+	}
+
+	statements := map[string]string{
+		`delete from query_trigger_captured_row where trigger_name = ?`: QueryTriggerCapturedRowName(triggerName),
+		`delete from query_trigger_cursor where trigger_name = ?`:       triggerName,
+		`delete from query_trigger_run where trigger_name = ?`:          triggerName,
+	}
+
+	for statement, name := range statements {
+		if _, err := tx.Exec(statement, name); err != nil {
+			slog.Error("error resetting query trigger state", "trigger", triggerName, "error", err)
+			if err2 := tx.Rollback(); err2 != nil {
+				slog.Error("error rolling back transaction", "error", err2)
+			}
+			return perr.InternalWithMessage("error resetting query trigger state " + err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return perr.InternalWithMessage("error committing transaction " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryTriggerCursor(t *testing.T) {
+	assert := assert.New(t)
+
+	err := copyNewFlowpipeDbCleanFile("./clean_test_files/flowpipe_clean.db")
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+	defer db.Close()
+
+	assert.Nil(CreateQueryTriggerCursorTable(db))
+
+	// no row seen yet
+	cursor, err := GetQueryTriggerCursor(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Nil(cursor)
+
+	assert.Nil(SetQueryTriggerCursor(db, "local.trigger.query.orders", int32(42)))
+	cursor, err = GetQueryTriggerCursor(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Equal(int64(42), cursor)
+
+	// the cursor keeps its type
+	updatedAt := time.Date(2024, 3, 1, 10, 30, 0, 123456789, time.UTC)
+	assert.Nil(SetQueryTriggerCursor(db, "local.trigger.query.orders", updatedAt))
+	cursor, err = GetQueryTriggerCursor(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Equal(updatedAt, cursor)
+
+	assert.Nil(SetQueryTriggerCursor(db, "local.trigger.query.users", 1.5))
+	cursor, err = GetQueryTriggerCursor(db, "local.trigger.query.users")
+	assert.Nil(err)
+	assert.Equal(1.5, cursor)
+
+	assert.Nil(SetQueryTriggerCursor(db, "local.trigger.query.users", []byte("2024-03-01")))
+	cursor, err = GetQueryTriggerCursor(db, "local.trigger.query.users")
+	assert.Nil(err)
+	assert.Equal("2024-03-01", cursor)
+}
+
+func TestQueryTriggerState(t *testing.T) {
+	assert := assert.New(t)
+
+	err := copyNewFlowpipeDbCleanFile("./clean_test_files/flowpipe_clean.db")
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+	defer db.Close()
+
+	assert.Nil(CreateQueryTriggerCursorTable(db))
+	assert.Nil(CreateQueryTriggerRunTable(db))
+
+	// not polled yet
+	run, err := GetQueryTriggerRun(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Nil(run)
+
+	before := time.Now().Add(-time.Second)
+	assert.Nil(SetQueryTriggerRun(db, "local.trigger.query.orders", 3, 0, 1))
+	assert.Nil(SetQueryTriggerRun(db, "local.trigger.query.users", 1, 1, 1))
+	run, err = GetQueryTriggerRun(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	if !assert.NotNil(run) {
+		return
+	}
+	assert.Equal(3, run.Inserted)
+	assert.Equal(0, run.Updated)
+	assert.Equal(1, run.Deleted)
+	assert.True(run.PolledAt.After(before))
+
+	_, err = db.Exec(`insert into query_trigger_captured_row (trigger_name, primary_key, row_hash, created_at) values (?, '1', 'a', ''), (?, '2', 'b', ''), (?, '1', 'c', '')`,
+		QueryTriggerCapturedRowName("local.trigger.query.orders"), QueryTriggerCapturedRowName("local.trigger.query.orders"), QueryTriggerCapturedRowName("local.trigger.query.users"))
+	assert.Nil(err)
+
+	count, err := CountQueryTriggerCapturedRows(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Equal(2, count)
+
+	assert.Nil(SetQueryTriggerCursor(db, "local.trigger.query.orders", 42))
+
+	// the reset only forgets the state of its trigger
+	assert.Nil(ResetQueryTriggerState(db, "local.trigger.query.orders"))
+
+	count, err = CountQueryTriggerCapturedRows(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Equal(0, count)
+
+	cursor, err := GetQueryTriggerCursor(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Nil(cursor)
+
+	run, err = GetQueryTriggerRun(db, "local.trigger.query.orders")
+	assert.Nil(err)
+	assert.Nil(run)
+
+	count, err = CountQueryTriggerCapturedRows(db, "local.trigger.query.users")
+	assert.Nil(err)
+	assert.Equal(1, count)
+
+	run, err = GetQueryTriggerRun(db, "local.trigger.query.users")
+	assert.Nil(err)
+	assert.NotNil(run)
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/pipe-fittings/perr"
+	putils "github.com/turbot/pipe-fittings/utils"
+)
+
+func CreateScheduleTriggerRunTable(db *sql.DB) error {
+	createTableSQL := `
+	create table if not exists schedule_trigger_run (
+		trigger_name text primary key,
+		scheduled_at text,
+		updated_at text
+	)`
+
+	_, err := db.Exec(createTableSQL)
+	if err != nil {
+		slog.Error("error creating schedule_trigger_run table", "error", err)
+		return perr.InternalWithMessage("error creating schedule_trigger_run table")
+	}
+
+	return nil
+}
+
+// GetScheduleTriggerLastRun returns the scheduled time of the last run of the schedule trigger, nil if the trigger
+// hasn't run yet
+func GetScheduleTriggerLastRun(db *sql.DB, triggerName string) (*time.Time, error) {
+	var scheduledAt string
+	err := db.QueryRow(`select scheduled_at from schedule_trigger_run where trigger_name = ?`, triggerName).Scan(&scheduledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("error reading schedule trigger run", "trigger", triggerName, "error", err)
+		return nil, perr.InternalWithMessage("error reading schedule trigger run " + err.Error())
+	}
+
+	lastRun, err := time.Parse(putils.RFC3339WithMS, scheduledAt)
+	if err != nil {
+		slog.Error("error parsing schedule trigger run", "trigger", triggerName, "scheduled_at", scheduledAt, "error", err)
+		return nil, perr.InternalWithMessage("error parsing schedule trigger run " + err.Error())
+	}
+
+	return &lastRun, nil
+}
+
+// SetScheduleTriggerLastRun saves the scheduled time of the last run of the schedule trigger
+func SetScheduleTriggerLastRun(db *sql.DB, triggerName string, scheduledAt time.Time) error {
+	currentTimeString := time.Now().UTC().Format(putils.RFC3339WithMS)
+
+	statement := `insert into schedule_trigger_run (trigger_name, scheduled_at, updated_at) values (?, ?, ?)
+		on conflict (trigger_name) do update set scheduled_at = excluded.scheduled_at, updated_at = excluded.updated_at`
+	_, err := db.Exec(statement, triggerName, scheduledAt.UTC().Format(putils.RFC3339WithMS), currentTimeString)
+	if err != nil {
+		slog.Error("error saving schedule trigger run", "trigger", triggerName, "error", err)
+		return perr.InternalWithMessage("error saving schedule trigger run " + err.Error())
+	}
+
+	return nil
+}
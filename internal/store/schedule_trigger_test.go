@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleTriggerLastRun(t *testing.T) {
+	assert := assert.New(t)
+
+	err := copyNewFlowpipeDbCleanFile("./clean_test_files/flowpipe_clean.db")
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+	defer db.Close()
+
+	assert.Nil(CreateScheduleTriggerRunTable(db))
+
+	lastRun, err := GetScheduleTriggerLastRun(db, "local.trigger.schedule.daily")
+	assert.Nil(err)
+	assert.Nil(lastRun)
+
+	scheduledAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	assert.Nil(SetScheduleTriggerLastRun(db, "local.trigger.schedule.daily", scheduledAt))
+	assert.Nil(SetScheduleTriggerLastRun(db, "local.trigger.schedule.hourly", scheduledAt.Add(time.Hour)))
+
+	lastRun, err = GetScheduleTriggerLastRun(db, "local.trigger.schedule.daily")
+	assert.Nil(err)
+	assert.Equal(scheduledAt, *lastRun)
+
+	scheduledAt = scheduledAt.Add(24 * time.Hour)
+	assert.Nil(SetScheduleTriggerLastRun(db, "local.trigger.schedule.daily", scheduledAt))
+
+	lastRun, err = GetScheduleTriggerLastRun(db, "local.trigger.schedule.daily")
+	assert.Nil(err)
+	assert.Equal(scheduledAt, *lastRun)
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/pipe-fittings/perr"
+	putils "github.com/turbot/pipe-fittings/utils"
+)
+
+// TriggerEvent is a fire of a trigger that didn't run its pipeline as usual, e.g. it was skipped because the previous
+// execution of the trigger was still running
+type TriggerEvent struct {
+	TriggerName string
+	Type        string
+	ExecutionID string
+	Reason      string
+	CreatedAt   time.Time
+}
+
+func CreateTriggerEventTable(db *sql.DB) error {
+	createTableSQL := `
+	create table if not exists trigger_event (
+		id integer primary key autoincrement,
+		trigger_name text,
+		event_type text,
+		execution_id text,
+		reason text,
+		created_at text
+	)`
+
+	_, err := db.Exec(createTableSQL)
+	if err != nil {
+		slog.Error("error creating trigger_event table", "error", err)
+		return perr.InternalWithMessage("error creating trigger_event table")
+	}
+
+	indexSql := `create index if not exists idx_trigger_event_trigger_name on trigger_event (trigger_name);`
+	_, err = db.Exec(indexSql)
+	if err != nil {
+		slog.Error("error creating trigger_event index", "error", err)
+		return perr.InternalWithMessage("error creating trigger_event index")
+	}
+
+	return nil
+}
+
+// SaveTriggerEvent records the event of the trigger, only the latest MaxTriggerEvents events of the trigger are kept
+func SaveTriggerEvent(db *sql.DB, triggerName, eventType, executionID, reason string) error {
+	currentTimeString := time.Now().UTC().Format(putils.RFC3339WithMS)
+
+	statement := `insert into trigger_event (trigger_name, event_type, execution_id, reason, created_at) values (?, ?, ?, ?, ?)`
+	_, err := db.Exec(statement, triggerName, eventType, executionID, reason, currentTimeString)
+	if err != nil {
+		slog.Error("error saving trigger event", "trigger", triggerName, "type", eventType, "error", err)
+		return perr.InternalWithMessage("error saving trigger event " + err.Error())
+	}
+
+	statement = `delete from trigger_event where trigger_name = ? and id not in
+		(select id from trigger_event where trigger_name = ? order by id desc limit ?)`
+	_, err = db.Exec(statement, triggerName, triggerName, constants.MaxTriggerEvents)
+	if err != nil {
+		slog.Error("error deleting old trigger events", "trigger", triggerName, "error", err)
+		return perr.InternalWithMessage("error deleting old trigger events " + err.Error())
+	}
+
+	return nil
+}
+
+// ListTriggerEvents returns the latest events of the trigger, the most recent first
+func ListTriggerEvents(db *sql.DB, triggerName string, limit int) ([]TriggerEvent, error) {
+	rows, err := db.Query(`select trigger_name, event_type, execution_id, reason, created_at from trigger_event
+		where trigger_name = ? order by id desc limit ?`, triggerName, limit)
+	if err != nil {
+		slog.Error("error querying trigger event", "error", err)
+		return nil, perr.InternalWithMessage("error querying trigger event")
+	}
+	defer rows.Close()
+
+	var events []TriggerEvent
+	for rows.Next() {
+		var e TriggerEvent
+		var createdAt string
+		err = rows.Scan(&e.TriggerName, &e.Type, &e.ExecutionID, &e.Reason, &createdAt)
+		if err != nil {
+			slog.Error("error scanning trigger event", "error", err)
+			return nil, perr.InternalWithMessage("error scanning trigger event")
+		}
+
+		e.CreatedAt, err = time.Parse(putils.RFC3339WithMS, createdAt)
+		if err != nil {
+			slog.Error("error parsing trigger event time", "created_at", createdAt, "error", err)
+			return nil, perr.InternalWithMessage("error parsing trigger event time")
+		}
+		events = append(events, e)
+	}
+
+	if rows.Err() != nil {
+		slog.Error("error iterating trigger_event table", "error", rows.Err())
+		return nil, perr.InternalWithMessage("error iterating trigger_event table")
+	}
+
+	return events, nil
+}
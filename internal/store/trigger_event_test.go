@@ -0,0 +1,58 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	err := copyNewFlowpipeDbCleanFile("./clean_test_files/flowpipe_clean.db")
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+	defer db.Close()
+
+	assert.Nil(CreateTriggerEventTable(db))
+
+	events, err := ListTriggerEvents(db, "local.trigger.schedule.every_minute", 10)
+	assert.Nil(err)
+	assert.Equal(0, len(events))
+
+	assert.Nil(SaveTriggerEvent(db, "local.trigger.schedule.every_minute", "skipped", "exec_1", "the previous execution is still running"))
+	assert.Nil(SaveTriggerEvent(db, "local.trigger.query.new_orders", "cancelled", "exec_2", "cancelled by the execution exec_3 of the trigger"))
+	assert.Nil(SaveTriggerEvent(db, "local.trigger.schedule.every_minute", "skipped", "exec_4", "the previous execution is still running"))
+
+	events, err = ListTriggerEvents(db, "local.trigger.schedule.every_minute", 10)
+	assert.Nil(err)
+	assert.Equal(2, len(events))
+
+	// the most recent first
+	assert.Equal("exec_4", events[0].ExecutionID)
+	assert.Equal("skipped", events[0].Type)
+	assert.Equal("the previous execution is still running", events[0].Reason)
+	assert.Equal("exec_1", events[1].ExecutionID)
+	assert.False(events[0].CreatedAt.IsZero())
+
+	// only the latest events are kept
+	for i := 0; i < 150; i++ {
+		assert.Nil(SaveTriggerEvent(db, "local.trigger.schedule.every_minute", "skipped", fmt.Sprintf("exec_%d", i+10), ""))
+	}
+
+	events, err = ListTriggerEvents(db, "local.trigger.schedule.every_minute", 1000)
+	assert.Nil(err)
+	assert.Equal(100, len(events))
+	assert.Equal("exec_159", events[0].ExecutionID)
+
+	events, err = ListTriggerEvents(db, "local.trigger.query.new_orders", 10)
+	assert.Nil(err)
+	assert.Equal(1, len(events))
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/pipe-fittings/perr"
+	putils "github.com/turbot/pipe-fittings/utils"
+)
+
+// The trigger_queue table holds the fires of the triggers with overlap = "queue" waiting for the running execution
+// of the trigger to end. The fires are kept with their execution command so they survive a restart.
+func CreateTriggerQueueTable(db *sql.DB) error {
+	createTableSQL := `
+	create table if not exists trigger_queue (
+		id integer primary key autoincrement,
+		trigger_name text,
+		execution_id text,
+		command text,
+		created_at text
+	)`
+
+	_, err := db.Exec(createTableSQL)
+	if err != nil {
+		slog.Error("error creating trigger_queue table", "error", err)
+		return perr.InternalWithMessage("error creating trigger_queue table")
+	}
+
+	indexSql := `create index if not exists idx_trigger_queue_trigger_name on trigger_queue (trigger_name);`
+	_, err = db.Exec(indexSql)
+	if err != nil {
+		slog.Error("error creating trigger_queue index", "error", err)
+		return perr.InternalWithMessage("error creating trigger_queue index")
+	}
+
+	return nil
+}
+
+// QueueTriggerExecution queues the execution command of the trigger fire
+func QueueTriggerExecution(db *sql.DB, triggerName, executionID string, command []byte) error {
+	currentTimeString := time.Now().UTC().Format(putils.RFC3339WithMS)
+
+	statement := `insert into trigger_queue (trigger_name, execution_id, command, created_at) values (?, ?, ?, ?)`
+	_, err := db.Exec(statement, triggerName, executionID, string(command), currentTimeString)
+	if err != nil {
+		slog.Error("error queueing trigger execution", "trigger", triggerName, "execution_id", executionID, "error", err)
+		return perr.InternalWithMessage("error queueing trigger execution " + err.Error())
+	}
+
+	return nil
+}
+
+// CountQueuedTriggerExecutions returns the number of fires queued for the trigger
+func CountQueuedTriggerExecutions(db *sql.DB, triggerName string) (int, error) {
+	var count int
+	err := db.QueryRow(`select count(*) from trigger_queue where trigger_name = ?`, triggerName).Scan(&count)
+	if err != nil {
+		slog.Error("error counting queued trigger executions", "trigger", triggerName, "error", err)
+		return 0, perr.InternalWithMessage("error counting queued trigger executions")
+	}
+	return count, nil
+}
+
+// DequeueTriggerExecution removes the oldest fire queued for the trigger and returns its execution command, nil if
+// there is none
+func DequeueTriggerExecution(db *sql.DB, triggerName string) ([]byte, error) {
+	var id int64
+	var command string
+	err := db.QueryRow(`select id, command from trigger_queue where trigger_name = ? order by id asc limit 1`, triggerName).Scan(&id, &command)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("error querying trigger_queue", "trigger", triggerName, "error", err)
+		return nil, perr.InternalWithMessage("error querying trigger_queue")
+	}
+
+	_, err = db.Exec(`delete from trigger_queue where id = ?`, id)
+	if err != nil {
+		slog.Error("error deleting queued trigger execution", "trigger", triggerName, "error", err)
+		return nil, perr.InternalWithMessage("error deleting queued trigger execution " + err.Error())
+	}
+
+	return []byte(command), nil
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	err := copyNewFlowpipeDbCleanFile("./clean_test_files/flowpipe_clean.db")
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+
+	db, err := OpenFlowpipeDB()
+	if err != nil {
+		assert.FailNow(err.Error())
+	}
+	defer db.Close()
+
+	assert.Nil(CreateTriggerQueueTable(db))
+
+	command, err := DequeueTriggerExecution(db, "local.trigger.schedule.every_minute")
+	assert.Nil(err)
+	assert.Nil(command)
+
+	assert.Nil(QueueTriggerExecution(db, "local.trigger.schedule.every_minute", "exec_1", []byte(`{"id":"exec_1"}`)))
+	assert.Nil(QueueTriggerExecution(db, "local.trigger.query.new_orders", "exec_2", []byte(`{"id":"exec_2"}`)))
+	assert.Nil(QueueTriggerExecution(db, "local.trigger.schedule.every_minute", "exec_3", []byte(`{"id":"exec_3"}`)))
+
+	count, err := CountQueuedTriggerExecutions(db, "local.trigger.schedule.every_minute")
+	assert.Nil(err)
+	assert.Equal(2, count)
+
+	// the oldest first
+	command, err = DequeueTriggerExecution(db, "local.trigger.schedule.every_minute")
+	assert.Nil(err)
+	assert.Equal(`{"id":"exec_1"}`, string(command))
+
+	command, err = DequeueTriggerExecution(db, "local.trigger.schedule.every_minute")
+	assert.Nil(err)
+	assert.Equal(`{"id":"exec_3"}`, string(command))
+
+	count, err = CountQueuedTriggerExecutions(db, "local.trigger.schedule.every_minute")
+	assert.Nil(err)
+	assert.Equal(0, count)
+
+	count, err = CountQueuedTriggerExecutions(db, "local.trigger.query.new_orders")
+	assert.Nil(err)
+	assert.Equal(1, count)
+}
@@ -158,6 +158,11 @@ var tests = []testSetup{
 		file:          "./pipelines/invalid_sleep_attribute.fp",
 		containsError: "Value of the attribute 'duration' must be a string or a whole number",
 	},
+	{
+		title:         "invalid on_restart",
+		file:          "./pipelines/invalid_on_restart.fp",
+		containsError: "Invalid value for on_restart: retry",
+	},
 	{
 		title:         "invalid http step base attribute - timeout",
 		file:          "./pipelines/invalid_http_timeout.fp",
@@ -168,11 +173,61 @@ var tests = []testSetup{
 		file:          "./pipelines/invalid_query_trigger.fp",
 		containsError: "expected exactly 5 fields, found 1: [days]", // if not valid interval we assume it's a cron statement
 	},
+	{
+		title:         "update capture in query trigger with cursor column",
+		file:          "./pipelines/invalid_query_trigger_cursor_capture.fp",
+		containsError: "Only the insert capture block is supported with cursor_column",
+	},
 	{
 		title:         "invalid execution mode in http trigger",
 		file:          "./pipelines/invalid_http_trigger_execution_mode.fp",
 		containsError: "The execution mode must be one of: synchronous,asynchronous",
 	},
+	{
+		title:         "invalid event in file trigger",
+		file:          "./pipelines/invalid_file_trigger_event.fp",
+		containsError: "Invalid file event: rename. Specify create, modify, delete",
+	},
+	{
+		title:         "invalid debounce in file trigger",
+		file:          "./pipelines/invalid_file_trigger_debounce.fp",
+		containsError: "Invalid debounce: soon",
+	},
+	{
+		title:         "stream without consumer in queue trigger",
+		file:          "./pipelines/invalid_queue_trigger_stream.fp",
+		containsError: "The stream of the queue trigger requires a consumer",
+	},
+	{
+		title:         "invalid url in queue trigger",
+		file:          "./pipelines/invalid_queue_trigger_url.fp",
+		containsError: "Invalid url: localhost",
+	},
+	{
+		title:         "invalid status in pipeline trigger",
+		file:          "./pipelines/invalid_pipeline_trigger_status.fp",
+		containsError: "Invalid pipeline status: paused. Specify finished, failed, canceled",
+	},
+	{
+		title:         "pipeline trigger running its upstream pipeline",
+		file:          "./pipelines/invalid_pipeline_trigger_upstream.fp",
+		containsError: "The pipeline trigger can't run its upstream pipeline: local.pipeline.etl",
+	},
+	{
+		title:         "pipeline triggers running their upstream pipelines in a cycle",
+		file:          "./pipelines/invalid_pipeline_trigger_cycle.fp",
+		containsError: "The pipeline triggers run their upstream pipelines in a cycle: local.trigger.pipeline.audit_etl (",
+	},
+	{
+		title:         "invalid url in email trigger",
+		file:          "./pipelines/invalid_email_trigger_url.fp",
+		containsError: "Invalid url: pop3://mail.example.com",
+	},
+	{
+		title:         "email trigger moving the messages to its mailbox",
+		file:          "./pipelines/invalid_email_trigger_move_to.fp",
+		containsError: "The email trigger can't move the processed messages to its mailbox: Support",
+	},
 	// This test doesn't work because it needs FlowpipeConfig to load the notifier otherwise the notifier reference will break,
 	// and notifier is a mandatory attribute so it will never test the option vs options
 	// {
@@ -190,6 +245,16 @@ var tests = []testSetup{
 		file:          "./pipelines/invalid_http_trigger_duplicate_method.fp",
 		containsError: "Duplicate method block for type: post",
 	},
+	{
+		title:         "invalid signature algorithm in http trigger",
+		file:          "./pipelines/invalid_http_trigger_signature_algorithm.fp",
+		containsError: "The signature algorithm must be one of: sha1,sha256",
+	},
+	{
+		title:         "invalid signature format in http trigger",
+		file:          "./pipelines/invalid_http_trigger_signature_format.fp",
+		containsError: "The signature format must be one of: hex,github,stripe",
+	},
 	{
 		title:         "invalid query trigger - missing required field sql",
 		file:          "./pipelines/query_trigger_missing_sql.fp",
@@ -200,6 +265,26 @@ var tests = []testSetup{
 		file:          "./pipelines/schedule_trigger_missing_schedule.fp",
 		containsError: "The argument \"schedule\" is required, but no definition was found.",
 	},
+	{
+		title:         "invalid timezone in schedule trigger",
+		file:          "./pipelines/invalid_schedule_trigger_timezone.fp",
+		containsError: "Invalid timezone: Mars/Olympus_Mons. Specify an IANA time zone, e.g. America/New_York",
+	},
+	{
+		title:         "invalid catch_up in schedule trigger",
+		file:          "./pipelines/invalid_schedule_trigger_catch_up.fp",
+		containsError: "The catch_up must be one of: none,latest,all",
+	},
+	{
+		title:         "invalid overlap in schedule trigger",
+		file:          "./pipelines/invalid_trigger_overlap.fp",
+		containsError: "The overlap must be one of: allow,skip,queue,cancel_previous",
+	},
+	{
+		title:         "overlap in http trigger",
+		file:          "./pipelines/invalid_http_trigger_overlap.fp",
+		containsError: "Unsupported argument: An argument named \"overlap\" is not expected here.",
+	},
 	{
 		title:         "duplicate output name",
 		file:          "./pipelines/duplicate_output_name.fp",
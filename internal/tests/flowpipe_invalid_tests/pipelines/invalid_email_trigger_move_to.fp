@@ -0,0 +1,15 @@
+pipeline "support_ticket" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+trigger "email" "invalid_email_trigger_move_to" {
+  url      = "imaps://mail.example.com"
+  username = "support@example.com"
+  password = "secret"
+  mailbox  = "Support"
+  move_to  = "Support"
+  pipeline = pipeline.support_ticket
+}
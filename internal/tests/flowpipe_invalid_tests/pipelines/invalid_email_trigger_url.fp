@@ -0,0 +1,13 @@
+pipeline "support_ticket" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+trigger "email" "invalid_email_trigger_url" {
+  url      = "pop3://mail.example.com"
+  username = "support@example.com"
+  password = "secret"
+  pipeline = pipeline.support_ticket
+}
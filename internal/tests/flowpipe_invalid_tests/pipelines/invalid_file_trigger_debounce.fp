@@ -0,0 +1,12 @@
+pipeline "process_export" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+trigger "file" "invalid_file_trigger_debounce" {
+  path     = "./exports/*.csv"
+  debounce = "soon"
+  pipeline = pipeline.process_export
+}
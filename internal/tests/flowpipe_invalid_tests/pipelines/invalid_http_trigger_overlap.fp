@@ -0,0 +1,13 @@
+trigger "http" "simple" {
+  overlap = "skip"
+
+  method "post" {
+    pipeline = pipeline.simple_with_trigger
+  }
+}
+
+pipeline "simple_with_trigger" {
+  step "transform" "echo" {
+    value = "hello"
+  }
+}
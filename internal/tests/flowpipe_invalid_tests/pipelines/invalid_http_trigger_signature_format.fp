@@ -0,0 +1,15 @@
+trigger "http" "http_trigger_signature" {
+  pipeline = pipeline.http_webhook_pipeline
+
+  signature {
+    secret = "topsecret"
+    header = "X-Signature"
+    format = "base64"
+  }
+}
+
+pipeline "http_webhook_pipeline" {
+  step "transform" "simple_echo" {
+    value = "hello"
+  }
+}
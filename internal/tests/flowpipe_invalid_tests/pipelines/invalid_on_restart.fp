@@ -0,0 +1,8 @@
+pipeline "invalid_on_restart" {
+
+  on_restart = "retry"
+
+  step "sleep" "sleep" {
+    duration = "1s"
+  }
+}
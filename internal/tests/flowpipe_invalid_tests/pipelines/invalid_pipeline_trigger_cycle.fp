@@ -0,0 +1,36 @@
+pipeline "etl" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+pipeline "report" {
+
+  step "transform" "echo" {
+    value = "bar"
+  }
+}
+
+pipeline "audit" {
+
+  step "transform" "echo" {
+    value = "baz"
+  }
+}
+
+trigger "pipeline" "etl_report" {
+  upstream = pipeline.etl
+  pipeline = pipeline.report
+}
+
+trigger "pipeline" "report_audit" {
+  upstream = pipeline.report
+  pipeline = pipeline.audit
+  statuses = ["failed"]
+}
+
+trigger "pipeline" "audit_etl" {
+  upstream = pipeline.audit
+  pipeline = pipeline.etl
+}
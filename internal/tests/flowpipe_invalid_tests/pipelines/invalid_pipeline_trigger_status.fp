@@ -0,0 +1,19 @@
+pipeline "etl" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+pipeline "report" {
+
+  step "transform" "echo" {
+    value = "bar"
+  }
+}
+
+trigger "pipeline" "invalid_pipeline_trigger_status" {
+  upstream = pipeline.etl
+  statuses = ["finished", "paused"]
+  pipeline = pipeline.report
+}
@@ -0,0 +1,11 @@
+pipeline "etl" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+trigger "pipeline" "invalid_pipeline_trigger_upstream" {
+  upstream = pipeline.etl
+  pipeline = pipeline.etl
+}
@@ -0,0 +1,18 @@
+trigger "query" "query_trigger_cursor" {
+  database      = "postgres://steampipe:@host.docker.internal:9193/steampipe"
+  sql           = "select id, item from orders where $1::bigint is null or id > $1"
+  cursor_column = "id"
+
+  capture "update" {
+    pipeline = pipeline.simple
+    args = {
+      rows = self.updated_rows
+    }
+  }
+}
+
+pipeline "simple" {
+  step "transform" "echo" {
+    value = "hello"
+  }
+}
@@ -0,0 +1,12 @@
+pipeline "process_order" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+trigger "queue" "invalid_queue_trigger_stream" {
+  subject  = "orders.created"
+  stream   = "ORDERS"
+  pipeline = pipeline.process_order
+}
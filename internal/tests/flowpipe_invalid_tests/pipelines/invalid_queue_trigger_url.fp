@@ -0,0 +1,12 @@
+pipeline "process_order" {
+
+  step "transform" "echo" {
+    value = "foo"
+  }
+}
+
+trigger "queue" "invalid_queue_trigger_url" {
+  url      = "localhost"
+  subject  = "orders.created"
+  pipeline = pipeline.process_order
+}
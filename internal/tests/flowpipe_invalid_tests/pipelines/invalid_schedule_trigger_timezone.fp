@@ -0,0 +1,11 @@
+trigger "schedule" "simple" {
+  schedule = "0 9 * * *"
+  timezone = "Mars/Olympus_Mons"
+  pipeline = pipeline.simple_with_trigger
+}
+
+pipeline "simple_with_trigger" {
+  step "transform" "echo" {
+    value = "hello"
+  }
+}
@@ -0,0 +1,11 @@
+trigger "schedule" "simple" {
+  schedule = "* * * * *"
+  overlap  = "later"
+  pipeline = pipeline.simple_with_trigger
+}
+
+pipeline "simple_with_trigger" {
+  step "transform" "echo" {
+    value = "hello"
+  }
+}
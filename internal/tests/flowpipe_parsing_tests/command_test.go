@@ -0,0 +1,48 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+func TestCommandStep(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelines, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/command.fp")
+	assert.Nil(err, "error found")
+	assert.Equal(1, len(pipelines), "wrong number of pipelines")
+
+	if pipelines["local.pipeline.pipeline_step_command"] == nil {
+		assert.Fail("pipeline_step_command pipeline not found")
+		return
+	}
+
+	step, ok := pipelines["local.pipeline.pipeline_step_command"].GetStep("command.shell").(*resources.PipelineStepCommand)
+	if !ok {
+		assert.Fail("command step not found")
+		return
+	}
+
+	assert.Equal("echo $GREETING | tr a-z A-Z", *step.Command)
+	assert.Equal("scripts", *step.Workdir)
+	assert.Equal("input", *step.Stdin)
+	assert.Equal([]int64{0, 2}, step.AllowedExitCodes)
+
+	// env is resolved when the step runs
+	assert.NotNil(step.UnresolvedAttributes[schema.AttributeTypeEnv])
+
+	argsStep := pipelines["local.pipeline.pipeline_step_command"].GetStep("command.args")
+	if argsStep == nil {
+		assert.Fail("command step not found")
+		return
+	}
+	assert.Equal(constants.BlockTypePipelineStepCommand, argsStep.GetType())
+	assert.Contains(argsStep.GetDependsOn(), "command.shell")
+	assert.NotNil(argsStep.GetUnresolvedAttributes()[schema.AttributeTypeArgs])
+}
@@ -0,0 +1,66 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/resources"
+)
+
+func TestEmailTriggerParse(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	_, triggers, err := parse.LoadPipelines(ctx, "./pipelines/email_trigger.fp")
+	assert.Nil(err, "error found")
+
+	emailTrigger := triggers["local.trigger.email.support"]
+	if emailTrigger == nil {
+		assert.Fail("support trigger not found")
+		return
+	}
+
+	et, ok := emailTrigger.Config.(*resources.TriggerEmail)
+	if !ok {
+		assert.Fail("support trigger is not an email trigger")
+		return
+	}
+
+	assert.Equal("5m", et.Schedule)
+	assert.Equal("imaps://imap.example.com", et.Url)
+	assert.Equal("support@example.com", et.Username)
+	assert.Equal("secret", et.Password)
+	assert.Equal("Support", et.Mailbox)
+	assert.Equal("Support/Processed", et.MoveTo)
+	assert.Equal("local.pipeline.support_ticket", emailTrigger.Pipeline.AsValueMap()["name"].AsString())
+	assert.NotNil(emailTrigger.ArgsRaw)
+
+	address, useTLS, err := et.GetAddress()
+	assert.Nil(err)
+	assert.Equal("imap.example.com:993", address)
+	assert.True(useTLS)
+
+	emailTrigger = triggers["local.trigger.email.support_defaults"]
+	if emailTrigger == nil {
+		assert.Fail("support_defaults trigger not found")
+		return
+	}
+
+	et, ok = emailTrigger.Config.(*resources.TriggerEmail)
+	if !ok {
+		assert.Fail("support_defaults trigger is not an email trigger")
+		return
+	}
+
+	assert.Equal("", et.Schedule)
+	assert.Equal("INBOX", et.Mailbox)
+	assert.Equal("", et.MoveTo)
+	assert.Nil(emailTrigger.ArgsRaw)
+
+	address, useTLS, err = et.GetAddress()
+	assert.Nil(err)
+	assert.Equal("localhost:1143", address)
+	assert.False(useTLS)
+}
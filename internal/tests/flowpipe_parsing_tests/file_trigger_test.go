@@ -0,0 +1,52 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/resources"
+)
+
+func TestFileTriggerParse(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	_, triggers, err := parse.LoadPipelines(ctx, "./pipelines/file_trigger.fp")
+	assert.Nil(err, "error found")
+
+	fileTrigger := triggers["local.trigger.file.csv_exports"]
+	if fileTrigger == nil {
+		assert.Fail("csv_exports trigger not found")
+		return
+	}
+
+	ft, ok := fileTrigger.Config.(*resources.TriggerFile)
+	if !ok {
+		assert.Fail("csv_exports trigger is not a file trigger")
+		return
+	}
+
+	assert.Equal("./exports/*.csv", ft.Path)
+	assert.Equal([]string{"create", "modify"}, ft.Events)
+	assert.Equal(5*time.Second, ft.GetDebounce())
+	assert.Equal("local.pipeline.process_export", fileTrigger.Pipeline.AsValueMap()["name"].AsString())
+	assert.NotNil(fileTrigger.ArgsRaw)
+
+	fileTrigger = triggers["local.trigger.file.csv_exports_defaults"]
+	if fileTrigger == nil {
+		assert.Fail("csv_exports_defaults trigger not found")
+		return
+	}
+
+	ft, ok = fileTrigger.Config.(*resources.TriggerFile)
+	if !ok {
+		assert.Fail("csv_exports_defaults trigger is not a file trigger")
+		return
+	}
+
+	assert.Equal([]string{"create", "modify", "delete"}, ft.Events)
+	assert.Equal(time.Second, ft.GetDebounce())
+}
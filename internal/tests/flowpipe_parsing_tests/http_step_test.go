@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/parse"
 	"github.com/turbot/pipe-fittings/schema"
 	"github.com/zclconf/go-cty/cty"
@@ -149,3 +150,151 @@ func TestHttpStepLoadTimeoutStringUnresolved(t *testing.T) {
 	assert.Equal("post", stepInputs[schema.AttributeTypeMethod], "wrong method")
 	assert.Equal("2s", stepInputs[schema.AttributeTypeTimeout], "wrong cert")
 }
+
+func TestHttpStepLoadPagination(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelines, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/http_step.fp")
+	assert.Nil(err, "error found")
+
+	if pipelines["local.pipeline.http_step_pagination"] == nil {
+		assert.Fail("http_step_pagination pipeline not found")
+		return
+	}
+
+	step := pipelines["local.pipeline.http_step_pagination"].GetStep("http.list_items")
+	if step == nil {
+		assert.Fail("http.list_items step not found")
+		return
+	}
+
+	paramsEvalContext := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"param": cty.ObjectVal(map[string]cty.Value{
+				"page_size": cty.NumberIntVal(20),
+			}),
+		},
+	}
+
+	stepInputs, err := step.GetInputs(paramsEvalContext)
+	assert.Nil(err, "error found")
+
+	pagination, ok := stepInputs[constants.BlockTypePagination].(map[string]interface{})
+	if !ok {
+		assert.Fail("pagination input not found")
+		return
+	}
+	assert.Equal("token", pagination[schema.AttributeTypeStyle])
+	assert.Equal("data.items", pagination[constants.AttributeTypeItemsPath])
+	assert.Equal("meta.next_token", pagination[constants.AttributeTypeTokenPath])
+	assert.Equal("page_token", pagination[constants.AttributeTypeTokenParam])
+	assert.Equal(int64(5), pagination[constants.AttributeTypeMaxPages])
+	assert.Equal(20, pagination[constants.AttributeTypeLimit])
+	assert.Nil(pagination[constants.AttributeTypeMaxItems])
+}
+
+func TestHttpStepLoadPaginationInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/http_step_pagination_invalid.fp")
+	assert.NotNil(err, "error not found")
+	assert.Contains(err.Error(), "The token pagination must define token_path and token_param")
+}
+
+func TestHttpStepLoadTransport(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelines, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/http_step.fp")
+	assert.Nil(err, "error found")
+
+	if pipelines["local.pipeline.http_step_transport"] == nil {
+		assert.Fail("http_step_transport pipeline not found")
+		return
+	}
+
+	step := pipelines["local.pipeline.http_step_transport"].GetStep("http.via_proxy")
+	if step == nil {
+		assert.Fail("http.via_proxy step not found")
+		return
+	}
+
+	paramsEvalContext := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"param": cty.ObjectVal(map[string]cty.Value{
+				"proxy": cty.StringVal("socks5://proxy.internal:1080"),
+			}),
+		},
+	}
+
+	stepInputs, err := step.GetInputs(paramsEvalContext)
+	assert.Nil(err, "error found")
+
+	assert.Equal("socks5://proxy.internal:1080", stepInputs[constants.AttributeTypeProxyUrl])
+	assert.Equal("5s", stepInputs[constants.AttributeTypeConnectTimeout])
+	assert.Equal(30000, stepInputs[constants.AttributeTypeReadTimeout])
+	assert.Equal(false, stepInputs[constants.AttributeTypeFollowRedirects])
+	assert.Equal(int64(3), stepInputs[constants.AttributeTypeMaxRedirects])
+	assert.Equal(true, stepInputs[constants.AttributeTypeHttp2])
+	assert.Nil(stepInputs[constants.AttributeTypeClientCertPem])
+}
+
+func TestHttpStepLoadFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelines, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/http_step.fp")
+	assert.Nil(err, "error found")
+
+	if pipelines["local.pipeline.http_step_files"] == nil {
+		assert.Fail("http_step_files pipeline not found")
+		return
+	}
+
+	step := pipelines["local.pipeline.http_step_files"].GetStep("http.upload")
+	if step == nil {
+		assert.Fail("http.upload step not found")
+		return
+	}
+
+	paramsEvalContext := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"param": cty.ObjectVal(map[string]cty.Value{
+				"report": cty.StringVal("./reports/weekly.pdf"),
+			}),
+		},
+	}
+
+	stepInputs, err := step.GetInputs(paramsEvalContext)
+	assert.Nil(err, "error found")
+
+	// the parts are in the order of their blocks
+	assert.Equal([]interface{}{
+		map[string]interface{}{
+			schema.AttributeTypeName:       "key",
+			constants.AttributeTypeContent: "reports/daily.pdf",
+		},
+		map[string]interface{}{
+			schema.AttributeTypeName:        "file",
+			constants.AttributeTypeFile:     "./reports/weekly.pdf",
+			schema.AttributeTypeContentType: "application/pdf",
+		},
+	}, stepInputs[constants.BlockTypeMultipart])
+
+	step = pipelines["local.pipeline.http_step_files"].GetStep("http.download")
+	if step == nil {
+		assert.Fail("http.download step not found")
+		return
+	}
+
+	stepInputs, err = step.GetInputs(paramsEvalContext)
+	assert.Nil(err, "error found")
+	assert.Equal("./artifacts/build.tar.gz", stepInputs[constants.AttributeTypeResponseFile])
+	assert.Nil(stepInputs[constants.BlockTypeMultipart])
+}
+
+func TestHttpStepLoadMultipartInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/http_step_multipart_invalid.fp")
+	assert.NotNil(err, "error not found")
+	assert.Contains(err.Error(), "The multipart block must define either a file or a content")
+}
@@ -0,0 +1,101 @@
+package pipeline_test
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/resources"
+)
+
+func httpTriggerSignature(assert *assert.Assertions, triggers map[string]*resources.Trigger, name string) *resources.TriggerHTTPSignature {
+	trigger := triggers[name]
+	if trigger == nil {
+		assert.FailNow(name + " trigger not found")
+		return nil
+	}
+
+	httpTrigger, ok := trigger.Config.(*resources.TriggerHttp)
+	if !ok {
+		assert.FailNow(name + " trigger is not an http trigger")
+		return nil
+	}
+
+	if httpTrigger.Signature == nil {
+		assert.FailNow(name + " trigger has no signature")
+		return nil
+	}
+
+	return httpTrigger.Signature
+}
+
+func TestHttpTriggerSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	_, triggers, err := parse.LoadPipelines(ctx, "./pipelines/http_trigger_signature.fp")
+	assert.Nil(err, "error found")
+
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	now := time.Now()
+
+	// github
+	signature := httpTriggerSignature(assert, triggers, "local.trigger.http.github_push")
+	assert.Equal("X-Hub-Signature-256", signature.Header)
+	assert.Equal("sha256", signature.Algorithm)
+	assert.Equal("github", signature.Format)
+
+	header := http.Header{}
+	assert.NotNil(signature.Verify(header, body, now))
+
+	header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(signature.Sign(body)))
+	assert.Nil(signature.Verify(header, body, now))
+	assert.NotNil(signature.Verify(header, []byte(`{"ref":"refs/heads/dev"}`), now))
+
+	header.Set("X-Hub-Signature-256", hex.EncodeToString(signature.Sign(body)))
+	assert.NotNil(signature.Verify(header, body, now))
+
+	// stripe
+	signature = httpTriggerSignature(assert, triggers, "local.trigger.http.stripe_event")
+	assert.Equal("stripe", signature.Format)
+	assert.Equal(2*time.Minute, signature.GetTolerance())
+
+	timestamp := strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+	v1 := hex.EncodeToString(signature.Sign(append([]byte(timestamp+"."), body...)))
+
+	header = http.Header{}
+	header.Set("Stripe-Signature", "t="+timestamp+",v1="+v1)
+	assert.Nil(signature.Verify(header, body, now))
+
+	// one of the signatures is valid while the secret is rolled
+	header.Set("Stripe-Signature", "t="+timestamp+",v1=abcdef,v1="+v1)
+	assert.Nil(signature.Verify(header, body, now))
+
+	// too old
+	assert.NotNil(signature.Verify(header, body, now.Add(5*time.Minute)))
+
+	header.Set("Stripe-Signature", "v1="+v1)
+	assert.NotNil(signature.Verify(header, body, now))
+
+	// the method block is still parsed
+	httpTrigger := triggers["local.trigger.http.stripe_event"].Config.(*resources.TriggerHttp)
+	assert.NotNil(httpTrigger.Methods["post"])
+
+	// hex
+	signature = httpTriggerSignature(assert, triggers, "local.trigger.http.legacy_hook")
+	assert.Equal("sha1", signature.Algorithm)
+	assert.Equal("hex", signature.Format)
+	assert.Equal(5*time.Minute, signature.GetTolerance())
+
+	header = http.Header{}
+	header.Set("X-Signature", hex.EncodeToString(signature.Sign(body)))
+	assert.Nil(signature.Verify(header, body, now))
+
+	header.Set("X-Signature", "not hex")
+	assert.NotNil(signature.Verify(header, body, now))
+}
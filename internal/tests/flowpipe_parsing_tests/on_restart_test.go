@@ -0,0 +1,32 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/parse"
+)
+
+func TestOnRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelines, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/on_restart.fp")
+	assert.Nil(err, "error found")
+
+	pipeline := pipelines["local.pipeline.on_restart_fail"]
+	if pipeline == nil {
+		assert.Fail("on_restart_fail pipeline not found")
+		return
+	}
+	assert.Equal(constants.OnRestartFail, pipeline.GetOnRestart())
+
+	pipeline = pipelines["local.pipeline.on_restart_default"]
+	if pipeline == nil {
+		assert.Fail("on_restart_default pipeline not found")
+		return
+	}
+	assert.Nil(pipeline.OnRestart)
+	assert.Equal(constants.OnRestartResume, pipeline.GetOnRestart())
+}
@@ -0,0 +1,54 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/resources"
+)
+
+func TestPipelineTriggerParse(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	_, triggers, err := parse.LoadPipelines(ctx, "./pipelines/pipeline_trigger.fp")
+	assert.Nil(err, "error found")
+
+	pipelineTrigger := triggers["local.trigger.pipeline.etl_report"]
+	if pipelineTrigger == nil {
+		assert.Fail("etl_report trigger not found")
+		return
+	}
+
+	pt, ok := pipelineTrigger.Config.(*resources.TriggerPipeline)
+	if !ok {
+		assert.Fail("etl_report trigger is not a pipeline trigger")
+		return
+	}
+
+	assert.Equal("local.pipeline.etl", pt.Upstream)
+	assert.Equal([]string{"failed", "canceled"}, pt.Statuses)
+	assert.Equal("local.pipeline.report", pipelineTrigger.Pipeline.AsValueMap()["name"].AsString())
+	assert.NotNil(pipelineTrigger.ArgsRaw)
+
+	assert.True(pt.FiresOn("local.pipeline.etl", "failed"))
+	assert.False(pt.FiresOn("local.pipeline.etl", "finished"))
+	assert.False(pt.FiresOn("local.pipeline.report", "failed"))
+
+	pipelineTrigger = triggers["local.trigger.pipeline.etl_audit"]
+	if pipelineTrigger == nil {
+		assert.Fail("etl_audit trigger not found")
+		return
+	}
+
+	pt, ok = pipelineTrigger.Config.(*resources.TriggerPipeline)
+	if !ok {
+		assert.Fail("etl_audit trigger is not a pipeline trigger")
+		return
+	}
+
+	assert.Equal([]string{"finished", "failed", "canceled"}, pt.Statuses)
+	assert.Nil(pipelineTrigger.ArgsRaw)
+}
@@ -0,0 +1,22 @@
+pipeline "pipeline_step_command" {
+
+  param "greeting" {
+    default = "hello"
+  }
+
+  step "command" "shell" {
+    command = "echo $GREETING | tr a-z A-Z"
+    env = {
+      GREETING = param.greeting
+    }
+    workdir            = "scripts"
+    stdin              = "input"
+    timeout            = "10s"
+    allowed_exit_codes = [0, 2]
+  }
+
+  step "command" "args" {
+    command = "ls"
+    args    = ["-l", step.command.shell.stdout]
+  }
+}
@@ -0,0 +1,57 @@
+pipeline "support_ticket" {
+  param "from" {
+    type = string
+  }
+
+  param "to" {
+    type    = list(string)
+    default = []
+  }
+
+  param "subject" {
+    type = string
+  }
+
+  param "text" {
+    type    = string
+    default = ""
+  }
+
+  param "html" {
+    type    = string
+    default = ""
+  }
+
+  param "attachments" {
+    type    = list(object({ filename = string, content_type = string, size = number }))
+    default = []
+  }
+
+  step "transform" "echo" {
+    value = "${param.from}: ${param.subject}"
+  }
+}
+
+trigger "email" "support" {
+  schedule = "5m"
+  url      = "imaps://imap.example.com"
+  username = "support@example.com"
+  password = "secret"
+  mailbox  = "Support"
+  move_to  = "Support/Processed"
+  pipeline = pipeline.support_ticket
+
+  args = {
+    from    = self.from
+    subject = self.subject
+  }
+}
+
+// the unseen messages of the inbox are flagged as seen, the pipeline is run with the from, to, subject, text, html
+// and attachments args
+trigger "email" "support_defaults" {
+  url      = "imap://localhost:1143"
+  username = "support@example.com"
+  password = "secret"
+  pipeline = pipeline.support_ticket
+}
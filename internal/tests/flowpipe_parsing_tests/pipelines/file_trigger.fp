@@ -0,0 +1,26 @@
+pipeline "process_export" {
+  param "file" {
+    type = string
+  }
+
+  step "transform" "echo" {
+    value = param.file
+  }
+}
+
+trigger "file" "csv_exports" {
+  path     = "./exports/*.csv"
+  events   = ["create", "modify"]
+  debounce = "5s"
+  pipeline = pipeline.process_export
+
+  args = {
+    file = self.path
+  }
+}
+
+// all events, debounce defaults to 1s
+trigger "file" "csv_exports_defaults" {
+  path     = "/data/exports/*.csv"
+  pipeline = pipeline.process_export
+}
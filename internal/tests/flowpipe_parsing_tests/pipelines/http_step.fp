@@ -54,3 +54,71 @@ pipeline "http_step_timeout_string_unresolved" {
     timeout = param.timeout
   }
 }
+
+pipeline "http_step_pagination" {
+
+  param "page_size" {
+    type    = number
+    default = 50
+  }
+
+  step "http" "list_items" {
+    url = "https://myapi.com/vi/api/items"
+
+    pagination {
+      style       = "token"
+      items_path  = "data.items"
+      token_path  = "meta.next_token"
+      token_param = "page_token"
+      limit       = param.page_size
+      max_pages   = 5
+    }
+  }
+}
+
+pipeline "http_step_transport" {
+
+  param "proxy" {
+    type    = string
+    default = "http://proxy.internal:3128"
+  }
+
+  step "http" "via_proxy" {
+    url              = "https://myapi.com/vi/api/items"
+    proxy_url        = param.proxy
+    connect_timeout  = "5s"
+    read_timeout     = 30000
+    follow_redirects = false
+    max_redirects    = 3
+    http2            = true
+  }
+}
+
+pipeline "http_step_files" {
+
+  param "report" {
+    type    = string
+    default = "./reports/daily.pdf"
+  }
+
+  step "http" "upload" {
+    url    = "https://storage.myapi.com/reports"
+    method = "post"
+
+    multipart {
+      name    = "key"
+      content = "reports/daily.pdf"
+    }
+
+    multipart {
+      name         = "file"
+      file         = param.report
+      content_type = "application/pdf"
+    }
+  }
+
+  step "http" "download" {
+    url           = "https://storage.myapi.com/artifacts/build.tar.gz"
+    response_file = "./artifacts/build.tar.gz"
+  }
+}
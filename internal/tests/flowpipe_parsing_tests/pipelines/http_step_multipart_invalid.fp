@@ -0,0 +1,13 @@
+pipeline "http_step_multipart_invalid" {
+
+  step "http" "upload" {
+    url    = "https://storage.myapi.com/reports"
+    method = "post"
+
+    multipart {
+      name    = "file"
+      file    = "./reports/daily.pdf"
+      content = "daily report"
+    }
+  }
+}
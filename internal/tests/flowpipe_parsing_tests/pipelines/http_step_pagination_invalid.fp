@@ -0,0 +1,10 @@
+pipeline "http_step_pagination_invalid" {
+
+  step "http" "list_items" {
+    url = "https://myapi.com/vi/api/items"
+
+    pagination {
+      style = "token"
+    }
+  }
+}
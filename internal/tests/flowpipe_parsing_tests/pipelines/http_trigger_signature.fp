@@ -0,0 +1,49 @@
+pipeline "github_push" {
+  param "body" {
+    type = string
+  }
+
+  step "transform" "echo" {
+    value = param.body
+  }
+}
+
+trigger "http" "github_push" {
+  pipeline = pipeline.github_push
+
+  signature {
+    secret = "topsecret"
+    header = "X-Hub-Signature-256"
+    format = "github"
+  }
+
+  args = {
+    body = self.request_body
+  }
+}
+
+trigger "http" "stripe_event" {
+  signature {
+    secret    = "whsec_topsecret"
+    header    = "Stripe-Signature"
+    format    = "stripe"
+    tolerance = "2m"
+  }
+
+  method "post" {
+    pipeline = pipeline.github_push
+    args = {
+      body = self.request_body
+    }
+  }
+}
+
+trigger "http" "legacy_hook" {
+  pipeline = pipeline.github_push
+
+  signature {
+    secret    = "topsecret"
+    header    = "X-Signature"
+    algorithm = "sha1"
+  }
+}
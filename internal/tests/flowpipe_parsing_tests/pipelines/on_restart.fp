@@ -0,0 +1,15 @@
+pipeline "on_restart_fail" {
+
+  on_restart = "fail"
+
+  step "sleep" "sleep" {
+    duration = "1s"
+  }
+}
+
+pipeline "on_restart_default" {
+
+  step "sleep" "sleep" {
+    duration = "1s"
+  }
+}
@@ -0,0 +1,46 @@
+// declared before the upstream pipeline
+trigger "pipeline" "etl_report" {
+  upstream = pipeline.etl
+  statuses = ["failed", "canceled"]
+  pipeline = pipeline.report
+
+  args = {
+    upstream_execution_id = self.execution_id
+    upstream_status       = self.status
+  }
+}
+
+pipeline "etl" {
+  param "table" {
+    type    = string
+    default = "orders"
+  }
+
+  step "transform" "load" {
+    value = param.table
+  }
+
+  output "rows" {
+    value = 10
+  }
+}
+
+pipeline "report" {
+  param "upstream_execution_id" {
+    type = string
+  }
+
+  param "upstream_status" {
+    type = string
+  }
+
+  step "transform" "echo" {
+    value = "${param.upstream_execution_id} ${param.upstream_status}"
+  }
+}
+
+// all statuses, the pipeline is run with the execution_id, status, args and output args
+trigger "pipeline" "etl_audit" {
+  upstream = pipeline.etl
+  pipeline = pipeline.report
+}
@@ -40,3 +40,21 @@ pipeline "query_with_args_expr" {
     ]
   }
 }
+
+pipeline "query_with_statements" {
+  param "order_id" {
+    default = 42
+  }
+
+  step "query" "query_1" {
+    database = "this is a connection string"
+
+    statements = [
+      "update stock set quantity = quantity - 1 where product_id = 7",
+      {
+        sql  = "insert into shipments (order_id) values ($1)"
+        args = [param.order_id]
+      }
+    ]
+  }
+}
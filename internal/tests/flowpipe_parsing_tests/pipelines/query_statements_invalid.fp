@@ -0,0 +1,11 @@
+pipeline "query_statements_invalid" {
+
+  step "query" "query_1" {
+    database = "this is a connection string"
+    sql      = "select * from foo"
+
+    statements = [
+      "update stock set quantity = quantity - 1"
+    ]
+  }
+}
@@ -60,3 +60,22 @@ trigger "query" "query_trigger_no_schedule" {
         where create_date < now() - interval '90 days'
     EOQ
 }
+
+// only the rows past the last seen id are captured
+trigger "query" "query_trigger_cursor" {
+  database      = "postgres://steampipe:@host.docker.internal:9193/steampipe"
+  cursor_column = "id"
+
+  sql = <<EOQ
+        select id, item from orders
+        where $1::bigint is null or id > $1
+        order by id
+    EOQ
+
+  capture "insert" {
+    pipeline = pipeline.simple_with_trigger
+    args = {
+      rows = self.inserted_rows
+    }
+  }
+}
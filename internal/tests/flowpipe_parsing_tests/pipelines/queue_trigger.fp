@@ -0,0 +1,27 @@
+pipeline "process_order" {
+  param "order" {
+    type = string
+  }
+
+  step "transform" "echo" {
+    value = param.order
+  }
+}
+
+trigger "queue" "orders" {
+  url      = "nats://nats.example.com:4222"
+  subject  = "orders.created"
+  stream   = "ORDERS"
+  consumer = "flowpipe"
+  pipeline = pipeline.process_order
+
+  args = {
+    order = self.body
+  }
+}
+
+// core NATS subscription on the default url, the pipeline is run with the body and headers args
+trigger "queue" "orders_defaults" {
+  subject  = "orders.>"
+  pipeline = pipeline.process_order
+}
@@ -0,0 +1,30 @@
+pipeline "daily_report" {
+  param "since" {
+    type = string
+  }
+
+  step "transform" "echo" {
+    value = param.since
+  }
+}
+
+trigger "schedule" "daily_report" {
+  schedule = "0 9 * * *"
+  timezone = "America/New_York"
+  catch_up = "latest"
+  pipeline = pipeline.daily_report
+
+  args = {
+    since = self.scheduled_time
+  }
+}
+
+// UTC, the missed runs are not run
+trigger "schedule" "daily_report_defaults" {
+  schedule = "daily"
+  pipeline = pipeline.daily_report
+
+  args = {
+    since = "yesterday"
+  }
+}
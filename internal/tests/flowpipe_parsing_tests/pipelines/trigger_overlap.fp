@@ -0,0 +1,34 @@
+pipeline "slow_report" {
+  step "sleep" "wait" {
+    duration = "90s"
+  }
+}
+
+trigger "schedule" "every_minute" {
+  schedule = "* * * * *"
+  overlap  = "skip"
+  pipeline = pipeline.slow_report
+}
+
+trigger "schedule" "every_minute_queued" {
+  schedule = "* * * * *"
+  overlap  = "queue"
+  pipeline = pipeline.slow_report
+}
+
+trigger "query" "new_orders" {
+  database = "sqlite:./orders.db"
+  sql      = "select * from orders"
+  schedule = "* * * * *"
+  overlap  = "cancel_previous"
+
+  capture "insert" {
+    pipeline = pipeline.slow_report
+  }
+}
+
+// overlapping executions are allowed
+trigger "schedule" "every_minute_defaults" {
+  schedule = "* * * * *"
+  pipeline = pipeline.slow_report
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/constants"
 	"github.com/turbot/flowpipe/internal/parse"
 	"github.com/turbot/pipe-fittings/schema"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestQueryStep(t *testing.T) {
@@ -76,3 +79,51 @@ func TestQueryStepWithArgs(t *testing.T) {
 	assert.Equal("two", args[0])
 	assert.Equal(10, args[1])
 }
+
+func TestQueryStepWithStatements(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelines, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/query.fp")
+	assert.Nil(err, "error found")
+
+	if pipelines["local.pipeline.query_with_statements"] == nil {
+		assert.Fail("query pipeline not found")
+		return
+	}
+
+	step := pipelines["local.pipeline.query_with_statements"].GetStep("query.query_1")
+	if step == nil {
+		assert.Fail("query step not found")
+		return
+	}
+
+	paramsEvalContext := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"param": cty.ObjectVal(map[string]cty.Value{
+				"order_id": cty.NumberIntVal(7),
+			}),
+		},
+	}
+
+	inputs, err := step.GetInputs(paramsEvalContext)
+	if err != nil {
+		assert.Fail("error getting inputs")
+		return
+	}
+	assert.Nil(inputs[schema.AttributeTypeSql])
+	assert.Equal([]interface{}{
+		"update stock set quantity = quantity - 1 where product_id = 7",
+		map[string]interface{}{
+			"sql":  "insert into shipments (order_id) values ($1)",
+			"args": []interface{}{7},
+		},
+	}, inputs[constants.AttributeTypeStatements])
+}
+
+func TestQueryStepWithStatementsInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := parse.LoadPipelines(context.TODO(), "./pipelines/query_statements_invalid.fp")
+	assert.NotNil(err, "error not found")
+	assert.Contains(err.Error(), "The attributes 'sql' and 'args' can't be set with 'statements'")
+}
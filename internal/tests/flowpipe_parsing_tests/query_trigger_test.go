@@ -32,4 +32,21 @@ func TestQueryTriggerParse(t *testing.T) {
 	}
 
 	assert.Equal("", st.Schedule)
+	assert.Equal("", st.CursorColumn)
+
+	queryTrigger = triggers["local.trigger.query.query_trigger_cursor"]
+	if queryTrigger == nil {
+		assert.Fail("query_trigger_cursor trigger not found")
+		return
+	}
+
+	st, ok = queryTrigger.Config.(*resources.TriggerQuery)
+	if !ok {
+		assert.Fail("query_trigger_cursor trigger is not a query trigger")
+		return
+	}
+
+	assert.Equal("id", st.CursorColumn)
+	assert.Equal(1, len(st.Captures))
+	assert.NotNil(st.Captures["insert"])
 }
@@ -0,0 +1,55 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/resources"
+)
+
+func TestQueueTriggerParse(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	_, triggers, err := parse.LoadPipelines(ctx, "./pipelines/queue_trigger.fp")
+	assert.Nil(err, "error found")
+
+	queueTrigger := triggers["local.trigger.queue.orders"]
+	if queueTrigger == nil {
+		assert.Fail("orders trigger not found")
+		return
+	}
+
+	qt, ok := queueTrigger.Config.(*resources.TriggerQueue)
+	if !ok {
+		assert.Fail("orders trigger is not a queue trigger")
+		return
+	}
+
+	assert.Equal("nats://nats.example.com:4222", qt.Url)
+	assert.Equal("orders.created", qt.Subject)
+	assert.Equal("ORDERS", qt.Stream)
+	assert.Equal("flowpipe", qt.Consumer)
+	assert.True(qt.IsJetStream())
+	assert.Equal("local.pipeline.process_order", queueTrigger.Pipeline.AsValueMap()["name"].AsString())
+	assert.NotNil(queueTrigger.ArgsRaw)
+
+	queueTrigger = triggers["local.trigger.queue.orders_defaults"]
+	if queueTrigger == nil {
+		assert.Fail("orders_defaults trigger not found")
+		return
+	}
+
+	qt, ok = queueTrigger.Config.(*resources.TriggerQueue)
+	if !ok {
+		assert.Fail("orders_defaults trigger is not a queue trigger")
+		return
+	}
+
+	assert.Equal("nats://127.0.0.1:4222", qt.Url)
+	assert.Equal("orders.>", qt.Subject)
+	assert.False(qt.IsJetStream())
+	assert.Nil(queueTrigger.ArgsRaw)
+}
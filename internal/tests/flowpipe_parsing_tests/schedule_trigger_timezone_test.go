@@ -0,0 +1,57 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+	"github.com/turbot/flowpipe/internal/resources"
+)
+
+func TestScheduleTriggerTimezone(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	_, triggers, err := parse.LoadPipelines(ctx, "./pipelines/schedule_trigger_timezone.fp")
+	assert.Nil(err, "error found")
+
+	scheduleTrigger := triggers["local.trigger.schedule.daily_report"]
+	if scheduleTrigger == nil {
+		assert.Fail("daily_report trigger not found")
+		return
+	}
+
+	st, ok := scheduleTrigger.Config.(*resources.TriggerSchedule)
+	if !ok {
+		assert.Fail("daily_report trigger is not a schedule trigger")
+		return
+	}
+
+	assert.Equal("America/New_York", st.Timezone)
+	assert.Equal("latest", st.CatchUp)
+
+	cronExpression, err := st.GetCronExpression(scheduleTrigger.FullName)
+	assert.Nil(err)
+	assert.Equal("CRON_TZ=America/New_York 0 9 * * *", cronExpression)
+
+	scheduleTrigger = triggers["local.trigger.schedule.daily_report_defaults"]
+	if scheduleTrigger == nil {
+		assert.Fail("daily_report_defaults trigger not found")
+		return
+	}
+
+	st, ok = scheduleTrigger.Config.(*resources.TriggerSchedule)
+	if !ok {
+		assert.Fail("daily_report_defaults trigger is not a schedule trigger")
+		return
+	}
+
+	assert.Equal("", st.Timezone)
+	assert.Equal("none", st.CatchUp)
+
+	// the interval is distributed by the trigger name
+	cronExpression, err = st.GetCronExpression(scheduleTrigger.FullName)
+	assert.Nil(err)
+	assert.Regexp(`^\d+ \d+ \* \* \*$`, cronExpression)
+}
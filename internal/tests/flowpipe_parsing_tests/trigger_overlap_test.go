@@ -0,0 +1,40 @@
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/parse"
+)
+
+func TestTriggerOverlap(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	_, triggers, err := parse.LoadPipelines(ctx, "./pipelines/trigger_overlap.fp")
+	assert.Nil(err, "error found")
+
+	expected := map[string]string{
+		"local.trigger.schedule.every_minute":          "skip",
+		"local.trigger.schedule.every_minute_queued":   "queue",
+		"local.trigger.query.new_orders":               "cancel_previous",
+		"local.trigger.schedule.every_minute_defaults": "allow",
+	}
+
+	for name, overlap := range expected {
+		trigger := triggers[name]
+		if trigger == nil {
+			assert.Fail(name + " trigger not found")
+			continue
+		}
+
+		assert.Equal(overlap, trigger.Overlap, name)
+	}
+
+	other := *triggers["local.trigger.schedule.every_minute"]
+	assert.True(other.Equals(triggers["local.trigger.schedule.every_minute"]))
+
+	other.Overlap = "allow"
+	assert.False(other.Equals(triggers["local.trigger.schedule.every_minute"]))
+}
@@ -0,0 +1,395 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/turbot/flowpipe"
+
+// The executions are event driven, a span starts and ends in different handlers. The running spans are kept here,
+// keyed by the execution ID (trigger spans), the pipeline execution ID or the step execution ID. The spans left
+// running when their execution ends are ended with it, the spans older than maxSpanAge (executions that never end)
+// are ended by the next span started.
+var (
+	mu       sync.Mutex
+	enabled  bool
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer = noop.NewTracerProvider().Tracer(tracerName)
+	spans                 = map[string]*runningSpan{}
+	// the paused pipelines, their span is ended on pause and a new span is started on resume
+	paused = map[string]*pausedPipeline{}
+
+	maxSpanAge = 24 * time.Hour
+	lastSweep  time.Time
+
+	propagator = propagation.TraceContext{}
+)
+
+var (
+	errSpanExpired  = errors.New("span expired before the end of the execution")
+	errPipelineDone = errors.New("pipeline ended before the step")
+)
+
+type runningSpan struct {
+	span        trace.Span
+	executionID string
+	// the pipeline execution of a step span
+	pipelineExecutionID string
+	startedAt           time.Time
+}
+
+type pausedPipeline struct {
+	executionID string
+	name        string
+	parent      trace.SpanContext
+	span        trace.SpanContext
+	pausedAt    time.Time
+}
+
+type TracingOption func(*tracingConfig)
+
+type tracingConfig struct {
+	exporter sdktrace.SpanExporter
+}
+
+// WithExporter exports the spans with the given exporter instead of OTLP, the spans are exported as soon as they end.
+// This is used in the tests with the tracetest in-memory exporter.
+func WithExporter(exporter sdktrace.SpanExporter) TracingOption {
+	return func(c *tracingConfig) {
+		c.exporter = exporter
+	}
+}
+
+// Start enables the tracing and exports the spans over OTLP HTTP to the endpoint, e.g. http://localhost:4318.
+//
+// Tracing is disabled if there is no endpoint (and no exporter), the returned function flushes and stops the exporter.
+func Start(ctx context.Context, endpoint string, opts ...TracingOption) (func(context.Context) error, error) {
+	cfg := &tracingConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var spanProcessor sdktrace.SpanProcessor
+	switch {
+	case cfg.exporter != nil:
+		spanProcessor = sdktrace.NewSimpleSpanProcessor(cfg.exporter)
+	case endpoint != "":
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+		if err != nil {
+			return nil, err
+		}
+		spanProcessor = sdktrace.NewBatchSpanProcessor(exporter)
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(spanProcessor),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "flowpipe"))),
+	)
+
+	mu.Lock()
+	provider = tp
+	tracer = tp.Tracer(tracerName)
+	enabled = true
+	mu.Unlock()
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+
+	return stop, nil
+}
+
+func stop(ctx context.Context) error {
+	mu.Lock()
+	tp := provider
+	provider = nil
+	tracer = noop.NewTracerProvider().Tracer(tracerName)
+	enabled = false
+	spans = map[string]*runningSpan{}
+	paused = map[string]*pausedPipeline{}
+	mu.Unlock()
+
+	if tp == nil {
+		return nil
+	}
+	return tp.Shutdown(ctx)
+}
+
+// Extract returns the context with the remote span of the W3C traceparent header, if any
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject sets the W3C traceparent header from the span in the context, if any
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// StartTrigger starts the root span of a trigger execution. The span is a child of the remote span in the context,
+// if any (e.g. the traceparent header of the webhook request).
+func StartTrigger(ctx context.Context, executionID, triggerName, triggerType string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("flowpipe.execution_id", executionID),
+			attribute.String("flowpipe.trigger", triggerName),
+			attribute.String("flowpipe.trigger_type", triggerType),
+		),
+	}
+	if !trace.SpanContextFromContext(ctx).IsRemote() {
+		opts = append(opts, trace.WithNewRoot())
+	}
+
+	_, span := tracer.Start(ctx, triggerName, opts...)
+	addSpan(executionID, &runningSpan{span: span, executionID: executionID})
+}
+
+// EndTrigger ends the span of the trigger execution when the trigger fails to start the execution
+func EndTrigger(executionID string, err error) {
+	endSpan(executionID, err)
+}
+
+// EndExecution ends the span of the trigger execution, the span covers the whole execution and ends once the
+// execution has ended. The pipeline and step spans of the execution still running are ended too.
+func EndExecution(executionID string, err error) {
+	mu.Lock()
+	var ended []*runningSpan
+	for id, s := range spans {
+		if s.executionID == executionID && id != executionID {
+			ended = append(ended, s)
+			delete(spans, id)
+		}
+	}
+	for id, p := range paused {
+		if p.executionID == executionID {
+			delete(paused, id)
+		}
+	}
+	mu.Unlock()
+
+	for _, s := range ended {
+		end(s.span, err)
+	}
+	endSpan(executionID, err)
+}
+
+// StartPipeline starts the span of a pipeline execution. A child pipeline is a child span of the pipeline step
+// running it, a pipeline run by a trigger is a child span of the trigger execution.
+func StartPipeline(executionID, pipelineExecutionID, parentStepExecutionID, pipelineName string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	parent := spans[parentStepExecutionID]
+	if parentStepExecutionID == "" {
+		parent = spans[executionID]
+	}
+
+	ctx := context.Background()
+	if parent != nil {
+		ctx = trace.ContextWithSpan(ctx, parent.span)
+	}
+
+	_, span := tracer.Start(ctx, pipelineName, trace.WithAttributes(
+		attribute.String("flowpipe.execution_id", executionID),
+		attribute.String("flowpipe.pipeline_execution_id", pipelineExecutionID),
+		attribute.String("flowpipe.pipeline", pipelineName),
+	))
+	addSpan(pipelineExecutionID, &runningSpan{span: span, executionID: executionID})
+}
+
+// PausePipeline ends the span of the paused pipeline execution, the span of the running steps of the pipeline end
+// when the steps finish. The pipeline may stay paused for a long time, a new span is started when it's resumed.
+func PausePipeline(pipelineExecutionID, reason string) {
+	mu.Lock()
+	s := spans[pipelineExecutionID]
+	if s == nil {
+		mu.Unlock()
+		return
+	}
+	delete(spans, pipelineExecutionID)
+
+	ro, _ := s.span.(sdktrace.ReadOnlySpan)
+	p := &pausedPipeline{
+		executionID: s.executionID,
+		span:        s.span.SpanContext(),
+		pausedAt:    time.Now(),
+	}
+	if ro != nil {
+		p.name = ro.Name()
+		p.parent = ro.Parent()
+	}
+	paused[pipelineExecutionID] = p
+	mu.Unlock()
+
+	s.span.AddEvent("pipeline_paused", trace.WithAttributes(attribute.String("reason", reason)))
+	s.span.End()
+}
+
+// ResumePipeline starts a new span for the resumed pipeline execution, linked to the span ended on pause
+func ResumePipeline(pipelineExecutionID string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	p := paused[pipelineExecutionID]
+	delete(paused, pipelineExecutionID)
+	if !enabled || p == nil {
+		return
+	}
+
+	ctx := context.Background()
+	if p.parent.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, p.parent)
+	}
+
+	_, span := tracer.Start(ctx, p.name,
+		trace.WithLinks(trace.Link{SpanContext: p.span}),
+		trace.WithAttributes(
+			attribute.String("flowpipe.execution_id", p.executionID),
+			attribute.String("flowpipe.pipeline_execution_id", pipelineExecutionID),
+			attribute.String("flowpipe.pipeline", p.name),
+		))
+	span.AddEvent("pipeline_resumed")
+	addSpan(pipelineExecutionID, &runningSpan{span: span, executionID: p.executionID})
+}
+
+// PipelineEvent records an event (e.g. pipeline_paused) on the span of the pipeline execution
+func PipelineEvent(pipelineExecutionID, name string, attributes ...attribute.KeyValue) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if s := spans[pipelineExecutionID]; s != nil {
+		s.span.AddEvent(name, trace.WithAttributes(attributes...))
+	}
+}
+
+// EndPipeline ends the span of the pipeline execution and the spans of its steps still running (e.g. the steps of a
+// cancelled pipeline)
+func EndPipeline(pipelineExecutionID string, err error) {
+	mu.Lock()
+	delete(paused, pipelineExecutionID)
+	var steps []*runningSpan
+	for id, s := range spans {
+		if s.pipelineExecutionID == pipelineExecutionID {
+			steps = append(steps, s)
+			delete(spans, id)
+		}
+	}
+	mu.Unlock()
+
+	for _, s := range steps {
+		end(s.span, errPipelineDone)
+	}
+	endSpan(pipelineExecutionID, err)
+}
+
+// StartStep starts the span of a step execution, child of the pipeline execution span
+func StartStep(pipelineExecutionID, stepExecutionID, stepName, stepType string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	ctx := context.Background()
+	executionID := ""
+	if parent := spans[pipelineExecutionID]; parent != nil {
+		ctx = trace.ContextWithSpan(ctx, parent.span)
+		executionID = parent.executionID
+	}
+
+	_, span := tracer.Start(ctx, stepName, trace.WithAttributes(
+		attribute.String("flowpipe.pipeline_execution_id", pipelineExecutionID),
+		attribute.String("flowpipe.step_execution_id", stepExecutionID),
+		attribute.String("flowpipe.step_type", stepType),
+	))
+	addSpan(stepExecutionID, &runningSpan{span: span, executionID: executionID, pipelineExecutionID: pipelineExecutionID})
+}
+
+// EndStep ends the span of the step execution
+func EndStep(stepExecutionID string, err error) {
+	endSpan(stepExecutionID, err)
+}
+
+// ContextWithStep returns the context with the span of the step execution, used to propagate the trace to the
+// services called by the step
+func ContextWithStep(ctx context.Context, stepExecutionID string) context.Context {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if s := spans[stepExecutionID]; s != nil {
+		return trace.ContextWithSpan(ctx, s.span)
+	}
+	return ctx
+}
+
+// addSpan keeps the running span, must be called with the lock held. The spans older than maxSpanAge are ended, at
+// most once a minute.
+func addSpan(id string, s *runningSpan) {
+	now := time.Now()
+	s.startedAt = now
+	spans[id] = s
+
+	if now.Sub(lastSweep) < time.Minute {
+		return
+	}
+	lastSweep = now
+
+	for id, s := range spans {
+		if now.Sub(s.startedAt) > maxSpanAge {
+			delete(spans, id)
+			end(s.span, errSpanExpired)
+		}
+	}
+	for id, p := range paused {
+		if now.Sub(p.pausedAt) > maxSpanAge {
+			delete(paused, id)
+		}
+	}
+}
+
+func endSpan(id string, err error) {
+	mu.Lock()
+	s := spans[id]
+	delete(spans, id)
+	mu.Unlock()
+
+	if s == nil {
+		return
+	}
+	end(s.span, err)
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
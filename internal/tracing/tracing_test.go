@@ -0,0 +1,253 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func startTestTracing(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	stop, err := Start(context.Background(), "", WithExporter(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = stop(context.Background())
+	})
+	return exporter
+}
+
+func spanByName(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestTracingDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	stop, err := Start(context.Background(), "")
+	assert.Nil(err)
+	assert.Nil(stop(context.Background()))
+
+	StartPipeline("exec_1", "pexec_1", "", "mod.pipeline.parent")
+	StartStep("pexec_1", "sexec_1", "http.get", "http")
+
+	header := http.Header{}
+	Inject(ContextWithStep(context.Background(), "sexec_1"), header)
+	assert.Equal("", header.Get("traceparent"))
+}
+
+func TestTracingSpans(t *testing.T) {
+	assert := assert.New(t)
+
+	exporter := startTestTracing(t)
+
+	StartTrigger(context.Background(), "exec_1", "mod.trigger.schedule.every_hour", "schedule")
+	StartPipeline("exec_1", "pexec_1", "", "mod.pipeline.parent")
+	PipelineEvent("pexec_1", "pipeline_started")
+
+	// a pipeline step running a child pipeline
+	StartStep("pexec_1", "sexec_1", "pipeline.child", "pipeline")
+	StartPipeline("exec_1", "pexec_2", "sexec_1", "mod.pipeline.child")
+	StartStep("pexec_2", "sexec_2", "http.get", "http")
+
+	header := http.Header{}
+	Inject(ContextWithStep(context.Background(), "sexec_2"), header)
+	assert.NotEqual("", header.Get("traceparent"))
+
+	EndStep("sexec_2", errors.New("404 Not Found"))
+	EndPipeline("pexec_2", errors.New("404 Not Found"))
+	EndStep("sexec_1", nil)
+	EndPipeline("pexec_1", nil)
+	EndExecution("exec_1", nil)
+
+	spans := exporter.GetSpans()
+	assert.Equal(5, len(spans))
+
+	triggerSpan := spanByName(spans, "mod.trigger.schedule.every_hour")
+	parentSpan := spanByName(spans, "mod.pipeline.parent")
+	pipelineStepSpan := spanByName(spans, "pipeline.child")
+	childSpan := spanByName(spans, "mod.pipeline.child")
+	httpStepSpan := spanByName(spans, "http.get")
+	if triggerSpan == nil || parentSpan == nil || pipelineStepSpan == nil || childSpan == nil || httpStepSpan == nil {
+		assert.FailNow("missing spans")
+		return
+	}
+
+	// the trigger execution is the root span
+	assert.False(triggerSpan.Parent.IsValid())
+	assert.Equal(triggerSpan.SpanContext.SpanID(), parentSpan.Parent.SpanID())
+	assert.Equal(parentSpan.SpanContext.SpanID(), pipelineStepSpan.Parent.SpanID())
+	assert.Equal(pipelineStepSpan.SpanContext.SpanID(), childSpan.Parent.SpanID())
+	assert.Equal(childSpan.SpanContext.SpanID(), httpStepSpan.Parent.SpanID())
+	for _, s := range spans {
+		assert.Equal(triggerSpan.SpanContext.TraceID(), s.SpanContext.TraceID())
+	}
+
+	assert.Equal("pipeline_started", parentSpan.Events[0].Name)
+	assert.Equal(codes.Error, childSpan.Status.Code)
+	assert.Equal(codes.Unset, parentSpan.Status.Code)
+
+	// the step span is propagated in the traceparent header
+	assert.Contains(header.Get("traceparent"), httpStepSpan.SpanContext.SpanID().String())
+}
+
+func TestTracingRemoteParent(t *testing.T) {
+	assert := assert.New(t)
+
+	exporter := startTestTracing(t)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	StartTrigger(Extract(context.Background(), header), "exec_1", "mod.trigger.http.webhook", "http")
+	StartPipeline("exec_1", "pexec_1", "", "mod.pipeline.webhook")
+	EndPipeline("pexec_1", nil)
+	EndExecution("exec_1", nil)
+
+	spans := exporter.GetSpans()
+	assert.Equal(2, len(spans))
+
+	triggerSpan := spanByName(spans, "mod.trigger.http.webhook")
+	if triggerSpan == nil {
+		assert.FailNow("missing trigger span")
+		return
+	}
+
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", triggerSpan.SpanContext.TraceID().String())
+	assert.Equal("00f067aa0ba902b7", triggerSpan.Parent.SpanID().String())
+	assert.True(triggerSpan.Parent.IsRemote())
+	assert.Equal(trace.SpanKindServer, triggerSpan.SpanKind)
+}
+
+func TestTracingPausedPipeline(t *testing.T) {
+	assert := assert.New(t)
+
+	exporter := startTestTracing(t)
+
+	StartTrigger(context.Background(), "exec_1", "mod.trigger.schedule.every_hour", "schedule")
+	StartPipeline("exec_1", "pexec_1", "", "mod.pipeline.parent")
+
+	// the paused pipeline span ends, a new span is started on resume
+	PausePipeline("pexec_1", "input")
+	assert.Equal(1, len(exporter.GetSpans()))
+
+	ResumePipeline("pexec_1")
+	StartStep("pexec_1", "sexec_1", "http.get", "http")
+	EndStep("sexec_1", nil)
+	EndPipeline("pexec_1", nil)
+	EndExecution("exec_1", nil)
+
+	spans := exporter.GetSpans()
+	assert.Equal(4, len(spans))
+
+	triggerSpan := spanByName(spans, "mod.trigger.schedule.every_hour")
+	stepSpan := spanByName(spans, "http.get")
+	pausedSpan := &spans[0]
+	var resumedSpan *tracetest.SpanStub
+	for i := range spans {
+		if spans[i].Name == "mod.pipeline.parent" && i != 0 {
+			resumedSpan = &spans[i]
+		}
+	}
+	if triggerSpan == nil || stepSpan == nil || resumedSpan == nil {
+		assert.FailNow("missing spans")
+		return
+	}
+
+	assert.Equal("mod.pipeline.parent", pausedSpan.Name)
+	assert.Equal("pipeline_paused", pausedSpan.Events[0].Name)
+	assert.Equal(triggerSpan.SpanContext.SpanID(), pausedSpan.Parent.SpanID())
+	assert.Equal(triggerSpan.SpanContext.SpanID(), resumedSpan.Parent.SpanID())
+	assert.Equal(pausedSpan.SpanContext.SpanID(), resumedSpan.Links[0].SpanContext.SpanID())
+	assert.Equal(resumedSpan.SpanContext.SpanID(), stepSpan.Parent.SpanID())
+
+	assert.Equal(0, len(spansOf("exec_1")))
+}
+
+func TestTracingEndedExecution(t *testing.T) {
+	assert := assert.New(t)
+
+	exporter := startTestTracing(t)
+
+	// the steps of a cancelled pipeline never finish
+	StartPipeline("exec_1", "pexec_1", "", "mod.pipeline.cancelled")
+	StartStep("pexec_1", "sexec_1", "sleep.wait", "sleep")
+	EndPipeline("pexec_1", nil)
+	assert.Equal(0, len(spansOf("exec_1")))
+
+	stepSpan := spanByName(exporter.GetSpans(), "sleep.wait")
+	if stepSpan == nil {
+		assert.FailNow("step span not ended")
+		return
+	}
+	assert.Equal(codes.Error, stepSpan.Status.Code)
+
+	// the execution failed before the pipelines ended
+	StartTrigger(context.Background(), "exec_2", "mod.trigger.schedule.every_hour", "schedule")
+	StartPipeline("exec_2", "pexec_2", "", "mod.pipeline.failed")
+	StartStep("pexec_2", "sexec_2", "http.get", "http")
+	StartPipeline("exec_2", "pexec_3", "", "mod.pipeline.paused")
+	PausePipeline("pexec_3", "input")
+	EndExecution("exec_2", errors.New("execution failed"))
+
+	assert.Equal(0, len(spansOf("exec_2")))
+	assert.Equal(6, len(exporter.GetSpans()))
+	mu.Lock()
+	assert.Equal(0, len(paused))
+	mu.Unlock()
+}
+
+func TestTracingExpiredSpans(t *testing.T) {
+	assert := assert.New(t)
+
+	exporter := startTestTracing(t)
+
+	previousMaxSpanAge := maxSpanAge
+	maxSpanAge = 10 * time.Millisecond
+	defer func() {
+		maxSpanAge = previousMaxSpanAge
+	}()
+
+	// the execution never ends
+	StartPipeline("exec_1", "pexec_1", "", "mod.pipeline.stuck")
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	lastSweep = time.Time{}
+	mu.Unlock()
+	StartPipeline("exec_2", "pexec_2", "", "mod.pipeline.next")
+
+	assert.Equal(0, len(spansOf("exec_1")))
+	stuckSpan := spanByName(exporter.GetSpans(), "mod.pipeline.stuck")
+	if stuckSpan == nil {
+		assert.FailNow("expired span not ended")
+		return
+	}
+	assert.Equal(codes.Error, stuckSpan.Status.Code)
+}
+
+func spansOf(executionID string) []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var ids []string
+	for id, s := range spans {
+		if s.executionID == executionID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,494 @@
+package trigger
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/db"
+	"github.com/turbot/flowpipe/internal/es/event"
+	o "github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The timeout of the connection to the IMAP server and of each of its commands
+var emailTriggerTimeout = 30 * time.Second
+
+// The keyword flagging the messages that can't be parsed or whose args can't be evaluated, they are left unseen but
+// are not fetched again
+const emailTriggerFailedFlag = "$FlowpipeFailed"
+
+type TriggerRunnerEmail struct {
+	TriggerRunnerBase
+
+	// the messages of the queued pipelines by pipeline execution ID, flagged as seen once their pipeline is sent
+	messages map[string]uint32
+}
+
+func NewTriggerRunnerEmail(trigger *resources.Trigger, executionID, triggerExecutionID string) *TriggerRunnerEmail {
+	return &TriggerRunnerEmail{
+		TriggerRunnerBase: TriggerRunnerBase{
+			Trigger:            trigger,
+			rootMod:            trigger.GetMod(),
+			ExecutionID:        executionID,
+			TriggerExecutionID: triggerExecutionID,
+			Type:               localconstants.TriggerTypeEmail,
+		},
+		messages: map[string]uint32{},
+	}
+}
+
+// emailMessage is an unseen message of the mailbox, available to the trigger args as self
+type emailMessage struct {
+	Uid         uint32
+	MessageID   string
+	Date        time.Time
+	From        string
+	To          []string
+	Cc          []string
+	Subject     string
+	Text        string
+	Html        string
+	Attachments []emailAttachment
+}
+
+type emailAttachment struct {
+	Filename    string
+	ContentType string
+	Size        int64
+}
+
+func (tr *TriggerRunnerEmail) GetPipelineQueuesWithArgs(ctx context.Context, args map[string]interface{}, argsString map[string]string) ([]*event.PipelineQueue, error) {
+	triggerRunArgs, err := tr.validate(args, argsString)
+
+	if err != nil {
+		slog.Error("Error validating trigger", "error", err)
+		return nil, err
+	}
+
+	cmds, err := tr.execute(ctx, tr.ExecutionID, triggerRunArgs)
+	if err != nil {
+		slog.Error("Error polling the mailbox", "trigger", tr.Trigger.Name(), "error", err)
+		if o.IsServerMode {
+			o.RenderServerOutput(ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "error running email trigger "+tr.Trigger.Name(), err))
+		}
+		return nil, err
+	}
+
+	return cmds, nil
+}
+
+// execute polls the mailbox and queues the pipeline for each unseen message in the same execution. The messages are
+// flagged as seen, or moved, once their pipeline is sent (see MessagesSent). A message that can't be parsed or whose
+// args can't be evaluated is left unseen and flagged with emailTriggerFailedFlag so that the next polls skip it.
+func (tr *TriggerRunnerEmail) execute(ctx context.Context, executionID string, triggerRunArgs map[string]interface{}) ([]*event.PipelineQueue, error) {
+
+	slog.Info("Running trigger", "trigger", tr.Trigger.Name())
+
+	config := tr.Trigger.Config.(*resources.TriggerEmail)
+
+	c, err := dialEmailServer(config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := c.Logout(); err != nil {
+			slog.Debug("Error logging out of the IMAP server", "trigger", tr.Trigger.Name(), "error", err)
+		}
+	}()
+
+	// a dry run leaves the messages unseen
+	if _, err := c.Select(config.Mailbox, tr.DryRun); err != nil {
+		return nil, perr.InternalWithMessage("error selecting mailbox " + config.Mailbox + ": " + err.Error())
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag, emailTriggerFailedFlag}
+
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return nil, perr.InternalWithMessage("error searching the unseen messages: " + err.Error())
+	}
+
+	if len(uids) == 0 {
+		slog.Info("No unseen messages in the mailbox", "trigger", tr.Trigger.Name(), "mailbox", config.Mailbox)
+		return nil, nil
+	}
+
+	// the oldest messages first, the remaining messages are fetched by the next poll
+	slices.Sort(uids)
+	if len(uids) > localconstants.MaxEmailTriggerMessages {
+		uids = uids[:localconstants.MaxEmailTriggerMessages]
+	}
+
+	messages, failed, err := fetchEmailMessages(c, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineDefn := tr.Trigger.Pipeline.AsValueMap()
+	pipelineName := pipelineDefn["name"].AsString()
+
+	var cmds []*event.PipelineQueue
+
+	for _, msg := range messages {
+		triggerArgs, err := tr.getEmailTriggerArgs(triggerRunArgs, msg)
+		if err != nil {
+			slog.Error("Error getting trigger args, the message is left unseen", "trigger", tr.Trigger.Name(), "message_id", msg.MessageID, "error", err)
+			if o.IsServerMode {
+				o.RenderServerOutput(ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "error getting args of email trigger "+tr.Trigger.Name(), err))
+			}
+			failed = append(failed, msg.Uid)
+			continue
+		}
+
+		pipelineCmd := &event.PipelineQueue{
+			Event:               event.NewEventForExecutionID(executionID),
+			PipelineExecutionID: util.NewPipelineExecutionId(),
+			Name:                pipelineName,
+			Args:                triggerArgs,
+			Trigger:             tr.Trigger.Name(),
+		}
+
+		slog.Info("Trigger fired", "trigger", tr.Trigger.Name(), "pipeline", pipelineName, "pipeline_execution_id", pipelineCmd.PipelineExecutionID, "message_id", msg.MessageID, "dry_run", tr.DryRun)
+		if o.IsServerMode && !tr.DryRun {
+			o.RenderServerOutput(ctx, types.NewServerOutputTriggerExecution(time.Now(), pipelineCmd.Event.ExecutionID, tr.Trigger.Name(), pipelineName))
+		}
+
+		cmds = append(cmds, pipelineCmd)
+		tr.messages[pipelineCmd.PipelineExecutionID] = msg.Uid
+	}
+
+	if len(failed) > 0 && !tr.DryRun {
+		tr.flagFailedMessages(c, failed)
+	}
+
+	return cmds, nil
+}
+
+// flagFailedMessages flags the messages that can't be processed so that they are not fetched again. The messages are
+// flagged as seen if the server doesn't accept the keyword, otherwise they would be fetched by every poll and hold
+// back the new messages once they fill the batch.
+func (tr *TriggerRunnerEmail) flagFailedMessages(c *client.Client, uids []uint32) {
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uids...)
+
+	err := c.UidStore(seqSet, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{emailTriggerFailedFlag}, nil)
+	if err == nil {
+		return
+	}
+
+	slog.Warn("Error flagging the failed messages, the messages are flagged as seen", "trigger", tr.Trigger.Name(), "flag", emailTriggerFailedFlag, "error", err)
+	err = c.UidStore(seqSet, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.SeenFlag}, nil)
+	if err != nil {
+		slog.Error("Error flagging the failed messages as seen", "trigger", tr.Trigger.Name(), "error", err)
+	}
+}
+
+// MessagesSent flags the messages of the sent pipelines as seen, and moves them if the trigger has move_to. The
+// messages of the pipelines that failed to be sent are left unseen, they are fetched again by the next poll.
+func (tr *TriggerRunnerEmail) MessagesSent(ctx context.Context, cmds []*event.PipelineQueue) error {
+	processed := new(imap.SeqSet)
+	for _, cmd := range cmds {
+		if uid, ok := tr.messages[cmd.PipelineExecutionID]; ok {
+			processed.AddNum(uid)
+			delete(tr.messages, cmd.PipelineExecutionID)
+		}
+	}
+
+	if processed.Empty() || tr.DryRun {
+		return nil
+	}
+
+	config := tr.Trigger.Config.(*resources.TriggerEmail)
+
+	c, err := dialEmailServer(config)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := c.Logout(); err != nil {
+			slog.Debug("Error logging out of the IMAP server", "trigger", tr.Trigger.Name(), "error", err)
+		}
+	}()
+
+	if _, err := c.Select(config.Mailbox, false); err != nil {
+		return perr.InternalWithMessage("error selecting mailbox " + config.Mailbox + ": " + err.Error())
+	}
+
+	// flag the messages as seen before moving them, the messages that failed to move are not processed again
+	if err := c.UidStore(processed, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.SeenFlag}, nil); err != nil {
+		return perr.InternalWithMessage("error flagging the processed messages as seen: " + err.Error())
+	}
+
+	if config.MoveTo != "" {
+		if err := c.UidMove(processed, config.MoveTo); err != nil {
+			slog.Error("Error moving the processed messages", "trigger", tr.Trigger.Name(), "mailbox", config.MoveTo, "error", err)
+			if o.IsServerMode {
+				o.RenderServerOutput(ctx, types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "error moving the messages of email trigger "+tr.Trigger.Name(), err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func dialEmailServer(config *resources.TriggerEmail) (*client.Client, error) {
+	address, useTLS, err := config.GetAddress()
+	if err != nil {
+		return nil, perr.BadRequestWithMessage("invalid url " + config.Url + ": " + err.Error())
+	}
+
+	dialer := &net.Dialer{Timeout: emailTriggerTimeout}
+
+	var c *client.Client
+	if useTLS {
+		host, _, _ := net.SplitHostPort(address)
+		c, err = client.DialWithDialerTLS(dialer, address, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+	} else {
+		c, err = client.DialWithDialer(dialer, address)
+	}
+	if err != nil {
+		return nil, perr.InternalWithMessage("error connecting to " + config.Url + ": " + err.Error())
+	}
+	c.Timeout = emailTriggerTimeout
+
+	if err := c.Login(config.Username, config.Password); err != nil {
+		_ = c.Logout()
+		return nil, perr.UnauthorizedWithMessage("error logging in to " + config.Url + ": " + err.Error())
+	}
+
+	return c, nil
+}
+
+// fetchEmailMessages fetches and parses the messages, the messages are fetched with BODY.PEEK so that they are not
+// flagged as seen until they are processed. It also returns the messages that can't be parsed.
+func fetchEmailMessages(c *client.Client, uids []uint32) ([]*emailMessage, []uint32, error) {
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uids...)
+
+	section := &imap.BodySectionName{Peek: true}
+	items := []imap.FetchItem{imap.FetchUid, section.FetchItem()}
+
+	fetched := make(chan *imap.Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.UidFetch(seqSet, items, fetched)
+	}()
+
+	var messages []*emailMessage
+	var failed []uint32
+	for msg := range fetched {
+		body := msg.GetBody(section)
+		if body == nil {
+			slog.Warn("Message without body", "uid", msg.Uid)
+			failed = append(failed, msg.Uid)
+			continue
+		}
+
+		parsed, err := parseEmailMessage(body)
+		if err != nil {
+			slog.Warn("Error parsing message", "uid", msg.Uid, "error", err)
+			failed = append(failed, msg.Uid)
+			continue
+		}
+		parsed.Uid = msg.Uid
+
+		messages = append(messages, parsed)
+	}
+
+	if err := <-done; err != nil {
+		return nil, nil, perr.InternalWithMessage("error fetching the unseen messages: " + err.Error())
+	}
+
+	return messages, failed, nil
+}
+
+// parseEmailMessage parses the headers, the text and HTML bodies and the attachments of the message. The first text
+// and HTML parts are the bodies of the message, the content of the attachments isn't kept.
+func parseEmailMessage(r io.Reader) (*emailMessage, error) {
+	mr, err := mail.CreateReader(r)
+	if err != nil && !message.IsUnknownCharset(err) {
+		return nil, err
+	}
+	defer mr.Close()
+
+	msg := &emailMessage{
+		To:          []string{},
+		Cc:          []string{},
+		Attachments: []emailAttachment{},
+	}
+
+	msg.MessageID, _ = mr.Header.MessageID()
+	msg.Date, _ = mr.Header.Date()
+	msg.Subject, _ = mr.Header.Subject()
+
+	if from, err := mr.Header.AddressList("From"); err == nil && len(from) > 0 {
+		msg.From = from[0].Address
+	}
+	if to, err := mr.Header.AddressList("To"); err == nil {
+		for _, a := range to {
+			msg.To = append(msg.To, a.Address)
+		}
+	}
+	if cc, err := mr.Header.AddressList("Cc"); err == nil {
+		for _, a := range cc {
+			msg.Cc = append(msg.Cc, a.Address)
+		}
+	}
+
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !message.IsUnknownCharset(err) {
+			return nil, err
+		}
+
+		switch h := p.Header.(type) {
+		case *mail.InlineHeader:
+			contentType, _, _ := h.ContentType()
+			content, err := io.ReadAll(p.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case contentType == "text/plain" && msg.Text == "":
+				msg.Text = string(content)
+			case contentType == "text/html" && msg.Html == "":
+				msg.Html = string(content)
+			}
+		case *mail.AttachmentHeader:
+			filename, _ := h.Filename()
+			contentType, _, _ := h.ContentType()
+			size, err := io.Copy(io.Discard, p.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			msg.Attachments = append(msg.Attachments, emailAttachment{
+				Filename:    filename,
+				ContentType: contentType,
+				Size:        size,
+			})
+		}
+	}
+
+	return msg, nil
+}
+
+// getEmailTriggerArgs evaluates the trigger args with the message, the pipeline is run with the from, to, subject,
+// text, html and attachments args if the trigger doesn't specify its args
+func (tr *TriggerRunnerEmail) getEmailTriggerArgs(triggerRunArgs map[string]interface{}, msg *emailMessage) (resources.Input, error) {
+
+	latestTrigger, err := db.GetTrigger(tr.Trigger.Name())
+	if err != nil {
+		slog.Error("Error getting latest trigger", "trigger", tr.Trigger.Name(), "error", err)
+		return nil, perr.NotFoundWithMessage("trigger not found")
+	}
+
+	if latestTrigger.ArgsRaw == nil {
+		attachments := []map[string]interface{}{}
+		for _, a := range msg.Attachments {
+			attachments = append(attachments, map[string]interface{}{
+				"filename":     a.Filename,
+				"content_type": a.ContentType,
+				"size":         a.Size,
+			})
+		}
+
+		return resources.Input{
+			"from":        msg.From,
+			"to":          msg.To,
+			"subject":     msg.Subject,
+			"text":        msg.Text,
+			"html":        msg.Html,
+			"attachments": attachments,
+		}, nil
+	}
+
+	evalContext, err := buildEvalContextForTriggerExecution(tr.rootMod, tr.Trigger.Params, tr.Trigger.Config, triggerRunArgs)
+	if err != nil {
+		slog.Error("Error building eval context", "error", err)
+		return nil, perr.InternalWithMessage("Error building eval context")
+	}
+
+	evalContext.Variables["self"] = msg.toCty()
+
+	pipelineArgs, diags := latestTrigger.GetArgs(evalContext)
+	if diags.HasErrors() {
+		slog.Error("Error getting trigger args", "trigger", tr.Trigger.Name(), "errors", diags)
+		err := error_helpers.HclDiagsToError("trigger", diags)
+		return nil, err
+	}
+
+	return pipelineArgs, nil
+}
+
+var emailAttachmentType = cty.Object(map[string]cty.Type{
+	"filename":     cty.String,
+	"content_type": cty.String,
+	"size":         cty.Number,
+})
+
+func (msg *emailMessage) toCty() cty.Value {
+	attachments := cty.ListValEmpty(emailAttachmentType)
+	if len(msg.Attachments) > 0 {
+		values := []cty.Value{}
+		for _, a := range msg.Attachments {
+			values = append(values, cty.ObjectVal(map[string]cty.Value{
+				"filename":     cty.StringVal(a.Filename),
+				"content_type": cty.StringVal(a.ContentType),
+				"size":         cty.NumberIntVal(a.Size),
+			}))
+		}
+		attachments = cty.ListVal(values)
+	}
+
+	date := ""
+	if !msg.Date.IsZero() {
+		date = msg.Date.UTC().Format(time.RFC3339)
+	}
+
+	return cty.ObjectVal(map[string]cty.Value{
+		"message_id":  cty.StringVal(msg.MessageID),
+		"date":        cty.StringVal(date),
+		"from":        cty.StringVal(msg.From),
+		"to":          toCtyStringList(msg.To),
+		"cc":          toCtyStringList(msg.Cc),
+		"subject":     cty.StringVal(msg.Subject),
+		"text":        cty.StringVal(msg.Text),
+		"html":        cty.StringVal(msg.Html),
+		"attachments": attachments,
+	})
+}
+
+func toCtyStringList(values []string) cty.Value {
+	if len(values) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+
+	ctyValues := []cty.Value{}
+	for _, v := range values {
+		ctyValues = append(ctyValues, cty.StringVal(v))
+	}
+	return cty.ListVal(ctyValues)
+}
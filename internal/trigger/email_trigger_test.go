@@ -0,0 +1,373 @@
+package trigger
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The memory backend doesn't support the MOVE extension advertised by the server, the messages are moved with copy,
+// store and expunge
+type moveBackend struct {
+	*memory.Backend
+}
+
+func (be *moveBackend) Login(connInfo *imap.ConnInfo, username, password string) (backend.User, error) {
+	user, err := be.Backend.Login(connInfo, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return &moveUser{User: user}, nil
+}
+
+type moveUser struct {
+	backend.User
+}
+
+func (u *moveUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+	return &moveMailbox{Mailbox: mbox}, nil
+}
+
+type moveMailbox struct {
+	backend.Mailbox
+}
+
+func (m *moveMailbox) MoveMessages(uid bool, seqSet *imap.SeqSet, dest string) error {
+	if err := m.CopyMessages(uid, seqSet, dest); err != nil {
+		return err
+	}
+	if err := m.UpdateMessagesFlags(uid, seqSet, imap.AddFlags, []string{imap.DeletedFlag}); err != nil {
+		return err
+	}
+	return m.Expunge()
+}
+
+// startImapServer starts an IMAP server with the memory backend on a random port, the user is username/password and
+// the inbox has a seen message
+func startImapServer(t *testing.T) (*memory.Backend, string) {
+	be := memory.New()
+
+	s := server.New(&moveBackend{Backend: be})
+	s.AllowInsecureAuth = true
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		_ = s.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return be, "imap://" + listener.Addr().String()
+}
+
+func getMailbox(t *testing.T, be *memory.Backend, name string) *memory.Mailbox {
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mbox, err := user.GetMailbox(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mbox.(*memory.Mailbox)
+}
+
+func appendMessage(t *testing.T, be *memory.Backend, body string) {
+	body = strings.ReplaceAll(body, "\n", "\r\n")
+	if err := getMailbox(t, be, "INBOX").CreateMessage(nil, time.Now(), bytes.NewBufferString(body)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countUnseen(t *testing.T, be *memory.Backend, name string) int {
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+
+	uids, err := getMailbox(t, be, name).SearchMessages(true, criteria)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(uids)
+}
+
+const plainMessage = `From: Alice <alice@example.com>
+To: support@example.com
+Subject: Printer on fire
+Message-ID: <1@example.com>
+Date: Mon, 02 Sep 2024 10:00:00 +0000
+Content-Type: text/plain
+
+The printer on the 3rd floor is on fire.
+`
+
+const multipartMessage = `From: bob@example.com
+To: support@example.com, ops@example.com
+Cc: carol@example.com
+Subject: Logs attached
+Message-ID: <2@example.com>
+Date: Mon, 02 Sep 2024 11:00:00 +0000
+Content-Type: multipart/mixed; boundary=outer
+
+--outer
+Content-Type: multipart/alternative; boundary=inner
+
+--inner
+Content-Type: text/plain
+
+See the logs.
+--inner
+Content-Type: text/html
+
+<p>See the logs.</p>
+--inner--
+--outer
+Content-Type: text/plain
+Content-Disposition: attachment; filename="server.log"
+
+line 1
+line 2
+--outer--
+`
+
+func newEmailTrigger(t *testing.T, config *resources.TriggerEmail) *resources.Trigger {
+	pipelineCty := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("support_ticket"),
+	})
+
+	trigger := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "local.trigger.email." + strings.ReplaceAll(t.Name(), "/", "_"),
+		},
+		Pipeline: pipelineCty,
+		Config:   config,
+	}
+	cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+	return trigger
+}
+
+func TestTriggerEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	be, url := startImapServer(t)
+	appendMessage(t, be, plainMessage)
+	appendMessage(t, be, multipartMessage)
+
+	trigger := newEmailTrigger(t, &resources.TriggerEmail{
+		Url:      url,
+		Username: "username",
+		Password: "password",
+		Mailbox:  "INBOX",
+	})
+
+	executionID := util.NewExecutionId()
+	triggerRunner := NewTriggerRunnerEmail(trigger, executionID, "")
+
+	// without args the pipeline is run with each unseen message
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	if !assert.Equal(2, len(pipelineQueues)) {
+		return
+	}
+
+	assert.Equal("support_ticket", pipelineQueues[0].Name)
+	assert.Equal(executionID, pipelineQueues[0].Event.ExecutionID)
+	assert.Equal(executionID, pipelineQueues[1].Event.ExecutionID)
+	assert.Equal(resources.Input{
+		"from":        "alice@example.com",
+		"to":          []string{"support@example.com"},
+		"subject":     "Printer on fire",
+		"text":        "The printer on the 3rd floor is on fire.\r\n",
+		"html":        "",
+		"attachments": []map[string]interface{}{},
+	}, pipelineQueues[0].Args)
+
+	assert.Equal("bob@example.com", pipelineQueues[1].Args["from"])
+	assert.Equal([]string{"support@example.com", "ops@example.com"}, pipelineQueues[1].Args["to"])
+	assert.Equal("See the logs.", pipelineQueues[1].Args["text"])
+	assert.Equal("<p>See the logs.</p>", pipelineQueues[1].Args["html"])
+	assert.Equal([]map[string]interface{}{
+		{"filename": "server.log", "content_type": "text/plain", "size": int64(14)},
+	}, pipelineQueues[1].Args["attachments"])
+
+	// the messages are flagged as seen once their pipelines are sent, they are not processed again
+	assert.Equal(2, countUnseen(t, be, "INBOX"))
+	assert.Nil(triggerRunner.MessagesSent(context.Background(), pipelineQueues))
+	assert.Equal(0, countUnseen(t, be, "INBOX"))
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(0, len(pipelineQueues))
+}
+
+func TestTriggerEmailMoveTo(t *testing.T) {
+	assert := assert.New(t)
+
+	be, url := startImapServer(t)
+	appendMessage(t, be, multipartMessage)
+
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	assert.Nil(user.CreateMailbox("Processed"))
+
+	trigger := newEmailTrigger(t, &resources.TriggerEmail{
+		Url:      url,
+		Username: "username",
+		Password: "password",
+		Mailbox:  "INBOX",
+		MoveTo:   "Processed",
+	})
+
+	// the args refer to the message with self
+	argsExpr, diags := hclsyntax.ParseExpression([]byte(`{ sender = self.from, cc = self.cc, message_id = self.message_id, files = [for a in self.attachments : a.filename] }`), "test.fp", hcl.InitialPos)
+	assert.False(diags.HasErrors())
+	trigger.ArgsRaw = argsExpr
+
+	triggerRunner := NewTriggerRunnerEmail(trigger, util.NewExecutionId(), "")
+
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	if !assert.Equal(1, len(pipelineQueues)) {
+		return
+	}
+
+	assert.Equal("bob@example.com", pipelineQueues[0].Args["sender"])
+	assert.Equal([]interface{}{"carol@example.com"}, pipelineQueues[0].Args["cc"])
+	assert.Equal("2@example.com", pipelineQueues[0].Args["message_id"])
+	assert.Equal([]interface{}{"server.log"}, pipelineQueues[0].Args["files"])
+	assert.Nil(triggerRunner.MessagesSent(context.Background(), pipelineQueues))
+
+	// the message is moved as seen, the seen message of the inbox is left alone
+	assert.Equal(1, len(getMailbox(t, be, "INBOX").Messages))
+	assert.Equal(1, len(getMailbox(t, be, "Processed").Messages))
+	assert.Equal(0, countUnseen(t, be, "Processed"))
+}
+
+func TestTriggerEmailLoginError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, url := startImapServer(t)
+
+	trigger := newEmailTrigger(t, &resources.TriggerEmail{
+		Url:      url,
+		Username: "username",
+		Password: "wrong",
+		Mailbox:  "INBOX",
+	})
+
+	pipelineQueues, err := NewTriggerRunnerEmail(trigger, util.NewExecutionId(), "").GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "error logging in to "+url)
+	assert.Equal(0, len(pipelineQueues))
+}
+
+func TestTriggerEmailNotSent(t *testing.T) {
+	assert := assert.New(t)
+
+	be, url := startImapServer(t)
+	appendMessage(t, be, plainMessage)
+	appendMessage(t, be, multipartMessage)
+
+	trigger := newEmailTrigger(t, &resources.TriggerEmail{
+		Url:      url,
+		Username: "username",
+		Password: "password",
+		Mailbox:  "INBOX",
+	})
+
+	triggerRunner := NewTriggerRunnerEmail(trigger, util.NewExecutionId(), "")
+
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	if !assert.Equal(2, len(pipelineQueues)) {
+		return
+	}
+
+	// the pipeline of the second message failed to be sent, the message is processed again by the next poll
+	assert.Nil(triggerRunner.MessagesSent(context.Background(), pipelineQueues[:1]))
+	assert.Equal(1, countUnseen(t, be, "INBOX"))
+
+	triggerRunner = NewTriggerRunnerEmail(trigger, util.NewExecutionId(), "")
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	if !assert.Equal(1, len(pipelineQueues)) {
+		return
+	}
+	assert.Equal("Logs attached", pipelineQueues[0].Args["subject"])
+}
+
+func TestTriggerEmailFailedMessages(t *testing.T) {
+	assert := assert.New(t)
+
+	be, url := startImapServer(t)
+
+	// a full batch of messages that can't be processed ahead of a new message
+	for i := 0; i < localconstants.MaxEmailTriggerMessages; i++ {
+		appendMessage(t, be, plainMessage)
+	}
+	appendMessage(t, be, strings.Replace(plainMessage, "Subject: Printer on fire", "Subject: 3", 1))
+
+	trigger := newEmailTrigger(t, &resources.TriggerEmail{
+		Url:      url,
+		Username: "username",
+		Password: "password",
+		Mailbox:  "INBOX",
+	})
+
+	// the args of a message without a numeric subject can't be evaluated
+	argsExpr, diags := hclsyntax.ParseExpression([]byte(`{ priority = parseint(self.subject, 10) }`), "test.fp", hcl.InitialPos)
+	assert.False(diags.HasErrors())
+	trigger.ArgsRaw = argsExpr
+
+	// the failed messages are left unseen but flagged
+	pipelineQueues, err := NewTriggerRunnerEmail(trigger, util.NewExecutionId(), "").GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(0, len(pipelineQueues))
+	assert.Equal(localconstants.MaxEmailTriggerMessages+1, countUnseen(t, be, "INBOX"))
+
+	criteria := imap.NewSearchCriteria()
+	// the server keeps the keywords in their canonical form
+	criteria.WithFlags = []string{imap.CanonicalFlag(emailTriggerFailedFlag)}
+	failed, err := getMailbox(t, be, "INBOX").SearchMessages(true, criteria)
+	assert.Nil(err)
+	assert.Equal(localconstants.MaxEmailTriggerMessages, len(failed))
+
+	// the next poll skips the failed messages
+	pipelineQueues, err = NewTriggerRunnerEmail(trigger, util.NewExecutionId(), "").GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	if !assert.Equal(1, len(pipelineQueues)) {
+		return
+	}
+	assert.EqualValues(3, pipelineQueues[0].Args["priority"])
+}
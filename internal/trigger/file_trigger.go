@@ -0,0 +1,272 @@
+package trigger
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/db"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type TriggerRunnerFile struct {
+	TriggerRunnerBase
+
+	// The changed file and the file event (create, modify or delete), available to the trigger args as self.path
+	// and self.event
+	Path  string
+	Event string
+}
+
+func NewTriggerRunnerFile(trigger *resources.Trigger, executionID, triggerExecutionID, path, fileEvent string) *TriggerRunnerFile {
+	return &TriggerRunnerFile{
+		TriggerRunnerBase: TriggerRunnerBase{
+			Trigger:            trigger,
+			rootMod:            trigger.GetMod(),
+			ExecutionID:        executionID,
+			TriggerExecutionID: triggerExecutionID,
+			Type:               localconstants.TriggerTypeFile,
+		},
+		Path:  path,
+		Event: fileEvent,
+	}
+}
+
+func (tr *TriggerRunnerFile) GetPipelineQueuesWithArgs(ctx context.Context, args map[string]interface{}, argsString map[string]string) ([]*event.PipelineQueue, error) {
+	triggerRunArgs, err := tr.validate(args, argsString)
+
+	if err != nil {
+		slog.Error("Error validating trigger", "error", err)
+		return nil, err
+	}
+
+	triggerArgs, err := tr.getFileTriggerArgs(triggerRunArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := tr.execute(ctx, tr.ExecutionID, triggerArgs, tr.Trigger)
+	if err != nil {
+		slog.Error("Error sending pipeline command", "error", err)
+		return nil, err
+	}
+
+	return cmds, nil
+}
+
+// getFileTriggerArgs evaluates the trigger args with the changed file, the pipeline is run with the path and the
+// event args if the trigger doesn't specify its args
+func (tr *TriggerRunnerFile) getFileTriggerArgs(triggerRunArgs map[string]interface{}) (resources.Input, error) {
+
+	evalContext, err := buildEvalContextForTriggerExecution(tr.rootMod, tr.Trigger.Params, tr.Trigger.Config, triggerRunArgs)
+	if err != nil {
+		slog.Error("Error building eval context", "error", err)
+		return nil, perr.InternalWithMessage("Error building eval context")
+	}
+
+	evalContext.Variables["self"] = cty.ObjectVal(map[string]cty.Value{
+		"path":  cty.StringVal(tr.Path),
+		"event": cty.StringVal(tr.Event),
+	})
+
+	latestTrigger, err := db.GetTrigger(tr.Trigger.Name())
+	if err != nil {
+		slog.Error("Error getting latest trigger", "trigger", tr.Trigger.Name(), "error", err)
+		return nil, perr.NotFoundWithMessage("trigger not found")
+	}
+
+	if latestTrigger.ArgsRaw == nil {
+		return resources.Input{
+			"path":  tr.Path,
+			"event": tr.Event,
+		}, nil
+	}
+
+	pipelineArgs, diags := latestTrigger.GetArgs(evalContext)
+	if diags.HasErrors() {
+		slog.Error("Error getting trigger args", "trigger", tr.Trigger.Name(), "errors", diags)
+		err := error_helpers.HclDiagsToError("trigger", diags)
+		return nil, err
+	}
+
+	return pipelineArgs, nil
+}
+
+// FileWatcher watches the files matching the path glob of a file trigger. The changes to a file are collected until
+// the file is left alone for the debounce window, the handler is then called once with the file and the file event.
+type FileWatcher struct {
+	pattern  string
+	events   []string
+	debounce time.Duration
+	handler  func(path, fileEvent string)
+
+	watcher *fsnotify.Watcher
+	mu      sync.Mutex
+	pending map[string]*pendingFileEvent
+}
+
+type pendingFileEvent struct {
+	fileEvent string
+	timer     *time.Timer
+}
+
+// NewFileWatcher creates the watcher of the file trigger config, a relative path is relative to the mod location
+func NewFileWatcher(config *resources.TriggerFile, handler func(path, fileEvent string)) *FileWatcher {
+	pattern := config.Path
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(viper.GetString(constants.ArgModLocation), pattern)
+	}
+
+	return &FileWatcher{
+		pattern:  filepath.Clean(pattern),
+		events:   config.Events,
+		debounce: config.GetDebounce(),
+		handler:  handler,
+		pending:  map[string]*pendingFileEvent{},
+	}
+}
+
+// Start watches the directories matching the directory part of the path glob, the directories are resolved when the
+// watcher starts
+func (w *FileWatcher) Start() error {
+	dirs, err := filepath.Glob(filepath.Dir(w.pattern))
+	if err != nil {
+		return perr.BadRequestWithMessage("invalid path " + w.pattern + ": " + err.Error())
+	}
+	if len(dirs) == 0 {
+		return perr.NotFoundWithMessage("no directory found for path " + w.pattern)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return perr.InternalWithMessage("error creating file watcher: " + err.Error())
+	}
+
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return perr.InternalWithMessage("error watching directory " + dir + ": " + err.Error())
+		}
+	}
+
+	w.watcher = watcher
+	go w.run()
+
+	return nil
+}
+
+// Stop stops watching the files, the pending events are dropped
+func (w *FileWatcher) Stop() {
+	if w.watcher != nil {
+		w.watcher.Close()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for path, pending := range w.pending {
+		pending.timer.Stop()
+		delete(w.pending, path)
+	}
+}
+
+func (w *FileWatcher) run() {
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(ev)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("File watcher error", "path", w.pattern, "error", err)
+		}
+	}
+}
+
+func (w *FileWatcher) handleEvent(ev fsnotify.Event) {
+	fileEvent := fileEventFromOp(ev.Op)
+	if fileEvent == "" {
+		return
+	}
+
+	if matched, _ := filepath.Match(w.pattern, ev.Name); !matched {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pending := w.pending[ev.Name]
+	if pending != nil {
+		pending.fileEvent = mergeFileEvents(pending.fileEvent, fileEvent)
+		pending.timer.Reset(w.debounce)
+		return
+	}
+
+	path := ev.Name
+	w.pending[path] = &pendingFileEvent{
+		fileEvent: fileEvent,
+		timer: time.AfterFunc(w.debounce, func() {
+			w.fire(path)
+		}),
+	}
+}
+
+func (w *FileWatcher) fire(path string) {
+	w.mu.Lock()
+	pending := w.pending[path]
+	delete(w.pending, path)
+	w.mu.Unlock()
+
+	if pending == nil || !slices.Contains(w.events, pending.fileEvent) {
+		return
+	}
+
+	slog.Debug("File changed", "path", path, "event", pending.fileEvent)
+	w.handler(path, pending.fileEvent)
+}
+
+func fileEventFromOp(op fsnotify.Op) string {
+	switch {
+	case op.Has(fsnotify.Remove), op.Has(fsnotify.Rename):
+		return localconstants.FileEventDelete
+	case op.Has(fsnotify.Create):
+		return localconstants.FileEventCreate
+	case op.Has(fsnotify.Write):
+		return localconstants.FileEventModify
+	default:
+		// chmod
+		return ""
+	}
+}
+
+// mergeFileEvents returns the event of a file that changed more than once within the debounce window, e.g. a file
+// that is created and then written to is a new file.
+func mergeFileEvents(previous, next string) string {
+	switch {
+	case next == localconstants.FileEventDelete:
+		return next
+	case previous == localconstants.FileEventCreate:
+		return previous
+	case previous == localconstants.FileEventDelete:
+		// the file was replaced, e.g. an editor saving to a temporary file and renaming it
+		return localconstants.FileEventModify
+	default:
+		return next
+	}
+}
@@ -0,0 +1,184 @@
+package trigger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type fileChange struct {
+	path      string
+	fileEvent string
+}
+
+type fileChangeRecorder struct {
+	mu      sync.Mutex
+	changes []fileChange
+}
+
+func (r *fileChangeRecorder) handle(path, fileEvent string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, fileChange{path: path, fileEvent: fileEvent})
+}
+
+func (r *fileChangeRecorder) take() []fileChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changes := r.changes
+	r.changes = nil
+	return changes
+}
+
+func TestFileWatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	recorder := &fileChangeRecorder{}
+
+	watcher := NewFileWatcher(&resources.TriggerFile{
+		Path:     filepath.Join(dir, "*.csv"),
+		Events:   []string{"create", "modify", "delete"},
+		Debounce: "200ms",
+	}, recorder.handle)
+	if err := watcher.Start(); err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	defer watcher.Stop()
+
+	exportFile := filepath.Join(dir, "export.csv")
+
+	// a file written in several goes is a single create
+	f, err := os.Create(exportFile)
+	assert.Nil(err)
+	_, err = f.WriteString("id,name\n")
+	assert.Nil(err)
+	_, err = f.WriteString("1,foo\n")
+	assert.Nil(err)
+	assert.Nil(f.Close())
+
+	// not matching the glob
+	assert.Nil(os.WriteFile(filepath.Join(dir, "export.txt"), []byte("foo"), 0600))
+
+	time.Sleep(time.Second)
+	assert.Equal([]fileChange{{path: exportFile, fileEvent: "create"}}, recorder.take())
+
+	assert.Nil(os.WriteFile(exportFile, []byte("id,name\n2,bar\n"), 0600))
+	time.Sleep(time.Second)
+	assert.Equal([]fileChange{{path: exportFile, fileEvent: "modify"}}, recorder.take())
+
+	assert.Nil(os.Remove(exportFile))
+	time.Sleep(time.Second)
+	assert.Equal([]fileChange{{path: exportFile, fileEvent: "delete"}}, recorder.take())
+}
+
+func TestFileWatcherEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	recorder := &fileChangeRecorder{}
+
+	watcher := NewFileWatcher(&resources.TriggerFile{
+		Path:     filepath.Join(dir, "*.csv"),
+		Events:   []string{"delete"},
+		Debounce: "100ms",
+	}, recorder.handle)
+	if err := watcher.Start(); err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	defer watcher.Stop()
+
+	exportFile := filepath.Join(dir, "export.csv")
+	assert.Nil(os.WriteFile(exportFile, []byte("id,name\n"), 0600))
+	time.Sleep(500 * time.Millisecond)
+	assert.Nil(recorder.take())
+
+	assert.Nil(os.Remove(exportFile))
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal([]fileChange{{path: exportFile, fileEvent: "delete"}}, recorder.take())
+}
+
+func TestFileWatcherMissingDirectory(t *testing.T) {
+	assert := assert.New(t)
+
+	watcher := NewFileWatcher(&resources.TriggerFile{
+		Path:     filepath.Join(t.TempDir(), "does_not_exist", "*.csv"),
+		Debounce: "1s",
+	}, func(string, string) {})
+
+	assert.NotNil(watcher.Start())
+}
+
+func TestMergeFileEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("create", mergeFileEvents("create", "modify"))
+	assert.Equal("delete", mergeFileEvents("create", "delete"))
+	assert.Equal("modify", mergeFileEvents("delete", "create"))
+	assert.Equal("modify", mergeFileEvents("modify", "modify"))
+	assert.Equal("delete", mergeFileEvents("modify", "delete"))
+}
+
+func TestTriggerFileArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelineCty := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("process_export"),
+	})
+
+	trigger := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "local.trigger.file.test_file_trigger",
+		},
+		Pipeline: pipelineCty,
+		Config: &resources.TriggerFile{
+			Path:     "./exports/*.csv",
+			Events:   []string{"create"},
+			Debounce: "1s",
+		},
+	}
+	cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+	executionID := util.NewExecutionId()
+	triggerRunner := NewTriggerRunner(trigger, executionID, "")
+	fileRunner, ok := triggerRunner.(*TriggerRunnerFile)
+	if !ok {
+		assert.FailNow("file trigger runner expected")
+		return
+	}
+	fileRunner.Path = "/data/exports/export.csv"
+	fileRunner.Event = "create"
+
+	// without args the pipeline is run with the changed file
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal("process_export", pipelineQueues[0].Name)
+	assert.Equal("local.trigger.file.test_file_trigger", pipelineQueues[0].Trigger)
+	assert.Equal(executionID, pipelineQueues[0].Event.ExecutionID)
+	assert.Equal(resources.Input{"path": "/data/exports/export.csv", "event": "create"}, pipelineQueues[0].Args)
+
+	// the args refer to the changed file with self
+	argsExpr, diags := hclsyntax.ParseExpression([]byte(`{ file = self.path, change = self.event }`), "test.fp", hcl.InitialPos)
+	assert.False(diags.HasErrors())
+	trigger.ArgsRaw = argsExpr
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal(resources.Input{"file": "/data/exports/export.csv", "change": "create"}, pipelineQueues[0].Args)
+}
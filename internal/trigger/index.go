@@ -34,25 +34,25 @@ type TriggerRunnerBase struct {
 	Trigger            *resources.Trigger
 	rootMod            *modconfig.Mod
 	Type               string
+
+	// A dry run returns the pipelines that would be queued without changing any state, e.g. the rows captured by a
+	// query trigger or the messages flagged as seen by an email trigger
+	DryRun bool
 }
 
 type TriggerRunner interface {
 	GetTrigger() *resources.Trigger
 	GetPipelineQueuesWithArgs(ctx context.Context, args map[string]interface{}, argsString map[string]string) ([]*event.PipelineQueue, error)
 	GetTriggerResponse([]*event.PipelineQueue) (types.TriggerExecutionResponse, error)
+	SetDryRun(bool)
 }
 
 func NewTriggerRunner(trigger *resources.Trigger, executionID, triggerExecutionID string) TriggerRunner {
 
 	switch trigger.Config.(type) {
 	case *resources.TriggerSchedule:
-		return &TriggerRunnerBase{
-			Trigger:            trigger,
-			rootMod:            trigger.GetMod(),
-			ExecutionID:        executionID,
-			TriggerExecutionID: triggerExecutionID,
-			Type:               "schedule",
-		}
+		// run on demand, the scheduler sets the scheduled time
+		return NewTriggerRunnerSchedule(trigger, executionID, triggerExecutionID, time.Time{})
 	case *resources.TriggerQuery:
 		return &TriggerRunnerQuery{
 			TriggerRunnerBase: TriggerRunnerBase{
@@ -63,6 +63,17 @@ func NewTriggerRunner(trigger *resources.Trigger, executionID, triggerExecutionI
 				Type:               "query",
 			},
 		}
+	case *resources.TriggerFile:
+		// run without a changed file, e.g. flowpipe trigger run
+		return NewTriggerRunnerFile(trigger, executionID, triggerExecutionID, "", "")
+	case *resources.TriggerQueue:
+		// run without a message, e.g. flowpipe trigger run
+		return NewTriggerRunnerQueue(trigger, executionID, triggerExecutionID, nil)
+	case *resources.TriggerPipeline:
+		// run without an upstream pipeline, e.g. flowpipe trigger run
+		return NewTriggerRunnerPipeline(trigger, executionID, triggerExecutionID, UpstreamPipeline{})
+	case *resources.TriggerEmail:
+		return NewTriggerRunnerEmail(trigger, executionID, triggerExecutionID)
 	default:
 		return nil
 	}
@@ -72,6 +83,10 @@ func (tr *TriggerRunnerBase) GetTrigger() *resources.Trigger {
 	return tr.Trigger
 }
 
+func (tr *TriggerRunnerBase) SetDryRun(dryRun bool) {
+	tr.DryRun = dryRun
+}
+
 func (tr *TriggerRunnerBase) GetPipelineQueuesWithArgs(ctx context.Context, args map[string]interface{}, argsString map[string]string) ([]*event.PipelineQueue, error) {
 	triggerRunArgs, err := tr.validate(args, argsString)
 
@@ -192,9 +207,9 @@ func (tr *TriggerRunnerBase) execute(ctx context.Context, executionID string, tr
 		Trigger:             trg.Name(),
 	}
 
-	slog.Info("Trigger fired", "trigger", trg.Name(), "pipeline", pipelineName, "pipeline_execution_id", pipelineCmd.PipelineExecutionID)
+	slog.Info("Trigger fired", "trigger", trg.Name(), "pipeline", pipelineName, "pipeline_execution_id", pipelineCmd.PipelineExecutionID, "dry_run", tr.DryRun)
 
-	if output.IsServerMode {
+	if output.IsServerMode && !tr.DryRun {
 		output.RenderServerOutput(ctx, types.NewServerOutputTriggerExecution(time.Now(), pipelineCmd.Event.ExecutionID, trg.Name(), pipelineName))
 	}
 
@@ -0,0 +1,194 @@
+package trigger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/store"
+)
+
+// The outcome of a trigger fire under the overlap policy of the trigger
+const (
+	OverlapStarted = "started"
+	OverlapSkipped = "skipped"
+	OverlapQueued  = "queued"
+)
+
+// The running executions of a trigger are the executions of the trigger not ended in pipeline_run, so they are still
+// known after a restart. An execution is running until it finishes, fails or is cancelled, a paused execution is
+// still running. The fires queued behind them are kept in the trigger_queue table.
+//
+// The executions started here are also tracked in memory: they are running before pipeline_run records them, and
+// pipeline_run doesn't record them at all when the processes are not kept.
+var (
+	overlapMu         sync.Mutex
+	activeExecutions  = map[string][]string{}
+	executionTriggers = map[string]string{}
+	// the executions replaced with cancel_previous, they are no longer running for the trigger while cancelled
+	replacedExecutions = map[string]bool{}
+)
+
+// StartExecution applies the overlap policy to a fire of the trigger, it returns the outcome and the running
+// executions of the trigger. The execution is started unless the trigger has a running execution and the policy is
+// skip (the fire is skipped) or queue (the fire is queued until the running execution ends, see EndExecution). With
+// cancel_previous the running executions are to be cancelled, they are no longer tracked.
+func StartExecution(triggerName, overlap string, cmd *event.ExecutionQueue) (string, []string) {
+	if overlap == "" || overlap == constants.OverlapAllow {
+		return OverlapStarted, nil
+	}
+
+	overlapMu.Lock()
+	defer overlapMu.Unlock()
+
+	running := runningExecutions(triggerName, "")
+
+	switch overlap {
+	case constants.OverlapSkip:
+		if len(running) > 0 {
+			return OverlapSkipped, running
+		}
+	case constants.OverlapQueue:
+		if len(running) > 0 {
+			if !queueExecution(triggerName, cmd) {
+				return OverlapSkipped, running
+			}
+			return OverlapQueued, running
+		}
+	case constants.OverlapCancelPrevious:
+		for _, executionID := range running {
+			delete(executionTriggers, executionID)
+			replacedExecutions[executionID] = true
+		}
+		activeExecutions[triggerName] = nil
+	}
+
+	startExecution(triggerName, cmd.Event.ExecutionID)
+
+	return OverlapStarted, running
+}
+
+// EndExecution removes the execution from the running executions of its trigger. The next fire queued behind it, if
+// any, is now running and returned to be sent.
+func EndExecution(executionID string) *event.ExecutionQueue {
+	overlapMu.Lock()
+	defer overlapMu.Unlock()
+
+	delete(replacedExecutions, executionID)
+
+	triggerName, ok := executionTriggers[executionID]
+	if ok {
+		delete(executionTriggers, executionID)
+		activeExecutions[triggerName] = slices.DeleteFunc(activeExecutions[triggerName], func(id string) bool {
+			return id == executionID
+		})
+	} else {
+		// the execution was started before a restart
+		var err error
+		triggerName, err = store.GetPipelineRunTrigger(executionID)
+		if err != nil {
+			slog.Error("Error getting the trigger of the execution", "execution_id", executionID, "error", err)
+			return nil
+		}
+		if triggerName == "" {
+			return nil
+		}
+	}
+
+	if len(runningExecutions(triggerName, executionID)) > 0 {
+		return nil
+	}
+
+	next := dequeueExecution(triggerName)
+	if next == nil {
+		return nil
+	}
+
+	startExecution(triggerName, next.Event.ExecutionID)
+	return next
+}
+
+// runningExecutions returns the running executions of the trigger but the ended one, must be called with the lock held
+func runningExecutions(triggerName, endedExecutionID string) []string {
+	running := slices.Clone(activeExecutions[triggerName])
+
+	recorded, err := store.ListRunningTriggerExecutionIDs(triggerName)
+	if err != nil {
+		slog.Error("Error listing the running executions of the trigger", "trigger", triggerName, "error", err)
+	}
+	for _, executionID := range recorded {
+		if replacedExecutions[executionID] || slices.Contains(running, executionID) {
+			continue
+		}
+		running = append(running, executionID)
+	}
+
+	return slices.DeleteFunc(running, func(id string) bool {
+		return id == endedExecutionID
+	})
+}
+
+func startExecution(triggerName, executionID string) {
+	activeExecutions[triggerName] = append(activeExecutions[triggerName], executionID)
+	executionTriggers[executionID] = triggerName
+}
+
+// queueExecution queues the fire of the trigger, it returns false if the queue of the trigger is full or the fire
+// can't be queued. The fire past the MaxQueuedTriggerRuns queued fires is skipped.
+func queueExecution(triggerName string, cmd *event.ExecutionQueue) bool {
+	command, err := json.Marshal(cmd)
+	if err != nil {
+		slog.Error("Error serializing the queued trigger execution", "trigger", triggerName, "error", err)
+		return false
+	}
+
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return false
+	}
+	defer db.Close()
+
+	err = store.CreateTriggerQueueTable(db)
+	if err != nil {
+		return false
+	}
+
+	queued, err := store.CountQueuedTriggerExecutions(db, triggerName)
+	if err != nil || queued >= constants.MaxQueuedTriggerRuns {
+		return false
+	}
+
+	return store.QueueTriggerExecution(db, triggerName, cmd.Event.ExecutionID, command) == nil
+}
+
+// dequeueExecution returns the next fire queued for the trigger, nil if there is none
+func dequeueExecution(triggerName string) *event.ExecutionQueue {
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return nil
+	}
+	defer db.Close()
+
+	err = store.CreateTriggerQueueTable(db)
+	if err != nil {
+		return nil
+	}
+
+	command, err := store.DequeueTriggerExecution(db, triggerName)
+	if err != nil || command == nil {
+		return nil
+	}
+
+	var cmd event.ExecutionQueue
+	err = json.Unmarshal(command, &cmd)
+	if err != nil {
+		slog.Error("Error deserializing the queued trigger execution", "trigger", triggerName, "error", err)
+		return nil
+	}
+	return &cmd
+}
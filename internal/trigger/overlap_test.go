@@ -0,0 +1,169 @@
+package trigger
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/filepaths"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// setupOverlapTest starts the test with a new flowpipe.db and no running executions in memory, as after a restart
+func setupOverlapTest(t *testing.T) {
+	err := os.Remove(filepaths.FlowpipeDBFileName())
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+
+	err = store.InitializeFlowpipeDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restartOverlap()
+}
+
+func restartOverlap() {
+	overlapMu.Lock()
+	defer overlapMu.Unlock()
+
+	activeExecutions = map[string][]string{}
+	executionTriggers = map[string]string{}
+	replacedExecutions = map[string]bool{}
+}
+
+func TestStartExecutionOverlap(t *testing.T) {
+	assert := assert.New(t)
+
+	setupOverlapTest(t)
+
+	fire := func(triggerName, overlap string) (*event.ExecutionQueue, string, []string) {
+		cmd := event.NewExecutionQueueForTrigger("", triggerName)
+		outcome, previous := StartExecution(triggerName, overlap, cmd)
+		return cmd, outcome, previous
+	}
+
+	// overlapping executions are allowed and not tracked
+	allowed, outcome, _ := fire("local.trigger.schedule.allow", "allow")
+	assert.Equal(OverlapStarted, outcome)
+	_, outcome, _ = fire("local.trigger.schedule.allow", "allow")
+	assert.Equal(OverlapStarted, outcome)
+	assert.Nil(EndExecution(allowed.Event.ExecutionID))
+
+	// the fires are skipped while the execution is running
+	running, outcome, _ := fire("local.trigger.schedule.skip", "skip")
+	assert.Equal(OverlapStarted, outcome)
+	_, outcome, previous := fire("local.trigger.schedule.skip", "skip")
+	assert.Equal(OverlapSkipped, outcome)
+	assert.Equal([]string{running.Event.ExecutionID}, previous)
+
+	assert.Nil(EndExecution(running.Event.ExecutionID))
+	_, outcome, _ = fire("local.trigger.schedule.skip", "skip")
+	assert.Equal(OverlapStarted, outcome)
+
+	// the fires are started one after the other
+	running, outcome, _ = fire("local.trigger.schedule.queue", "queue")
+	assert.Equal(OverlapStarted, outcome)
+	second, outcome, _ := fire("local.trigger.schedule.queue", "queue")
+	assert.Equal(OverlapQueued, outcome)
+	third, outcome, _ := fire("local.trigger.schedule.queue", "queue")
+	assert.Equal(OverlapQueued, outcome)
+
+	next := EndExecution(running.Event.ExecutionID)
+	assert.Equal(second, next)
+	_, outcome, _ = fire("local.trigger.schedule.queue", "queue")
+	assert.Equal(OverlapQueued, outcome)
+
+	next = EndExecution(second.Event.ExecutionID)
+	assert.Equal(third, next)
+
+	// the previous executions are replaced
+	first, outcome, cancelled := fire("local.trigger.query.cancel", "cancel_previous")
+	assert.Equal(OverlapStarted, outcome)
+	assert.Nil(cancelled)
+
+	latest, outcome, cancelled := fire("local.trigger.query.cancel", "cancel_previous")
+	assert.Equal(OverlapStarted, outcome)
+	assert.Equal([]string{first.Event.ExecutionID}, cancelled)
+
+	// the cancelled execution ending doesn't end the latest one
+	assert.Nil(EndExecution(first.Event.ExecutionID))
+	_, _, cancelled = fire("local.trigger.query.cancel", "cancel_previous")
+	assert.Equal([]string{latest.Event.ExecutionID}, cancelled)
+}
+
+func TestStartExecutionQueueFull(t *testing.T) {
+	assert := assert.New(t)
+
+	setupOverlapTest(t)
+
+	triggerName := "local.trigger.schedule.queue_full"
+
+	outcome, _ := StartExecution(triggerName, "queue", event.NewExecutionQueueForTrigger("", triggerName))
+	assert.Equal(OverlapStarted, outcome)
+
+	for i := 0; i < 100; i++ {
+		outcome, _ = StartExecution(triggerName, "queue", event.NewExecutionQueueForTrigger("", triggerName))
+		assert.Equal(OverlapQueued, outcome)
+	}
+
+	outcome, _ = StartExecution(triggerName, "queue", event.NewExecutionQueueForTrigger("", triggerName))
+	assert.Equal(OverlapSkipped, outcome)
+}
+
+func TestStartExecutionAfterRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	setupOverlapTest(t)
+
+	// the executions are recorded in pipeline_run when the processes are kept
+	previousRetention := viper.GetInt(constants.ArgProcessRetention)
+	viper.Set(constants.ArgProcessRetention, 604800)
+	defer viper.Set(constants.ArgProcessRetention, previousRetention)
+
+	fire := func(triggerName, overlap string) (*event.ExecutionQueue, string, []string) {
+		cmd := event.NewExecutionQueueForTrigger("", triggerName)
+		outcome, previous := StartExecution(triggerName, overlap, cmd)
+		if outcome == OverlapStarted {
+			assert.Nil(store.StartPipeline(cmd.Event.ExecutionID, "local.pipeline.report", triggerName))
+		}
+		return cmd, outcome, previous
+	}
+
+	skipping, outcome, _ := fire("local.trigger.schedule.skip", "skip")
+	assert.Equal(OverlapStarted, outcome)
+	queueing, outcome, _ := fire("local.trigger.schedule.queue", "queue")
+	assert.Equal(OverlapStarted, outcome)
+	queued, outcome, _ := fire("local.trigger.schedule.queue", "queue")
+	assert.Equal(OverlapQueued, outcome)
+
+	restartOverlap()
+
+	// the executions started before the restart are still running
+	_, outcome, previous := fire("local.trigger.schedule.skip", "skip")
+	assert.Equal(OverlapSkipped, outcome)
+	assert.Equal([]string{skipping.Event.ExecutionID}, previous)
+
+	_, outcome, previous = fire("local.trigger.schedule.queue", "queue")
+	assert.Equal(OverlapQueued, outcome)
+	assert.Equal([]string{queueing.Event.ExecutionID}, previous)
+
+	// the fires queued before the restart are sent once the running execution ends
+	assert.Nil(store.UpdatePipelineState(queueing.Event.ExecutionID, "finished"))
+	next := EndExecution(queueing.Event.ExecutionID)
+	if next == nil {
+		assert.FailNow("queued execution not sent")
+		return
+	}
+	assert.Equal(queued.Event.ExecutionID, next.Event.ExecutionID)
+	assert.Equal("local.trigger.schedule.queue", next.TriggerQueue.Name)
+
+	assert.Nil(store.UpdatePipelineState(skipping.Event.ExecutionID, "failed"))
+	assert.Nil(EndExecution(skipping.Event.ExecutionID))
+	_, outcome, _ = fire("local.trigger.schedule.skip", "skip")
+	assert.Equal(OverlapStarted, outcome)
+}
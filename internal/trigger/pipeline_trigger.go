@@ -0,0 +1,139 @@
+package trigger
+
+import (
+	"context"
+	"log/slog"
+
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/db"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// UpstreamPipeline is the ended root pipeline execution of the upstream pipeline of a pipeline trigger
+type UpstreamPipeline struct {
+	ExecutionID         string
+	PipelineExecutionID string
+	Pipeline            string
+	Status              string
+	Args                resources.Input
+	Output              map[string]interface{}
+}
+
+type TriggerRunnerPipeline struct {
+	TriggerRunnerBase
+
+	// The ended upstream pipeline, available to the trigger args as self.execution_id, self.pipeline_execution_id,
+	// self.pipeline, self.status, self.args and self.output
+	Upstream UpstreamPipeline
+}
+
+func NewTriggerRunnerPipeline(trigger *resources.Trigger, executionID, triggerExecutionID string, upstream UpstreamPipeline) *TriggerRunnerPipeline {
+	return &TriggerRunnerPipeline{
+		TriggerRunnerBase: TriggerRunnerBase{
+			Trigger:            trigger,
+			rootMod:            trigger.GetMod(),
+			ExecutionID:        executionID,
+			TriggerExecutionID: triggerExecutionID,
+			Type:               localconstants.TriggerTypePipeline,
+		},
+		Upstream: upstream,
+	}
+}
+
+func (tr *TriggerRunnerPipeline) GetPipelineQueuesWithArgs(ctx context.Context, args map[string]interface{}, argsString map[string]string) ([]*event.PipelineQueue, error) {
+	triggerRunArgs, err := tr.validate(args, argsString)
+
+	if err != nil {
+		slog.Error("Error validating trigger", "error", err)
+		return nil, err
+	}
+
+	triggerArgs, err := tr.getPipelineTriggerArgs(triggerRunArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := tr.execute(ctx, tr.ExecutionID, triggerArgs, tr.Trigger)
+	if err != nil {
+		slog.Error("Error sending pipeline command", "error", err)
+		return nil, err
+	}
+
+	return cmds, nil
+}
+
+// getPipelineTriggerArgs evaluates the trigger args with the upstream pipeline, the pipeline is run with the
+// execution_id, status, args and output args if the trigger doesn't specify its args
+func (tr *TriggerRunnerPipeline) getPipelineTriggerArgs(triggerRunArgs map[string]interface{}) (resources.Input, error) {
+
+	evalContext, err := buildEvalContextForTriggerExecution(tr.rootMod, tr.Trigger.Params, tr.Trigger.Config, triggerRunArgs)
+	if err != nil {
+		slog.Error("Error building eval context", "error", err)
+		return nil, perr.InternalWithMessage("Error building eval context")
+	}
+
+	upstreamArgs, err := toCtyObject(tr.Upstream.Args)
+	if err != nil {
+		slog.Error("Error converting upstream pipeline args", "error", err)
+		return nil, perr.InternalWithMessage("Error converting upstream pipeline args")
+	}
+
+	upstreamOutput, err := toCtyObject(tr.Upstream.Output)
+	if err != nil {
+		slog.Error("Error converting upstream pipeline output", "error", err)
+		return nil, perr.InternalWithMessage("Error converting upstream pipeline output")
+	}
+
+	evalContext.Variables["self"] = cty.ObjectVal(map[string]cty.Value{
+		"execution_id":          cty.StringVal(tr.Upstream.ExecutionID),
+		"pipeline_execution_id": cty.StringVal(tr.Upstream.PipelineExecutionID),
+		"pipeline":              cty.StringVal(tr.Upstream.Pipeline),
+		"status":                cty.StringVal(tr.Upstream.Status),
+		"args":                  upstreamArgs,
+		"output":                upstreamOutput,
+	})
+
+	latestTrigger, err := db.GetTrigger(tr.Trigger.Name())
+	if err != nil {
+		slog.Error("Error getting latest trigger", "trigger", tr.Trigger.Name(), "error", err)
+		return nil, perr.NotFoundWithMessage("trigger not found")
+	}
+
+	if latestTrigger.ArgsRaw == nil {
+		input := resources.Input{
+			"execution_id": tr.Upstream.ExecutionID,
+			"status":       tr.Upstream.Status,
+			"args":         map[string]interface{}{},
+			"output":       map[string]interface{}{},
+		}
+		if tr.Upstream.Args != nil {
+			input["args"] = map[string]interface{}(tr.Upstream.Args)
+		}
+		if tr.Upstream.Output != nil {
+			input["output"] = tr.Upstream.Output
+		}
+		return input, nil
+	}
+
+	pipelineArgs, diags := latestTrigger.GetArgs(evalContext)
+	if diags.HasErrors() {
+		slog.Error("Error getting trigger args", "trigger", tr.Trigger.Name(), "errors", diags)
+		err := error_helpers.HclDiagsToError("trigger", diags)
+		return nil, err
+	}
+
+	return pipelineArgs, nil
+}
+
+// toCtyObject converts the args or output of a pipeline, an empty object if there are none
+func toCtyObject(values map[string]interface{}) (cty.Value, error) {
+	if len(values) == 0 {
+		return cty.EmptyObjectVal, nil
+	}
+	return hclhelpers.ConvertInterfaceToCtyValue(values)
+}
@@ -0,0 +1,148 @@
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTriggerPipelineArgs(t *testing.T) {
+	finished := UpstreamPipeline{
+		ExecutionID:         "exec_upstream",
+		PipelineExecutionID: "pexec_upstream",
+		Pipeline:            "local.pipeline.etl",
+		Status:              "finished",
+		Args:                resources.Input{"table": "orders"},
+		Output:              map[string]interface{}{"rows": 10},
+	}
+
+	// a failed pipeline has no output
+	failed := UpstreamPipeline{
+		ExecutionID:         "exec_upstream",
+		PipelineExecutionID: "pexec_upstream",
+		Pipeline:            "local.pipeline.etl",
+		Status:              "failed",
+		Args:                resources.Input{"table": "orders"},
+	}
+
+	tests := []struct {
+		title         string
+		upstream      UpstreamPipeline
+		args          string
+		expected      resources.Input
+		containsError string
+	}{
+		{
+			title:    "finished upstream without args",
+			upstream: finished,
+			expected: resources.Input{
+				"execution_id": "exec_upstream",
+				"status":       "finished",
+				"args":         map[string]interface{}{"table": "orders"},
+				"output":       map[string]interface{}{"rows": 10},
+			},
+		},
+		{
+			title:    "failed upstream without args",
+			upstream: failed,
+			expected: resources.Input{
+				"execution_id": "exec_upstream",
+				"status":       "failed",
+				"args":         map[string]interface{}{"table": "orders"},
+				"output":       map[string]interface{}{},
+			},
+		},
+		{
+			title:    "args from the upstream pipeline",
+			upstream: finished,
+			args:     `{ upstream = self.pipeline, pipeline_execution_id = self.pipeline_execution_id, status = self.status, table = self.args.table, rows = self.output.rows }`,
+			expected: resources.Input{
+				"upstream":              "local.pipeline.etl",
+				"pipeline_execution_id": "pexec_upstream",
+				"status":                "finished",
+				"table":                 "orders",
+				"rows":                  10,
+			},
+		},
+		{
+			title:    "args from the output of a failed upstream",
+			upstream: failed,
+			args:     `{ status = self.status, rows = try(self.output.rows, 0) }`,
+			expected: resources.Input{
+				"status": "failed",
+				"rows":   0,
+			},
+		},
+		{
+			title:         "args from a missing output",
+			upstream:      failed,
+			args:          `{ rows = self.output.rows }`,
+			containsError: "Unsupported attribute",
+		},
+		{
+			title:    "run on demand without args",
+			upstream: UpstreamPipeline{},
+			expected: resources.Input{
+				"execution_id": "",
+				"status":       "",
+				"args":         map[string]interface{}{},
+				"output":       map[string]interface{}{},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			assert := assert.New(t)
+
+			trigger := &resources.Trigger{
+				HclResourceImpl: modconfig.HclResourceImpl{
+					FullName: "local.trigger.pipeline.test_pipeline_trigger_" + string(rune('a'+i)),
+				},
+				Pipeline: cty.ObjectVal(map[string]cty.Value{
+					"name": cty.StringVal("report"),
+				}),
+				Config: &resources.TriggerPipeline{
+					Upstream: "local.pipeline.etl",
+					Statuses: []string{"finished", "failed", "canceled"},
+				},
+			}
+			if test.args != "" {
+				argsExpr, diags := hclsyntax.ParseExpression([]byte(test.args), "test.fp", hcl.InitialPos)
+				if !assert.False(diags.HasErrors()) {
+					return
+				}
+				trigger.ArgsRaw = argsExpr
+			}
+			cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+			executionID := util.NewExecutionId()
+			triggerRunner := NewTriggerRunnerPipeline(trigger, executionID, "", test.upstream)
+
+			pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+			if test.containsError != "" {
+				if assert.NotNil(err) {
+					assert.Contains(err.Error(), test.containsError)
+				}
+				return
+			}
+
+			if !assert.Nil(err) || !assert.Equal(1, len(pipelineQueues)) {
+				return
+			}
+			assert.Equal("report", pipelineQueues[0].Name)
+			assert.Equal(trigger.Name(), pipelineQueues[0].Trigger)
+			assert.Equal(executionID, pipelineQueues[0].Event.ExecutionID)
+			assert.EqualValues(test.expected, pipelineQueues[0].Args)
+		})
+	}
+}
@@ -10,11 +10,11 @@ import (
 	"log/slog"
 	"reflect"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/metrics"
 	o "github.com/turbot/flowpipe/internal/output"
 	"github.com/turbot/flowpipe/internal/primitive"
 	"github.com/turbot/flowpipe/internal/store"
@@ -30,6 +30,9 @@ import (
 
 type TriggerRunnerQuery struct {
 	TriggerRunnerBase
+
+	// The number of inserted, updated and deleted rows captured by the last run
+	QueryStat map[string]int
 }
 
 type queryTriggerMetadata struct {
@@ -109,8 +112,8 @@ func queuePipeline(capture *resources.TriggerQueryCapture, executionID string, t
 		TriggerCapture:      capture.Type,
 	}
 
-	slog.Info("Trigger fired", "trigger", tr.Trigger.Name(), "pipeline", pipelineName, "pipeline_execution_id", pipelineCmd.PipelineExecutionID, "args", pipelineArgs, "capture_type", capture.Type, "capture_count", queryStat[capture.Type])
-	if o.IsServerMode {
+	slog.Info("Trigger fired", "trigger", tr.Trigger.Name(), "pipeline", pipelineName, "pipeline_execution_id", pipelineCmd.PipelineExecutionID, "args", pipelineArgs, "capture_type", capture.Type, "capture_count", queryStat[capture.Type], "dry_run", tr.DryRun)
+	if o.IsServerMode && !tr.DryRun {
 		o.RenderServerOutput(context.TODO(), types.NewServerOutputTriggerExecution(time.Now(), pipelineCmd.Event.ExecutionID, tr.Trigger.Name(), pipelineName))
 	}
 
@@ -125,7 +128,9 @@ func queuePipeline(capture *resources.TriggerQueryCapture, executionID string, t
 	return pipelineCmd, nil
 }
 
-func calculatedNewUpdatedDeletedData(db *sql.DB, triggerName string, controlItems []queryTriggerMetadata) ([]string, []string, []string, error) {
+// calculatedNewUpdatedDeletedData compares the rows with the captured rows and replaces the captured rows, a dry run
+// rolls back the replacement
+func calculatedNewUpdatedDeletedData(db *sql.DB, triggerName string, controlItems []queryTriggerMetadata, dryRun bool) ([]string, []string, []string, error) {
 	if len(controlItems) == 0 {
 		return nil, nil, nil, nil
 	}
@@ -252,6 +257,14 @@ func calculatedNewUpdatedDeletedData(db *sql.DB, triggerName string, controlItem
 		return nil, nil, nil, err
 	}
 
+	if dryRun {
+		if err := tx.Rollback(); err != nil {
+			slog.Error("Error rolling back transaction", "error", err)
+			return nil, nil, nil, err
+		}
+		return newItems, updatedItems, deletedItems, nil
+	}
+
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		slog.Error("Error committing transaction", "error", err)
@@ -359,11 +372,47 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 
 	slog.Info("Running trigger", "trigger", tr.Trigger.Name())
 
+	evalContext, resolvedTriggerConfig, err := tr.resolveConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedTriggerConfig.CursorColumn != "" {
+		return tr.executeCursor(executionID, evalContext, resolvedTriggerConfig)
+	}
+
+	newRows, updatedRows, deletedPrimaryKeys, err := tr.diffRows(resolvedTriggerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return tr.queueCaptures(executionID, evalContext, resolvedTriggerConfig, newRows, updatedRows, deletedPrimaryKeys)
+}
+
+// Seed records the rows currently returned by the query as known by the trigger without queueing the captures, the
+// next poll only captures the rows inserted, updated or deleted after the seed
+func (tr *TriggerRunnerQuery) Seed(ctx context.Context) error {
+	slog.Info("Seeding trigger", "trigger", tr.Trigger.Name())
+
+	_, resolvedTriggerConfig, err := tr.resolveConfig()
+	if err != nil {
+		return err
+	}
+
+	if resolvedTriggerConfig.CursorColumn != "" {
+		return tr.seedCursor(resolvedTriggerConfig)
+	}
+
+	_, _, _, err = tr.diffRows(resolvedTriggerConfig)
+	return err
+}
+
+func (tr *TriggerRunnerQuery) resolveConfig() (*hcl.EvalContext, *resources.TriggerQuery, error) {
 	var triggerRunArgs map[string]interface{}
 	evalContext, err := buildEvalContextForTriggerExecution(tr.rootMod, tr.Trigger.Params, tr.Trigger.Config, triggerRunArgs)
 	if err != nil {
 		slog.Error("Error building eval context", "error", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	config := tr.Trigger.Config.(*resources.TriggerQuery)
@@ -371,15 +420,21 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 	resolvedConfig, err := config.GetConfig(evalContext, tr.rootMod)
 	if err != nil {
 		slog.Error("Error resolving trigger config", "error", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	resolvedTriggerConfig, ok := resolvedConfig.(*resources.TriggerQuery)
 	if !ok {
 		slog.Error("Error converting resolved config to TriggerQueryConfig", "error", err)
-		return nil, perr.InternalWithMessage("Error converting resolved config to TriggerQueryConfig")
+		return nil, nil, perr.InternalWithMessage("Error converting resolved config to TriggerQueryConfig")
 	}
 
+	return evalContext, resolvedTriggerConfig, nil
+}
+
+// diffRows runs the query and compares the returned rows with the rows captured by the previous polls, the captured
+// rows are replaced with the returned rows
+func (tr *TriggerRunnerQuery) diffRows(resolvedTriggerConfig *resources.TriggerQuery) ([]map[string]interface{}, []map[string]interface{}, []string, error) {
 	queryPrimitive := primitive.Query{}
 
 	input := resources.Input{
@@ -393,15 +448,12 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 		if o.IsServerMode {
 			o.RenderServerOutput(context.TODO(), types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "error running query trigger "+tr.Trigger.Name(), err))
 		}
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	if output.Data["rows"] == nil {
 		slog.Info("No rows returned from trigger query", "trigger", tr.Trigger.Name())
-		if o.IsServerMode {
-			o.RenderServerOutput(context.TODO(), types.NewServerOutputQueryTriggerRun(tr.Trigger.Name(), 0, 0, 0))
-		}
-		return nil, nil
+		return nil, nil, nil, nil
 	}
 
 	rows, ok := output.Data["rows"].([]map[string]interface{})
@@ -410,7 +462,7 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 		if o.IsServerMode {
 			o.RenderServerOutput(context.TODO(), types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "error converting rows to []interface{} "+tr.Trigger.Name(), err))
 		}
-		return nil, nil
+		return nil, nil, nil, nil
 	}
 
 	controlItems := []queryTriggerMetadata{}
@@ -433,7 +485,7 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 							errorString,
 							err))
 				}
-				return nil, perr.InternalWithMessage("Primary key not found in row")
+				return nil, nil, nil, perr.InternalWithMessage("Primary key not found in row")
 			}
 			pkString, ok := primaryKey.(string)
 			if !ok {
@@ -464,19 +516,19 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 		}
 	}
 
-	safeTriggerName := strings.ReplaceAll(tr.Trigger.FullName, ".", "_")
+	safeTriggerName := store.QueryTriggerCapturedRowName(tr.Trigger.FullName)
 
 	db, err := store.OpenFlowpipeDB()
 	if err != nil {
 		slog.Error("Error opening Flowpipe db", "error", err)
-		return nil, err
+		return nil, nil, nil, err
 	}
 	defer db.Close()
 
-	newItemPrimaryKeys, updatedItemPrimaryKeys, deletedPrimaryKeys, err := calculatedNewUpdatedDeletedData(db, safeTriggerName, controlItems)
+	newItemPrimaryKeys, updatedItemPrimaryKeys, deletedPrimaryKeys, err := calculatedNewUpdatedDeletedData(db, safeTriggerName, controlItems, tr.DryRun)
 	if err != nil {
 		slog.Error("Error storing slice", "error", err)
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	newRows := []map[string]interface{}{}
@@ -493,12 +545,6 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 		newRows = append(newRows, row.(map[string]interface{}))
 	}
 
-	newRowCtyVals, err := hclhelpers.ConvertInterfaceToCtyValue(newRows)
-	if err != nil {
-		slog.Error("Error building new rows cty", "error", err)
-		return nil, err
-	}
-
 	updatedRows := []map[string]interface{}{}
 	for _, k := range updatedItemPrimaryKeys {
 		slog.Debug("New item key", "key", k)
@@ -513,6 +559,17 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 		updatedRows = append(updatedRows, row.(map[string]interface{}))
 	}
 
+	return newRows, updatedRows, deletedPrimaryKeys, nil
+}
+
+// queueCaptures queues the pipelines of the capture blocks with the inserted, updated and deleted rows
+func (tr *TriggerRunnerQuery) queueCaptures(executionID string, evalContext *hcl.EvalContext, config *resources.TriggerQuery, newRows, updatedRows []map[string]interface{}, deletedPrimaryKeys []string) ([]*event.PipelineQueue, error) {
+	newRowCtyVals, err := hclhelpers.ConvertInterfaceToCtyValue(newRows)
+	if err != nil {
+		slog.Error("Error building new rows cty", "error", err)
+		return nil, err
+	}
+
 	updatedRowCtyVals, err := hclhelpers.ConvertInterfaceToCtyValue(updatedRows)
 	if err != nil {
 		slog.Error("Error building updated rows cty", "error", err)
@@ -554,13 +611,21 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 		"delete": len(deletedPrimaryKeys),
 	}
 
-	slog.Info("Trigger stats", "stats", queryStat)
-	if o.IsServerMode {
-		o.RenderServerOutput(context.TODO(), types.NewServerOutputQueryTriggerRun(tr.Trigger.Name(), len(newRows), len(updatedRows), len(deletedPrimaryKeys)))
+	tr.QueryStat = queryStat
+
+	slog.Info("Trigger stats", "stats", queryStat, "dry_run", tr.DryRun)
+
+	// a dry run isn't a poll of the trigger
+	if !tr.DryRun {
+		metrics.QueryTriggerRows(tr.Trigger.Name(), len(newRows), len(updatedRows), len(deletedPrimaryKeys))
+		tr.saveRun(len(newRows), len(updatedRows), len(deletedPrimaryKeys))
+		if o.IsServerMode {
+			o.RenderServerOutput(context.TODO(), types.NewServerOutputQueryTriggerRun(tr.Trigger.Name(), len(newRows), len(updatedRows), len(deletedPrimaryKeys)))
+		}
 	}
 
 	var pipelineCmds []*event.PipelineQueue
-	for _, capture := range resolvedTriggerConfig.Captures {
+	for _, capture := range config.Captures {
 		cmd, err := queuePipeline(capture, executionID, tr, evalContext, queryStat)
 		if err != nil {
 			slog.Error("Error running pipeline", "error", err)
@@ -574,3 +639,23 @@ func (tr *TriggerRunnerQuery) execute(ctx context.Context, executionID string, t
 
 	return pipelineCmds, nil
 }
+
+// saveRun saves the poll of the trigger for the trigger state, failing to save it doesn't fail the poll
+func (tr *TriggerRunnerQuery) saveRun(inserted, updated, deleted int) {
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return
+	}
+	defer db.Close()
+
+	err = store.CreateQueryTriggerRunTable(db)
+	if err != nil {
+		return
+	}
+
+	err = store.SetQueryTriggerRun(db, tr.Trigger.Name(), inserted, updated, deleted)
+	if err != nil {
+		slog.Warn("Unable to save query trigger run", "trigger", tr.Trigger.Name(), "error", err)
+	}
+}
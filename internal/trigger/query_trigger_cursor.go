@@ -0,0 +1,255 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/flowpipe/internal/es/event"
+	o "github.com/turbot/flowpipe/internal/output"
+	"github.com/turbot/flowpipe/internal/primitive"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+// executeCursor runs the query with the last value of the cursor column seen by the trigger, the rows past the cursor
+// are the inserted rows. The query is expected to filter on the cursor parameter, e.g.
+//
+//	select * from orders where $1::bigint is null or id > $1 order by id
+//
+// The cursor is null until the trigger has seen a row. Unlike the comparison of all the rows the returned rows are not
+// stored, only the cursor is.
+func (tr *TriggerRunnerQuery) executeCursor(executionID string, evalContext *hcl.EvalContext, config *resources.TriggerQuery) ([]*event.PipelineQueue, error) {
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return nil, err
+	}
+	defer db.Close()
+
+	err = store.CreateQueryTriggerCursorTable(db)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := store.GetQueryTriggerCursor(db, tr.Trigger.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	newRows, latest, err := tr.cursorRows(config, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineCmds, err := tr.queueCaptures(executionID, evalContext, config, newRows, []map[string]interface{}{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(newRows) > 0 && !tr.DryRun {
+		slog.Debug("Saving query trigger cursor", "trigger", tr.Trigger.Name(), "cursor", latest)
+		err = store.SetQueryTriggerCursor(db, tr.Trigger.Name(), latest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pipelineCmds, nil
+}
+
+// seedCursor saves the last value of the cursor column returned by the query without queueing the captures
+func (tr *TriggerRunnerQuery) seedCursor(config *resources.TriggerQuery) error {
+	db, err := store.OpenFlowpipeDB()
+	if err != nil {
+		slog.Error("Error opening Flowpipe db", "error", err)
+		return err
+	}
+	defer db.Close()
+
+	err = store.CreateQueryTriggerCursorTable(db)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := store.GetQueryTriggerCursor(db, tr.Trigger.Name())
+	if err != nil {
+		return err
+	}
+
+	newRows, latest, err := tr.cursorRows(config, cursor)
+	if err != nil {
+		return err
+	}
+
+	if len(newRows) == 0 {
+		return nil
+	}
+
+	slog.Debug("Saving query trigger cursor", "trigger", tr.Trigger.Name(), "cursor", latest)
+	return store.SetQueryTriggerCursor(db, tr.Trigger.Name(), latest)
+}
+
+// cursorRows runs the query with the cursor, it returns the rows past the cursor and the last value of the cursor
+// column
+func (tr *TriggerRunnerQuery) cursorRows(config *resources.TriggerQuery, cursor interface{}) ([]map[string]interface{}, interface{}, error) {
+	queryPrimitive := primitive.Query{}
+
+	input := resources.Input{
+		schema.AttributeTypeSql:      config.Sql,
+		schema.AttributeTypeDatabase: config.Database,
+		schema.AttributeTypeArgs:     []interface{}{cursor},
+	}
+
+	output, _, err := queryPrimitive.RunWithMetadata(context.Background(), input)
+	if err != nil {
+		slog.Error("Error running trigger query", "error", err)
+		if o.IsServerMode {
+			o.RenderServerOutput(context.TODO(), types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), "error running query trigger "+tr.Trigger.Name(), err))
+		}
+		return nil, nil, err
+	}
+
+	rows, _ := output.Data["rows"].([]map[string]interface{})
+
+	newRows := []map[string]interface{}{}
+	latest := cursor
+	for _, r := range rows {
+		value := r[config.CursorColumn]
+		if value == nil {
+			errorString := fmt.Sprintf("cursor column %s not found in query row from query trigger %s", config.CursorColumn, tr.Trigger.Name())
+			slog.Error("Cursor column not found in row", "trigger", tr.Trigger.Name(), "cursor_column", config.CursorColumn)
+			err := perr.InternalWithMessage(errorString)
+			if o.IsServerMode {
+				o.RenderServerOutput(context.TODO(), types.NewServerOutputError(types.NewServerOutputPrefix(time.Now(), "flowpipe"), errorString, err))
+			}
+			return nil, nil, err
+		}
+
+		// the query should only return the rows past the cursor, the rows that aren't (e.g. the query uses >=) have
+		// already been captured
+		if cursor != nil {
+			cmp, err := compareCursorValues(value, cursor)
+			if err != nil {
+				slog.Error("Error comparing cursor", "trigger", tr.Trigger.Name(), "error", err)
+				return nil, nil, err
+			}
+			if cmp <= 0 {
+				continue
+			}
+		}
+
+		newRows = append(newRows, r)
+
+		if latest == nil {
+			latest = value
+			continue
+		}
+
+		cmp, err := compareCursorValues(value, latest)
+		if err != nil {
+			slog.Error("Error comparing cursor", "trigger", tr.Trigger.Name(), "error", err)
+			return nil, nil, err
+		}
+		if cmp > 0 {
+			latest = value
+		}
+	}
+
+	return newRows, latest, nil
+}
+
+// compareCursorValues compares two values of the cursor column, it returns -1, 0 or 1 like strings.Compare. The values
+// can be numbers of different types, e.g. an integer column read by the database driver and the integer cursor read
+// from the Flowpipe db.
+func compareCursorValues(a, b interface{}) (int, error) {
+	if aNum, ok := cursorNumber(a); ok {
+		if bNum, ok := cursorNumber(b); ok {
+			return aNum.Cmp(bNum), nil
+		}
+	}
+
+	if aTime, ok := cursorTime(a); ok {
+		if bTime, ok := cursorTime(b); ok {
+			return aTime.Compare(bTime), nil
+		}
+	}
+
+	aString, aOk := cursorString(a)
+	bString, bOk := cursorString(b)
+	if aOk && bOk {
+		return strings.Compare(aString, bString), nil
+	}
+
+	return 0, perr.BadRequestWithMessage(fmt.Sprintf("unable to compare cursor values %v (%T) and %v (%T)", a, a, b, b))
+}
+
+func cursorNumber(v interface{}) (*big.Float, bool) {
+	switch n := v.(type) {
+	case int:
+		return new(big.Float).SetInt64(int64(n)), true
+	case int8:
+		return new(big.Float).SetInt64(int64(n)), true
+	case int16:
+		return new(big.Float).SetInt64(int64(n)), true
+	case int32:
+		return new(big.Float).SetInt64(int64(n)), true
+	case int64:
+		return new(big.Float).SetInt64(n), true
+	case uint:
+		return new(big.Float).SetUint64(uint64(n)), true
+	case uint8:
+		return new(big.Float).SetUint64(uint64(n)), true
+	case uint16:
+		return new(big.Float).SetUint64(uint64(n)), true
+	case uint32:
+		return new(big.Float).SetUint64(uint64(n)), true
+	case uint64:
+		return new(big.Float).SetUint64(n), true
+	case float32:
+		return cursorFloat(float64(n))
+	case float64:
+		return cursorFloat(n)
+	default:
+		return nil, false
+	}
+}
+
+func cursorFloat(f float64) (*big.Float, bool) {
+	if math.IsNaN(f) {
+		return nil, false
+	}
+	return big.NewFloat(f), true
+}
+
+func cursorTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		// a time cursor read back from a database that stores times as text
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		return parsed, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+func cursorString(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	default:
+		return "", false
+	}
+}
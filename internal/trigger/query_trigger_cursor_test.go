@@ -0,0 +1,206 @@
+package trigger
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/filepaths"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTriggerQueryCursor(t *testing.T) {
+	ctx := context.Background()
+
+	assert := assert.New(t)
+
+	sourceDbFilename := "./test_trigger_query_cursor.db"
+	_, err := os.Stat(sourceDbFilename)
+	if !os.IsNotExist(err) {
+		err = os.Remove(sourceDbFilename)
+		if err != nil {
+			assert.Fail("Error removing test db", err)
+			return
+		}
+	}
+
+	db, err := sql.Open("sqlite3", sourceDbFilename)
+	if err != nil {
+		assert.Fail("Error initializing db", err)
+		return
+	}
+	defer db.Close()
+
+	flowpipeDbFilename := filepaths.FlowpipeDBFileName()
+
+	_, err = os.Stat(flowpipeDbFilename)
+	if !os.IsNotExist(err) {
+		err = os.Remove(flowpipeDbFilename)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = store.InitializeFlowpipeDB()
+	if err != nil {
+		assert.Fail("Error initializing db", err)
+		return
+	}
+
+	_, err = db.Exec(`create table orders (id integer primary key, item text)`)
+	if err != nil {
+		assert.Fail("Error creating test table", err)
+		return
+	}
+
+	_, err = db.Exec(`insert into orders (id, item) values (1, 'apple'), (2, 'banana'), (3, 'cherry')`)
+	if err != nil {
+		assert.Fail("Error populating test table", err)
+		return
+	}
+
+	var generatedEvalContext *hcl.EvalContext
+	hclExpressionMock := &util.HclExpressionMock{
+		ValueFunc: func(evalCtx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+			generatedEvalContext = evalCtx
+			return cty.ObjectVal(map[string]cty.Value{
+				"from": cty.StringVal("test"),
+			}), nil
+		},
+	}
+
+	trigger := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "query.test_trigger_cursor",
+		},
+		ArgsRaw: hclExpressionMock,
+	}
+
+	trigger.Config = &resources.TriggerQuery{
+		Database:     "sqlite:./test_trigger_query_cursor.db",
+		Sql:          "select * from orders where ?1 is null or id > ?1 order by id",
+		CursorColumn: "id",
+		Captures: map[string]*resources.TriggerQueryCapture{
+			"insert": {
+				Type:     "insert",
+				Pipeline: cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("insert_pipe")}),
+				ArgsRaw:  hclExpressionMock,
+			},
+		},
+	}
+
+	cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+	triggerRunner := NewTriggerRunner(trigger, util.NewExecutionId(), util.NewTriggerExecutionId())
+
+	insertedIDs := func() []int64 {
+		var ids []int64
+		for _, row := range generatedEvalContext.Variables["self"].AsValueMap()["inserted_rows"].AsValueSlice() {
+			ids = append(ids, util.BigFloatToInt64(row.AsValueMap()["id"].AsBigFloat()))
+		}
+		return ids
+	}
+
+	// the first run captures all the rows
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+	if err != nil {
+		assert.Fail("Error executing trigger", err)
+		return
+	}
+
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal("insert_pipe", pipelineQueues[0].Name)
+	assert.Equal([]int64{1, 2, 3}, insertedIDs())
+
+	flowpipeDb, err := store.OpenFlowpipeDB()
+	if err != nil {
+		assert.Fail("Error opening flowpipe db", err)
+		return
+	}
+	defer flowpipeDb.Close()
+
+	cursor, err := store.GetQueryTriggerCursor(flowpipeDb, trigger.Name())
+	assert.Nil(err)
+	assert.Equal(int64(3), cursor)
+
+	// no new rows, updating a row past the cursor isn't captured
+	_, err = db.Exec(`update orders set item = 'avocado' where id = 1`)
+	assert.Nil(err)
+
+	generatedEvalContext = nil
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+	if err != nil {
+		assert.Fail("Error executing trigger", err)
+		return
+	}
+	assert.Equal(0, len(pipelineQueues))
+
+	// only the rows past the cursor are captured
+	_, err = db.Exec(`insert into orders (id, item) values (5, 'elderberry'), (4, 'date')`)
+	assert.Nil(err)
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+	if err != nil {
+		assert.Fail("Error executing trigger", err)
+		return
+	}
+
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal([]int64{4, 5}, insertedIDs())
+
+	cursor, err = store.GetQueryTriggerCursor(flowpipeDb, trigger.Name())
+	assert.Nil(err)
+	assert.Equal(int64(5), cursor)
+
+	// a query that returns the row at the cursor doesn't capture it again
+	trigger.Config.(*resources.TriggerQuery).Sql = "select * from orders where ?1 is null or id >= ?1 order by id"
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+	if err != nil {
+		assert.Fail("Error executing trigger", err)
+		return
+	}
+	assert.Equal(0, len(pipelineQueues))
+}
+
+func TestCompareCursorValues(t *testing.T) {
+	assert := assert.New(t)
+
+	cmp, err := compareCursorValues(int64(10), 9)
+	assert.Nil(err)
+	assert.Equal(1, cmp)
+
+	cmp, err = compareCursorValues(int32(7), 7.5)
+	assert.Nil(err)
+	assert.Equal(-1, cmp)
+
+	cmp, err = compareCursorValues(uint64(1<<63), int64(1<<62))
+	assert.Nil(err)
+	assert.Equal(1, cmp)
+
+	now := time.Now().UTC()
+	cmp, err = compareCursorValues(now, now.Add(-time.Second))
+	assert.Nil(err)
+	assert.Equal(1, cmp)
+
+	// a time cursor read back as text
+	cmp, err = compareCursorValues(now, now.Format(time.RFC3339Nano))
+	assert.Nil(err)
+	assert.Equal(0, cmp)
+
+	cmp, err = compareCursorValues("2024-01-02", []byte("2024-01-10"))
+	assert.Nil(err)
+	assert.Equal(-1, cmp)
+
+	_, err = compareCursorValues(10, "ten")
+	assert.NotNil(err)
+}
@@ -0,0 +1,162 @@
+package trigger
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/filepaths"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/store"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTriggerQuerySeed(t *testing.T) {
+	ctx := context.Background()
+
+	assert := assert.New(t)
+
+	sourceDbFilename := "./test_trigger_query_seed.db"
+	_, err := os.Stat(sourceDbFilename)
+	if !os.IsNotExist(err) {
+		err = os.Remove(sourceDbFilename)
+		if err != nil {
+			assert.Fail("Error removing test db", err)
+			return
+		}
+	}
+
+	db, err := sql.Open("sqlite3", sourceDbFilename)
+	if err != nil {
+		assert.Fail("Error initializing db", err)
+		return
+	}
+	defer db.Close()
+
+	flowpipeDbFilename := filepaths.FlowpipeDBFileName()
+
+	_, err = os.Stat(flowpipeDbFilename)
+	if !os.IsNotExist(err) {
+		err = os.Remove(flowpipeDbFilename)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = store.InitializeFlowpipeDB()
+	if err != nil {
+		assert.Fail("Error initializing db", err)
+		return
+	}
+
+	_, err = db.Exec(`create table orders (id integer primary key, item text)`)
+	if err != nil {
+		assert.Fail("Error creating test table", err)
+		return
+	}
+
+	_, err = db.Exec(`insert into orders (id, item) values (1, 'apple'), (2, 'banana'), (3, 'cherry')`)
+	if err != nil {
+		assert.Fail("Error populating test table", err)
+		return
+	}
+
+	var generatedEvalContext *hcl.EvalContext
+	hclExpressionMock := &util.HclExpressionMock{
+		ValueFunc: func(evalCtx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+			generatedEvalContext = evalCtx
+			return cty.ObjectVal(map[string]cty.Value{
+				"from": cty.StringVal("test"),
+			}), nil
+		},
+	}
+
+	trigger := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "query.test_trigger_seed",
+		},
+		ArgsRaw: hclExpressionMock,
+	}
+
+	trigger.Config = &resources.TriggerQuery{
+		Database:   "sqlite:./test_trigger_query_seed.db",
+		Sql:        "select * from orders order by id",
+		PrimaryKey: "id",
+		Captures: map[string]*resources.TriggerQueryCapture{
+			"insert": {
+				Type:     "insert",
+				Pipeline: cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("insert_pipe")}),
+				ArgsRaw:  hclExpressionMock,
+			},
+		},
+	}
+
+	cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+	triggerRunner := NewTriggerRunner(trigger, util.NewExecutionId(), util.NewTriggerExecutionId()).(*TriggerRunnerQuery)
+
+	insertedIDs := func() []int64 {
+		var ids []int64
+		for _, row := range generatedEvalContext.Variables["self"].AsValueMap()["inserted_rows"].AsValueSlice() {
+			ids = append(ids, util.BigFloatToInt64(row.AsValueMap()["id"].AsBigFloat()))
+		}
+		return ids
+	}
+
+	flowpipeDb, err := store.OpenFlowpipeDB()
+	if err != nil {
+		assert.Fail("Error opening flowpipe db", err)
+		return
+	}
+	defer flowpipeDb.Close()
+
+	// the seed records the existing rows without capturing them
+	err = triggerRunner.Seed(ctx)
+	if err != nil {
+		assert.Fail("Error seeding trigger", err)
+		return
+	}
+
+	count, err := store.CountQueryTriggerCapturedRows(flowpipeDb, trigger.FullName)
+	assert.Nil(err)
+	assert.Equal(3, count)
+	assert.Nil(generatedEvalContext)
+
+	// only the rows inserted after the seed are captured
+	_, err = db.Exec(`insert into orders (id, item) values (4, 'date')`)
+	assert.Nil(err)
+
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+	if err != nil {
+		assert.Fail("Error executing trigger", err)
+		return
+	}
+
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal([]int64{4}, insertedIDs())
+
+	run, err := store.GetQueryTriggerRun(flowpipeDb, trigger.Name())
+	assert.Nil(err)
+	if assert.NotNil(run) {
+		assert.Equal(1, run.Inserted)
+	}
+
+	// after a reset all the rows are captured again
+	assert.Nil(store.ResetQueryTriggerState(flowpipeDb, trigger.Name()))
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+	if err != nil {
+		assert.Fail("Error executing trigger", err)
+		return
+	}
+
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal([]int64{1, 2, 3, 4}, insertedIDs())
+}
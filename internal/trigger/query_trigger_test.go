@@ -1712,3 +1712,134 @@ func updateTestTableB(db *sql.DB, tableName string, data map[string]interface{})
 
 	return nil
 }
+
+func TestTriggerQueryDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	assert := assert.New(t)
+
+	sourceDbFilename := "./test_trigger_query_dry_run.db"
+	_, err := os.Stat(sourceDbFilename)
+	if !os.IsNotExist(err) {
+		err = os.Remove(sourceDbFilename)
+		if err != nil {
+			assert.Fail("Error removing test db", err)
+			return
+		}
+	}
+
+	db, err := sql.Open("sqlite3", sourceDbFilename)
+	if err != nil {
+		assert.Fail("Error initializing db", err)
+		return
+	}
+	defer db.Close()
+
+	flowpipeDbFilename := filepaths.FlowpipeDBFileName()
+
+	_, err = os.Stat(flowpipeDbFilename)
+	if !os.IsNotExist(err) {
+		err = os.Remove(flowpipeDbFilename)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = store.InitializeFlowpipeDB()
+	if err != nil {
+		assert.Fail("Error initializing db", err)
+		return
+	}
+
+	_, err = db.Exec(`create table orders (id integer primary key, item text)`)
+	if err != nil {
+		assert.Fail("Error creating test table", err)
+		return
+	}
+
+	_, err = db.Exec(`insert into orders (id, item) values (1, 'apple'), (2, 'banana')`)
+	if err != nil {
+		assert.Fail("Error populating test table", err)
+		return
+	}
+
+	hclExpressionMock := &util.HclExpressionMock{
+		ValueFunc: func(evalCtx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+			return cty.ObjectVal(map[string]cty.Value{
+				"from": cty.StringVal("test"),
+			}), nil
+		},
+	}
+
+	trigger := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "query.test_trigger_dry_run",
+		},
+		ArgsRaw: hclExpressionMock,
+	}
+
+	trigger.Config = &resources.TriggerQuery{
+		Database:   "sqlite:./test_trigger_query_dry_run.db",
+		Sql:        "select * from orders order by id",
+		PrimaryKey: "id",
+		Captures: map[string]*resources.TriggerQueryCapture{
+			"insert": {
+				Type:     "insert",
+				Pipeline: cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("insert_pipe")}),
+				ArgsRaw:  hclExpressionMock,
+			},
+		},
+	}
+
+	cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+	flowpipeDb, err := store.OpenFlowpipeDB()
+	if err != nil {
+		assert.Fail("Error opening flowpipe db", err)
+		return
+	}
+	defer flowpipeDb.Close()
+
+	err = store.CreateQueryTriggerRunTable(flowpipeDb)
+	if err != nil {
+		assert.Fail("Error creating query trigger run table", err)
+		return
+	}
+
+	// a dry run reports the rows it would capture without capturing them, so every dry run reports the same rows
+	for i := 0; i < 2; i++ {
+		triggerRunner := NewTriggerRunner(trigger, util.NewExecutionId(), util.NewTriggerExecutionId()).(*TriggerRunnerQuery)
+		triggerRunner.SetDryRun(true)
+
+		pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+		if err != nil {
+			assert.Fail("Error executing trigger", err)
+			return
+		}
+
+		assert.Equal(1, len(pipelineQueues))
+		assert.Equal(2, triggerRunner.QueryStat["insert"])
+
+		count, err := store.CountQueryTriggerCapturedRows(flowpipeDb, trigger.FullName)
+		assert.Nil(err)
+		assert.Equal(0, count)
+
+		run, err := store.GetQueryTriggerRun(flowpipeDb, trigger.Name())
+		assert.Nil(err)
+		assert.Nil(run)
+	}
+
+	// the real run captures the rows
+	triggerRunner := NewTriggerRunner(trigger, util.NewExecutionId(), util.NewTriggerExecutionId()).(*TriggerRunnerQuery)
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(ctx, nil, nil)
+	if err != nil {
+		assert.Fail("Error executing trigger", err)
+		return
+	}
+
+	assert.Equal(1, len(pipelineQueues))
+
+	count, err := store.CountQueryTriggerCapturedRows(flowpipeDb, trigger.FullName)
+	assert.Nil(err)
+	assert.Equal(2, count)
+}
@@ -0,0 +1,278 @@
+package trigger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/es/db"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type TriggerRunnerQueue struct {
+	TriggerRunnerBase
+
+	// The received message, available to the trigger args as self.subject, self.body and self.headers
+	Subject string
+	Body    string
+	Headers map[string]string
+}
+
+// NewTriggerRunnerQueue creates the runner of the queue trigger for the message, the message is nil if the trigger is
+// run on demand
+func NewTriggerRunnerQueue(trigger *resources.Trigger, executionID, triggerExecutionID string, msg *nats.Msg) *TriggerRunnerQueue {
+	tr := &TriggerRunnerQueue{
+		TriggerRunnerBase: TriggerRunnerBase{
+			Trigger:            trigger,
+			rootMod:            trigger.GetMod(),
+			ExecutionID:        executionID,
+			TriggerExecutionID: triggerExecutionID,
+			Type:               localconstants.TriggerTypeQueue,
+		},
+		Headers: map[string]string{},
+	}
+
+	if msg == nil {
+		return tr
+	}
+
+	tr.Subject = msg.Subject
+	tr.Body = string(msg.Data)
+	for k, v := range msg.Header {
+		if len(v) > 0 {
+			tr.Headers[k] = v[0]
+		}
+	}
+
+	return tr
+}
+
+func (tr *TriggerRunnerQueue) GetPipelineQueuesWithArgs(ctx context.Context, args map[string]interface{}, argsString map[string]string) ([]*event.PipelineQueue, error) {
+	triggerRunArgs, err := tr.validate(args, argsString)
+
+	if err != nil {
+		slog.Error("Error validating trigger", "error", err)
+		return nil, err
+	}
+
+	triggerArgs, err := tr.getQueueTriggerArgs(triggerRunArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := tr.execute(ctx, tr.ExecutionID, triggerArgs, tr.Trigger)
+	if err != nil {
+		slog.Error("Error sending pipeline command", "error", err)
+		return nil, err
+	}
+
+	return cmds, nil
+}
+
+// getQueueTriggerArgs evaluates the trigger args with the received message, the pipeline is run with the body and the
+// headers args if the trigger doesn't specify its args
+func (tr *TriggerRunnerQueue) getQueueTriggerArgs(triggerRunArgs map[string]interface{}) (resources.Input, error) {
+
+	evalContext, err := buildEvalContextForTriggerExecution(tr.rootMod, tr.Trigger.Params, tr.Trigger.Config, triggerRunArgs)
+	if err != nil {
+		slog.Error("Error building eval context", "error", err)
+		return nil, perr.InternalWithMessage("Error building eval context")
+	}
+
+	headers := cty.MapValEmpty(cty.String)
+	if len(tr.Headers) > 0 {
+		headers, err = hclhelpers.ConvertInterfaceToCtyValue(tr.Headers)
+		if err != nil {
+			slog.Error("Error converting message headers", "error", err)
+			return nil, perr.InternalWithMessage("Error converting message headers")
+		}
+	}
+
+	evalContext.Variables["self"] = cty.ObjectVal(map[string]cty.Value{
+		"subject": cty.StringVal(tr.Subject),
+		"body":    cty.StringVal(tr.Body),
+		"headers": headers,
+	})
+
+	latestTrigger, err := db.GetTrigger(tr.Trigger.Name())
+	if err != nil {
+		slog.Error("Error getting latest trigger", "trigger", tr.Trigger.Name(), "error", err)
+		return nil, perr.NotFoundWithMessage("trigger not found")
+	}
+
+	if latestTrigger.ArgsRaw == nil {
+		return resources.Input{
+			"body":    tr.Body,
+			"headers": tr.Headers,
+		}, nil
+	}
+
+	pipelineArgs, diags := latestTrigger.GetArgs(evalContext)
+	if diags.HasErrors() {
+		slog.Error("Error getting trigger args", "trigger", tr.Trigger.Name(), "errors", diags)
+		err := error_helpers.HclDiagsToError("trigger", diags)
+		return nil, err
+	}
+
+	return pipelineArgs, nil
+}
+
+// QueueSubscriber receives the messages of a queue trigger, the handler is called for each message. The messages of a
+// JetStream consumer are acked by the handler, see TrackQueueMessage.
+type QueueSubscriber struct {
+	config  *resources.TriggerQueue
+	handler func(msg *nats.Msg)
+
+	conn *nats.Conn
+}
+
+func NewQueueSubscriber(config *resources.TriggerQueue, handler func(msg *nats.Msg)) *QueueSubscriber {
+	return &QueueSubscriber{
+		config:  config,
+		handler: handler,
+	}
+}
+
+// Start connects to the NATS server and subscribes to the subject. The JetStream consumer is created if it doesn't
+// exist, the connection is re-established if it is lost.
+func (s *QueueSubscriber) Start() error {
+	conn, err := nats.Connect(s.config.Url, nats.Name("flowpipe"), nats.MaxReconnects(-1))
+	if err != nil {
+		return perr.InternalWithMessage("error connecting to " + s.config.Url + ": " + err.Error())
+	}
+
+	if !s.config.IsJetStream() {
+		_, err = conn.Subscribe(s.config.Subject, s.handler)
+		if err != nil {
+			conn.Close()
+			return perr.InternalWithMessage("error subscribing to " + s.config.Subject + ": " + err.Error())
+		}
+
+		s.conn = conn
+		return nil
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return perr.InternalWithMessage("error connecting to JetStream: " + err.Error())
+	}
+
+	opts := []nats.SubOpt{nats.Durable(s.config.Consumer), nats.ManualAck()}
+	if s.config.Stream != "" {
+		opts = append(opts, nats.BindStream(s.config.Stream))
+	}
+
+	_, err = js.Subscribe(s.config.Subject, s.handler, opts...)
+	if err != nil {
+		conn.Close()
+		return perr.InternalWithMessage("error subscribing to consumer " + s.config.Consumer + ": " + err.Error())
+	}
+
+	s.conn = conn
+	return nil
+}
+
+// Stop closes the connection. The subscription isn't unsubscribed: the library deletes the consumer it has created on
+// unsubscribe, the durable consumer keeps the messages that are not acked yet for the next subscription.
+func (s *QueueSubscriber) Stop() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// The interval at which the messages of the running executions are marked in progress, well within the default
+// JetStream ack wait of 30s
+var queueMessageProgressInterval = 10 * time.Second
+
+// The message of a failed execution is redelivered after a delay growing with the number of deliveries, so that a
+// message that keeps failing doesn't run the pipeline in a loop
+var (
+	queueMessageRedeliveryDelay    = 5 * time.Second
+	maxQueueMessageRedeliveryDelay = 5 * time.Minute
+)
+
+type queueMessage struct {
+	msg  *nats.Msg
+	done chan struct{}
+}
+
+// The JetStream messages of the running executions of the queue triggers, keyed by the execution ID
+var (
+	queueMessagesMu sync.Mutex
+	queueMessages   = map[string]*queueMessage{}
+)
+
+// TrackQueueMessage keeps the JetStream message in progress until the execution ends, see EndQueueMessage, so that
+// it isn't redelivered while the pipeline is running
+func TrackQueueMessage(executionID string, msg *nats.Msg) {
+	qm := &queueMessage{
+		msg:  msg,
+		done: make(chan struct{}),
+	}
+
+	queueMessagesMu.Lock()
+	queueMessages[executionID] = qm
+	queueMessagesMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(queueMessageProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-qm.done:
+				return
+			case <-ticker.C:
+				if err := msg.InProgress(); err != nil {
+					slog.Warn("Error marking queue message in progress", "execution_id", executionID, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// EndQueueMessage acks the JetStream message of the ended execution if the execution has finished. The message is
+// redelivered with a delay if the execution has failed, it is terminated if the execution has been cancelled.
+func EndQueueMessage(executionID, state string) {
+	queueMessagesMu.Lock()
+	qm, ok := queueMessages[executionID]
+	delete(queueMessages, executionID)
+	queueMessagesMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	close(qm.done)
+
+	var err error
+	switch state {
+	case localconstants.StateFinished:
+		err = qm.msg.Ack()
+	case localconstants.StateFailed:
+		err = qm.msg.NakWithDelay(redeliveryDelay(qm.msg))
+	default:
+		err = qm.msg.Term()
+	}
+
+	if err != nil {
+		slog.Error("Error acknowledging queue message", "execution_id", executionID, "state", state, "error", err)
+	}
+}
+
+func redeliveryDelay(msg *nats.Msg) time.Duration {
+	delay := queueMessageRedeliveryDelay
+	if metadata, err := msg.Metadata(); err == nil && metadata.NumDelivered > 1 {
+		delay *= time.Duration(metadata.NumDelivered)
+	}
+	return min(delay, maxQueueMessageRedeliveryDelay)
+}
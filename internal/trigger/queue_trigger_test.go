@@ -0,0 +1,239 @@
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// startNatsServer starts an embedded NATS server with JetStream on a random port
+func startNatsServer(t *testing.T) *server.Server {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	t.Cleanup(ns.Shutdown)
+
+	return ns
+}
+
+func receiveQueueMessage(t *testing.T, messages chan *nats.Msg) *nats.Msg {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+		return nil
+	}
+}
+
+func TestQueueSubscriber(t *testing.T) {
+	assert := assert.New(t)
+
+	ns := startNatsServer(t)
+	messages := make(chan *nats.Msg, 10)
+
+	subscriber := NewQueueSubscriber(&resources.TriggerQueue{
+		Url:     ns.ClientURL(),
+		Subject: "orders.>",
+	}, func(msg *nats.Msg) {
+		messages <- msg
+	})
+	if err := subscriber.Start(); err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	defer subscriber.Stop()
+
+	conn, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	defer conn.Close()
+
+	msg := nats.NewMsg("orders.created")
+	msg.Data = []byte(`{"id":1}`)
+	msg.Header.Set("Order-Source", "web")
+	assert.Nil(conn.PublishMsg(msg))
+
+	// not matching the subject
+	assert.Nil(conn.Publish("invoices.created", []byte(`{"id":2}`)))
+
+	received := receiveQueueMessage(t, messages)
+	assert.Equal("orders.created", received.Subject)
+	assert.Equal(`{"id":1}`, string(received.Data))
+	assert.Equal("web", received.Header.Get("Order-Source"))
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(0, len(messages))
+}
+
+func TestQueueSubscriberJetStream(t *testing.T) {
+	assert := assert.New(t)
+
+	ns := startNatsServer(t)
+
+	conn, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	defer conn.Close()
+
+	js, err := conn.JetStream()
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	_, err = js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+
+	config := &resources.TriggerQueue{
+		Url:      ns.ClientURL(),
+		Subject:  "orders.created",
+		Stream:   "ORDERS",
+		Consumer: "flowpipe",
+	}
+	messages := make(chan *nats.Msg, 10)
+
+	subscriber := NewQueueSubscriber(config, func(msg *nats.Msg) {
+		messages <- msg
+	})
+	if err := subscriber.Start(); err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+
+	_, err = js.Publish("orders.created", []byte(`{"id":1}`))
+	assert.Nil(err)
+
+	// the message of a failed execution is redelivered after the delay
+	previousDelay := queueMessageRedeliveryDelay
+	queueMessageRedeliveryDelay = 500 * time.Millisecond
+	defer func() {
+		queueMessageRedeliveryDelay = previousDelay
+	}()
+
+	msg := receiveQueueMessage(t, messages)
+	TrackQueueMessage("exec_failed", msg)
+	failedAt := time.Now()
+	EndQueueMessage("exec_failed", localconstants.StateFailed)
+
+	msg = receiveQueueMessage(t, messages)
+	assert.GreaterOrEqual(time.Since(failedAt), 400*time.Millisecond)
+	assert.Equal(`{"id":1}`, string(msg.Data))
+	metadata, err := msg.Metadata()
+	assert.Nil(err)
+	assert.Equal(uint64(2), metadata.NumDelivered)
+
+	// the message of a finished execution is acked
+	TrackQueueMessage("exec_finished", msg)
+	EndQueueMessage("exec_finished", localconstants.StateFinished)
+
+	assert.Eventually(func() bool {
+		info, err := js.ConsumerInfo("ORDERS", "flowpipe")
+		return err == nil && info.NumAckPending == 0 && info.NumPending == 0
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// the durable consumer is kept when the subscription is stopped, the messages published meanwhile are received
+	// by the next subscription
+	subscriber.Stop()
+
+	_, err = js.Publish("orders.created", []byte(`{"id":2}`))
+	assert.Nil(err)
+
+	subscriber = NewQueueSubscriber(config, func(msg *nats.Msg) {
+		messages <- msg
+	})
+	if err := subscriber.Start(); err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	defer subscriber.Stop()
+
+	msg = receiveQueueMessage(t, messages)
+	assert.Equal(`{"id":2}`, string(msg.Data))
+	assert.Nil(msg.Ack())
+}
+
+func TestTriggerQueueArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	pipelineCty := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("process_order"),
+	})
+
+	trigger := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "local.trigger.queue.test_queue_trigger",
+		},
+		Pipeline: pipelineCty,
+		Config: &resources.TriggerQueue{
+			Url:     localconstants.DefaultQueueTriggerUrl,
+			Subject: "orders.>",
+		},
+	}
+	cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+	msg := nats.NewMsg("orders.created")
+	msg.Data = []byte(`{"id":1}`)
+	msg.Header.Set("Order-Source", "web")
+
+	executionID := util.NewExecutionId()
+	triggerRunner := NewTriggerRunnerQueue(trigger, executionID, "", msg)
+
+	// without args the pipeline is run with the message
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal("process_order", pipelineQueues[0].Name)
+	assert.Equal("local.trigger.queue.test_queue_trigger", pipelineQueues[0].Trigger)
+	assert.Equal(executionID, pipelineQueues[0].Event.ExecutionID)
+	assert.Equal(resources.Input{"body": `{"id":1}`, "headers": map[string]string{"Order-Source": "web"}}, pipelineQueues[0].Args)
+
+	// the args refer to the message with self
+	argsExpr, diags := hclsyntax.ParseExpression([]byte(`{ order = jsondecode(self.body).id, subject = self.subject, source = self.headers["Order-Source"] }`), "test.fp", hcl.InitialPos)
+	assert.False(diags.HasErrors())
+	trigger.ArgsRaw = argsExpr
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal("orders.created", pipelineQueues[0].Args["subject"])
+	assert.Equal("web", pipelineQueues[0].Args["source"])
+	assert.EqualValues(1, pipelineQueues[0].Args["order"])
+
+	// run on demand, without a message
+	triggerRunner = NewTriggerRunnerQueue(trigger, util.NewExecutionId(), "", nil)
+	trigger.ArgsRaw = nil
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(resources.Input{"body": "", "headers": map[string]string{}}, pipelineQueues[0].Args)
+}
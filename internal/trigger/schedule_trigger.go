@@ -0,0 +1,91 @@
+package trigger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/es/db"
+	"github.com/turbot/flowpipe/internal/es/event"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type TriggerRunnerSchedule struct {
+	TriggerRunnerBase
+
+	// The time the trigger was scheduled to run, available to the trigger args as self.scheduled_time. A missed run
+	// replayed at startup is run with the time it should have run, a trigger run on demand with the current time.
+	ScheduledTime time.Time
+}
+
+func NewTriggerRunnerSchedule(trigger *resources.Trigger, executionID, triggerExecutionID string, scheduledTime time.Time) *TriggerRunnerSchedule {
+	return &TriggerRunnerSchedule{
+		TriggerRunnerBase: TriggerRunnerBase{
+			Trigger:            trigger,
+			rootMod:            trigger.GetMod(),
+			ExecutionID:        executionID,
+			TriggerExecutionID: triggerExecutionID,
+			Type:               schema.TriggerTypeSchedule,
+		},
+		ScheduledTime: scheduledTime,
+	}
+}
+
+func (tr *TriggerRunnerSchedule) GetPipelineQueuesWithArgs(ctx context.Context, args map[string]interface{}, argsString map[string]string) ([]*event.PipelineQueue, error) {
+	triggerRunArgs, err := tr.validate(args, argsString)
+
+	if err != nil {
+		slog.Error("Error validating trigger", "error", err)
+		return nil, err
+	}
+
+	triggerArgs, err := tr.getScheduleTriggerArgs(triggerRunArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := tr.execute(ctx, tr.ExecutionID, triggerArgs, tr.Trigger)
+	if err != nil {
+		slog.Error("Error sending pipeline command", "error", err)
+		return nil, err
+	}
+
+	return cmds, nil
+}
+
+func (tr *TriggerRunnerSchedule) getScheduleTriggerArgs(triggerRunArgs map[string]interface{}) (resources.Input, error) {
+
+	evalContext, err := buildEvalContextForTriggerExecution(tr.rootMod, tr.Trigger.Params, tr.Trigger.Config, triggerRunArgs)
+	if err != nil {
+		slog.Error("Error building eval context", "error", err)
+		return nil, perr.InternalWithMessage("Error building eval context")
+	}
+
+	scheduledTime := tr.ScheduledTime
+	if scheduledTime.IsZero() {
+		scheduledTime = time.Now()
+	}
+
+	evalContext.Variables["self"] = cty.ObjectVal(map[string]cty.Value{
+		"scheduled_time": cty.StringVal(scheduledTime.UTC().Format(time.RFC3339)),
+	})
+
+	latestTrigger, err := db.GetTrigger(tr.Trigger.Name())
+	if err != nil {
+		slog.Error("Error getting latest trigger", "trigger", tr.Trigger.Name(), "error", err)
+		return nil, perr.NotFoundWithMessage("trigger not found")
+	}
+
+	pipelineArgs, diags := latestTrigger.GetArgs(evalContext)
+	if diags.HasErrors() {
+		slog.Error("Error getting trigger args", "trigger", tr.Trigger.Name(), "errors", diags)
+		err := error_helpers.HclDiagsToError("trigger", diags)
+		return nil, err
+	}
+
+	return pipelineArgs, nil
+}
@@ -0,0 +1,66 @@
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/cache"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTriggerScheduleArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	argsExpr, diags := hclsyntax.ParseExpression([]byte(`{ since = self.scheduled_time }`), "test.fp", hcl.InitialPos)
+	assert.False(diags.HasErrors())
+
+	trigger := &resources.Trigger{
+		HclResourceImpl: modconfig.HclResourceImpl{
+			FullName: "local.trigger.schedule.test_schedule_trigger",
+		},
+		Pipeline: cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal("daily_report"),
+		}),
+		ArgsRaw: argsExpr,
+		Config: &resources.TriggerSchedule{
+			Schedule: "daily",
+			Timezone: "America/New_York",
+			CatchUp:  "latest",
+		},
+	}
+	cache.GetCache().SetWithTTL(trigger.Name(), trigger, 10*time.Minute)
+
+	triggerRunner := NewTriggerRunner(trigger, util.NewExecutionId(), "")
+	scheduleRunner, ok := triggerRunner.(*TriggerRunnerSchedule)
+	if !ok {
+		assert.FailNow("schedule trigger runner expected")
+		return
+	}
+
+	// a missed run is run with the time it was scheduled to run
+	scheduleRunner.ScheduledTime = time.Date(2024, 3, 2, 9, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	pipelineQueues, err := triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(1, len(pipelineQueues))
+	assert.Equal("daily_report", pipelineQueues[0].Name)
+	assert.Equal(resources.Input{"since": "2024-03-02T14:00:00Z"}, pipelineQueues[0].Args)
+
+	// a run on demand is run with the current time
+	scheduleRunner.ScheduledTime = time.Time{}
+
+	pipelineQueues, err = triggerRunner.GetPipelineQueuesWithArgs(context.Background(), nil, nil)
+	assert.Nil(err)
+	assert.Equal(1, len(pipelineQueues))
+
+	since, err := time.Parse(time.RFC3339, pipelineQueues[0].Args["since"].(string))
+	assert.Nil(err)
+	assert.WithinDuration(time.Now(), since, time.Minute)
+}
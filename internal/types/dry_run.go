@@ -0,0 +1,204 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/turbot/pipe-fittings/printers"
+	"github.com/turbot/pipe-fittings/sanitize"
+)
+
+type CmdDryRun struct {
+	Args       map[string]interface{} `json:"args,omitempty"`
+	ArgsString map[string]string      `json:"args_string,omitempty"`
+}
+
+// FpTriggerDryRun is what a trigger run would do: the pipelines it would queue with their resolved args
+type FpTriggerDryRun struct {
+	Name      string                    `json:"name"`
+	Type      string                    `json:"type"`
+	Pipelines []FpTriggerDryRunPipeline `json:"pipelines"`
+
+	// The number of rows a query trigger would capture, by capture type
+	QueryStat map[string]int `json:"query_stat,omitempty"`
+}
+
+type FpTriggerDryRunPipeline struct {
+	Pipeline string                 `json:"pipeline"`
+	Capture  string                 `json:"capture,omitempty"`
+	Args     map[string]interface{} `json:"args"`
+}
+
+func (t FpTriggerDryRun) String(sanitizer *sanitize.Sanitizer, opts sanitize.RenderOptions) string {
+	au := aurora.NewAurora(opts.ColorEnabled)
+
+	// deliberately shadow the receiver with a sanitized version of the struct
+	var err error
+	if t, err = sanitize.SanitizeStruct(sanitizer, t); err != nil {
+		return ""
+	}
+
+	output := fmt.Sprintf("%-*s%s %s%s%s\n", 10, au.Blue("Name:"), t.Name, au.BrightBlack("["), au.Yellow("dry run"), au.BrightBlack("]"))
+	output += fmt.Sprintf("%-*s%s\n", 10, au.Blue("Type:"), t.Type)
+
+	if t.QueryStat != nil {
+		output += fmt.Sprintf("%s\n", au.Blue("Rows:"))
+		for _, captureType := range []string{"insert", "update", "delete"} {
+			output += fmt.Sprintf("  %s %d\n", au.Cyan(captureType+":"), t.QueryStat[captureType])
+		}
+	}
+
+	if len(t.Pipelines) == 0 {
+		output += fmt.Sprintf("%-*s%s\n", 10, au.Blue("Pipeline:"), au.BrightBlack("none would be queued"))
+		return output
+	}
+
+	for _, p := range t.Pipelines {
+		pipelineText := p.Pipeline
+		if p.Capture != "" {
+			pipelineText += " " + au.Sprintf(au.BrightBlack("("+p.Capture+")"))
+		}
+		output += fmt.Sprintf("%-*s%s\n", 10, au.Blue("Pipeline:"), pipelineText)
+		output += sortAndParseMap(p.Args, "", " ", au, opts)
+	}
+
+	return output
+}
+
+type PrintableTriggerDryRun struct {
+	Items []FpTriggerDryRun
+}
+
+func NewPrintableTriggerDryRun(input *FpTriggerDryRun) *PrintableTriggerDryRun {
+	return &PrintableTriggerDryRun{
+		Items: []FpTriggerDryRun{*input},
+	}
+}
+
+func (p PrintableTriggerDryRun) GetItems() []FpTriggerDryRun {
+	return p.Items
+}
+
+func (p PrintableTriggerDryRun) GetTable() (*printers.Table, error) {
+	var tableRows []printers.TableRow
+	for _, item := range p.Items {
+		for _, pipeline := range item.Pipelines {
+			cells := []any{
+				item.Name,
+				pipeline.Pipeline,
+				pipeline.Capture,
+				len(pipeline.Args),
+			}
+			tableRows = append(tableRows, printers.TableRow{Cells: cells})
+		}
+	}
+
+	return printers.NewTable().WithData(tableRows, p.getColumns()), nil
+}
+
+func (PrintableTriggerDryRun) getColumns() (columns []string) {
+	return []string{"NAME", "PIPELINE", "CAPTURE", "ARGS"}
+}
+
+// FpPipelineDryRun is what a pipeline run would do: the validated args and the steps in the order they would start
+type FpPipelineDryRun struct {
+	Pipeline string                 `json:"pipeline"`
+	Args     map[string]interface{} `json:"args"`
+	Steps    []FpPipelineDryRunStep `json:"steps"`
+}
+
+// FpPipelineDryRunStep is a step of the pipeline. The steps of a stage start once the steps of the previous stages
+// are complete. The input is resolved if it only refers to the params, variables and locals of the pipeline, the input
+// that refers to the output of another step is only known when the pipeline runs.
+type FpPipelineDryRunStep struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Stage      int                    `json:"stage"`
+	DependsOn  []string               `json:"depends_on,omitempty"`
+	ForEach    bool                   `json:"for_each,omitempty"`
+	Skip       bool                   `json:"skip,omitempty"`
+	Input      map[string]interface{} `json:"input,omitempty"`
+	Unresolved string                 `json:"unresolved,omitempty"`
+}
+
+func (p FpPipelineDryRun) String(sanitizer *sanitize.Sanitizer, opts sanitize.RenderOptions) string {
+	au := aurora.NewAurora(opts.ColorEnabled)
+
+	// deliberately shadow the receiver with a sanitized version of the struct
+	var err error
+	if p, err = sanitize.SanitizeStruct(sanitizer, p); err != nil {
+		return ""
+	}
+
+	output := fmt.Sprintf("%-*s%s %s%s%s\n", 10, au.Blue("Pipeline:"), p.Pipeline, au.BrightBlack("["), au.Yellow("dry run"), au.BrightBlack("]"))
+	if len(p.Args) > 0 {
+		output += fmt.Sprintf("%s\n", au.Blue("Args:"))
+		output += sortAndParseMap(p.Args, "", " ", au, opts)
+	}
+
+	output += fmt.Sprintf("%s\n", au.Blue("Steps:"))
+	for _, s := range p.Steps {
+		var notes []string
+		if len(s.DependsOn) > 0 {
+			notes = append(notes, "after "+strings.Join(s.DependsOn, ", "))
+		}
+		if s.ForEach {
+			notes = append(notes, "for each")
+		}
+
+		stepText := fmt.Sprintf("  %s %s", au.BrightBlack(fmt.Sprintf("%d.", s.Stage)), s.Name)
+		if len(notes) > 0 {
+			stepText += " " + au.Sprintf(au.BrightBlack("("+strings.Join(notes, "; ")+")"))
+		}
+
+		switch {
+		case s.Skip:
+			output += fmt.Sprintf("%s %s\n", stepText, au.BrightBlack("Skipped"))
+		case s.Unresolved != "":
+			output += fmt.Sprintf("%s\n", stepText)
+			output += fmt.Sprintf("     %s\n", au.BrightBlack(s.Unresolved))
+		default:
+			output += fmt.Sprintf("%s\n", stepText)
+			output += sortAndParseMap(s.Input, "", "    ", au, opts)
+		}
+	}
+
+	return output
+}
+
+type PrintablePipelineDryRun struct {
+	Items []FpPipelineDryRun
+}
+
+func NewPrintablePipelineDryRun(input *FpPipelineDryRun) *PrintablePipelineDryRun {
+	return &PrintablePipelineDryRun{
+		Items: []FpPipelineDryRun{*input},
+	}
+}
+
+func (p PrintablePipelineDryRun) GetItems() []FpPipelineDryRun {
+	return p.Items
+}
+
+func (p PrintablePipelineDryRun) GetTable() (*printers.Table, error) {
+	var tableRows []printers.TableRow
+	for _, item := range p.Items {
+		for _, step := range item.Steps {
+			cells := []any{
+				item.Pipeline,
+				step.Stage,
+				step.Name,
+				strings.Join(step.DependsOn, ", "),
+				step.Skip,
+			}
+			tableRows = append(tableRows, printers.TableRow{Cells: cells})
+		}
+	}
+
+	return printers.NewTable().WithData(tableRows, p.getColumns()), nil
+}
+
+func (PrintablePipelineDryRun) getColumns() (columns []string) {
+	return []string{"PIPELINE", "STAGE", "STEP", "DEPENDS ON", "SKIP"}
+}
@@ -256,6 +256,8 @@ func pipelineStepFromApiResponse(apiStep map[string]any) (resources.PipelineStep
 		step = &resources.PipelineStepFunction{}
 	case schema.BlockTypePipelineStepContainer:
 		step = &resources.PipelineStepContainer{}
+	case localconstants.BlockTypePipelineStepCommand:
+		step = &resources.PipelineStepCommand{}
 	case schema.BlockTypePipelineStepInput:
 		step = &resources.PipelineStepInput{}
 	case schema.BlockTypePipelineStepMessage:
@@ -15,10 +15,14 @@ import (
 
 // The definition of a single Flowpipe Process
 type Process struct {
-	ID        string    `json:"execution_id"`
-	Pipeline  string    `json:"pipeline"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string    `json:"execution_id"`
+	Pipeline   string    `json:"pipeline"`
+	Trigger    string    `json:"trigger,omitempty"`
+	Status     string    `json:"status"`
+	FailedStep bool      `json:"failed_step,omitempty"`
+	RetryOf    string    `json:"retry_of,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 func (p Process) String(sanitizer *sanitize.Sanitizer, opts sanitize.RenderOptions) string {
@@ -33,7 +37,13 @@ func (p Process) String(sanitizer *sanitize.Sanitizer, opts sanitize.RenderOptio
 
 	output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Execution ID:"), p.ID)
 	output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Pipeline:"), p.Pipeline)
+	if p.Trigger != "" {
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Trigger:"), p.Trigger)
+	}
 	output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Status:"), p.Status)
+	if p.RetryOf != "" {
+		output += fmt.Sprintf("%-*s%s (attempt %d)\n", keyWidth, au.Blue("Retry of:"), p.RetryOf, p.Attempt)
+	}
 	output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Created:"), p.CreatedAt.Local().Format(time.DateTime))
 	return output
 }
@@ -86,6 +96,7 @@ func (p PrintableProcess) GetTable() (*printers.Table, error) {
 		cells := []any{
 			item.ID,
 			item.Pipeline,
+			item.Trigger,
 			item.CreatedAt.Local().Format(time.DateTime),
 			item.Status,
 		}
@@ -96,7 +107,7 @@ func (p PrintableProcess) GetTable() (*printers.Table, error) {
 }
 
 func (PrintableProcess) getColumns() (columns []string) {
-	return []string{"EXECUTION_ID", "PIPELINE", "CREATED_AT", "STATUS"}
+	return []string{"EXECUTION_ID", "PIPELINE", "TRIGGER", "CREATED_AT", "STATUS"}
 }
 
 // This type is used by the API to return a list of processs.
@@ -142,8 +153,19 @@ type ListProcessLogJSONResponse struct {
 	NextToken *string              `json:"next_token,omitempty"`
 }
 
+// ListProcessRequestQuery filters and sorts the processes, the paging parameters are in ListRequestQuery
+type ListProcessRequestQuery struct {
+	Pipeline      string     `json:"pipeline,omitempty" form:"pipeline" binding:"omitempty"`
+	Status        string     `json:"status,omitempty" form:"status" binding:"omitempty,oneof=queued started finished failed cancelled"`
+	Trigger       string     `json:"trigger,omitempty" form:"trigger" binding:"omitempty"`
+	StartedBefore *time.Time `json:"started_before,omitempty" form:"started_before" time_format:"2006-01-02T15:04:05Z07:00" binding:"omitempty"`
+	StartedAfter  *time.Time `json:"started_after,omitempty" form:"started_after" time_format:"2006-01-02T15:04:05Z07:00" binding:"omitempty"`
+	FailedStep    *bool      `json:"failed_step,omitempty" form:"failed_step" binding:"omitempty"`
+	Sort          string     `json:"sort,omitempty" form:"sort" binding:"omitempty,oneof=asc desc"`
+}
+
 type CmdProcess struct {
-	Command             string `json:"command" binding:"required,oneof=resume"`
+	Command             string `json:"command" binding:"required,oneof=resume pause retry cancel"`
 	PipelineExecutionID string `json:"pipeline_execution_id,omitempty" format:"^(pexec)_[0-9a-v]{20}$"`
 	Reason              string `json:"reason,omitempty"`
 }
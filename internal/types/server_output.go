@@ -283,9 +283,14 @@ type ServerOutputTrigger struct {
 	Type     string
 	Enabled  *bool
 	Schedule *string
+	Timezone *string
 	Method   *string
 	Url      *string
 	Sql      *string
+	Path     *string
+	Subject  *string
+	Upstream *string
+	Mailbox  *string
 }
 
 func NewServerOutputTrigger(prefix ServerOutputPrefix, n string, t string, e *bool) *ServerOutputTrigger {
@@ -322,10 +327,26 @@ func (o ServerOutputTrigger) String(sanitizer *sanitize.Sanitizer, opts sanitize
 	case "schedule", "interval":
 		s := kitTypes.SafeString(o.Schedule)
 		suffix = fmt.Sprintf("Schedule: %s", au.Blue(s))
+		if o.Timezone != nil {
+			suffix += fmt.Sprintf(" (%s)", au.Blue(*o.Timezone))
+		}
 	case "query":
 		s := kitTypes.SafeString(o.Schedule)
 		q := kitTypes.SafeString(o.Sql)
 		suffix = fmt.Sprintf("Schedule: %s - Query: %s", au.Blue(s), au.Blue(q))
+	case "file":
+		p := kitTypes.SafeString(o.Path)
+		suffix = fmt.Sprintf("Path: %s", au.Blue(p))
+	case "queue":
+		s := kitTypes.SafeString(o.Subject)
+		suffix = fmt.Sprintf("Subject: %s", au.Blue(s))
+	case "pipeline":
+		u := kitTypes.SafeString(o.Upstream)
+		suffix = fmt.Sprintf("Upstream: %s", au.Blue(u))
+	case "email":
+		s := kitTypes.SafeString(o.Schedule)
+		m := kitTypes.SafeString(o.Mailbox)
+		suffix = fmt.Sprintf("Schedule: %s - Mailbox: %s", au.Blue(s), au.Blue(m))
 	default:
 		suffix = "loaded"
 	}
@@ -36,9 +36,26 @@ type FpTrigger struct {
 	Documentation   *string             `json:"documentation,omitempty"`
 	Tags            map[string]string   `json:"tags,omitempty"`
 	Schedule        *string             `json:"schedule,omitempty"`
+	Timezone        *string             `json:"timezone,omitempty"`
 	Query           *string             `json:"query,omitempty"`
+	Path            *string             `json:"path,omitempty"`
+	Subject         *string             `json:"subject,omitempty"`
+	Upstream        *string             `json:"upstream,omitempty"`
+	Statuses        []string            `json:"statuses,omitempty"`
+	Mailbox         *string             `json:"mailbox,omitempty"`
+	Overlap         *string             `json:"overlap,omitempty"`
 	RootMod         string              `json:"root_mod"`
 	Params          []FpPipelineParam   `json:"params,omitempty"`
+	Events          []FpTriggerEvent    `json:"events,omitempty"`
+}
+
+// FpTriggerEvent is a fire of the trigger that didn't run its pipeline as usual, e.g. it was skipped because the
+// previous execution was still running
+type FpTriggerEvent struct {
+	Type        string    `json:"type"`
+	ExecutionID string    `json:"execution_id,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type FpTriggerPipeline struct {
@@ -94,7 +111,40 @@ func (t FpTrigger) String(_ *sanitize.Sanitizer, opts sanitize.RenderOptions) st
 		if t.Schedule != nil {
 			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Schedule:"), *t.Schedule)
 		}
+		if t.Timezone != nil {
+			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Timezone:"), *t.Timezone)
+		}
 		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Pipeline:"), t.getPipelineDisplay(t.Pipelines[0].Pipeline))
+	case localconstants.TriggerTypeFile:
+		if t.Path != nil {
+			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Path:"), *t.Path)
+		}
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Pipeline:"), t.getPipelineDisplay(t.Pipelines[0].Pipeline))
+	case localconstants.TriggerTypeQueue:
+		if t.Subject != nil {
+			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Subject:"), *t.Subject)
+		}
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Pipeline:"), t.getPipelineDisplay(t.Pipelines[0].Pipeline))
+	case localconstants.TriggerTypePipeline:
+		if t.Upstream != nil {
+			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Upstream:"), t.getPipelineDisplay(*t.Upstream))
+		}
+		if len(t.Statuses) > 0 {
+			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Statuses:"), strings.Join(t.Statuses, ", "))
+		}
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Pipeline:"), t.getPipelineDisplay(t.Pipelines[0].Pipeline))
+	case localconstants.TriggerTypeEmail:
+		if t.Schedule != nil {
+			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Schedule:"), *t.Schedule)
+		}
+		if t.Mailbox != nil {
+			output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Mailbox:"), *t.Mailbox)
+		}
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Pipeline:"), t.getPipelineDisplay(t.Pipelines[0].Pipeline))
+	}
+
+	if t.Overlap != nil {
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Overlap:"), *t.Overlap)
 	}
 
 	if len(t.Tags) > 0 {
@@ -104,6 +154,13 @@ func (t FpTrigger) String(_ *sanitize.Sanitizer, opts sanitize.RenderOptions) st
 		}
 	}
 
+	if len(t.Events) > 0 {
+		output += fmt.Sprintf("%s\n", au.Blue("Events:"))
+		for _, e := range t.Events {
+			output += fmt.Sprintf("  %s %s %s %s\n", au.BrightBlack(e.CreatedAt.Local().Format(time.DateTime)), au.Yellow(e.Type), e.ExecutionID, e.Reason)
+		}
+	}
+
 	if strings.HasSuffix(output, "\n\n") {
 		output = strings.TrimSuffix(output, "\n")
 	}
@@ -292,6 +349,89 @@ func (c *CmdTrigger) GetWaitRetry() int {
 	return utils.Deref(c.WaitRetry, localconstants.DefaultWaitRetry)
 }
 
+// FpTriggerState is what a query trigger knows of the rows returned by its query
+type FpTriggerState struct {
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	Rows         int        `json:"rows"`
+	Cursor       *string    `json:"cursor,omitempty"`
+	LastPolledAt *time.Time `json:"last_polled_at,omitempty"`
+	Inserted     int        `json:"inserted"`
+	Updated      int        `json:"updated"`
+	Deleted      int        `json:"deleted"`
+}
+
+func (t FpTriggerState) String(_ *sanitize.Sanitizer, opts sanitize.RenderOptions) string {
+	au := aurora.NewAurora(opts.ColorEnabled)
+	keyWidth := 11
+
+	output := fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Name:"), t.Name)
+	output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Type:"), t.Type)
+	if t.Cursor != nil && *t.Cursor != "" {
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Cursor:"), *t.Cursor)
+	} else if t.Cursor != nil {
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Cursor:"), au.BrightBlack("none"))
+	} else {
+		output += fmt.Sprintf("%-*s%d\n", keyWidth, au.Blue("Rows:"), t.Rows)
+	}
+
+	if t.LastPolledAt == nil {
+		output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Last Poll:"), au.BrightBlack("never"))
+		return output
+	}
+	output += fmt.Sprintf("%-*s%s\n", keyWidth, au.Blue("Last Poll:"), t.LastPolledAt.Local().Format(time.DateTime))
+	output += fmt.Sprintf("  %s %d\n", au.Cyan("Inserted:"), t.Inserted)
+	output += fmt.Sprintf("  %s %d\n", au.Cyan("Updated:"), t.Updated)
+	output += fmt.Sprintf("  %s %d\n", au.Cyan("Deleted:"), t.Deleted)
+
+	return output
+}
+
+type PrintableTriggerState struct {
+	Items []FpTriggerState
+}
+
+func NewPrintableTriggerState(input *FpTriggerState) *PrintableTriggerState {
+	return &PrintableTriggerState{
+		Items: []FpTriggerState{*input},
+	}
+}
+
+func (p PrintableTriggerState) GetItems() []FpTriggerState {
+	return p.Items
+}
+
+func (p PrintableTriggerState) GetTable() (*printers.Table, error) {
+	var tableRows []printers.TableRow
+	for _, item := range p.Items {
+		var lastPolledAt string
+		if item.LastPolledAt != nil {
+			lastPolledAt = item.LastPolledAt.Local().Format(time.DateTime)
+		}
+
+		cells := []any{
+			item.Name,
+			item.Rows,
+			typehelpers.SafeString(item.Cursor),
+			lastPolledAt,
+			item.Inserted,
+			item.Updated,
+			item.Deleted,
+		}
+		tableRows = append(tableRows, printers.TableRow{Cells: cells})
+	}
+
+	return printers.NewTable().WithData(tableRows, p.getColumns()), nil
+}
+
+func (PrintableTriggerState) getColumns() (columns []string) {
+	return []string{"NAME", "ROWS", "CURSOR", "LAST POLL", "INSERTED", "UPDATED", "DELETED"}
+}
+
+type CmdTriggerState struct {
+	Command string `json:"command" binding:"required,oneof=reset seed"`
+}
+
 func FpTriggerFromModTrigger(t resources.Trigger, rootMod string) (*FpTrigger, error) {
 	tt := resources.GetTriggerTypeFromTriggerConfig(t.Config)
 
@@ -310,6 +450,10 @@ func FpTriggerFromModTrigger(t resources.Trigger, rootMod string) (*FpTrigger, e
 		RootMod:         rootMod,
 	}
 
+	if t.Overlap != "" && t.Overlap != localconstants.OverlapAllow {
+		fpTrigger.Overlap = &t.Overlap
+	}
+
 	var pipelineParams []FpPipelineParam
 	for i, param := range t.Params {
 
@@ -362,6 +506,47 @@ func FpTriggerFromModTrigger(t resources.Trigger, rootMod string) (*FpTrigger, e
 	case schema.TriggerTypeSchedule:
 		cfg := t.Config.(*resources.TriggerSchedule)
 		fpTrigger.Schedule = &cfg.Schedule
+		if cfg.Timezone != "" {
+			fpTrigger.Timezone = &cfg.Timezone
+		}
+		pipelineInfo := t.GetPipeline().AsValueMap()
+		pipelineName := pipelineInfo["name"].AsString()
+		fpTrigger.Pipelines = append(fpTrigger.Pipelines, FpTriggerPipeline{
+			CaptureGroup: "default",
+			Pipeline:     pipelineName,
+		})
+	case localconstants.TriggerTypeFile:
+		cfg := t.Config.(*resources.TriggerFile)
+		fpTrigger.Path = &cfg.Path
+		pipelineInfo := t.GetPipeline().AsValueMap()
+		pipelineName := pipelineInfo["name"].AsString()
+		fpTrigger.Pipelines = append(fpTrigger.Pipelines, FpTriggerPipeline{
+			CaptureGroup: "default",
+			Pipeline:     pipelineName,
+		})
+	case localconstants.TriggerTypeQueue:
+		cfg := t.Config.(*resources.TriggerQueue)
+		fpTrigger.Subject = &cfg.Subject
+		pipelineInfo := t.GetPipeline().AsValueMap()
+		pipelineName := pipelineInfo["name"].AsString()
+		fpTrigger.Pipelines = append(fpTrigger.Pipelines, FpTriggerPipeline{
+			CaptureGroup: "default",
+			Pipeline:     pipelineName,
+		})
+	case localconstants.TriggerTypePipeline:
+		cfg := t.Config.(*resources.TriggerPipeline)
+		fpTrigger.Upstream = &cfg.Upstream
+		fpTrigger.Statuses = cfg.Statuses
+		pipelineInfo := t.GetPipeline().AsValueMap()
+		pipelineName := pipelineInfo["name"].AsString()
+		fpTrigger.Pipelines = append(fpTrigger.Pipelines, FpTriggerPipeline{
+			CaptureGroup: "default",
+			Pipeline:     pipelineName,
+		})
+	case localconstants.TriggerTypeEmail:
+		cfg := t.Config.(*resources.TriggerEmail)
+		fpTrigger.Schedule = &cfg.Schedule
+		fpTrigger.Mailbox = &cfg.Mailbox
 		pipelineInfo := t.GetPipeline().AsValueMap()
 		pipelineName := pipelineInfo["name"].AsString()
 		fpTrigger.Pipelines = append(fpTrigger.Pipelines, FpTriggerPipeline{
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+// WorkerJob is a step claimed by a worker, the worker runs the step primitive with the already resolved input
+type WorkerJob struct {
+	ExecutionID            string          `json:"execution_id"`
+	PipelineExecutionID    string          `json:"pipeline_execution_id"`
+	StepExecutionID        string          `json:"step_execution_id"`
+	PipelineName           string          `json:"pipeline_name"`
+	StepName               string          `json:"step_name"`
+	StepType               string          `json:"step_type"`
+	FullyQualifiedStepName string          `json:"fully_qualified_step_name"`
+	Input                  resources.Input `json:"input"`
+}
+
+type WorkerStepRequestURI struct {
+	StepExecutionID string `uri:"step_execution_id" binding:"required"`
+}
+
+type WorkerClaimRequest struct {
+	WorkerID string `json:"worker_id" binding:"required"`
+	// The step types the worker runs, empty for all the step types dispatched to the workers
+	StepTypes []string `json:"step_types,omitempty"`
+}
+
+type WorkerHeartbeatRequest struct {
+	WorkerID string `json:"worker_id" binding:"required"`
+}
+
+type WorkerCompleteRequest struct {
+	WorkerID string            `json:"worker_id" binding:"required"`
+	Output   *resources.Output `json:"output,omitempty"`
+	// Set when the primitive failed to run
+	Error *perr.ErrorModel `json:"error,omitempty"`
+}
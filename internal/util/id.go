@@ -33,3 +33,7 @@ func NewProcessId() string {
 func NewTriggerExecutionId() string {
 	return "texec_" + NewUniqueId()
 }
+
+func NewWorkerId() string {
+	return "worker_" + NewUniqueId()
+}
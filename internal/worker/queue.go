@@ -0,0 +1,320 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+)
+
+// The workers send a heartbeat for every running step, a step whose worker missed the heartbeats for longer than
+// the lease is given to another worker. A step that no worker claims within the claim timeout fails.
+const (
+	DefaultHeartbeatInterval = 10 * time.Second
+	DefaultLeaseTimeout      = 3 * DefaultHeartbeatInterval
+	DefaultClaimTimeout      = 5 * time.Minute
+)
+
+// RemoteStepTypes are the step types that can run on a worker. The other step types (pipeline, input, message)
+// need the server execution state.
+var RemoteStepTypes = []string{
+	schema.BlockTypePipelineStepContainer,
+	schema.BlockTypePipelineStepFunction,
+	schema.BlockTypePipelineStepHttp,
+	schema.BlockTypePipelineStepQuery,
+	schema.BlockTypePipelineStepEmail,
+	schema.BlockTypePipelineStepSleep,
+	schema.BlockTypePipelineStepTransform,
+	constants.BlockTypePipelineStepCommand,
+}
+
+// ParseStepTypes parses a comma separated list of step types, every step type must be one of RemoteStepTypes
+func ParseStepTypes(stepTypes string) ([]string, error) {
+	var result []string
+	for _, stepType := range strings.Split(stepTypes, ",") {
+		stepType = strings.TrimSpace(stepType)
+		if stepType == "" {
+			continue
+		}
+
+		if !slices.Contains(RemoteStepTypes, stepType) {
+			return nil, perr.BadRequestWithMessage("step type " + stepType + " can't run on a worker, must be one of: " + strings.Join(RemoteStepTypes, ", "))
+		}
+		result = append(result, stepType)
+	}
+	return result, nil
+}
+
+// ErrClosed is returned to the steps dispatched to the workers when the queue is closed
+var ErrClosed = errors.New("worker queue closed")
+
+// ErrPaused is the cause of the dispatch context cancellation when the pipeline of the step is paused. A pending step
+// is taken out of the queue and ErrPaused is returned, a step already claimed keeps running on its worker.
+var ErrPaused = errors.New("pipeline paused")
+
+type result struct {
+	output *resources.Output
+	err    error
+}
+
+type job struct {
+	types.WorkerJob
+
+	// the worker running the job, empty while the job is pending
+	workerID string
+	lastSeen time.Time
+
+	resultCh chan result
+}
+
+// Queue holds the steps dispatched to the workers until a worker claims them and reports their output
+type Queue struct {
+	StepTypes    []string
+	LeaseTimeout time.Duration
+	ClaimTimeout time.Duration
+
+	mu      *sync.Mutex
+	pending []*job
+	claimed map[string]*job
+	// closed and replaced every time a job is queued, wakes up the waiting claims
+	wake   chan struct{}
+	closed chan struct{}
+}
+
+// QueueOption defines a type of function to configures the Queue.
+type QueueOption func(*Queue)
+
+func WithLeaseTimeout(leaseTimeout time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.LeaseTimeout = leaseTimeout
+	}
+}
+
+func WithClaimTimeout(claimTimeout time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.ClaimTimeout = claimTimeout
+	}
+}
+
+func NewQueue(stepTypes []string, opts ...QueueOption) *Queue {
+	q := &Queue{
+		StepTypes:    stepTypes,
+		LeaseTimeout: DefaultLeaseTimeout,
+		ClaimTimeout: DefaultClaimTimeout,
+		mu:           &sync.Mutex{},
+		claimed:      map[string]*job{},
+		wake:         make(chan struct{}),
+		closed:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Handles returns true if the step type is run by the workers
+func (q *Queue) Handles(stepType string) bool {
+	if q == nil {
+		return false
+	}
+	return slices.Contains(q.StepTypes, stepType)
+}
+
+// Dispatch queues the step for the workers and blocks until a worker reports the output of the step. The step is
+// taken out of the queue when the context is cancelled or when no worker claims it within the claim timeout.
+func (q *Queue) Dispatch(ctx context.Context, workerJob types.WorkerJob) (*resources.Output, error) {
+	j := &job{
+		WorkerJob: workerJob,
+		resultCh:  make(chan result, 1),
+	}
+
+	q.mu.Lock()
+	q.pending = append(q.pending, j)
+	q.notify()
+	q.mu.Unlock()
+
+	slog.Debug("Step dispatched to the workers", "step_execution_id", j.StepExecutionID, "step_type", j.StepType)
+
+	claimTimer := time.NewTimer(q.ClaimTimeout)
+	defer claimTimer.Stop()
+
+	done := ctx.Done()
+	for {
+		select {
+		case r := <-j.resultCh:
+			return r.output, r.err
+		case <-done:
+			if errors.Is(context.Cause(ctx), ErrPaused) {
+				if !q.removePending(j) {
+					// the worker carries on with the step while the pipeline is paused
+					done = nil
+					continue
+				}
+				return nil, ErrPaused
+			}
+			q.remove(j.StepExecutionID)
+			return nil, perr.InternalWithMessage("step " + j.StepName + " was cancelled while waiting for a worker")
+		case <-claimTimer.C:
+			if q.removePending(j) {
+				slog.Warn("Step not claimed by a worker", "step_execution_id", j.StepExecutionID, "step_type", j.StepType, "claim_timeout", q.ClaimTimeout)
+				return nil, perr.InternalWithMessage("step " + j.StepName + " was not claimed by a worker within " + q.ClaimTimeout.String() + ", no worker running the " + j.StepType + " steps is connected to the server")
+			}
+			// the step is running on a worker, the lease covers it from now on. Check again in case the lease expires
+			// and the step goes back to the queue.
+			claimTimer.Reset(q.ClaimTimeout)
+		case <-q.closed:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// Claim gives the first pending step of the given step types (any step type if empty) to the worker. Claim waits
+// for a step until the context is done, it returns nil if there's no step to run.
+func (q *Queue) Claim(ctx context.Context, workerID string, stepTypes []string) *types.WorkerJob {
+	for {
+		select {
+		case <-q.closed:
+			return nil
+		default:
+		}
+
+		q.mu.Lock()
+		q.requeueExpired()
+		for i, j := range q.pending {
+			if len(stepTypes) > 0 && !slices.Contains(stepTypes, j.StepType) {
+				continue
+			}
+
+			q.pending = slices.Delete(q.pending, i, i+1)
+			j.workerID = workerID
+			j.lastSeen = time.Now()
+			q.claimed[j.StepExecutionID] = j
+			q.mu.Unlock()
+
+			slog.Debug("Step claimed by worker", "step_execution_id", j.StepExecutionID, "worker_id", workerID)
+			workerJob := j.WorkerJob
+			return &workerJob
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil
+		case <-q.closed:
+			return nil
+		}
+	}
+}
+
+// Heartbeat extends the lease of the worker on the step
+func (q *Queue) Heartbeat(stepExecutionID, workerID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, err := q.claimedBy(stepExecutionID, workerID)
+	if err != nil {
+		return err
+	}
+	j.lastSeen = time.Now()
+	return nil
+}
+
+// Complete hands over the output reported by the worker to the dispatcher of the step
+func (q *Queue) Complete(stepExecutionID, workerID string, output *resources.Output, primitiveError error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, err := q.claimedBy(stepExecutionID, workerID)
+	if err != nil {
+		return err
+	}
+	delete(q.claimed, stepExecutionID)
+
+	j.resultCh <- result{output: output, err: primitiveError}
+	return nil
+}
+
+// Len returns the number of pending and claimed steps
+func (q *Queue) Len() (int, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending), len(q.claimed)
+}
+
+// Close releases the dispatched steps and the waiting claims
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-q.closed:
+	default:
+		close(q.closed)
+	}
+}
+
+func (q *Queue) claimedBy(stepExecutionID, workerID string) (*job, error) {
+	j := q.claimed[stepExecutionID]
+	if j == nil {
+		return nil, perr.NotFoundWithMessage("step execution " + stepExecutionID + " is not claimed by a worker")
+	}
+	if j.workerID != workerID {
+		return nil, perr.ConflictWithMessage("step execution " + stepExecutionID + " is claimed by another worker")
+	}
+	return j, nil
+}
+
+func (q *Queue) remove(stepExecutionID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.claimed, stepExecutionID)
+	q.pending = slices.DeleteFunc(q.pending, func(j *job) bool {
+		return j.StepExecutionID == stepExecutionID
+	})
+}
+
+// removePending takes the job out of the queue if no worker claimed it, it returns false if the job is claimed
+func (q *Queue) removePending(j *job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := slices.Index(q.pending, j)
+	if i < 0 {
+		return false
+	}
+	q.pending = slices.Delete(q.pending, i, i+1)
+	return true
+}
+
+// requeueExpired puts back in the queue the steps of the workers that stopped sending heartbeats, must be called
+// with the lock held
+func (q *Queue) requeueExpired() {
+	for id, j := range q.claimed {
+		if time.Since(j.lastSeen) < q.LeaseTimeout {
+			continue
+		}
+
+		slog.Warn("Worker lease expired, requeueing step", "step_execution_id", id, "worker_id", j.workerID)
+		delete(q.claimed, id)
+		j.workerID = ""
+		q.pending = append(q.pending, j)
+	}
+}
+
+// notify wakes up the waiting claims, must be called with the lock held
+func (q *Queue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
@@ -0,0 +1,252 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/pipe-fittings/perr"
+)
+
+func dispatch(q *Queue, job types.WorkerJob) chan result {
+	resultCh := make(chan result, 1)
+	go func() {
+		output, err := q.Dispatch(context.Background(), job)
+		resultCh <- result{output: output, err: err}
+	}()
+	return resultCh
+}
+
+func claim(q *Queue, workerID string, stepTypes []string) *types.WorkerJob {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return q.Claim(ctx, workerID, stepTypes)
+}
+
+func TestQueueDispatchAndComplete(t *testing.T) {
+	assert := assert.New(t)
+
+	q := NewQueue([]string{"container", "function"})
+	assert.True(q.Handles("container"))
+	assert.False(q.Handles("http"))
+
+	resultCh := dispatch(q, types.WorkerJob{StepExecutionID: "sexec_1", StepName: "one", StepType: "container"})
+
+	job := claim(q, "worker_1", nil)
+	if job == nil {
+		assert.FailNow("step not claimed")
+		return
+	}
+	assert.Equal("sexec_1", job.StepExecutionID)
+
+	// only the worker that claimed the step can complete it
+	err := q.Complete("sexec_1", "worker_2", &resources.Output{}, nil)
+	assert.True(perr.IsConflict(err))
+
+	err = q.Complete("sexec_1", "worker_1", &resources.Output{Data: resources.OutputData{"value": "done"}}, nil)
+	assert.Nil(err)
+
+	r := <-resultCh
+	assert.Nil(r.err)
+	assert.Equal("done", r.output.Get("value"))
+
+	// the step is completed once
+	err = q.Complete("sexec_1", "worker_1", &resources.Output{}, nil)
+	assert.True(perr.IsNotFound(err))
+}
+
+func TestQueueClaimStepTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	q := NewQueue([]string{"container", "function"})
+
+	dispatch(q, types.WorkerJob{StepExecutionID: "sexec_1", StepType: "container"})
+	dispatch(q, types.WorkerJob{StepExecutionID: "sexec_2", StepType: "function"})
+
+	// wait for both steps to be queued
+	assert.Eventually(func() bool {
+		pending, _ := q.Len()
+		return pending == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	job := claim(q, "worker_1", []string{"function"})
+	if job == nil {
+		assert.FailNow("function step not claimed")
+		return
+	}
+	assert.Equal("sexec_2", job.StepExecutionID)
+
+	// no more function steps
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Nil(q.Claim(ctx, "worker_1", []string{"function"}))
+
+	job = claim(q, "worker_2", nil)
+	if job == nil {
+		assert.FailNow("container step not claimed")
+		return
+	}
+	assert.Equal("sexec_1", job.StepExecutionID)
+}
+
+func TestQueueLeaseExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	q := NewQueue([]string{"container"}, WithLeaseTimeout(200*time.Millisecond))
+
+	resultCh := dispatch(q, types.WorkerJob{StepExecutionID: "sexec_1", StepType: "container"})
+
+	job := claim(q, "worker_1", nil)
+	if job == nil {
+		assert.FailNow("step not claimed")
+		return
+	}
+
+	// the heartbeat keeps the lease
+	time.Sleep(150 * time.Millisecond)
+	assert.Nil(q.Heartbeat("sexec_1", "worker_1"))
+	time.Sleep(150 * time.Millisecond)
+	assert.Nil(q.Heartbeat("sexec_1", "worker_1"))
+
+	// worker_1 stops sending heartbeats, the step is given to worker_2
+	time.Sleep(250 * time.Millisecond)
+	job = claim(q, "worker_2", nil)
+	if job == nil {
+		assert.FailNow("step not claimed again after the lease expired")
+		return
+	}
+	assert.Equal("sexec_1", job.StepExecutionID)
+
+	assert.True(perr.IsConflict(q.Heartbeat("sexec_1", "worker_1")))
+	assert.True(perr.IsConflict(q.Complete("sexec_1", "worker_1", &resources.Output{}, nil)))
+
+	err := q.Complete("sexec_1", "worker_2", nil, perr.InternalWithMessage("container failed"))
+	assert.Nil(err)
+
+	r := <-resultCh
+	assert.NotNil(r.err)
+	assert.Equal("container failed", r.err.(perr.ErrorModel).Detail)
+}
+
+func TestQueueClose(t *testing.T) {
+	assert := assert.New(t)
+
+	q := NewQueue([]string{"container"})
+	resultCh := dispatch(q, types.WorkerJob{StepExecutionID: "sexec_1", StepType: "container"})
+
+	assert.Eventually(func() bool {
+		pending, _ := q.Len()
+		return pending == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	q.Close()
+
+	r := <-resultCh
+	assert.ErrorIs(r.err, ErrClosed)
+	assert.Nil(claim(q, "worker_1", nil))
+}
+
+func TestQueueClaimTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	q := NewQueue([]string{"container", "function"}, WithClaimTimeout(200*time.Millisecond))
+
+	// no worker claims the container step
+	resultCh := dispatch(q, types.WorkerJob{StepExecutionID: "sexec_1", StepName: "one", StepType: "container"})
+	r := <-resultCh
+	assert.NotNil(r.err)
+	assert.Contains(r.err.(perr.ErrorModel).Detail, "step one was not claimed by a worker within 200ms")
+	pending, claimed := q.Len()
+	assert.Equal(0, pending)
+	assert.Equal(0, claimed)
+
+	// a claimed step waits for its worker past the claim timeout
+	resultCh = dispatch(q, types.WorkerJob{StepExecutionID: "sexec_2", StepName: "two", StepType: "function"})
+	job := claim(q, "worker_1", nil)
+	if job == nil {
+		assert.FailNow("step not claimed")
+		return
+	}
+	time.Sleep(300 * time.Millisecond)
+	assert.Nil(q.Complete("sexec_2", "worker_1", &resources.Output{Data: resources.OutputData{"value": "done"}}, nil))
+
+	r = <-resultCh
+	assert.Nil(r.err)
+	assert.Equal("done", r.output.Get("value"))
+}
+
+func TestQueueDispatchCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	q := NewQueue([]string{"container"})
+
+	// the pending step is taken out of the queue when its pipeline is paused
+	ctx, cancel := context.WithCancelCause(context.Background())
+	resultCh := make(chan result, 1)
+	go func() {
+		output, err := q.Dispatch(ctx, types.WorkerJob{StepExecutionID: "sexec_1", StepName: "one", StepType: "container"})
+		resultCh <- result{output: output, err: err}
+	}()
+	assert.Eventually(func() bool {
+		pending, _ := q.Len()
+		return pending == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel(ErrPaused)
+	r := <-resultCh
+	assert.ErrorIs(r.err, ErrPaused)
+	pending, _ := q.Len()
+	assert.Equal(0, pending)
+
+	// the claimed step carries on while its pipeline is paused
+	ctx, cancel = context.WithCancelCause(context.Background())
+	go func() {
+		output, err := q.Dispatch(ctx, types.WorkerJob{StepExecutionID: "sexec_2", StepName: "two", StepType: "container"})
+		resultCh <- result{output: output, err: err}
+	}()
+	job := claim(q, "worker_1", nil)
+	if job == nil {
+		assert.FailNow("step not claimed")
+		return
+	}
+	cancel(ErrPaused)
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(q.Complete("sexec_2", "worker_1", &resources.Output{}, nil))
+	r = <-resultCh
+	assert.Nil(r.err)
+
+	// the step is taken out of the queue when its pipeline is cancelled, claimed or not
+	ctx, cancel = context.WithCancelCause(context.Background())
+	go func() {
+		output, err := q.Dispatch(ctx, types.WorkerJob{StepExecutionID: "sexec_3", StepName: "three", StepType: "container"})
+		resultCh <- result{output: output, err: err}
+	}()
+	job = claim(q, "worker_1", nil)
+	cancel(nil)
+	if job == nil {
+		assert.FailNow("step not claimed")
+		return
+	}
+	r = <-resultCh
+	assert.NotNil(r.err)
+	assert.Equal("step three was cancelled while waiting for a worker", r.err.(perr.ErrorModel).Detail)
+	assert.True(perr.IsNotFound(q.Complete("sexec_3", "worker_1", &resources.Output{}, nil)))
+}
+
+func TestParseStepTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	stepTypes, err := ParseStepTypes(" container, function,")
+	assert.Nil(err)
+	assert.Equal([]string{"container", "function"}, stepTypes)
+
+	stepTypes, err = ParseStepTypes("")
+	assert.Nil(err)
+	assert.Nil(stepTypes)
+
+	_, err = ParseStepTypes("container,input")
+	assert.NotNil(err)
+}
@@ -0,0 +1,329 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/docker"
+	"github.com/turbot/flowpipe/internal/primitive"
+	"github.com/turbot/flowpipe/internal/resources"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/util"
+	"github.com/turbot/pipe-fittings/perr"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+// how long to wait before claiming again after the server could not be reached
+const retryInterval = 5 * time.Second
+
+// Worker claims the steps dispatched by a Flowpipe server, runs their primitive and reports the output back to the
+// server
+type Worker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	ID          string
+	Host        string
+	Token       string
+	StepTypes   []string
+	Concurrency int
+	// The mod the function steps are loaded from
+	ModPath           string
+	HeartbeatInterval time.Duration
+
+	client *http.Client
+	wg     sync.WaitGroup
+
+	Status    string     `json:"status"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+}
+
+// WorkerOption defines a type of function to configures the Worker.
+type WorkerOption func(*Worker) error
+
+func WithID(id string) WorkerOption {
+	return func(w *Worker) error {
+		w.ID = id
+		return nil
+	}
+}
+
+// WithStepTypes limits the steps claimed by the worker to the given step types
+func WithStepTypes(stepTypes []string) WorkerOption {
+	return func(w *Worker) error {
+		w.StepTypes = stepTypes
+		return nil
+	}
+}
+
+// WithConcurrency sets the number of steps the worker runs at the same time
+func WithConcurrency(concurrency int) WorkerOption {
+	return func(w *Worker) error {
+		if concurrency < 1 {
+			return perr.BadRequestWithMessage("worker concurrency must be at least 1")
+		}
+		w.Concurrency = concurrency
+		return nil
+	}
+}
+
+func WithModPath(modPath string) WorkerOption {
+	return func(w *Worker) error {
+		w.ModPath = modPath
+		return nil
+	}
+}
+
+func WithHTTPClient(client *http.Client) WorkerOption {
+	return func(w *Worker) error {
+		w.client = client
+		return nil
+	}
+}
+
+func WithHeartbeatInterval(interval time.Duration) WorkerOption {
+	return func(w *Worker) error {
+		w.HeartbeatInterval = interval
+		return nil
+	}
+}
+
+func NewWorker(ctx context.Context, host, token string, opts ...WorkerOption) (*Worker, error) {
+	if host == "" {
+		return nil, perr.BadRequestWithMessage("worker host is required")
+	}
+
+	if token == "" {
+		return nil, perr.BadRequestWithMessage("worker token is required")
+	}
+
+	// Defaults
+	w := &Worker{
+		ID:                util.NewWorkerId(),
+		Host:              strings.TrimSuffix(host, "/"),
+		Token:             token,
+		Concurrency:       1,
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		client:            http.DefaultClient,
+		Status:            "initialized",
+	}
+	// Set options
+	for _, opt := range opts {
+		err := opt(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w, nil
+}
+
+func (w *Worker) Start() error {
+	slog.Debug("Worker starting", "worker_id", w.ID, "host", w.Host)
+	defer slog.Debug("Worker started", "worker_id", w.ID)
+
+	for i := 0; i < w.Concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.run()
+		}()
+	}
+
+	w.Status = "running"
+	w.StartedAt = utils.TimeNow()
+	return nil
+}
+
+// Stop stops claiming steps, the running steps are abandoned and given to another worker once their lease expires
+func (w *Worker) Stop() error {
+	slog.Debug("Worker stopping", "worker_id", w.ID)
+	defer slog.Debug("Worker stopped", "worker_id", w.ID)
+
+	w.cancel()
+	w.wg.Wait()
+
+	w.Status = "stopped"
+	w.StoppedAt = utils.TimeNow()
+	return nil
+}
+
+func (w *Worker) run() {
+	for {
+		job, err := w.claim()
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return
+			}
+
+			slog.Error("Error claiming a step", "worker_id", w.ID, "error", err)
+			select {
+			case <-time.After(retryInterval):
+			case <-w.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		if job != nil {
+			w.execute(job)
+		}
+
+		if w.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (w *Worker) execute(job *types.WorkerJob) {
+	slog.Info("Running step", "worker_id", w.ID, "step", job.StepName, "step_type", job.StepType, "step_execution_id", job.StepExecutionID)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(w.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := w.post("/worker/step/"+job.StepExecutionID+"/heartbeat", types.WorkerHeartbeatRequest{WorkerID: w.ID}, nil)
+				if err != nil {
+					slog.Warn("Error sending heartbeat", "worker_id", w.ID, "step_execution_id", job.StepExecutionID, "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	output, primitiveError := RunJob(w.ctx, job, w.ModPath)
+
+	// the step will be run again by another worker
+	if w.ctx.Err() != nil {
+		slog.Warn("Worker stopped while running step", "worker_id", w.ID, "step_execution_id", job.StepExecutionID)
+		return
+	}
+
+	request := types.WorkerCompleteRequest{
+		WorkerID: w.ID,
+		Output:   output,
+	}
+	if primitiveError != nil {
+		errorModel := perr.InternalWithMessage(primitiveError.Error())
+		if perr.IsPerr(primitiveError) {
+			errorModel = primitiveError.(perr.ErrorModel)
+		}
+		request.Error = &errorModel
+	}
+
+	err := w.post("/worker/step/"+job.StepExecutionID+"/complete", request, nil)
+	if err != nil {
+		slog.Error("Error reporting step output", "worker_id", w.ID, "step_execution_id", job.StepExecutionID, "error", err)
+	}
+}
+
+// RunJob runs the primitive of the step
+func RunJob(ctx context.Context, job *types.WorkerJob, modPath string) (*resources.Output, error) {
+	if job.StepType == schema.BlockTypePipelineStepContainer || job.StepType == schema.BlockTypePipelineStepFunction {
+		// NOTE: as in pipeline_load, Docker is initialized with a background context rather than the step context
+		err := docker.Initialize(context.Background())
+		if err != nil {
+			slog.Error("Error initializing Docker client", "error", err)
+			return nil, perr.InternalWithMessage("Unable to initialize the Docker client. Please ensure that Docker is installed and running.")
+		}
+	}
+
+	switch job.StepType {
+	case schema.BlockTypePipelineStepHttp:
+		p := primitive.HTTPRequest{ModPath: modPath}
+		return p.Run(ctx, job.Input)
+	case schema.BlockTypePipelineStepEmail:
+		p := primitive.Email{}
+		return p.Run(ctx, job.Input)
+	case schema.BlockTypePipelineStepQuery:
+		p := primitive.Query{}
+		return p.Run(ctx, job.Input)
+	case schema.BlockTypePipelineStepSleep:
+		p := primitive.Sleep{}
+		return p.Run(ctx, job.Input)
+	case schema.BlockTypePipelineStepTransform:
+		p := primitive.Transform{}
+		return p.Run(ctx, job.Input)
+	case schema.BlockTypePipelineStepFunction:
+		p := primitive.Function{ModPath: modPath}
+		return p.Run(ctx, job.Input)
+	case schema.BlockTypePipelineStepContainer:
+		p := primitive.Container{FullyQualifiedStepName: job.FullyQualifiedStepName}
+		return p.Run(ctx, job.Input)
+	case constants.BlockTypePipelineStepCommand:
+		// the output isn't streamed to the process event log of the server, the lines are in the step output
+		p := primitive.Exec{ModPath: modPath}
+		return p.Run(ctx, job.Input)
+	}
+
+	return nil, perr.BadRequestWithMessage("step type " + job.StepType + " can't run on a worker")
+}
+
+// claim waits for a step, it returns nil if the server has no step for this worker
+func (w *Worker) claim() (*types.WorkerJob, error) {
+	var job types.WorkerJob
+	found := false
+	err := w.post("/worker/claim", types.WorkerClaimRequest{WorkerID: w.ID, StepTypes: w.StepTypes}, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		found = true
+		return json.NewDecoder(resp.Body).Decode(&job)
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (w *Worker) post(path string, body interface{}, handleResponse func(*http.Response) error) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.Host+"/api/v0"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+w.Token)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var errorModel perr.ErrorModel
+		respBody, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(respBody, &errorModel) == nil && errorModel.Detail != "" {
+			return errorModel
+		}
+		return fmt.Errorf("unexpected response from the server: %s", resp.Status)
+	}
+
+	if handleResponse != nil {
+		return handleResponse(resp)
+	}
+	return nil
+}
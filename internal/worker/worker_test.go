@@ -0,0 +1,100 @@
+package worker_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	localconstants "github.com/turbot/flowpipe/internal/constants"
+	"github.com/turbot/flowpipe/internal/service/api"
+	"github.com/turbot/flowpipe/internal/service/es"
+	"github.com/turbot/flowpipe/internal/types"
+	"github.com/turbot/flowpipe/internal/worker"
+)
+
+// startWorkerAPI serves the worker API of a server dispatching the given step types to the workers
+func startWorkerAPI(t *testing.T, stepTypes []string) (*worker.Queue, *httptest.Server) {
+	viper.Set(localconstants.ArgWorkerToken, "secret")
+	t.Cleanup(func() {
+		viper.Set(localconstants.ArgWorkerToken, "")
+	})
+
+	q := worker.NewQueue(stepTypes)
+	apiService := &api.APIService{EsService: &es.ESService{Workers: q}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	apiService.WorkerRegisterAPI(router.Group("/api/v0"))
+
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		q.Close()
+		server.Close()
+	})
+	return q, server
+}
+
+func TestWorkerRunsDispatchedStep(t *testing.T) {
+	assert := assert.New(t)
+
+	q, server := startWorkerAPI(t, []string{"transform"})
+
+	w, err := worker.NewWorker(context.Background(), server.URL, "secret", worker.WithHeartbeatInterval(50*time.Millisecond))
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	assert.Nil(w.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	output, err := q.Dispatch(ctx, types.WorkerJob{
+		ExecutionID:     "exec_1",
+		StepExecutionID: "sexec_1",
+		StepName:        "echo",
+		StepType:        "transform",
+		Input:           map[string]interface{}{"value": "hello"},
+	})
+	assert.Nil(err)
+	if output == nil {
+		assert.FailNow("no output reported by the worker")
+		return
+	}
+	assert.Equal("hello", output.Get("value"))
+	assert.NotNil(output.Flowpipe["started_at"])
+
+	assert.Nil(w.Stop())
+	assert.Equal("stopped", w.Status)
+}
+
+func TestWorkerInvalidToken(t *testing.T) {
+	assert := assert.New(t)
+
+	q, server := startWorkerAPI(t, []string{"transform"})
+
+	w, err := worker.NewWorker(context.Background(), server.URL, "wrong")
+	if err != nil {
+		assert.FailNow(err.Error())
+		return
+	}
+	assert.Nil(w.Start())
+	defer func() {
+		_ = w.Stop()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	// the worker is rejected, nobody runs the step
+	_, err = q.Dispatch(ctx, types.WorkerJob{StepExecutionID: "sexec_1", StepName: "echo", StepType: "transform"})
+	assert.NotNil(err)
+
+	pending, claimed := q.Len()
+	assert.Equal(0, pending)
+	assert.Equal(0, claimed)
+}